@@ -0,0 +1,315 @@
+package goride
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+const backupManifest = "manifest.json"
+
+// BackupOptions control what Backup saves, and how it deals with rate limits.
+type BackupOptions struct {
+	// User whose rides are saved. Defaults to the current user.
+	User     int
+	NoGPX    bool
+	NoPhotos bool
+	RetryOptions
+	// Progress, if set, is called after each ride.
+	Progress func(BackupProgress)
+}
+
+func (o BackupOptions) withDefaults() BackupOptions {
+	o.RetryOptions = o.RetryOptions.withDefaults()
+	return o
+}
+
+// BackupProgress reports on a ride Backup just finished with.
+type BackupProgress struct {
+	Done  int
+	Total int
+	Ride  *RideSlim
+	// Skipped is set when the ride was already saved, and didn't change.
+	Skipped bool
+	Err     error
+}
+
+// BackupManifest lists everything saved by Backup, keyed by ride ID. It's kept
+// in the backup directory, and used to resume the next backup.
+type BackupManifest struct {
+	Updated time.Time            `json:"updated"`
+	Rides   map[int]*BackupEntry `json:"rides"`
+}
+
+// BackupEntry is a ride's saved files. Rides that failed have Error set, and
+// are tried again by the next Backup.
+type BackupEntry struct {
+	UpdatedAt time.Time    `json:"updated_at"`
+	Files     []BackupFile `json:"files"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// BackupFile is a saved file. Path is relative to the backup directory, and
+// uses forward slashes.
+type BackupFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Failed returns the IDs of the rides that weren't saved.
+func (m *BackupManifest) Failed() []int {
+	var res []int
+	for id, e := range m.Rides {
+		if e.Error != "" {
+			res = append(res, id)
+		}
+	}
+	sort.Ints(res)
+
+	return res
+}
+
+// Backup saves every ride's JSON, its GPX track and its photos under dir, in
+// year/month/ride ID directories. Rides that didn't change since the last
+// backup, and whose files are intact, are skipped. A ride that fails is
+// recorded in the manifest and the backup moves on, so check Failed on the
+// result. Errors are only returned when the backup can't go on at all.
+func (r *RWGPS) Backup(dir string, opts BackupOptions) (*BackupManifest, error) {
+	opts = opts.withDefaults()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("can't create backup dir %q: %w", dir, err)
+	}
+
+	m := &BackupManifest{Rides: make(map[int]*BackupEntry)}
+	manifestPath := filepath.Join(dir, backupManifest)
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		if err := json.Unmarshal(data, m); err != nil {
+			// Everything is checked against the files anyway, so just start
+			// over.
+			r.log().Warn("ignoring unreadable backup manifest", "path", manifestPath, "err", err)
+			m = &BackupManifest{Rides: make(map[int]*BackupEntry)}
+		}
+		if m.Rides == nil {
+			m.Rides = make(map[int]*BackupEntry)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("can't read backup manifest: %w", err)
+	}
+
+	user := opts.User
+	if user == 0 {
+		u, err := r.loggedInUser()
+		if err != nil {
+			return nil, err
+		}
+		user = u.ID
+	}
+
+	var rides []*RideSlim
+	for offset := 0; ; {
+		var page []*RideSlim
+		var count int
+		err := r.retryRateLimited(context.Background(), opts.RetryOptions, func() error {
+			var err error
+			page, count, err = r.GetRides(user, offset, ridesPageSize)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't list rides to back up: %w", err)
+		}
+		for _, ride := range page {
+			if !ride.IsDeleted() {
+				rides = append(rides, ride)
+			}
+		}
+		offset += len(page)
+		if len(page) == 0 || offset >= count {
+			break
+		}
+	}
+
+	for i, ride := range rides {
+		p := BackupProgress{Done: i + 1, Total: len(rides), Ride: ride}
+		old := m.Rides[ride.ID]
+		if old != nil && old.Error == "" && old.UpdatedAt.Equal(ride.UpdatedAt) && backupIntact(dir, old.Files) {
+			p.Skipped = true
+		} else {
+			entry, err := r.backupRide(dir, ride, old, opts)
+			if err != nil {
+				r.log().Warn("ride backup failed", "ride", ride.ID, "err", err)
+				entry.Error = err.Error()
+				p.Err = err
+			}
+			m.Rides[ride.ID] = entry
+			m.Updated = time.Now().UTC()
+			if err := saveManifest(manifestPath, m); err != nil {
+				return nil, err
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+
+	m.Updated = time.Now().UTC()
+	if err := saveManifest(manifestPath, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// backupRide saves a ride's files. The returned entry lists the files saved,
+// even when there's an error.
+func (r *RWGPS) backupRide(dir string, ride *RideSlim, old *BackupEntry, opts BackupOptions) (*BackupEntry, error) {
+	entry := &BackupEntry{}
+	local := ride.LocalDepartedAt()
+	rideDir := path.Join(local.Format("2006"), local.Format("01"), strconv.Itoa(ride.ID))
+
+	var raw string
+	err := r.retryRateLimited(context.Background(), opts.RetryOptions, func() error {
+		var err error
+		raw, err = r.do(http.MethodGet, fmt.Sprintf("/trips/%d.json", ride.ID), nil, nil)
+		return err
+	})
+	if err != nil {
+		return entry, fmt.Errorf("error getting ride id %d: %w", ride.ID, err)
+	}
+	var resStruct struct{ Trip Ride }
+	if err := r.decode(raw, &resStruct); err != nil {
+		return entry, fmt.Errorf("error decoding ride id %d: %w", ride.ID, err)
+	}
+	full := &resStruct.Trip
+
+	f, err := writeBackupFile(dir, path.Join(rideDir, "ride.json"), []byte(raw))
+	if err != nil {
+		return entry, err
+	}
+	entry.Files = append(entry.Files, f)
+
+	if !opts.NoGPX {
+		var gpx bytes.Buffer
+		if err := ExportGPX(&gpx, full.Name, full.TrackPoints); err != nil {
+			return entry, fmt.Errorf("can't export ride %d as GPX: %w", ride.ID, err)
+		}
+		f, err := writeBackupFile(dir, path.Join(rideDir, "ride.gpx"), gpx.Bytes())
+		if err != nil {
+			return entry, err
+		}
+		entry.Files = append(entry.Files, f)
+	}
+
+	if !opts.NoPhotos {
+		saved := make(map[string]BackupFile)
+		if old != nil {
+			for _, f := range old.Files {
+				saved[f.Path] = f
+			}
+		}
+		for _, photo := range full.Photos {
+			name := path.Join(rideDir, "photos", strconv.Itoa(photo.ID)+photoExt(photo.URL))
+			if f, ok := saved[name]; ok && backupIntact(dir, []BackupFile{f}) {
+				entry.Files = append(entry.Files, f)
+				continue
+			}
+
+			var data []byte
+			err := r.retryRateLimited(context.Background(), opts.RetryOptions, func() error {
+				body, err := r.client.GetStream(photo.URL, nil)
+				if err != nil {
+					return err
+				}
+				defer body.Close()
+				data, err = io.ReadAll(body)
+				return err
+			})
+			if err != nil {
+				return entry, fmt.Errorf("error getting photo %d of ride %d: %w", photo.ID, ride.ID, err)
+			}
+			f, err := writeBackupFile(dir, name, data)
+			if err != nil {
+				return entry, err
+			}
+			entry.Files = append(entry.Files, f)
+		}
+	}
+
+	// Only a complete backup of the ride records when it was updated, so a
+	// failed one is always tried again.
+	entry.UpdatedAt = ride.UpdatedAt
+
+	return entry, nil
+}
+
+func photoExt(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		if ext := path.Ext(parsed.Path); ext != "" {
+			return ext
+		}
+	}
+	return ".jpg"
+}
+
+// backupIntact returns true if all the files exist with the recorded size and
+// checksum.
+func backupIntact(dir string, files []BackupFile) bool {
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil || int64(len(data)) != f.Size || checksum(data) != f.SHA256 {
+			return false
+		}
+	}
+	return true
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeBackupFile writes data to name under dir, unless it's already there.
+// Files are written to a temp file first, so they're never left half written.
+func writeBackupFile(dir, name string, data []byte) (BackupFile, error) {
+	f := BackupFile{Path: name, Size: int64(len(data)), SHA256: checksum(data)}
+	if backupIntact(dir, []BackupFile{f}) {
+		return f, nil
+	}
+
+	full := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(full), 0700); err != nil {
+		return f, fmt.Errorf("can't create dir for %q: %w", name, err)
+	}
+	if err := atomicfile.WriteFile(full, data); err != nil {
+		return f, fmt.Errorf("can't write %q: %w", name, err)
+	}
+
+	return f, nil
+}
+
+func saveManifest(path string, m *BackupManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode backup manifest: %w", err)
+	}
+	if err := atomicfile.WriteFile(path, data); err != nil {
+		return fmt.Errorf("can't write backup manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBackup(t *testing.T) {
+	day := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	f := newFakeRWGPS(t)
+	var slims []*RideSlim
+	for i := 1; i <= 3; i++ {
+		departed := day.AddDate(0, 0, i*20)
+		slims = append(slims, &RideSlim{ID: i, DepartedAt: departed, UpdatedAt: departed, TimeZone: "UTC"})
+		f.rides[i] = &Ride{
+			ID:          i,
+			Name:        fmt.Sprintf("ride %d", i),
+			Started:     departed,
+			UpdatedAt:   departed,
+			Photos:      []Photo{{ID: i * 10, URL: fmt.Sprintf("%s/photos/%d.png", f.URL, i*10)}},
+			TrackPoints: []TrackPoint{{Lat: 37, Lng: -122, Time: departed}, {Lat: 37.01, Lng: -122, Time: departed.Add(time.Minute)}},
+		}
+	}
+	f.handle("/users/1268590/trips.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ridesPage(t, len(slims), slims))
+	})
+	calls := make(map[int]int)
+	failPhoto := true
+	for _, id := range []int{10, 20, 30} {
+		id := id
+		f.handle(fmt.Sprintf("/photos/%d.png", id), func(w http.ResponseWriter, r *http.Request) {
+			calls[id]++
+			switch {
+			case id == 20 && calls[id] == 1:
+				http.Error(w, "slow down", http.StatusTooManyRequests)
+			case id == 30 && failPhoto:
+				http.Error(w, "oops", http.StatusInternalServerError)
+			default:
+				fmt.Fprintf(w, "photo %d", id)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	obj := testObj(f.URL)
+	var progress []string
+	opts := BackupOptions{
+		RetryOptions: RetryOptions{RetryWait: time.Millisecond},
+		Progress: func(p BackupProgress) {
+			progress = append(progress, fmt.Sprintf("%d/%d ride %d skipped=%v failed=%v", p.Done, p.Total, p.Ride.ID, p.Skipped, p.Err != nil))
+		},
+	}
+
+	m, err := obj.Backup(dir, opts)
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	if diff := cmp.Diff([]int{3}, m.Failed()); diff != "" {
+		t.Errorf("bad failed rides: -want +got\n%s", diff)
+	}
+	want := []string{
+		"1/3 ride 1 skipped=false failed=false",
+		"2/3 ride 2 skipped=false failed=false",
+		"3/3 ride 3 skipped=false failed=true",
+	}
+	if diff := cmp.Diff(want, progress); diff != "" {
+		t.Errorf("bad progress: -want +got\n%s", diff)
+	}
+	for _, name := range []string{
+		"manifest.json",
+		"2021/06/1/ride.json",
+		"2021/06/1/ride.gpx",
+		"2021/06/1/photos/10.png",
+		"2021/07/2/photos/20.png",
+		"2021/07/3/ride.json",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+	gpx, err := os.ReadFile(filepath.Join(dir, "2021/06/1/ride.gpx"))
+	if err != nil || !strings.Contains(string(gpx), "<name>ride 1</name>") {
+		t.Errorf("bad GPX: %s", gpx)
+	}
+
+	// The second run only retries the failed ride.
+	failPhoto = false
+	progress = nil
+	f.requests = nil
+	m, err = obj.Backup(dir, opts)
+	if err != nil {
+		t.Fatalf("second backup failed: %v", err)
+	}
+	if len(m.Failed()) != 0 {
+		t.Errorf("expected no failures, got %v", m.Failed())
+	}
+	want = []string{
+		"1/3 ride 1 skipped=true failed=false",
+		"2/3 ride 2 skipped=true failed=false",
+		"3/3 ride 3 skipped=false failed=false",
+	}
+	if diff := cmp.Diff(want, progress); diff != "" {
+		t.Errorf("bad progress on resume: -want +got\n%s", diff)
+	}
+	wantReqs := []string{"GET /users/1268590/trips.json", "GET /trips/3.json", "GET /photos/30.png"}
+	if diff := cmp.Diff(wantReqs, f.requests); diff != "" {
+		t.Errorf("bad requests on resume: -want +got\n%s", diff)
+	}
+
+	// A damaged file gets the ride saved again, but intact photos aren't
+	// downloaded again.
+	if err := os.WriteFile(filepath.Join(dir, "2021/06/1/ride.gpx"), []byte("<gpx"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f.requests = nil
+	if _, err := obj.Backup(dir, opts); err != nil {
+		t.Fatalf("third backup failed: %v", err)
+	}
+	wantReqs = []string{"GET /users/1268590/trips.json", "GET /trips/1.json"}
+	if diff := cmp.Diff(wantReqs, f.requests); diff != "" {
+		t.Errorf("bad requests after damage: -want +got\n%s", diff)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "2021/06/1/ride.gpx")); string(got) != string(gpx) {
+		t.Errorf("damaged GPX wasn't restored")
+	}
+}
+
+func TestBackupRateLimited(t *testing.T) {
+	f := newFakeRWGPS(t)
+	tries := 0
+	f.handle("/users/1268590/trips.json", func(w http.ResponseWriter, r *http.Request) {
+		tries++
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	})
+
+	_, err := testObj(f.URL).Backup(t.TempDir(), BackupOptions{RetryOptions: RetryOptions{Retries: 2, RetryWait: time.Millisecond}})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+	if tries != 3 {
+		t.Errorf("expected 3 tries, got %d", tries)
+	}
+}
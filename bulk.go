@@ -0,0 +1,159 @@
+package goride
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BulkOptions control BulkUpdateRides.
+type BulkOptions struct {
+	// Concurrency is how many rides are updated at once. Defaults to 4.
+	Concurrency int
+	// DryRun only reads the rides, and reports what would change.
+	DryRun bool
+	RetryOptions
+	// Progress, if set, is called after each ride, one call at a time.
+	Progress func(BulkProgress)
+}
+
+func (o BulkOptions) withDefaults() BulkOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	o.RetryOptions = o.RetryOptions.withDefaults()
+	return o
+}
+
+// BulkProgress reports on a ride BulkUpdateRides just finished with.
+type BulkProgress struct {
+	Done  int
+	Total int
+	ID    int
+	Err   error
+}
+
+// BulkResult is what BulkUpdateRides did, or would do in a dry run.
+type BulkResult struct {
+	// Updated lists the rides that were changed, or would be, sorted by ID.
+	Updated []int
+	// Changes describes what would change on each ride, e.g. "gear: 17 ->
+	// 23". Only set in a dry run.
+	Changes map[int][]string
+	Failed  map[int]error
+}
+
+// BulkUpdateRides applies the same update to many rides. A ride failing
+// doesn't stop the rest; the failures are listed in the result, and
+// summarized in the returned error.
+func (r *RWGPS) BulkUpdateRides(ids []int, u RideUpdate, opts BulkOptions) (BulkResult, error) {
+	return r.bulkUpdateRides(ids, func(int) RideUpdate { return u }, opts)
+}
+
+// bulkUpdateRides is BulkUpdateRides, with each ride's update from update.
+func (r *RWGPS) bulkUpdateRides(ids []int, update func(id int) RideUpdate, opts BulkOptions) (BulkResult, error) {
+	opts = opts.withDefaults()
+	res := BulkResult{Failed: make(map[int]error)}
+	if opts.DryRun {
+		res.Changes = make(map[int][]string)
+	}
+
+	var mu sync.Mutex
+	done := 0
+	finish := func(id int, changes []string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		switch {
+		case err != nil:
+			res.Failed[id] = err
+		case opts.DryRun && len(changes) > 0:
+			res.Changes[id] = changes
+			res.Updated = append(res.Updated, id)
+		case !opts.DryRun:
+			res.Updated = append(res.Updated, id)
+		}
+		if opts.Progress != nil {
+			opts.Progress(BulkProgress{Done: done, Total: len(ids), ID: id, Err: err})
+		}
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range work {
+				if !opts.DryRun {
+					finish(id, nil, r.retryRateLimited(context.Background(), opts.RetryOptions, func() error {
+						return r.UpdateRide(id, update(id))
+					}))
+					continue
+				}
+				var ride *Ride
+				err := r.retryRateLimited(context.Background(), opts.RetryOptions, func() error {
+					var err error
+					ride, err = r.GetRideSummary(id)
+					return err
+				})
+				if err != nil {
+					finish(id, nil, err)
+					continue
+				}
+				finish(id, describeUpdate(ride, update(id)), nil)
+			}
+		}()
+	}
+	for _, id := range ids {
+		work <- id
+	}
+	close(work)
+	wg.Wait()
+
+	sort.Ints(res.Updated)
+	if len(res.Failed) > 0 {
+		var failed []int
+		for id := range res.Failed {
+			failed = append(failed, id)
+		}
+		sort.Ints(failed)
+		return res, fmt.Errorf("%d of %d rides failed, first error: %w", len(failed), len(ids), res.Failed[failed[0]])
+	}
+
+	return res, nil
+}
+
+// describeUpdate lists what u would change on the ride.
+func describeUpdate(ride *Ride, u RideUpdate) []string {
+	var res []string
+	change := func(field, before, after string) {
+		if before != after {
+			res = append(res, fmt.Sprintf("%s: %s -> %s", field, before, after))
+		}
+	}
+	if u.Name != nil {
+		change("name", strconv.Quote(ride.Name), strconv.Quote(*u.Name))
+	}
+	if u.Description != nil && ride.Description != *u.Description {
+		res = append(res, "description")
+	}
+	if u.GearID != nil {
+		before := "none"
+		if ride.Gear != nil {
+			before = strconv.Itoa(ride.Gear.ID)
+		}
+		change("gear", before, strconv.Itoa(*u.GearID))
+	}
+	if u.Visibility != nil {
+		change("visibility", ride.Visibility.String(), u.Visibility.String())
+	}
+	if u.Tags != nil {
+		change("tags", strings.Join(ride.Tags, ","), strings.Join(*u.Tags, ","))
+	}
+
+	return res
+}
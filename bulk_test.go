@@ -0,0 +1,100 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func bulkRides() []*Ride {
+	var res []*Ride
+	for id := 1; id <= 8; id++ {
+		res = append(res, &Ride{ID: id, Name: fmt.Sprintf("Ride %d", id), Gear: &Gear{ID: 17}})
+	}
+	res[2].Gear = &Gear{ID: 23}
+	return res
+}
+
+func TestBulkUpdateRides(t *testing.T) {
+	f := newFakeRWGPS(t, bulkRides()...)
+	r := testObj(f.URL)
+
+	f.handle("/trips/5.json", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	limited := 0
+	f.handle("/trips/6.json", func(w http.ResponseWriter, req *http.Request) {
+		if limited++; limited == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	})
+
+	var progress []int
+	gear := 23
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	got, err := r.BulkUpdateRides(ids, RideUpdate{GearID: &gear}, BulkOptions{
+		Concurrency:  3,
+		RetryOptions: RetryOptions{RetryWait: time.Millisecond},
+		Progress:     func(p BulkProgress) { progress = append(progress, p.Done) },
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 8 rides failed") {
+		t.Errorf("want an error for the failed ride, got %v", err)
+	}
+
+	if diff := cmp.Diff([]int{1, 2, 3, 4, 6, 7, 8}, got.Updated); diff != "" {
+		t.Errorf("bad updated rides: -want +got\n%s", diff)
+	}
+	if len(got.Failed) != 1 || got.Failed[5] == nil {
+		t.Errorf("want only ride 5 to fail, got %v", got.Failed)
+	}
+	for _, id := range []int{1, 2, 4, 7, 8} {
+		if g := f.ride(id).Gear; g == nil || g.ID != 23 {
+			t.Errorf("ride %d wasn't updated: %+v", id, g)
+		}
+	}
+	if limited != 2 {
+		t.Errorf("want the rate limited ride retried once, got %d requests", limited)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3, 4, 5, 6, 7, 8}, progress); diff != "" {
+		t.Errorf("bad progress: -want +got\n%s", diff)
+	}
+}
+
+func TestBulkUpdateRidesDryRun(t *testing.T) {
+	f := newFakeRWGPS(t, bulkRides()...)
+	r := testObj(f.URL)
+
+	gear, vis := 23, Private
+	got, err := r.BulkUpdateRides([]int{1, 3, 42}, RideUpdate{GearID: &gear, Visibility: &vis}, BulkOptions{DryRun: true})
+	if err == nil {
+		t.Errorf("expected an error for the missing ride")
+	}
+	if !errors.Is(got.Failed[42], ErrNotFound) {
+		t.Errorf("want ErrNotFound for ride 42, got %v", got.Failed[42])
+	}
+
+	want := BulkResult{
+		Updated: []int{1, 3},
+		Changes: map[int][]string{
+			1: {"gear: 17 -> 23", "visibility: public -> private"},
+			3: {"visibility: public -> private"},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(BulkResult{}, "Failed")); diff != "" {
+		t.Errorf("bad dry run: -want +got\n%s", diff)
+	}
+	if w := f.writes(); len(w) != 0 {
+		t.Errorf("dry run wrote: %v", w)
+	}
+	if n := f.trackPointGets(); n != 0 {
+		t.Errorf("dry run fetched the track points %d times", n)
+	}
+}
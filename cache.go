@@ -0,0 +1,108 @@
+package goride
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// CachedResponse is a response body, along with the validators used to check
+// if it's still fresh.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Body         string
+}
+
+func (c CachedResponse) setValidators(req *http.Request) {
+	if c.ETag != "" {
+		req.Header.Set("If-None-Match", c.ETag)
+	}
+	if c.LastModified != "" {
+		req.Header.Set("If-Modified-Since", c.LastModified)
+	}
+}
+
+// ResponseCache stores GET responses by URL, so unchanged responses don't need
+// to be downloaded again. Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	Get(url string) (CachedResponse, bool)
+	Set(url string, resp CachedResponse)
+}
+
+// WithCache makes the client send conditional GET requests, using the cached
+// response when the server says nothing changed. Off by default.
+func WithCache(c ResponseCache) Option {
+	return func(r *RWGPS) {
+		r.client.cache = c
+	}
+}
+
+// store caches the response if the server gave us a way to validate it.
+func (c *Client) store(uri string, resp *http.Response, body string) {
+	cr := CachedResponse{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+	}
+	if cr.ETag != "" || cr.LastModified != "" {
+		c.cache.Set(uri, cr)
+	}
+}
+
+// MemoryCache is a ResponseCache keeping up to a fixed number of responses in
+// memory, evicting the least recently used.
+type MemoryCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	url  string
+	resp CachedResponse
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		max:     maxEntries,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(url string) (CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[url]
+	if !ok {
+		return CachedResponse{}, false
+	}
+	m.order.MoveToFront(e)
+
+	return e.Value.(*memoryEntry).resp, true
+}
+
+func (m *MemoryCache) Set(url string, resp CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[url]; ok {
+		e.Value.(*memoryEntry).resp = resp
+		m.order.MoveToFront(e)
+		return
+	}
+
+	m.entries[url] = m.order.PushFront(&memoryEntry{url: url, resp: resp})
+	for m.order.Len() > m.max {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).url)
+	}
+}
+
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
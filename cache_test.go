@@ -0,0 +1,128 @@
+package goride
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// etagServer serves "<path> v<version>" with a matching ETag, and counts full
+// and not-modified responses.
+type etagServer struct {
+	mu          sync.Mutex
+	version     int
+	full        int
+	notModified int
+	lastModOnly bool
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastModOnly {
+		mod := fmt.Sprintf("Sun, 0%d Aug 2021 12:00:00 GMT", s.version)
+		if r.Header.Get("If-Modified-Since") == mod {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", mod)
+	} else {
+		etag := fmt.Sprintf(`"%s-%d"`, r.URL.Path, s.version)
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}
+	s.full++
+	fmt.Fprintf(w, "%s v%d", r.URL.Path, s.version)
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		desc        string
+		lastModOnly bool
+	}{
+		{desc: "etag"},
+		{desc: "last modified", lastModOnly: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := &etagServer{version: 1, lastModOnly: tc.lastModOnly}
+			server := httptest.NewServer(s)
+			defer server.Close()
+
+			c := &Client{server: server.URL, cache: NewMemoryCache(10)}
+			get := func(want string) {
+				t.Helper()
+				got, err := c.Get("/a", nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != want {
+					t.Errorf("bad body: want %q, got %q", want, got)
+				}
+			}
+
+			get("/a v1")
+			get("/a v1")
+			if s.full != 1 || s.notModified != 1 {
+				t.Errorf("expected the second request to be served from the cache: %d full, %d not modified", s.full, s.notModified)
+			}
+
+			s.version = 2
+			get("/a v2")
+			if s.full != 2 {
+				t.Errorf("expected a changed response to be downloaded: %d full", s.full)
+			}
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	s := &etagServer{version: 1}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	cache := NewMemoryCache(2)
+	c := &Client{server: server.URL, cache: cache}
+	for _, path := range []string{"/a", "/b", "/a", "/c", "/b"} {
+		if _, err := c.Get(path, nil); err != nil {
+			t.Fatalf("unexpected error getting %s: %v", path, err)
+		}
+	}
+
+	// /a was refreshed before /c was added, so /b was evicted and had to be
+	// downloaded again.
+	if s.full != 4 || s.notModified != 1 {
+		t.Errorf("bad eviction: %d full, %d not modified", s.full, s.notModified)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache grew past its size: %d", cache.Len())
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	cache := NewMemoryCache(5)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := fmt.Sprintf("/%d", i%8)
+			cache.Set(url, CachedResponse{ETag: url})
+			cache.Get(url)
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() != 5 {
+		t.Errorf("bad cache size: %d", cache.Len())
+	}
+}
@@ -0,0 +1,190 @@
+package goride
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// CleanOptions tune CleanTrack. Zero fields use the defaults.
+type CleanOptions struct {
+	// MaxSpeed, in km/h, is the fastest a point can be reached from the one
+	// before it. Defaults to 120.
+	MaxSpeed float64
+	// MaxAccel, in m/s², is the biggest change in speed between points.
+	// Defaults to 10.
+	MaxAccel float64
+	// MaxClimbRate, in meters per second, is the fastest the elevation can
+	// change. Defaults to 10.
+	MaxClimbRate float64
+	// Interpolate keeps the bad points, moving them between the good points
+	// around them, instead of removing them.
+	Interpolate bool
+}
+
+func (o CleanOptions) withDefaults() CleanOptions {
+	if o.MaxSpeed == 0 {
+		o.MaxSpeed = 120
+	}
+	if o.MaxAccel == 0 {
+		o.MaxAccel = 10
+	}
+	if o.MaxClimbRate == 0 {
+		o.MaxClimbRate = 10
+	}
+	return o
+}
+
+// Reasons for a CleanChange.
+const (
+	CleanSpeed        = "speed"
+	CleanAcceleration = "acceleration"
+	CleanElevation    = "elevation"
+)
+
+// CleanChange is a point CleanTrack changed, in the order of Index, which is
+// into the input track.
+// Points with a bad position are removed or interpolated, and points with a
+// bad elevation only get their elevation interpolated.
+type CleanChange struct {
+	Index   int
+	Reason  string
+	Removed bool
+}
+
+// CleanTrack returns a copy of the track without GPS glitches: points that
+// would take an impossible speed or acceleration to reach, or whose elevation
+// jumps too fast. Each point is checked against the last good one before it.
+// The first and last points are never changed, and points without a position
+// or time are kept as they are. Interpolated values are by time, between the
+// good points on either side. The input isn't changed.
+func CleanTrack(points []TrackPoint, opts CleanOptions) ([]TrackPoint, []CleanChange) {
+	opts = opts.withDefaults()
+	changes := []CleanChange{}
+	if len(points) < 3 {
+		return append([]TrackPoint(nil), points...), changes
+	}
+
+	// Find the points with bad positions.
+	bad := make([]bool, len(points))
+	good, goodSpeed, haveSpeed := 0, 0.0, false
+	for i := 1; i < len(points); i++ {
+		p, g := points[i], points[good]
+		dt := p.Time.Sub(g.Time).Seconds()
+		if p.Time.IsZero() || g.Time.IsZero() || (p.Lat == 0 && p.Lng == 0) || dt <= 0 {
+			continue
+		}
+		speed := haversine(g.Lat, g.Lng, p.Lat, p.Lng) / dt
+		reason := ""
+		switch {
+		case speed*3.6 > opts.MaxSpeed:
+			reason = CleanSpeed
+		case haveSpeed && math.Abs(speed-goodSpeed)/dt > opts.MaxAccel:
+			reason = CleanAcceleration
+		}
+		if reason == "" || i == len(points)-1 {
+			good, goodSpeed, haveSpeed = i, speed, true
+			continue
+		}
+		bad[i] = true
+		changes = append(changes, CleanChange{Index: i, Reason: reason, Removed: !opts.Interpolate})
+	}
+
+	res := make([]TrackPoint, 0, len(points))
+	idx := make([]int, 0, len(points))
+	for i, p := range points {
+		if bad[i] && !opts.Interpolate {
+			continue
+		}
+		if bad[i] {
+			a, b := goodAround(bad, i)
+			p = interpolatePosition(p, points[a], points[b])
+		}
+		res = append(res, p)
+		idx = append(idx, i)
+	}
+
+	// Then the elevations, of what's left.
+	badElev := make([]bool, len(res))
+	good = 0
+	for i := 1; i < len(res)-1; i++ {
+		p, g := res[i], res[good]
+		dt := p.Time.Sub(g.Time).Seconds()
+		if p.Time.IsZero() || g.Time.IsZero() || dt <= 0 {
+			continue
+		}
+		if math.Abs(p.Elevation-g.Elevation)/dt > opts.MaxClimbRate {
+			badElev[i] = true
+			changes = append(changes, CleanChange{Index: idx[i], Reason: CleanElevation})
+			continue
+		}
+		good = i
+	}
+	for i := range res {
+		if badElev[i] {
+			a, b := goodAround(badElev, i)
+			res[i].Elevation = lerp(res[a].Elevation, res[b].Elevation, timeFraction(res[i].Time, res[a].Time, res[b].Time))
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Index < changes[j].Index })
+
+	return res, changes
+}
+
+// goodAround returns the nearest points before and after i that aren't bad.
+// The first and last points are never bad.
+func goodAround(bad []bool, i int) (int, int) {
+	a, b := i-1, i+1
+	for bad[a] {
+		a--
+	}
+	for bad[b] {
+		b++
+	}
+	return a, b
+}
+
+// interpolatePosition moves p between a and b, by time.
+func interpolatePosition(p, a, b TrackPoint) TrackPoint {
+	f := timeFraction(p.Time, a.Time, b.Time)
+	p.Lat = lerp(a.Lat, b.Lat, f)
+	p.Lng = lerp(a.Lng, b.Lng, f)
+	p.Distance = lerp(a.Distance, b.Distance, f)
+	p.Speed = lerp(a.Speed, b.Speed, f)
+	return p
+}
+
+// timeFraction is how far t is from a to b, or halfway if they're the same.
+func timeFraction(t, a, b time.Time) float64 {
+	if !b.After(a) {
+		return 0.5
+	}
+	return float64(t.Sub(a)) / float64(b.Sub(a))
+}
+
+func lerp(a, b, f float64) float64 {
+	return a + (b-a)*f
+}
+
+// TrackMaxSpeed is the fastest speed between two points of a track, in km/h.
+// Use CleanTrack first, so glitches don't count.
+func TrackMaxSpeed(points []TrackPoint) float64 {
+	var res float64
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		dt := q.Time.Sub(p.Time).Hours()
+		if dt <= 0 || (p.Lat == 0 && p.Lng == 0) || (q.Lat == 0 && q.Lng == 0) {
+			continue
+		}
+		res = math.Max(res, haversine(p.Lat, p.Lng, q.Lat, q.Lng)/1000/dt)
+	}
+	return res
+}
+
+// MaxSpeeds returns the ride's max speed in km/h as the server reports it,
+// and recomputed from the track after cleaning it with opts, which is 0 if
+// the ride has no track points.
+func (r *Ride) MaxSpeeds(opts CleanOptions) (api, cleaned float64) {
+	points, _ := CleanTrack(r.TrackPoints, opts)
+	return float64(r.Metrics.Speed.Max), TrackMaxSpeed(points)
+}
@@ -0,0 +1,154 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// cleanTrace is 300 points a second apart, heading north at 30km/h and
+// climbing half a meter a second.
+func cleanTrace() []TrackPoint {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	step := 30 / 3.6 / metersPerDegree
+	res := make([]TrackPoint, 300)
+	for i := range res {
+		res[i] = TrackPoint{
+			Time:      start.Add(time.Duration(i) * time.Second),
+			Lat:       45 + float64(i)*step,
+			Lng:       -122,
+			Elevation: 100 + float64(i)/2,
+		}
+	}
+	return res
+}
+
+// spiky is cleanTrace with glitches: a jump of a km at 50, two in a row at
+// 100 and 101, a 200m elevation jump at 150, a 15m jump forward at 200, and a
+// jump at the last point.
+func spiky() []TrackPoint {
+	res := cleanTrace()
+	res[50].Lat += 0.01
+	res[100].Lat += 0.01
+	res[101].Lng += 0.01
+	res[150].Elevation += 200
+	res[200].Lat += 15 / metersPerDegree
+	res[299].Lat += 0.01
+	return res
+}
+
+func TestCleanTrack(t *testing.T) {
+	removed := func(idx ...int) []TrackPoint {
+		var res []TrackPoint
+		for i, p := range cleanTrace() {
+			if len(idx) > 0 && i == idx[0] {
+				idx = idx[1:]
+				continue
+			}
+			res = append(res, p)
+		}
+		return res
+	}
+	lastSpike := func(points []TrackPoint) []TrackPoint {
+		points[len(points)-1].Lat += 0.01
+		return points
+	}
+
+	tests := []struct {
+		desc        string
+		points      []TrackPoint
+		opts        CleanOptions
+		want        []TrackPoint
+		wantChanges []CleanChange
+	}{
+		{
+			desc:        "clean",
+			points:      cleanTrace(),
+			want:        cleanTrace(),
+			wantChanges: []CleanChange{},
+		},
+		{
+			desc:   "remove",
+			points: spiky(),
+			want:   lastSpike(removed(50, 100, 101, 200)),
+			wantChanges: []CleanChange{
+				{Index: 50, Reason: CleanSpeed, Removed: true},
+				{Index: 100, Reason: CleanSpeed, Removed: true},
+				{Index: 101, Reason: CleanSpeed, Removed: true},
+				{Index: 150, Reason: CleanElevation},
+				{Index: 200, Reason: CleanAcceleration, Removed: true},
+			},
+		},
+		{
+			desc:   "interpolate",
+			points: spiky(),
+			opts:   CleanOptions{Interpolate: true},
+			want:   lastSpike(cleanTrace()),
+			wantChanges: []CleanChange{
+				{Index: 50, Reason: CleanSpeed},
+				{Index: 100, Reason: CleanSpeed},
+				{Index: 101, Reason: CleanSpeed},
+				{Index: 150, Reason: CleanElevation},
+				{Index: 200, Reason: CleanAcceleration},
+			},
+		},
+		{
+			desc:   "looser thresholds",
+			points: spiky(),
+			opts:   CleanOptions{MaxAccel: 20, MaxClimbRate: 500},
+			want: func() []TrackPoint {
+				res := lastSpike(removed(50, 100, 101))
+				res[147].Elevation += 200
+				res[197].Lat += 15 / metersPerDegree
+				return res
+			}(),
+			wantChanges: []CleanChange{
+				{Index: 50, Reason: CleanSpeed, Removed: true},
+				{Index: 100, Reason: CleanSpeed, Removed: true},
+				{Index: 101, Reason: CleanSpeed, Removed: true},
+			},
+		},
+		{
+			desc:        "too short",
+			points:      spiky()[49:51],
+			want:        spiky()[49:51],
+			wantChanges: []CleanChange{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			orig := append([]TrackPoint(nil), tc.points...)
+			got, changes := CleanTrack(tc.points, tc.opts)
+			if diff := cmp.Diff(tc.wantChanges, changes); diff != "" {
+				t.Errorf("bad changes: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad track: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(orig, tc.points); diff != "" {
+				t.Errorf("input was changed: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMaxSpeeds(t *testing.T) {
+	ride := &Ride{TrackPoints: spiky()}
+	ride.Metrics.Speed.Max = 4000
+	if got := TrackMaxSpeed(ride.TrackPoints); got < 1000 {
+		t.Errorf("expected the spikes to be fast, got max speed %v", got)
+	}
+
+	// The glitch at the end stays, since the last point is never removed.
+	ride.TrackPoints = ride.TrackPoints[:len(ride.TrackPoints)-1]
+	api, cleaned := ride.MaxSpeeds(CleanOptions{})
+	if api != 4000 {
+		t.Errorf("bad API max speed: %v", api)
+	}
+	if cleaned < 29.9 || cleaned > 30.1 {
+		t.Errorf("want a cleaned max speed of 30km/h, got %v", cleaned)
+	}
+}
@@ -0,0 +1,185 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+)
+
+// ClimbCategory is a climb's difficulty, from Cat4 (easiest) to HC.
+type ClimbCategory int
+
+const (
+	Uncategorized ClimbCategory = iota
+	Cat4
+	Cat3
+	Cat2
+	Cat1
+	HC
+)
+
+func (c ClimbCategory) String() string {
+	switch c {
+	case Uncategorized:
+		return "uncategorized"
+	case Cat4:
+		return "4"
+	case Cat3:
+		return "3"
+	case Cat2:
+		return "2"
+	case Cat1:
+		return "1"
+	case HC:
+		return "HC"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(c))
+}
+
+// Minimum scores (length in meters * average grade in percent) for each
+// category.
+var climbScores = []struct {
+	score float64
+	cat   ClimbCategory
+}{
+	{80000, HC},
+	{64000, Cat1},
+	{32000, Cat2},
+	{16000, Cat3},
+	{8000, Cat4},
+}
+
+// ClimbCategoryForScore returns the category for a climb's score, its length
+// in meters times its average grade in percent.
+func ClimbCategoryForScore(score float64) ClimbCategory {
+	for _, s := range climbScores {
+		if score >= s.score {
+			return s.cat
+		}
+	}
+
+	return Uncategorized
+}
+
+// ClimbOptions tunes DetectClimbs. Zero fields use the defaults.
+type ClimbOptions struct {
+	// Minimum average grade, in percent. Defaults to 3.
+	MinGrade float64
+	// Minimum elevation gained, in meters. Defaults to 20.
+	MinGain float64
+	// Climbs separated by descents of less than this many meters are merged
+	// into one. Defaults to 10.
+	MaxDip float64
+}
+
+// Climb is a significant climb along a track. Start and End are indexes into
+// the track points, Length and Gain are in meters, and grades in percent.
+// MaxGrade is the steepest 100m of the climb.
+type Climb struct {
+	Start    int
+	End      int
+	Length   float64
+	Gain     float64
+	AvgGrade float64
+	MaxGrade float64
+	Score    float64
+	Category ClimbCategory
+}
+
+func (o ClimbOptions) withDefaults() (ClimbOptions, error) {
+	if o.MinGrade < 0 || o.MinGain < 0 || o.MaxDip < 0 {
+		return o, fmt.Errorf("invalid climb options %+v: values can't be negative", o)
+	}
+	if o.MinGrade == 0 {
+		o.MinGrade = 3
+	}
+	if o.MinGain == 0 {
+		o.MinGain = 20
+	}
+	if o.MaxDip == 0 {
+		o.MaxDip = 10
+	}
+
+	return o, nil
+}
+
+// DetectClimbs finds the climbs in a track. Points that only carry sensor
+// data are ignored.
+func DetectClimbs(points []TrackPoint, opts ClimbOptions) ([]Climb, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	dist := trackDistances(points)
+	var idx []int
+	skip := sensorOnly(points)
+	for i, p := range points {
+		if !skip(p) {
+			idx = append(idx, i)
+		}
+	}
+
+	res := []Climb{}
+	if len(idx) < 2 {
+		return res, nil
+	}
+
+	// Each climb runs from its lowest point to its highest, and ends when the
+	// elevation drops by more than MaxDip, or below where it started.
+	start, top := idx[0], idx[0]
+	for _, i := range idx[1:] {
+		e := points[i].Elevation
+		if e > points[top].Elevation {
+			top = i
+			continue
+		}
+		if top == start {
+			// Not climbing yet.
+			start, top = i, i
+			continue
+		}
+		if points[top].Elevation-e > opts.MaxDip || e < points[start].Elevation {
+			if c, ok := newClimb(points, dist, skip, start, top, opts); ok {
+				res = append(res, c)
+			}
+			start, top = i, i
+		}
+	}
+	if c, ok := newClimb(points, dist, skip, start, top, opts); ok {
+		res = append(res, c)
+	}
+
+	return res, nil
+}
+
+// newClimb returns the climb from start to end, if it's significant.
+func newClimb(points []TrackPoint, dist []float64, skip func(TrackPoint) bool, start, end int, opts ClimbOptions) (Climb, bool) {
+	c := Climb{
+		Start:  start,
+		End:    end,
+		Length: dist[end] - dist[start],
+		Gain:   points[end].Elevation - points[start].Elevation,
+	}
+	if c.Length <= 0 || c.Gain < opts.MinGain {
+		return c, false
+	}
+	c.AvgGrade = c.Gain / c.Length * 100
+	if c.AvgGrade < opts.MinGrade {
+		return c, false
+	}
+
+	sub := make([]TrackPoint, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		p := points[i]
+		p.Distance = dist[i]
+		if !skip(p) {
+			sub = append(sub, p)
+		}
+	}
+	c.MaxGrade = math.Max(steepest(sub, 100), c.AvgGrade)
+	c.Score = c.Length * c.AvgGrade
+	c.Category = ClimbCategoryForScore(c.Score)
+
+	return c, true
+}
@@ -0,0 +1,167 @@
+package goride
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// synthMountain is 2km flat, a 10km 7% climb, a short dip, 2km at 5% to the
+// top, a 5km descent, then a small and a tiny bump.
+func synthMountain() []TrackPoint {
+	return synthRoute(0,
+		[2]float64{2000, 0},
+		[2]float64{10000, 0.07},
+		[2]float64{200, -0.025},
+		[2]float64{2000, 0.05},
+		[2]float64{5000, -0.08},
+		[2]float64{1000, 0},
+		[2]float64{1000, 0.04},
+		[2]float64{500, 0.02},
+	).TrackPoints
+}
+
+func TestDetectClimbs(t *testing.T) {
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		opts   ClimbOptions
+		want   []Climb
+	}{
+		{
+			desc:   "mountain",
+			points: synthMountain(),
+			want: []Climb{
+				{
+					Start:    20,
+					End:      142,
+					Length:   12200,
+					Gain:     795,
+					AvgGrade: 795.0 / 122,
+					MaxGrade: 7,
+					Score:    79500,
+					Category: Cat1,
+				},
+				{
+					Start:    202,
+					End:      217,
+					Length:   1500,
+					Gain:     50,
+					AvgGrade: 50.0 / 15,
+					MaxGrade: 4,
+					Score:    5000,
+				},
+			},
+		},
+		{
+			desc:   "no merging",
+			points: synthMountain(),
+			opts:   ClimbOptions{MaxDip: 3},
+			want: []Climb{
+				{
+					Start:    20,
+					End:      120,
+					Length:   10000,
+					Gain:     700,
+					AvgGrade: 7,
+					MaxGrade: 7,
+					Score:    70000,
+					Category: Cat1,
+				},
+				{
+					Start:    122,
+					End:      142,
+					Length:   2000,
+					Gain:     100,
+					AvgGrade: 5,
+					MaxGrade: 5,
+					Score:    10000,
+					Category: Cat4,
+				},
+				{
+					Start:    202,
+					End:      217,
+					Length:   1500,
+					Gain:     50,
+					AvgGrade: 50.0 / 15,
+					MaxGrade: 4,
+					Score:    5000,
+				},
+			},
+		},
+		{
+			desc:   "downhill",
+			points: synthRoute(0, [2]float64{5000, -0.05}).TrackPoints,
+			want:   []Climb{},
+		},
+		{
+			desc: "empty",
+			want: []Climb{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := DetectClimbs(tc.points, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-6)); diff != "" {
+				t.Errorf("bad climbs: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := DetectClimbs(synthMountain(), ClimbOptions{MinGain: -1}); err == nil {
+		t.Errorf("expected an error for negative options")
+	}
+}
+
+func TestDetectClimbsRide(t *testing.T) {
+	got, err := DetectClimbs(getTestRide(t).TrackPoints, ClimbOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cats []ClimbCategory
+	prev := -1
+	for _, c := range got {
+		if c.Start <= prev || c.End <= c.Start {
+			t.Errorf("climb out of order: %+v", c)
+		}
+		prev = c.End
+		if c.Gain < 20 || c.AvgGrade < 3 || c.MaxGrade < c.AvgGrade {
+			t.Errorf("bad climb: %+v", c)
+		}
+		if c.Category != Uncategorized {
+			cats = append(cats, c.Category)
+		}
+	}
+
+	if diff := cmp.Diff([]ClimbCategory{Cat3, Cat3}, cats); diff != "" {
+		t.Errorf("bad categories: -want +got\n%s", diff)
+	}
+}
+
+func TestClimbCategoryForScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "uncategorized"},
+		{7999, "uncategorized"},
+		{8000, "4"},
+		{20000, "3"},
+		{32000, "2"},
+		{70000, "1"},
+		{80000, "HC"},
+		{1e6, "HC"},
+	}
+
+	for _, tc := range tests {
+		if got := ClimbCategoryForScore(tc.score).String(); got != tc.want {
+			t.Errorf("bad category for %f: want %q, got %q", tc.score, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package goride
+
+import (
+	"fmt"
+	"time"
+)
+
+type Club struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+	MemberCount int        `json:"members_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ClubMember struct {
+	UserID   int       `json:"user_id"`
+	Name     string    `json:"name"`
+	Admin    bool      `json:"admin"`
+	JoinedAt time.Time `json:"created_at"`
+}
+
+// GetClubs lists the clubs the current user is a member of.
+func (r *RWGPS) GetClubs() ([]*Club, error) {
+	res, err := r.Get("/clubs.json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting clubs: %w", err)
+	}
+
+	var resStruct struct {
+		Results []*Club
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return resStruct.Results, nil
+}
+
+// GetClub gets a club. Private clubs the user isn't a member of return
+// ErrPrivate.
+func (r *RWGPS) GetClub(id int) (*Club, error) {
+	res, err := r.Get(fmt.Sprintf("/clubs/%d.json", id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting club %d: %w", id, err)
+	}
+
+	var resStruct struct {
+		Club *Club
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	if resStruct.Club == nil {
+		return nil, fmt.Errorf("error getting club %d: %w", id, ErrNotFound)
+	}
+
+	return resStruct.Club, nil
+}
+
+func (r *RWGPS) GetClubMembers(id, offset, limit int) ([]*ClubMember, int, error) {
+	var members []*ClubMember
+	count, err := r.getPage(fmt.Sprintf("/clubs/%d/members.json", id), offset, limit, nil, &members)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting members %d+%d for club %d: %w", offset, limit, id, err)
+	}
+
+	return members, count, nil
+}
+
+// GetClubRoutes lists a club's routes. The routes don't include track points,
+// use GetRoute for those.
+func (r *RWGPS) GetClubRoutes(id, offset, limit int) ([]*Route, int, error) {
+	var routes []*Route
+	count, err := r.getPage(fmt.Sprintf("/clubs/%d/routes.json", id), offset, limit, nil, &routes)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting routes %d+%d for club %d: %w", offset, limit, id, err)
+	}
+
+	return routes, count, nil
+}
@@ -0,0 +1,142 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetClubs(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/clubs.json": getTestData("clubs.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, err := r.GetClubs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, c := range got {
+		names = append(names, c.Name)
+	}
+	if diff := cmp.Diff([]string{"East Bay Randonneurs", "Tuesday Coffee Ride"}, names); diff != "" {
+		t.Errorf("bad clubs: -want +got\n%s", diff)
+	}
+	if got[1].Visibility != Private || got[1].MemberCount != 14 {
+		t.Errorf("bad club: %+v", got[1])
+	}
+}
+
+func TestGetClub(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/clubs/501.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("club.json"))
+	})
+	private := func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "members only", http.StatusForbidden)
+	}
+	f.handle("/clubs/502.json", private)
+	f.handle("/clubs/502/members.json", private)
+	f.handle("/clubs/502/routes.json", private)
+	r := testObj(f.URL)
+
+	got, err := r.GetClub(501)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 501 || got.Name != "East Bay Randonneurs" || got.MemberCount != 212 {
+		t.Errorf("bad club: %+v", got)
+	}
+
+	if _, err := r.GetClub(502); !errors.Is(err, ErrPrivate) {
+		t.Errorf("private club: want ErrPrivate, got %v", err)
+	}
+	if _, _, err := r.GetClubMembers(502, 0, 2); !errors.Is(err, ErrPrivate) {
+		t.Errorf("private club members: want ErrPrivate, got %v", err)
+	}
+	if _, _, err := r.GetClubRoutes(502, 0, 2); !errors.Is(err, ErrPrivate) {
+		t.Errorf("private club routes: want ErrPrivate, got %v", err)
+	}
+	if _, err := r.GetClub(503); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing club: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetClubMembers(t *testing.T) {
+	tests := []struct {
+		desc    string
+		offset  int
+		limit   int
+		wantIDs []int
+	}{
+		{
+			desc:    "0, 2",
+			offset:  0,
+			limit:   2,
+			wantIDs: []int{1268590, 2},
+		},
+		{
+			desc:    "1, 2",
+			offset:  1,
+			limit:   2,
+			wantIDs: []int{2, 3},
+		},
+	}
+
+	f := func(_ string, args url.Values) string {
+		return getTestData(fmt.Sprintf("club_members%s-%s.json", args.Get("offset"), args.Get("limit")))
+	}
+	server := startServer(t,
+		nil,
+		map[string]func(string, url.Values) string{
+			"/clubs/501/members.json": f,
+		})
+	defer server.Close()
+	r := testObj(server.URL)
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, count, err := r.GetClubMembers(501, tc.offset, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != 212 {
+				t.Errorf("wrong count: %d", count)
+			}
+
+			var gotIDs []int
+			for _, m := range got {
+				gotIDs = append(gotIDs, m.UserID)
+			}
+			if diff := cmp.Diff(tc.wantIDs, gotIDs); diff != "" {
+				t.Errorf("bad member IDs: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetClubRoutes(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/clubs/501/routes.json": getTestData("club_routes0-2.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, count, err := r.GetClubRoutes(501, 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 7 || len(got) != 2 {
+		t.Fatalf("bad routes: %d of %d", len(got), count)
+	}
+	if got[0].ID != 31330404 || got[0].Name != "Mt Diablo 200k" || got[0].ElevationGain != 3120.2 {
+		t.Errorf("bad route: %+v", got[0])
+	}
+}
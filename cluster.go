@@ -0,0 +1,80 @@
+package goride
+
+import "sort"
+
+// StartCluster is a group of rides that start near each other.
+type StartCluster struct {
+	Centroid LatLng
+	Count    int
+	IDs      []int
+	// Label is the rides' "Locality, AdministrativeArea", if they all agree.
+	Label string
+}
+
+// ClusterStarts groups rides by where they start. Each ride joins the nearest
+// cluster whose centroid is within radiusMeters, or starts a new one. Clusters
+// are returned biggest first, along with the IDs of rides that have no start
+// coordinates.
+func ClusterStarts(rides []*RideSlim, radiusMeters float64) ([]StartCluster, []int) {
+	type cluster struct {
+		lat, lng float64
+		ids      []int
+		label    string
+		mixed    bool
+	}
+	var clusters []*cluster
+	var unknown []int
+	for _, r := range rides {
+		if r.FirstLat == 0 && r.FirstLng == 0 {
+			unknown = append(unknown, r.ID)
+			continue
+		}
+
+		var best *cluster
+		bestDist := radiusMeters
+		for _, c := range clusters {
+			if d := haversine(c.lat, c.lng, r.FirstLat, r.FirstLng); d <= bestDist {
+				best, bestDist = c, d
+			}
+		}
+		if best == nil {
+			best = &cluster{}
+			clusters = append(clusters, best)
+		}
+
+		n := float64(len(best.ids))
+		best.lat = (best.lat*n + r.FirstLat) / (n + 1)
+		best.lng = (best.lng*n + r.FirstLng) / (n + 1)
+		best.ids = append(best.ids, r.ID)
+		if label := rideLabel(r); label != "" && !best.mixed {
+			if best.label == "" {
+				best.label = label
+			} else if best.label != label {
+				best.label, best.mixed = "", true
+			}
+		}
+	}
+
+	res := make([]StartCluster, len(clusters))
+	for i, c := range clusters {
+		res[i] = StartCluster{
+			Centroid: LatLng{Lat: float32(c.lat), Lng: float32(c.lng)},
+			Count:    len(c.ids),
+			IDs:      c.ids,
+			Label:    c.label,
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Count > res[j].Count })
+
+	return res, unknown
+}
+
+func rideLabel(r *RideSlim) string {
+	switch {
+	case r.Locality != "" && r.AdministrativeArea != "":
+		return r.Locality + ", " + r.AdministrativeArea
+	case r.Locality != "":
+		return r.Locality
+	}
+	return r.AdministrativeArea
+}
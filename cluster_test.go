@@ -0,0 +1,51 @@
+package goride
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestClusterStarts(t *testing.T) {
+	at := func(id int, lat, lng float64, locality string) *RideSlim {
+		r := &RideSlim{ID: id, FirstLat: lat, FirstLng: lng}
+		if locality != "" {
+			r.Locality, r.AdministrativeArea = locality, "CA"
+		}
+		return r
+	}
+	rides := []*RideSlim{
+		// Around Berkeley.
+		at(1, 37.8700, -122.2700, "Berkeley"),
+		at(2, 37.8705, -122.2705, "Berkeley"),
+		at(3, 37.8695, -122.2695, "Berkeley"),
+		// Around Palo Alto, one of them tagged with the next town over.
+		at(4, 37.4400, -122.1600, "Palo Alto"),
+		at(5, 37.4402, -122.1598, "Menlo Park"),
+		// Trainer ride.
+		{ID: 6},
+		// Tahoe.
+		at(7, 39.0968, -120.0324, ""),
+		// Not geocoded, so it doesn't change the label.
+		at(8, 37.8702, -122.2698, ""),
+	}
+
+	gotClusters, gotUnknown := ClusterStarts(rides, 500)
+
+	want := []StartCluster{
+		{Centroid: LatLng{Lat: 37.87005, Lng: -122.26995}, Count: 4, IDs: []int{1, 2, 3, 8}, Label: "Berkeley, CA"},
+		{Centroid: LatLng{Lat: 37.4401, Lng: -122.1599}, Count: 2, IDs: []int{4, 5}},
+		{Centroid: LatLng{Lat: 39.0968, Lng: -120.0324}, Count: 1, IDs: []int{7}},
+	}
+	if diff := cmp.Diff(want, gotClusters, cmpopts.EquateApprox(0, 1e-5)); diff != "" {
+		t.Errorf("bad clusters: -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{6}, gotUnknown); diff != "" {
+		t.Errorf("bad unknown rides: -want +got\n%s", diff)
+	}
+
+	if got, _ := ClusterStarts(rides, 100000); len(got) != 2 {
+		t.Errorf("want the bay area as one cluster with a big radius, got %d clusters", len(got))
+	}
+}
@@ -0,0 +1,356 @@
+// Command goride is a command line client for the RWGPS API.
+//
+//	goride [--config path] [--json] <command> [args]
+//
+// Commands:
+//
+//	whoami                     show the logged in user
+//	rides [--limit N] [--offset N] [--user ID]
+//	                           list rides, newest first
+//	ride ID                    show a ride
+//	export ID --gpx|--kml FILE write a ride's track, "-" for stdout
+//
+// The exit code is 3 when the login fails, 4 when something isn't found, 5
+// for network errors, 2 for bad usage and 1 for anything else.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zigdon/goride"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitAuth
+	exitNotFound
+	exitNetwork
+)
+
+// warmupTimeout bounds the connection warmup, which only helps if it's done
+// around when the first request is sent.
+const warmupTimeout = 5 * time.Second
+
+// connectFunc returns the service to use, given the config path.
+type connectFunc func(cfgPath string) (goride.Service, error)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, func(cfgPath string) (goride.Service, error) {
+		return connect(cfgPath)
+	}))
+}
+
+// connect creates the client, and starts warming up its connection to the
+// server while the command gets going.
+func connect(cfgPath string, opts ...goride.Option) (goride.Service, error) {
+	r, err := goride.New(cfgPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+		defer cancel()
+		r.Warmup(ctx)
+	}()
+
+	return r, nil
+}
+
+func defaultConfig() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "goride.ini"
+	}
+	return filepath.Join(dir, "goride", "goride.ini")
+}
+
+// cli is the state shared by all the commands.
+type cli struct {
+	svc    goride.Service
+	json   bool
+	stdout io.Writer
+}
+
+type command struct {
+	usage string
+	// args is the number of positional arguments.
+	args  int
+	flags func(fs *flag.FlagSet) func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"whoami": {usage: "whoami", flags: whoamiCmd},
+	"rides":  {usage: "rides [--limit N] [--offset N] [--user ID]", flags: ridesCmd},
+	"ride":   {usage: "ride ID", args: 1, flags: rideCmd},
+	"export": {usage: "export ID --gpx|--kml FILE", args: 1, flags: exportCmd},
+}
+
+func run(args []string, stdout, stderr io.Writer, connect connectFunc) int {
+	global := flag.NewFlagSet("goride", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	cfgPath := global.String("config", defaultConfig(), "path to the config file")
+	asJSON := global.Bool("json", false, "print JSON instead of tables")
+	global.Usage = func() {
+		fmt.Fprintln(stderr, "usage: goride [--config path] [--json] <command> [args]\n\ncommands:")
+		for _, name := range []string{"whoami", "rides", "ride", "export"} {
+			fmt.Fprintf(stderr, "  %s\n", commands[name].usage)
+		}
+	}
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+
+	name := global.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		global.Usage()
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(asJSON, "json", *asJSON, "print JSON instead of tables")
+	fs.Usage = func() { fmt.Fprintf(stderr, "usage: goride %s\n", cmd.usage) }
+	do := cmd.flags(fs)
+	pos, err := parseInterspersed(fs, global.Args()[1:])
+	if err != nil {
+		return exitUsage
+	}
+	if len(pos) != cmd.args {
+		fs.Usage()
+		return exitUsage
+	}
+
+	svc, err := connect(*cfgPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "goride: %v\n", err)
+		return exitError
+	}
+
+	c := &cli{svc: svc, json: *asJSON, stdout: stdout}
+	if err := do(c, pos); err != nil {
+		fmt.Fprintf(stderr, "goride: %v\n", err)
+		var usage usageError
+		if errors.As(err, &usage) {
+			fs.Usage()
+		}
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+// parseInterspersed parses flags that come before, after or between the
+// positional arguments, and returns the positional ones.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func exitCode(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.As(err, new(usageError)):
+		return exitUsage
+	case errors.Is(err, goride.ErrAuthFailed):
+		return exitAuth
+	case errors.Is(err, goride.ErrNotFound):
+		return exitNotFound
+	case errors.As(err, &netErr):
+		return exitNetwork
+	}
+	return exitError
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, usageError(fmt.Sprintf("bad ID %q", s))
+	}
+	return id, nil
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *cli) table(header string, rows [][]interface{}) error {
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	if header != "" {
+		fmt.Fprintln(tw, header)
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, v)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func whoamiCmd(fs *flag.FlagSet) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		u, err := c.svc.GetCurrentUser()
+		if err != nil {
+			return err
+		}
+		if c.json {
+			pub := *u
+			pub.AuthToken = ""
+			return c.printJSON(pub)
+		}
+		return c.table("", [][]interface{}{
+			{"ID:", u.ID},
+			{"Name:", u.Name},
+			{"Rides:", u.TotalTrips},
+		})
+	}
+}
+
+func ridesCmd(fs *flag.FlagSet) func(c *cli, args []string) error {
+	limit := fs.Int("limit", 20, "number of rides to list")
+	offset := fs.Int("offset", 0, "number of rides to skip")
+	user := fs.Int("user", 0, "user whose rides to list, defaults to the logged in user")
+	return func(c *cli, args []string) error {
+		if *limit <= 0 || *offset < 0 {
+			return usageError("--limit must be positive, and --offset can't be negative")
+		}
+		if *user == 0 {
+			u, err := c.svc.GetCurrentUser()
+			if err != nil {
+				return err
+			}
+			*user = u.ID
+		}
+		opts := goride.GetRidesOpts{SortBy: "departed_at", Order: goride.OrderDesc}
+		rides, _, err := c.svc.GetRidesWithOpts(*user, *offset, *limit, opts)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			if rides == nil {
+				rides = []*goride.RideSlim{}
+			}
+			return c.printJSON(rides)
+		}
+
+		var rows [][]interface{}
+		for _, r := range rides {
+			rows = append(rows, []interface{}{
+				r.ID,
+				r.LocalDepartedAt().Format("2006-01-02"),
+				fmt.Sprintf("%.1f", r.Distance/1000),
+				fmt.Sprintf("%.0f", r.ElevationGain),
+				time.Duration(r.MovingTime) * time.Second,
+				r.Name,
+			})
+		}
+		return c.table("ID\tDATE\tKM\tCLIMB (M)\tMOVING\tNAME", rows)
+	}
+}
+
+func rideCmd(fs *flag.FlagSet) func(c *cli, args []string) error {
+	return func(c *cli, args []string) error {
+		id, err := parseID(args[0])
+		if err != nil {
+			return err
+		}
+		r, err := c.svc.GetRide(id)
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return c.printJSON(r)
+		}
+
+		gear := ""
+		if r.Gear != nil {
+			gear = r.Gear.Name
+		}
+		return c.table("", [][]interface{}{
+			{"ID:", r.ID},
+			{"Name:", r.Name},
+			{"Date:", r.LocalStarted().Format("2006-01-02 15:04 MST")},
+			{"Distance:", fmt.Sprintf("%.1f km", r.Distance/1000)},
+			{"Climbing:", fmt.Sprintf("%.0f m", r.Metrics.ElevationGain)},
+			{"Moving time:", time.Duration(r.Metrics.MovingTime) * time.Second},
+			{"Gear:", gear},
+			{"Visibility:", r.Visibility},
+			{"Points:", len(r.TrackPoints)},
+		})
+	}
+}
+
+func exportCmd(fs *flag.FlagSet) func(c *cli, args []string) error {
+	gpx := fs.String("gpx", "", "write the track as GPX to this file")
+	kml := fs.String("kml", "", "write the track as KML to this file")
+	return func(c *cli, args []string) error {
+		id, err := parseID(args[0])
+		if err != nil {
+			return err
+		}
+		if (*gpx == "") == (*kml == "") {
+			return usageError("exactly one of --gpx or --kml is needed")
+		}
+		r, err := c.svc.GetRide(id)
+		if err != nil {
+			return err
+		}
+
+		export, path := goride.ExportGPX, *gpx
+		if *kml != "" {
+			export, path = goride.ExportKML, *kml
+		}
+		if path == "-" {
+			return export(c.stdout, r.Name, r.TrackPoints)
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := export(f, r.Name, r.TrackPoints); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
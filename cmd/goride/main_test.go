@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride"
+	"github.com/zigdon/goride/goridetest"
+)
+
+func testFake() *goridetest.Fake {
+	f := goridetest.New()
+	f.SetUser(&goride.User{ID: 7, Name: "zigdon", AuthToken: "secret", TotalTrips: 2})
+	day := time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+	f.AddRides(7,
+		&goride.RideSlim{ID: 1, Name: "Older", DepartedAt: day, Distance: 10000, ElevationGain: 100, MovingTime: 1800},
+		&goride.RideSlim{ID: 2, Name: "Newer", DepartedAt: day.AddDate(0, 0, 1), Distance: 25500, ElevationGain: 350, MovingTime: 3600},
+	)
+	f.AddRide(&goride.Ride{
+		ID:      2,
+		Name:    "Newer",
+		Started: day.AddDate(0, 0, 1),
+		TrackPoints: []goride.TrackPoint{
+			{Lat: 37.5, Lng: -122.25},
+			{Lat: 37.51, Lng: -122.25},
+		},
+	})
+
+	return f
+}
+
+func runTest(t *testing.T, f *goridetest.Fake, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr, func(string) (goride.Service, error) {
+		return f, nil
+	})
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestConnectWarmsUp(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	// Warming up only does something for HTTPS servers.
+	server.StartTLS()
+	defer server.Close()
+	cfg := filepath.Join(t.TempDir(), "goride.ini")
+	if err := os.WriteFile(cfg, []byte("[Auth]\nname = \"test key\"\nauth_token = beef1337\n"), 0600); err != nil {
+		t.Fatalf("can't write config: %v", err)
+	}
+
+	if _, err := connect(cfg, goride.WithServer(server.URL)); err != nil {
+		t.Fatalf("connect(): %v", err)
+	}
+	// Nothing else was sent, so the connection is the warmup's.
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt32(&conns) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("connect didn't warm up the connection")
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		desc     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			desc: "whoami",
+			args: []string{"whoami"},
+			want: []string{"ID:     7", "Name:   zigdon", "Rides:  2"},
+		},
+		{
+			desc: "rides",
+			args: []string{"rides", "--limit", "1"},
+			want: []string{
+				"ID  DATE        KM    CLIMB (M)  MOVING  NAME",
+				"2   2021-06-02  25.5  350        1h0m0s  Newer",
+			},
+		},
+		{
+			desc: "ride",
+			args: []string{"ride", "2"},
+			want: []string{"Name:         Newer", "Points:       2"},
+		},
+		{
+			desc: "export to stdout",
+			args: []string{"export", "2", "--gpx", "-"},
+			want: []string{`<trkpt lat="37.51" lon="-122.25">`},
+		},
+		{
+			desc:     "no command",
+			wantCode: exitUsage,
+		},
+		{
+			desc:     "unknown command",
+			args:     []string{"frobnicate"},
+			wantCode: exitUsage,
+		},
+		{
+			desc:     "missing ID",
+			args:     []string{"ride"},
+			wantCode: exitUsage,
+		},
+		{
+			desc:     "bad ID",
+			args:     []string{"ride", "x"},
+			wantCode: exitUsage,
+		},
+		{
+			desc:     "no export format",
+			args:     []string{"export", "2"},
+			wantCode: exitUsage,
+		},
+		{
+			desc:     "not found",
+			args:     []string{"ride", "5"},
+			wantCode: exitNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			code, stdout, stderr := runTest(t, testFake(), tc.args...)
+			if code != tc.wantCode {
+				t.Errorf("bad exit code: want %d, got %d\n%s", tc.wantCode, code, stderr)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("%q not in output:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	f := testFake()
+	code, stdout, stderr := runTest(t, f, "--json", "rides")
+	if code != exitOK {
+		t.Fatalf("rides failed with %d: %s", code, stderr)
+	}
+	var rides []*goride.RideSlim
+	if err := json.Unmarshal([]byte(stdout), &rides); err != nil {
+		t.Fatalf("bad JSON: %v\n%s", err, stdout)
+	}
+	var ids []int
+	for _, r := range rides {
+		ids = append(ids, r.ID)
+	}
+	if diff := cmp.Diff([]int{2, 1}, ids); diff != "" {
+		t.Errorf("bad rides: -want +got\n%s", diff)
+	}
+
+	// --json also works after the command, and never shows the token.
+	code, stdout, _ = runTest(t, f, "whoami", "--json")
+	if code != exitOK || !strings.Contains(stdout, `"Name": "zigdon"`) || strings.Contains(stdout, "secret") {
+		t.Errorf("bad whoami JSON (%d):\n%s", code, stdout)
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.kml")
+	code, _, stderr := runTest(t, testFake(), "export", "--kml", out, "2")
+	if code != exitOK {
+		t.Fatalf("export failed with %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), "<name>Newer</name>") {
+		t.Errorf("bad export: %v\n%s", err, data)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		desc string
+		err  error
+		want int
+	}{
+		{desc: "auth", err: fmt.Errorf("can't log in: %w", goride.ErrAuthFailed), want: exitAuth},
+		{desc: "not found", err: fmt.Errorf("error getting user: %w", goride.ErrNotFound), want: exitNotFound},
+		{desc: "network", err: fmt.Errorf("error in GET: %w", &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}), want: exitNetwork},
+		{desc: "other", err: fmt.Errorf("boom"), want: exitError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := testFake()
+			f.SetError("GetCurrentUser", tc.err)
+			code, _, stderr := runTest(t, f, "whoami")
+			if code != tc.want {
+				t.Errorf("bad exit code: want %d, got %d", tc.want, code)
+			}
+			if !strings.Contains(stderr, tc.err.Error()) {
+				t.Errorf("error not shown: %q", stderr)
+			}
+		})
+	}
+}
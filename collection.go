@@ -0,0 +1,224 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const collectionItemsPageSize = 50
+
+// CollectionKind is the type of an item in a collection.
+type CollectionKind string
+
+const (
+	CollectionRoute CollectionKind = "route"
+	CollectionRide  CollectionKind = "trip"
+)
+
+// Collection is a user's list of routes and rides, e.g. for planning a tour.
+// Items is only filled in by GetCollection.
+type Collection struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Visibility  Visibility `json:"visibility"`
+	ItemCount   int        `json:"items_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Items []CollectionItem `json:"-"`
+}
+
+// CollectionItem is a route or a ride in a collection. Only the one matching
+// Kind is set, and neither for kinds this package doesn't know about.
+type CollectionItem struct {
+	Kind  CollectionKind
+	Route *Route
+	Ride  *RideSlim
+}
+
+func (c *CollectionItem) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type  CollectionKind  `json:"type"`
+		Route *Route          `json:"route"`
+		Trip  json.RawMessage `json:"trip"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bad collection item: %w", err)
+	}
+	*c = CollectionItem{Kind: raw.Type}
+	switch raw.Type {
+	case CollectionRoute:
+		c.Route = raw.Route
+	case CollectionRide:
+		if len(raw.Trip) > 0 {
+			c.Ride = &RideSlim{}
+			if err := json.Unmarshal(raw.Trip, c.Ride); err != nil {
+				return fmt.Errorf("bad collection ride: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Routes returns the routes in the collection, in order.
+func (c *Collection) Routes() []*Route {
+	var res []*Route
+	for _, i := range c.Items {
+		if i.Route != nil {
+			res = append(res, i.Route)
+		}
+	}
+	return res
+}
+
+// Rides returns the rides in the collection, in order.
+func (c *Collection) Rides() []*RideSlim {
+	var res []*RideSlim
+	for _, i := range c.Items {
+		if i.Ride != nil {
+			res = append(res, i.Ride)
+		}
+	}
+	return res
+}
+
+// GetCollections lists the current user's collections, without their items.
+func (r *RWGPS) GetCollections() ([]*Collection, error) {
+	u, err := r.loggedInUser()
+	if err != nil {
+		return nil, fmt.Errorf("error getting collections: %w", err)
+	}
+
+	var resStruct struct {
+		Results []*Collection
+	}
+	if err := r.getJSON(fmt.Sprintf("/users/%d/collections.json", u.ID), nil, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting collections: %w", err)
+	}
+
+	return resStruct.Results, nil
+}
+
+// GetCollection gets a collection, with all its items. Collections that don't
+// exist return ErrNotFound, and ones the user can't see return ErrPrivate.
+func (r *RWGPS) GetCollection(id int) (*Collection, error) {
+	var resStruct struct {
+		Collection *Collection
+	}
+	if err := r.getJSON(fmt.Sprintf("/collections/%d.json", id), nil, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting collection %d: %w", id, err)
+	}
+	if resStruct.Collection == nil {
+		return nil, fmt.Errorf("error getting collection %d: %w", id, ErrNotFound)
+	}
+
+	c := resStruct.Collection
+	c.Items = []CollectionItem{}
+	for {
+		page, count, err := r.GetCollectionItems(id, len(c.Items), collectionItemsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		c.Items = append(c.Items, page...)
+		if len(page) == 0 || len(c.Items) >= count {
+			break
+		}
+	}
+
+	return c, nil
+}
+
+// GetCollectionItems gets a page of a collection's items, and the total
+// number of items. Routes and rides don't include track points.
+func (r *RWGPS) GetCollectionItems(id, offset, limit int) ([]CollectionItem, int, error) {
+	var items []CollectionItem
+	count, err := r.getPage(fmt.Sprintf("/collections/%d/items.json", id), offset, limit, nil, &items)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting items %d+%d for collection %d: %w", offset, limit, id, err)
+	}
+
+	return items, count, nil
+}
+
+// CreateCollection creates an empty collection, and returns it with its new
+// ID.
+func (r *RWGPS) CreateCollection(name, description string) (*Collection, error) {
+	if name == "" {
+		return nil, fmt.Errorf("can't create a collection without a name")
+	}
+
+	body, err := json.Marshal(map[string]map[string]string{
+		"collection": {"name": name, "description": description},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode collection: %w", err)
+	}
+
+	res, err := r.do(http.MethodPost, "/collections.json", nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating collection %q: %w", name, err)
+	}
+
+	var resStruct struct {
+		Collection *Collection
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	if resStruct.Collection == nil {
+		return nil, fmt.Errorf("creating collection %q didn't return it: %w", name, ErrDecode)
+	}
+
+	return resStruct.Collection, nil
+}
+
+// AddToCollection adds a route or a ride to a collection.
+func (r *RWGPS) AddToCollection(collectionID int, kind CollectionKind, itemID int) error {
+	if err := checkCollectionKind(kind); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]map[string]interface{}{
+		"item": {"type": kind, "id": itemID},
+	})
+	if err != nil {
+		return fmt.Errorf("can't encode collection item: %w", err)
+	}
+
+	path := fmt.Sprintf("/collections/%d/items.json", collectionID)
+	if err := r.send(http.MethodPost, path, nil, body); err != nil {
+		return fmt.Errorf("error adding %s %d to collection %d: %w", kind, itemID, collectionID, err)
+	}
+
+	return nil
+}
+
+// RemoveFromCollection removes a route or a ride from a collection. Items
+// that aren't in it return ErrNotFound.
+func (r *RWGPS) RemoveFromCollection(collectionID int, kind CollectionKind, itemID int) error {
+	if err := checkCollectionKind(kind); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/collections/%d/items.json", collectionID)
+	args := url.Values{"type": {string(kind)}, "id": {strconv.Itoa(itemID)}}
+	if err := r.send(http.MethodDelete, path, args, nil); err != nil {
+		return fmt.Errorf("error removing %s %d from collection %d: %w", kind, itemID, collectionID, err)
+	}
+
+	return nil
+}
+
+func checkCollectionKind(kind CollectionKind) error {
+	if kind != CollectionRoute && kind != CollectionRide {
+		return fmt.Errorf("bad collection item kind %q", kind)
+	}
+	return nil
+}
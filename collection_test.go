@@ -0,0 +1,163 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetCollections(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/users/1268590/collections.json": getTestData("collections.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, err := r.GetCollections()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, c := range got {
+		names = append(names, c.Name)
+	}
+	if diff := cmp.Diff([]string{"Sierra tour 2022", "Winter routes"}, names); diff != "" {
+		t.Errorf("bad collections: -want +got\n%s", diff)
+	}
+	if got[0].ID != 801 || got[0].Visibility != Private || got[0].ItemCount != 4 || got[0].Items != nil {
+		t.Errorf("bad collection: %+v", got[0])
+	}
+}
+
+func TestGetCollection(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/collections/801.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("collection.json"))
+	})
+	f.handle("/collections/801/items.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData(fmt.Sprintf("collection_items%s.json", req.URL.Query().Get("offset"))))
+	})
+	f.handle("/collections/802.json", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "private", http.StatusForbidden)
+	})
+	r := testObj(f.URL)
+
+	got, err := r.GetCollection(801)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Sierra tour 2022" || got.Description != "Five days, Tahoe to Yosemite." {
+		t.Errorf("bad collection: %+v", got)
+	}
+
+	// The server sends 2 items at a time, and the last one is of a kind we
+	// don't know.
+	var kinds []CollectionKind
+	for _, i := range got.Items {
+		kinds = append(kinds, i.Kind)
+	}
+	if diff := cmp.Diff([]CollectionKind{CollectionRoute, CollectionRide, CollectionRoute, "segment"}, kinds); diff != "" {
+		t.Errorf("bad items: -want +got\n%s", diff)
+	}
+	if i := got.Items[3]; i.Route != nil || i.Ride != nil {
+		t.Errorf("unknown item kind should be empty: %+v", i)
+	}
+
+	var routes []string
+	for _, rt := range got.Routes() {
+		routes = append(routes, rt.Name)
+	}
+	if diff := cmp.Diff([]string{"Day 1: Tahoe City to Sierraville", "Day 2: Sierraville to Downieville"}, routes); diff != "" {
+		t.Errorf("bad routes: -want +got\n%s", diff)
+	}
+	rides := got.Rides()
+	if len(rides) != 1 {
+		t.Fatalf("want 1 ride, got %d", len(rides))
+	}
+	if rides[0].ID != 38045212 || rides[0].RouteID != 31330404 || rides[0].Duration != 14400 {
+		t.Errorf("bad ride: %+v", rides[0])
+	}
+
+	if _, err := r.GetCollection(802); !errors.Is(err, ErrPrivate) {
+		t.Errorf("private collection: want ErrPrivate, got %v", err)
+	}
+	if _, err := r.GetCollection(803); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing collection: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestEditCollection(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var bodies []string
+	f.handle("/collections.json", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		fmt.Fprint(w, `{"collection":{"id":803,"name":"Coast ride","description":"SF to LA"}}`)
+	})
+	f.handle("/collections/803/items.json", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			bodies = append(bodies, req.URL.Query().Get("type")+" "+req.URL.Query().Get("id"))
+			if req.URL.Query().Get("id") == "42" {
+				http.NotFound(w, req)
+				return
+			}
+		} else {
+			b, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(b))
+		}
+		fmt.Fprint(w, "{}")
+	})
+	r := testObj(f.URL)
+
+	c, err := r.CreateCollection("Coast ride", "SF to LA")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.ID != 803 || c.Name != "Coast ride" {
+		t.Errorf("bad collection: %+v", c)
+	}
+	if err := r.AddToCollection(803, CollectionRoute, 31330404); err != nil {
+		t.Errorf("unexpected error adding a route: %v", err)
+	}
+	if err := r.AddToCollection(803, CollectionRide, 38045212); err != nil {
+		t.Errorf("unexpected error adding a ride: %v", err)
+	}
+	if err := r.RemoveFromCollection(803, CollectionRoute, 31330404); err != nil {
+		t.Errorf("unexpected error removing a route: %v", err)
+	}
+	if err := r.RemoveFromCollection(803, CollectionRide, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing item: want ErrNotFound, got %v", err)
+	}
+	if err := r.AddToCollection(803, "segment", 9); err == nil {
+		t.Errorf("expected an error adding an unknown kind")
+	}
+	if _, err := r.CreateCollection("", "nameless"); err == nil {
+		t.Errorf("expected an error creating a collection without a name")
+	}
+
+	want := []string{
+		`{"collection":{"description":"SF to LA","name":"Coast ride"}}`,
+		`{"item":{"id":31330404,"type":"route"}}`,
+		`{"item":{"id":38045212,"type":"trip"}}`,
+		"route 31330404",
+		"trip 42",
+	}
+	if diff := cmp.Diff(want, bodies); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+	wantWrites := []string{
+		"POST /collections.json",
+		"POST /collections/803/items.json",
+		"POST /collections/803/items.json",
+		"DELETE /collections/803/items.json",
+		"DELETE /collections/803/items.json",
+	}
+	if diff := cmp.Diff(wantWrites, f.writes()); diff != "" {
+		t.Errorf("bad writes: -want +got\n%s", diff)
+	}
+}
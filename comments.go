@@ -0,0 +1,108 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const commentsPageSize = 50
+
+// ErrEmptyComment is returned when posting a comment with no text.
+var ErrEmptyComment = errors.New("empty comment")
+
+// Comment is a comment on a ride. Text has its HTML entities decoded.
+type Comment struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetRideComments gets all the comments on a ride, oldest first.
+func (r *RWGPS) GetRideComments(id int) ([]*Comment, error) {
+	var res []*Comment
+	for {
+		var page []*Comment
+		count, err := r.getPage(fmt.Sprintf("/trips/%d/comments.json", id), len(res), commentsPageSize, nil, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error getting comments %d+%d for ride %d: %w", len(res), commentsPageSize, id, err)
+		}
+		for _, c := range page {
+			c.Text = html.UnescapeString(c.Text)
+		}
+		res = append(res, page...)
+		if len(page) == 0 || len(res) >= count {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// PostRideComment comments on a ride, and returns the new comment.
+func (r *RWGPS) PostRideComment(id int, text string) (*Comment, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("can't comment on ride %d: %w", id, ErrEmptyComment)
+	}
+
+	body, err := json.Marshal(map[string]map[string]string{"comment": {"text": text}})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode comment for ride %d: %w", id, err)
+	}
+
+	res, err := r.do(http.MethodPost, fmt.Sprintf("/trips/%d/comments.json", id), nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("error commenting on ride %d: %w", id, err)
+	}
+
+	var resStruct struct {
+		Comment Comment
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	resStruct.Comment.Text = html.UnescapeString(resStruct.Comment.Text)
+
+	return &resStruct.Comment, nil
+}
+
+// DeleteComment deletes one of the user's comments.
+func (r *RWGPS) DeleteComment(commentID int) error {
+	if err := r.send(http.MethodDelete, fmt.Sprintf("/comments/%d.json", commentID), nil, nil); err != nil {
+		return fmt.Errorf("error deleting comment %d: %w", commentID, err)
+	}
+
+	return nil
+}
+
+// LikeRide likes a ride, and returns its updated like count.
+func (r *RWGPS) LikeRide(id int) (int, error) {
+	return r.like(http.MethodPost, id)
+}
+
+// UnlikeRide takes back a like, and returns the ride's updated like count.
+func (r *RWGPS) UnlikeRide(id int) (int, error) {
+	return r.like(http.MethodDelete, id)
+}
+
+func (r *RWGPS) like(verb string, id int) (int, error) {
+	res, err := r.do(verb, fmt.Sprintf("/trips/%d/likes.json", id), nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error in %s like for ride %d: %w", verb, id, err)
+	}
+
+	var resStruct struct {
+		LikesCount int `json:"likes_count"`
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return 0, err
+	}
+
+	return resStruct.LikesCount, nil
+}
@@ -0,0 +1,119 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetRideComments(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	texts := []string{"Nice!", "Fish &amp; chips after?", "&quot;Brutal&quot; climb", "See you Sunday", "&#128075;"}
+	f.handle("/trips/5/comments.json", func(w http.ResponseWriter, req *http.Request) {
+		// Pages of two, whatever the limit.
+		offset, _ := strconv.Atoi(req.URL.Query().Get("offset"))
+		fmt.Fprint(w, `{"results_count":5,"results":[`)
+		for i := offset; i < offset+2 && i < len(texts); i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":%d,"user_id":2,"text":%q}`, i+1, texts[i])
+		}
+		fmt.Fprint(w, "]}")
+	})
+
+	got, err := r.GetRideComments(5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var gotTexts []string
+	for _, c := range got {
+		gotTexts = append(gotTexts, fmt.Sprintf("%d %s", c.ID, c.Text))
+	}
+	want := []string{"1 Nice!", "2 Fish & chips after?", `3 "Brutal" climb`, "4 See you Sunday", "5 👋"}
+	if diff := cmp.Diff(want, gotTexts); diff != "" {
+		t.Errorf("bad comments: -want +got\n%s", diff)
+	}
+
+	wantRequests := []string{
+		"GET /trips/5/comments.json",
+		"GET /trips/5/comments.json",
+		"GET /trips/5/comments.json",
+	}
+	if diff := cmp.Diff(wantRequests, f.requests); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+
+	if _, err := r.GetRideComments(6); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for a missing ride, got %v", err)
+	}
+}
+
+func TestRideSocialWrites(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	var bodies []string
+	f.handle("/trips/5/comments.json", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		fmt.Fprint(w, `{"comment":{"id":9,"user_id":1268590,"text":"Tea &amp; cake"}}`)
+	})
+	likes := 3
+	f.handle("/trips/5/likes.json", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			likes++
+		} else {
+			likes--
+		}
+		fmt.Fprintf(w, `{"likes_count":%d}`, likes)
+	})
+	f.handle("/comments/9.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "{}")
+	})
+
+	c, err := r.PostRideComment(5, "Tea & cake")
+	if err != nil {
+		t.Fatalf("can't comment: %v", err)
+	}
+	if c.ID != 9 || c.Text != "Tea & cake" {
+		t.Errorf("bad comment: %+v", c)
+	}
+	if _, err := r.PostRideComment(5, " \n"); !errors.Is(err, ErrEmptyComment) {
+		t.Errorf("want ErrEmptyComment, got %v", err)
+	}
+	if diff := cmp.Diff([]string{`{"comment":{"text":"Tea \u0026 cake"}}`}, bodies); diff != "" {
+		t.Errorf("bad comment body: -want +got\n%s", diff)
+	}
+
+	if n, err := r.LikeRide(5); err != nil || n != 4 {
+		t.Errorf("want 4 likes, got %d, %v", n, err)
+	}
+	if n, err := r.UnlikeRide(5); err != nil || n != 3 {
+		t.Errorf("want 3 likes, got %d, %v", n, err)
+	}
+	if err := r.DeleteComment(9); err != nil {
+		t.Errorf("can't delete comment: %v", err)
+	}
+	if err := r.DeleteComment(10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound deleting a missing comment, got %v", err)
+	}
+
+	want := []string{
+		"POST /trips/5/comments.json",
+		"POST /trips/5/likes.json",
+		"DELETE /trips/5/likes.json",
+		"DELETE /comments/9.json",
+		"DELETE /comments/10.json",
+	}
+	if diff := cmp.Diff(want, f.writes()); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,63 @@
+package goride
+
+import "time"
+
+// CommuteOptions narrow down which rides ClassifyCommutes flags.
+type CommuteOptions struct {
+	// WeekdaysOnly skips rides that leave on a weekend, in the ride's own
+	// timezone.
+	WeekdaysOnly bool
+	// MinDistance and MaxDistance, in meters, bound the ride's distance.
+	// Zero means no bound.
+	MinDistance float64
+	MaxDistance float64
+}
+
+// CommuteMatch is a ride that looks like a commute.
+type CommuteMatch struct {
+	ID int
+	// ToWork is true for rides from home to work, false for the way back.
+	ToWork bool
+	// Confidence is between 0.5 and 1, and is higher the closer the ride
+	// starts and ends to the anchors.
+	Confidence float64
+}
+
+// ClassifyCommutes flags the rides that start within radiusMeters of one of
+// home and work, and end within it of the other. Rides without coordinates,
+// like trainer rides, are skipped.
+func ClassifyCommutes(rides []*RideSlim, home, work LatLng, radiusMeters float64, opts CommuteOptions) []CommuteMatch {
+	var res []CommuteMatch
+	for _, r := range rides {
+		if (r.FirstLat == 0 && r.FirstLng == 0) || (r.LastLat == 0 && r.LastLng == 0) {
+			continue
+		}
+		if opts.WeekdaysOnly {
+			if day := r.LocalDepartedAt().Weekday(); day == time.Saturday || day == time.Sunday {
+				continue
+			}
+		}
+		dist := float64(r.Distance)
+		if (opts.MinDistance > 0 && dist < opts.MinDistance) || (opts.MaxDistance > 0 && dist > opts.MaxDistance) {
+			continue
+		}
+
+		from := func(a LatLng) float64 { return haversine(r.FirstLat, r.FirstLng, float64(a.Lat), float64(a.Lng)) }
+		to := func(a LatLng) float64 { return haversine(r.LastLat, r.LastLng, float64(a.Lat), float64(a.Lng)) }
+		m := CommuteMatch{ID: r.ID}
+		var start, end float64
+		switch {
+		case from(home) <= radiusMeters && to(work) <= radiusMeters:
+			m.ToWork = true
+			start, end = from(home), to(work)
+		case from(work) <= radiusMeters && to(home) <= radiusMeters:
+			start, end = from(work), to(home)
+		default:
+			continue
+		}
+		m.Confidence = 1 - (start+end)/(4*radiusMeters)
+		res = append(res, m)
+	}
+
+	return res
+}
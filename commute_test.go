@@ -0,0 +1,80 @@
+package goride
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestClassifyCommutes(t *testing.T) {
+	home := LatLng{Lat: 37.5, Lng: -122.25}
+	work := LatLng{Lat: 37.6, Lng: -122.25}
+	// north is m meters due north of home.
+	north := func(m float64) float64 { return 37.5 + m/(earthRadius*math.Pi/180) }
+	// 2021-08-02 is a Monday.
+	monday := time.Date(2021, 8, 2, 15, 0, 0, 0, time.UTC)
+	saturday := monday.Add(5 * 24 * time.Hour)
+	ride := func(id int, from, to float64, at time.Time, dist float32) *RideSlim {
+		return &RideSlim{
+			ID: id, DepartedAt: at, Distance: dist,
+			FirstLat: north(from), FirstLng: -122.25,
+			LastLat: north(to), LastLng: -122.25,
+		}
+	}
+	workDist := home.DistanceTo(work)
+
+	rides := []*RideSlim{
+		ride(1, 0, workDist, monday, 12000),
+		ride(2, workDist, 50, monday, 12000),
+		ride(3, 100, workDist-100, saturday, 15000),
+		ride(4, 0, 0, monday, 30000),
+		ride(5, 0, workDist+300, monday, 12000),
+		ride(6, 0, workDist, monday, 40000),
+		{ID: 7, DepartedAt: monday, Distance: 12000},
+		{ID: 8, DepartedAt: monday, Distance: 12000, LastLat: float64(work.Lat), LastLng: float64(work.Lng)},
+	}
+
+	tests := []struct {
+		desc string
+		opts CommuteOptions
+		want []CommuteMatch
+	}{
+		{
+			desc: "any",
+			want: []CommuteMatch{
+				{ID: 1, ToWork: true, Confidence: 1},
+				{ID: 2, Confidence: 1 - 50.0/800},
+				{ID: 3, ToWork: true, Confidence: 1 - 200.0/800},
+				{ID: 6, ToWork: true, Confidence: 1},
+			},
+		},
+		{
+			desc: "weekdays",
+			opts: CommuteOptions{WeekdaysOnly: true},
+			want: []CommuteMatch{
+				{ID: 1, ToWork: true, Confidence: 1},
+				{ID: 2, Confidence: 1 - 50.0/800},
+				{ID: 6, ToWork: true, Confidence: 1},
+			},
+		},
+		{
+			desc: "distance band",
+			opts: CommuteOptions{MinDistance: 12500, MaxDistance: 20000},
+			want: []CommuteMatch{
+				{ID: 3, ToWork: true, Confidence: 1 - 200.0/800},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := ClassifyCommutes(rides, home, work, 200, tc.opts)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-3)); diff != "" {
+				t.Errorf("bad commutes: -want +got\n%s", diff)
+			}
+		})
+	}
+}
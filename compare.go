@@ -0,0 +1,123 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StatDelta compares one number between two rides.
+type StatDelta struct {
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Delta float64 `json:"delta"`
+	// Percent is the change from A to B, and is nil when A is zero.
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+func statDelta(a, b float64) StatDelta {
+	d := StatDelta{A: a, B: b, Delta: b - a}
+	if a != 0 {
+		pct := (b - a) / a * 100
+		d.Percent = &pct
+	}
+	return d
+}
+
+// SplitDelta compares the same kilometer of two rides.
+type SplitDelta struct {
+	Number     int           `json:"number"`
+	MovingTime time.Duration `json:"moving_time"`
+	TimeDelta  time.Duration `json:"time_delta"`
+	AvgSpeed   StatDelta     `json:"avg_speed"`
+	// Nil unless both rides recorded heart rate for the split.
+	AvgHeartRate *StatDelta `json:"avg_heart_rate,omitempty"`
+}
+
+// RideComparison is the difference between two rides, B - A. Distance and
+// elevation are in meters, MovingTime in seconds and speeds in km/h.
+type RideComparison struct {
+	A             int       `json:"a"`
+	B             int       `json:"b"`
+	Distance      StatDelta `json:"distance"`
+	MovingTime    StatDelta `json:"moving_time"`
+	AvgSpeed      StatDelta `json:"avg_speed"`
+	ElevationGain StatDelta `json:"elevation_gain"`
+	// Nil if either ride has no heart rate.
+	AvgHeartRate *StatDelta `json:"avg_heart_rate,omitempty"`
+	// Per kilometer, for as many full kilometers as both rides have.
+	Splits []SplitDelta `json:"splits,omitempty"`
+	// Missing lists what couldn't be compared, like "heart rate" or "splits".
+	Missing []string `json:"missing,omitempty"`
+}
+
+// CompareRides compares ride b to ride a. Anything only one of the rides has
+// is left out and listed in Missing.
+func CompareRides(a, b *Ride) (*RideComparison, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("can't compare a missing ride")
+	}
+
+	res := &RideComparison{
+		A:             a.ID,
+		B:             b.ID,
+		Distance:      statDelta(rideDistance(a), rideDistance(b)),
+		MovingTime:    statDelta(float64(a.Metrics.MovingTime), float64(b.Metrics.MovingTime)),
+		AvgSpeed:      statDelta(rideAvgSpeed(a), rideAvgSpeed(b)),
+		ElevationGain: statDelta(float64(a.Metrics.ElevationGain), float64(b.Metrics.ElevationGain)),
+	}
+
+	if a.Metrics.HR.Avg > 0 && b.Metrics.HR.Avg > 0 {
+		hr := statDelta(float64(a.Metrics.HR.Avg), float64(b.Metrics.HR.Avg))
+		res.AvgHeartRate = &hr
+	} else {
+		res.Missing = append(res.Missing, "heart rate")
+	}
+
+	if len(a.TrackPoints) < 2 || len(b.TrackPoints) < 2 {
+		res.Missing = append(res.Missing, "splits")
+		return res, nil
+	}
+	as, err := ComputeSplits(a.TrackPoints, SplitKm)
+	if err != nil {
+		return nil, fmt.Errorf("can't split ride %d: %w", a.ID, err)
+	}
+	bs, err := ComputeSplits(b.TrackPoints, SplitKm)
+	if err != nil {
+		return nil, fmt.Errorf("can't split ride %d: %w", b.ID, err)
+	}
+	for i := 0; i < len(as) && i < len(bs) && !as[i].Partial && !bs[i].Partial; i++ {
+		sd := SplitDelta{
+			Number:     as[i].Number,
+			MovingTime: bs[i].MovingTime,
+			TimeDelta:  bs[i].MovingTime - as[i].MovingTime,
+			AvgSpeed:   statDelta(as[i].AvgSpeed, bs[i].AvgSpeed),
+		}
+		if as[i].AvgHeartRate > 0 && bs[i].AvgHeartRate > 0 {
+			hr := statDelta(as[i].AvgHeartRate, bs[i].AvgHeartRate)
+			sd.AvgHeartRate = &hr
+		}
+		res.Splits = append(res.Splits, sd)
+	}
+
+	return res, nil
+}
+
+func rideDistance(r *Ride) float64 {
+	if r.Metrics.Distance != 0 {
+		return float64(r.Metrics.Distance)
+	}
+	return float64(r.Distance)
+}
+
+// rideAvgSpeed is the server's average speed, or the distance over the moving
+// time if it's missing.
+func rideAvgSpeed(r *Ride) float64 {
+	if r.Metrics.Speed.Avg != 0 {
+		return float64(r.Metrics.Speed.Avg)
+	}
+	if r.Metrics.MovingTime > 0 {
+		return rideDistance(r) / 1000 / (float64(r.Metrics.MovingTime) / 3600)
+	}
+	return 0
+}
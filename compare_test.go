@@ -0,0 +1,135 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func pct(f float64) *float64 {
+	return &f
+}
+
+func TestCompareRides(t *testing.T) {
+	slow := &Ride{ID: 1, TrackPoints: synthTrack(splitStep{n: 25, dist: 100, dt: 20 * time.Second, rise: 1, hr: 140})}
+	slow.Metrics.Distance = 2500
+	slow.Metrics.MovingTime = 500
+	slow.Metrics.ElevationGain = 25
+	slow.Metrics.HR.Avg = 140
+
+	fast := &Ride{ID: 2, TrackPoints: synthTrack(splitStep{n: 25, dist: 100, dt: 15 * time.Second, rise: 1, hr: 150})}
+	fast.Metrics.Distance = 2500
+	fast.Metrics.MovingTime = 375
+	fast.Metrics.ElevationGain = 25
+	fast.Metrics.HR.Avg = 150
+
+	noTrack := &Ride{ID: 3, Distance: 2000}
+	noTrack.Metrics.MovingTime = 400
+
+	trainer := &Ride{ID: 4}
+	trainer.Metrics.MovingTime = 3600
+	trainer.Metrics.HR.Avg = 130
+
+	hrDelta := statDelta(140, 150)
+
+	tests := []struct {
+		desc string
+		a, b *Ride
+		want *RideComparison
+	}{
+		{
+			desc: "same loop",
+			a:    slow,
+			b:    fast,
+			want: &RideComparison{
+				A:             1,
+				B:             2,
+				Distance:      StatDelta{A: 2500, B: 2500, Percent: pct(0)},
+				MovingTime:    StatDelta{A: 500, B: 375, Delta: -125, Percent: pct(-25)},
+				AvgSpeed:      StatDelta{A: 18, B: 24, Delta: 6, Percent: pct(100.0 / 3)},
+				ElevationGain: StatDelta{A: 25, B: 25, Percent: pct(0)},
+				AvgHeartRate:  &hrDelta,
+				Splits: []SplitDelta{
+					{Number: 1, MovingTime: 150 * time.Second, TimeDelta: -50 * time.Second, AvgSpeed: statDelta(18, 24)},
+					{Number: 2, MovingTime: 150 * time.Second, TimeDelta: -50 * time.Second, AvgSpeed: statDelta(18, 24)},
+				},
+			},
+		},
+		{
+			desc: "no track points",
+			a:    slow,
+			b:    noTrack,
+			want: &RideComparison{
+				A:             1,
+				B:             3,
+				Distance:      StatDelta{A: 2500, B: 2000, Delta: -500, Percent: pct(-20)},
+				MovingTime:    StatDelta{A: 500, B: 400, Delta: -100, Percent: pct(-20)},
+				AvgSpeed:      StatDelta{A: 18, B: 18, Percent: pct(0)},
+				ElevationGain: StatDelta{A: 25, Delta: -25, Percent: pct(-100)},
+				Missing:       []string{"heart rate", "splits"},
+			},
+		},
+		{
+			desc: "trainer",
+			a:    trainer,
+			b:    slow,
+			want: &RideComparison{
+				A:             4,
+				B:             1,
+				Distance:      StatDelta{B: 2500, Delta: 2500},
+				MovingTime:    StatDelta{A: 3600, B: 500, Delta: -3100, Percent: pct(-3100.0 / 36)},
+				AvgSpeed:      StatDelta{B: 18, Delta: 18},
+				ElevationGain: StatDelta{B: 25, Delta: 25},
+				AvgHeartRate:  &StatDelta{A: 130, B: 140, Delta: 10, Percent: pct(100.0 / 13)},
+				Missing:       []string{"splits"},
+			},
+		},
+	}
+
+	// Split heart rates are checked against the fixture below.
+	ignoreSplitHR := cmpopts.IgnoreFields(SplitDelta{}, "AvgHeartRate")
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := CompareRides(tc.a, tc.b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-6), ignoreSplitHR); diff != "" {
+				t.Errorf("bad comparison: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := CompareRides(slow, nil); err == nil {
+		t.Errorf("expected an error for a missing ride")
+	}
+}
+
+func TestCompareRidesFixture(t *testing.T) {
+	a := getTestRide(t)
+	b := getTestRide(t)
+	b.Metrics.MovingTime -= 60
+
+	got, err := CompareRides(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Missing) != 0 {
+		t.Errorf("expected everything to be compared, missing %v", got.Missing)
+	}
+	if len(got.Splits) != 42 {
+		t.Errorf("expected 42 splits, got %d", len(got.Splits))
+	}
+	for _, s := range got.Splits {
+		if s.TimeDelta != 0 || s.AvgHeartRate == nil || s.AvgHeartRate.Delta != 0 {
+			t.Errorf("bad split for identical tracks: %+v", s)
+			break
+		}
+	}
+	if got.MovingTime.Delta != -60 {
+		t.Errorf("bad moving time delta: %+v", got.MovingTime)
+	}
+}
@@ -0,0 +1,135 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// How much of a body to include in decoding errors.
+const snippetSize = 512
+
+// WithStrictDecoding makes responses with fields we don't know about, or
+// without an ID where one is expected, fail to decode instead of being
+// silently ignored or left zero. Meant for tests and canaries that should
+// catch changes to the API. Off by default.
+func WithStrictDecoding(on bool) Option {
+	return func(r *RWGPS) {
+		r.strict = on
+	}
+}
+
+func decodeJSON(data string, obj interface{}) error {
+	return decodeJSONReader(strings.NewReader(data), obj, false)
+}
+
+// decode is decodeJSON, strict if the client is.
+func (r *RWGPS) decode(data string, obj interface{}) error {
+	return decodeJSONReader(strings.NewReader(data), obj, r.strict)
+}
+
+func (r *RWGPS) decodeReader(rd io.Reader, obj interface{}) error {
+	return decodeJSONReader(rd, obj, r.strict)
+}
+
+// decodeJSONReader decodes straight from rd, without reading it all first,
+// unless strict. Errors include the start of the body. Types with their own
+// UnmarshalJSON aren't checked for unknown fields, even when strict, except
+// for rides and their metrics.
+func decodeJSONReader(rd io.Reader, obj interface{}, strict bool) error {
+	var body bytes.Buffer
+	if strict {
+		// The rides in it are checked again, see checkFlex.
+		rd = io.TeeReader(rd, &body)
+	}
+	head := &headReader{r: rd}
+	dec := json.NewDecoder(head)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(obj); err != nil {
+		return fmt.Errorf("%w: %w\n%s", ErrDecode, err, head.snippet())
+	}
+	if strict {
+		if err := checkFlex(body.Bytes(), reflect.TypeOf(obj)); err != nil {
+			return fmt.Errorf("%w: %w\n%s", ErrDecode, err, head.snippet())
+		}
+		if err := checkIDs(reflect.ValueOf(obj), ""); err != nil {
+			return fmt.Errorf("%w: %w\n%s", ErrDecode, err, head.snippet())
+		}
+	}
+
+	return nil
+}
+
+// checkIDs returns an error for any struct reachable from v with a zero ID
+// field. Nil pointers aren't followed.
+func checkIDs(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return checkIDs(v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkIDs(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkIDs(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key())); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.TrimPrefix(path+"."+f.Name, ".")
+			if f.Name == "ID" && v.Field(i).IsZero() {
+				return fmt.Errorf("missing required field %s", name)
+			}
+			if err := checkIDs(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// headReader keeps a copy of the first snippetSize bytes read through it.
+type headReader struct {
+	r   io.Reader
+	buf []byte
+	// Set once there's more than the copy.
+	more bool
+}
+
+func (h *headReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	keep := min(n, snippetSize-len(h.buf))
+	h.buf = append(h.buf, p[:keep]...)
+	if keep < n {
+		h.more = true
+	}
+
+	return n, err
+}
+
+func (h *headReader) snippet() string {
+	if h.more {
+		return string(h.buf) + "..."
+	}
+
+	return string(h.buf)
+}
@@ -0,0 +1,160 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONReaderError(t *testing.T) {
+	body := `{"trip": {"track_points": [` + strings.Repeat(`{"x": 1, "y": 2},`, 1000) + `]}}`
+
+	var got struct{ Trip Ride }
+	err := decodeJSONReader(strings.NewReader(body), &got, false)
+	if err == nil {
+		t.Fatalf("expected an error for a trailing comma")
+	}
+	if !strings.HasSuffix(err.Error(), "\n"+body[:snippetSize]+"...") {
+		t.Errorf("error should end with the start of the body: %v", err)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	tests := []struct {
+		desc      string
+		data      string
+		wantErr   string
+		strictErr string
+	}{
+		{
+			desc: "known fields",
+			data: `{"user": {"id": 1, "name": "zigdon", "gear": [{"id": 2, "name": "bike"}]}}`,
+		},
+		{
+			desc:      "unknown field",
+			data:      `{"user": {"id": 1, "nickname": "zigdon"}}`,
+			strictErr: `unknown field "nickname"`,
+		},
+		{
+			desc:      "renamed id",
+			data:      `{"user": {"id": 1, "gear": [{"id": 2}, {"name": "bike"}]}}`,
+			strictErr: "missing required field User.Gear[1].ID",
+		},
+		{
+			desc:    "bad json",
+			data:    `{"user": {"id": 1,}}`,
+			wantErr: "invalid character",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				var got struct{ User *User }
+				err := decodeJSONReader(strings.NewReader(tc.data), &got, strict)
+				want := tc.wantErr
+				if strict && tc.strictErr != "" {
+					want = tc.strictErr
+				}
+				switch {
+				case want == "" && err != nil:
+					t.Errorf("strict=%v: unexpected error: %v", strict, err)
+				case want != "" && (err == nil || !strings.Contains(err.Error(), want)):
+					t.Errorf("strict=%v: want error %q, got %v", strict, want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWithStrictDecoding(t *testing.T) {
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/users/2.json": func(string, url.Values) string {
+			return `{"user": {"id": 2, "name": "Club Mate", "display_name": "mate"}}`
+		},
+	})
+	defer server.Close()
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			r := testObj(server.URL)
+			WithStrictDecoding(strict)(r)
+			r.authUser = &User{ID: 1, AuthToken: "beef1337"}
+
+			_, err := r.GetUser(2)
+			if strict && err == nil {
+				t.Errorf("expected an error for an unknown field")
+			}
+			if !strict && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictDecodingRides(t *testing.T) {
+	var trip struct {
+		Trip *Ride `json:"trip"`
+	}
+	if err := decodeJSON(getTestData("trip.json"), &trip); err != nil {
+		t.Fatalf("can't decode trip.json: %v", err)
+	}
+	// The fixture, with only the fields we know about.
+	known, err := json.Marshal(trip.Trip)
+	if err != nil {
+		t.Fatalf("can't encode ride: %v", err)
+	}
+	withField := func(path ...string) string {
+		var ride map[string]interface{}
+		if err := json.Unmarshal(known, &ride); err != nil {
+			t.Fatalf("can't decode ride: %v", err)
+		}
+		obj := ride
+		for _, p := range path[:len(path)-1] {
+			obj = obj[p].(map[string]interface{})
+		}
+		obj[path[len(path)-1]] = 1
+		res, err := json.Marshal(map[string]interface{}{"type": "trip", "trip": ride})
+		if err != nil {
+			t.Fatalf("can't encode ride: %v", err)
+		}
+		return string(res)
+	}
+
+	tests := []struct {
+		desc    string
+		data    string
+		wantErr string
+	}{
+		{desc: "known fields", data: `{"type":"trip","trip":` + string(known) + `}`},
+		{desc: "unknown ride field", data: withField("renamed_distance"), wantErr: `unknown field "renamed_distance"`},
+		{desc: "unknown metrics field", data: withField("metrics", "bogus"), wantErr: `unknown field "bogus"`},
+		{desc: "quoted number", data: strings.Replace(withField("metrics", "bogus"), `"distance":42990.7`, `"distance":"42990.7"`, 1), wantErr: `unknown field "bogus"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			server := startServer(t, map[string]string{"/trips/94.json": tc.data}, nil)
+			defer server.Close()
+
+			for _, strict := range []bool{false, true} {
+				r := testObj(server.URL)
+				WithStrictDecoding(strict)(r)
+				r.authUser = &User{ID: 1, AuthToken: "beef1337"}
+				for _, get := range []func(int) (*Ride, error){r.GetRide, r.GetRideSummary} {
+					_, err := get(94)
+					switch {
+					case !strict || tc.wantErr == "":
+						if err != nil {
+							t.Errorf("strict=%v: unexpected error: %v", strict, err)
+						}
+					case err == nil || !strings.Contains(err.Error(), tc.wantErr):
+						t.Errorf("strict=%v: want error %q, got %v", strict, tc.wantErr, err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package goride
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DupOptions control how close two rides have to be to count as duplicates.
+type DupOptions struct {
+	// Window is how far apart the departure times can be. Defaults to 5
+	// minutes.
+	Window time.Duration
+	// DistanceTolerance and DurationTolerance are the largest allowed
+	// differences, as a fraction of the larger ride. Default to 0.05 and 0.1.
+	DistanceTolerance float64
+	DurationTolerance float64
+	// CompareEndpoints also requires the starts and the ends to be within
+	// EndpointRadius meters (default 250). Rides without coordinates, like
+	// manual entries, aren't compared on their endpoints.
+	CompareEndpoints bool
+	EndpointRadius   float64
+}
+
+func (o DupOptions) withDefaults() DupOptions {
+	if o.Window == 0 {
+		o.Window = 5 * time.Minute
+	}
+	if o.DistanceTolerance == 0 {
+		o.DistanceTolerance = 0.05
+	}
+	if o.DurationTolerance == 0 {
+		o.DurationTolerance = 0.1
+	}
+	if o.EndpointRadius == 0 {
+		o.EndpointRadius = 250
+	}
+	return o
+}
+
+// DupGroup is a set of rides that look like the same ride. Keep is the one to
+// keep: GPS rides over manual ones, then the longest.
+type DupGroup struct {
+	Rides []*RideSlim
+	Keep  *RideSlim
+	// Confidence is between 0 and 1, and is higher the closer the rides are.
+	Confidence float64
+}
+
+// Extras returns the rides in the group besides Keep.
+func (g DupGroup) Extras() []*RideSlim {
+	var res []*RideSlim
+	for _, r := range g.Rides {
+		if r != g.Keep {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// FindDuplicates groups rides that look like the same ride, most confident
+// first. Rides with no duplicates aren't returned.
+func FindDuplicates(rides []*RideSlim, opts DupOptions) []DupGroup {
+	opts = opts.withDefaults()
+	var sorted []*RideSlim
+	for _, r := range rides {
+		if r != nil {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DepartedAt.Before(sorted[j].DepartedAt) })
+
+	// Each group starts with the earliest ride not in a group yet, and takes
+	// the rides that match every ride already in it. A ride without
+	// coordinates can't join two rides that don't match each other.
+	grouped := make([]bool, len(sorted))
+	var res []DupGroup
+	for i := range sorted {
+		if grouped[i] {
+			continue
+		}
+		members := []int{i}
+		confidence := 1.0
+		for j := i + 1; j < len(sorted) && sorted[j].DepartedAt.Sub(sorted[i].DepartedAt) <= opts.Window; j++ {
+			if grouped[j] {
+				continue
+			}
+			worst := 1.0
+			ok := true
+			for _, m := range members {
+				var c float64
+				if c, ok = dupConfidence(sorted[m], sorted[j], opts); !ok {
+					break
+				}
+				worst = min(worst, c)
+			}
+			if ok {
+				members = append(members, j)
+				confidence = min(confidence, worst)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+
+		g := DupGroup{Confidence: confidence}
+		for _, m := range members {
+			grouped[m] = true
+			r := sorted[m]
+			g.Rides = append(g.Rides, r)
+			if g.Keep == nil || betterKeep(r, g.Keep) {
+				g.Keep = r
+			}
+		}
+		res = append(res, g)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Confidence > res[j].Confidence })
+
+	return res
+}
+
+// dupConfidence returns how alike two rides are, and whether they're close
+// enough to be duplicates.
+func dupConfidence(a, b *RideSlim, opts DupOptions) (float64, bool) {
+	diffs := []float64{
+		math.Abs(float64(b.DepartedAt.Sub(a.DepartedAt))) / float64(opts.Window),
+		relDiff(float64(a.Distance), float64(b.Distance)) / opts.DistanceTolerance,
+		relDiff(float64(a.Duration), float64(b.Duration)) / opts.DurationTolerance,
+	}
+	if opts.CompareEndpoints && hasEndpoints(a) && hasEndpoints(b) {
+		diffs = append(diffs,
+			haversine(a.FirstLat, a.FirstLng, b.FirstLat, b.FirstLng)/opts.EndpointRadius,
+			haversine(a.LastLat, a.LastLng, b.LastLat, b.LastLng)/opts.EndpointRadius,
+		)
+	}
+
+	var sum float64
+	for _, d := range diffs {
+		if d > 1 {
+			return 0, false
+		}
+		sum += d
+	}
+
+	return 1 - sum/float64(len(diffs)), true
+}
+
+func relDiff(a, b float64) float64 {
+	if m := math.Max(a, b); m > 0 {
+		return math.Abs(a-b) / m
+	}
+	return 0
+}
+
+func hasEndpoints(r *RideSlim) bool {
+	return (r.FirstLat != 0 || r.FirstLng != 0) && (r.LastLat != 0 || r.LastLng != 0)
+}
+
+// betterKeep returns true if a should be kept over b.
+func betterKeep(a, b *RideSlim) bool {
+	if a.IsGps != b.IsGps {
+		return a.IsGps
+	}
+	if a.Duration != b.Duration {
+		return a.Duration > b.Duration
+	}
+	return a.ID < b.ID
+}
+
+// DuplicateDeletes returns the operations deleting every ride in the groups
+// but the one to keep, for Plan and Apply.
+func DuplicateDeletes(groups []DupGroup) []Operation {
+	var ops []Operation
+	for _, g := range groups {
+		for _, r := range g.Extras() {
+			ops = append(ops, Operation{Kind: OpDelete, RideID: r.ID})
+		}
+	}
+	return ops
+}
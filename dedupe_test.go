@@ -0,0 +1,111 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func dupRide(id int, departed time.Time, km float32, secs int, gps bool, lat, lng float64) *RideSlim {
+	return &RideSlim{
+		ID:         id,
+		DepartedAt: departed,
+		Distance:   km * 1000,
+		Duration:   secs,
+		IsGps:      gps,
+		FirstLat:   lat,
+		FirstLng:   lng,
+		LastLat:    lat,
+		LastLng:    lng,
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	day := time.Date(2021, 8, 1, 8, 0, 0, 0, time.UTC)
+	rides := []*RideSlim{
+		// Head unit, phone and a manual entry for the same ride.
+		dupRide(1, day, 30, 3600, true, 37.87, -122.27),
+		dupRide(2, day.Add(time.Minute), 30.5, 3500, true, 37.8705, -122.2702),
+		dupRide(3, day.Add(2*time.Minute), 30, 3600, false, 0, 0),
+		// Near misses: too late, too short, and somewhere else.
+		dupRide(4, day.Add(10*time.Minute), 30, 3600, true, 37.87, -122.27),
+		dupRide(5, day.Add(time.Minute), 10, 3600, true, 37.87, -122.27),
+		dupRide(6, day, 30, 3600, true, 40.7, -74),
+		// The same upload, twice.
+		dupRide(7, day.AddDate(0, 0, 1), 50, 7200, true, 37.87, -122.27),
+		dupRide(8, day.AddDate(0, 0, 1), 50, 7200, true, 37.87, -122.27),
+		nil,
+	}
+
+	type group struct {
+		IDs  []int
+		Keep int
+	}
+	tests := []struct {
+		desc string
+		opts DupOptions
+		want []group
+	}{
+		{
+			desc: "endpoints",
+			opts: DupOptions{CompareEndpoints: true},
+			want: []group{
+				{IDs: []int{7, 8}, Keep: 7},
+				{IDs: []int{1, 2, 3}, Keep: 1},
+			},
+		},
+		{
+			desc: "no endpoints",
+			want: []group{
+				{IDs: []int{7, 8}, Keep: 7},
+				{IDs: []int{1, 6, 2, 3}, Keep: 1},
+			},
+		},
+		{
+			desc: "wide window",
+			opts: DupOptions{Window: 15 * time.Minute, CompareEndpoints: true},
+			want: []group{
+				{IDs: []int{7, 8}, Keep: 7},
+				{IDs: []int{1, 2, 3, 4}, Keep: 1},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			groups := FindDuplicates(rides, tc.opts)
+			var got []group
+			for i, g := range groups {
+				gr := group{Keep: g.Keep.ID}
+				for _, r := range g.Rides {
+					gr.IDs = append(gr.IDs, r.ID)
+				}
+				got = append(got, gr)
+				if g.Confidence <= 0 || g.Confidence > 1 {
+					t.Errorf("bad confidence %f", g.Confidence)
+				}
+				if i > 0 && g.Confidence > groups[i-1].Confidence {
+					t.Errorf("groups aren't sorted by confidence")
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad groups: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDuplicateDeletes(t *testing.T) {
+	day := time.Date(2021, 8, 1, 8, 0, 0, 0, time.UTC)
+	groups := FindDuplicates([]*RideSlim{
+		dupRide(1, day, 30, 3000, true, 0, 0),
+		dupRide(2, day, 30, 3100, true, 0, 0),
+		dupRide(3, day, 30, 3000, false, 0, 0),
+	}, DupOptions{})
+
+	want := []Operation{{Kind: OpDelete, RideID: 1}, {Kind: OpDelete, RideID: 3}}
+	if diff := cmp.Diff(want, DuplicateDeletes(groups)); diff != "" {
+		t.Errorf("bad deletes: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,196 @@
+package goride
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DiskCacheMode int
+
+const (
+	// DiskFallback fetches from the server, and only uses the cache when the
+	// network fails.
+	DiskFallback DiskCacheMode = iota
+	// DiskReadThrough uses cached responses when they're fresh, and fetches
+	// (and caches) the rest.
+	DiskReadThrough
+	// DiskOffline never touches the network.
+	DiskOffline
+)
+
+const diskCacheExt = ".cache"
+
+// DiskCache keeps the bodies of successful GET responses in a directory, so
+// they're available without a network connection. Entries older than the TTL
+// are ignored. Logins aren't cached, so the user's auth token isn't written to
+// disk. Logging in with a password always needs the network; an auth token
+// from the config is trusted unchecked when the server can't be reached, see
+// GetCurrentUser.
+type DiskCache struct {
+	dir  string
+	ttl  time.Duration
+	mode DiskCacheMode
+	now  func() time.Time
+}
+
+func NewDiskCache(dir string, ttl time.Duration, mode DiskCacheMode) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("can't create cache dir %q: %w", dir, err)
+	}
+
+	return &DiskCache{dir: dir, ttl: ttl, mode: mode, now: time.Now}, nil
+}
+
+// WithDiskCache stores every successful GET response in the cache.
+func WithDiskCache(d *DiskCache) Option {
+	return func(r *RWGPS) {
+		r.client.disk = d
+	}
+}
+
+// Credentials don't make a response different, and shouldn't end up on disk.
+var uncachedArgs = map[string]bool{
+	"apikey":     true,
+	"auth_token": true,
+	"email":      true,
+	"password":   true,
+	"version":    true,
+}
+
+// diskCacheable reports whether the response can be kept on disk. Logins, and
+// anything else sent with a password, have the auth token in them, and a
+// cached one would let in any password.
+func diskCacheable(base string, args url.Values) bool {
+	return base != "/users/current.json" && !args.Has("password")
+}
+
+func (d *DiskCache) path(base string, args url.Values) string {
+	var keys []string
+	for k := range args {
+		if !uncachedArgs[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	key := []string{base}
+	for _, k := range keys {
+		for _, v := range args[k] {
+			key = append(key, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(key, "\n")))
+
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+diskCacheExt)
+}
+
+func (d *DiskCache) load(path string) (string, bool) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if d.ttl > 0 && d.now().Sub(st.ModTime()) > d.ttl {
+		return "", false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+func (d *DiskCache) save(path, body string) error {
+	tmp, err := ioutil.TempFile(d.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	now := d.now()
+	if err := os.Chtimes(tmp.Name(), now, now); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// get returns the response for the request, using the cache as the mode
+// allows, and fetch otherwise.
+func (d *DiskCache) get(base string, args url.Values, fetch func() (string, error)) (string, error) {
+	path := d.path(base, args)
+	if d.mode != DiskFallback {
+		if body, ok := d.load(path); ok {
+			return body, nil
+		}
+		if d.mode == DiskOffline {
+			return "", fmt.Errorf("%q isn't in the offline cache", base)
+		}
+	}
+
+	body, err := fetch()
+	if err == nil {
+		// Caching is best effort, a full disk shouldn't fail the request.
+		d.save(path, body)
+		return body, nil
+	}
+
+	if d.mode == DiskFallback && networkFailure(err) {
+		if body, ok := d.load(path); ok {
+			return body, nil
+		}
+	}
+
+	return "", err
+}
+
+// offline reports whether d is set and never touches the network.
+func (d *DiskCache) offline() bool {
+	return d != nil && d.mode == DiskOffline
+}
+
+// networkFailure is true if err didn't come with a response from the server.
+func networkFailure(err error) bool {
+	var se *statusError
+	return !errors.As(err, &se)
+}
+
+// Invalidate removes a single cached response.
+func (d *DiskCache) Invalidate(base string, args url.Values) error {
+	err := os.Remove(d.path(base, args))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't invalidate %q: %w", base, err)
+	}
+
+	return nil
+}
+
+// Clear removes all cached responses.
+func (d *DiskCache) Clear() error {
+	files, err := filepath.Glob(filepath.Join(d.dir, "*"+diskCacheExt))
+	if err != nil {
+		return fmt.Errorf("can't list cache dir %q: %w", d.dir, err)
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("can't clear cache: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package goride
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheOffline(t *testing.T) {
+	t.Setenv(authTokenEnv, "")
+	tests := []struct {
+		desc string
+		mode DiskCacheMode
+	}{
+		{desc: "fallback", mode: DiskFallback},
+		{desc: "offline", mode: DiskOffline},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := filepath.Join(dir, "cfg.ini")
+			writeTestFile(t, cfg, "[Auth]\nname = \"test key\"\nauth_token = beef1337\n")
+			server := startServer(t,
+				map[string]string{
+					"/trips/94.json":      getTestData("trip.json"),
+					"/users/1/trips.json": getTestData("trips0-2.json"),
+				},
+				nil)
+			newClient := func(mode DiskCacheMode) *RWGPS {
+				cache, err := NewDiskCache(filepath.Join(dir, "cache"), time.Hour, mode)
+				if err != nil {
+					t.Fatalf("can't create cache: %v", err)
+				}
+				r, err := New(cfg, WithServer(server.URL), WithDiskCache(cache))
+				if err != nil {
+					t.Fatalf("can't create client: %v", err)
+				}
+				return r
+			}
+
+			r := newClient(DiskFallback)
+			if _, _, err := r.GetRides(1, 0, 2); err != nil {
+				t.Fatalf("error getting rides: %v", err)
+			}
+			if _, err := r.GetRide(94); err != nil {
+				t.Fatalf("error getting ride: %v", err)
+			}
+			server.Close()
+
+			// A new client with no network can't check its auth token, but
+			// should still get the same rides from the cache.
+			r = newClient(tc.mode)
+			rides, count, err := r.GetRides(1, 0, 2)
+			if err != nil {
+				t.Fatalf("error getting cached rides: %v", err)
+			}
+			if len(rides) != 2 || count != 1273 {
+				t.Errorf("bad cached rides: %d of %d", len(rides), count)
+			}
+			ride, err := r.GetRide(94)
+			if err != nil {
+				t.Fatalf("error getting cached ride: %v", err)
+			}
+			if ride.Name != "Peak To Peak" {
+				t.Errorf("bad cached ride: %q", ride.Name)
+			}
+
+			if _, err := r.GetRide(95); err == nil {
+				t.Errorf("expected an error for an uncached ride")
+			}
+		})
+	}
+}
+
+func TestDiskCacheLogin(t *testing.T) {
+	server := startServer(t, nil, nil)
+	defer server.Close()
+
+	tests := []struct {
+		desc string
+		mode DiskCacheMode
+	}{
+		{desc: "fallback", mode: DiskFallback},
+		{desc: "read through", mode: DiskReadThrough},
+		{desc: "offline", mode: DiskOffline},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			newClient := func(mode DiskCacheMode, password string) *RWGPS {
+				cache, err := NewDiskCache(dir, time.Hour, mode)
+				if err != nil {
+					t.Fatalf("can't create cache: %v", err)
+				}
+				r := testObj(server.URL)
+				r.config.Password = password
+				WithDiskCache(cache)(r)
+				return r
+			}
+
+			if err := newClient(DiskReadThrough, "supers3cret").Auth(); err != nil {
+				t.Fatalf("can't log in: %v", err)
+			}
+			if err := newClient(tc.mode, "wrong").Auth(); err == nil {
+				t.Errorf("logged in with the wrong password")
+			}
+			err := newClient(tc.mode, "supers3cret").Auth()
+			if tc.mode == DiskOffline && err == nil {
+				t.Errorf("logged in offline")
+			}
+			if tc.mode != DiskOffline && err != nil {
+				t.Errorf("can't log in again: %v", err)
+			}
+
+			files, err := filepath.Glob(filepath.Join(dir, "*"))
+			if err != nil {
+				t.Fatalf("can't list cache: %v", err)
+			}
+			for _, f := range files {
+				data, err := os.ReadFile(f)
+				if err != nil {
+					t.Fatalf("can't read %q: %v", f, err)
+				}
+				if strings.Contains(string(data), "ffffff") {
+					t.Errorf("auth token cached in %q", f)
+				}
+			}
+		})
+	}
+}
+
+func TestDiskCacheModes(t *testing.T) {
+	requests := 0
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/count": func(string, url.Values) string {
+			requests++
+			return fmt.Sprintf("response %d", requests)
+		},
+	})
+	defer server.Close()
+
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	newClient := func(mode DiskCacheMode, dir string) (*Client, *DiskCache) {
+		cache, err := NewDiskCache(dir, time.Hour, mode)
+		if err != nil {
+			t.Fatalf("can't create cache: %v", err)
+		}
+		cache.now = func() time.Time { return now }
+		return &Client{server: server.URL, disk: cache}, cache
+	}
+	get := func(c *Client, args url.Values, want string) {
+		t.Helper()
+		got, err := c.Get("/count", args)
+		if want == "" {
+			if err == nil {
+				t.Errorf("expected an error, got %q", got)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("bad response: want %q, got %q", want, got)
+		}
+	}
+
+	dir := t.TempDir()
+	fallback, cache := newClient(DiskFallback, dir)
+	get(fallback, nil, "response 1")
+	get(fallback, nil, "response 2")
+	// Credentials aren't part of the key, but other args are.
+	get(fallback, url.Values{"auth_token": {"abc"}}, "response 3")
+	get(fallback, url.Values{"page": {"2"}}, "response 4")
+
+	readThrough, _ := newClient(DiskReadThrough, dir)
+	get(readThrough, url.Values{"auth_token": {"def"}}, "response 3")
+
+	offline, _ := newClient(DiskOffline, dir)
+	get(offline, url.Values{"page": {"2"}}, "response 4")
+	get(offline, url.Values{"page": {"3"}}, "")
+
+	// Expired entries are refetched.
+	now = now.Add(2 * time.Hour)
+	get(readThrough, nil, "response 5")
+	get(offline, url.Values{"page": {"2"}}, "")
+
+	if err := cache.Invalidate("/count", nil); err != nil {
+		t.Fatalf("error invalidating: %v", err)
+	}
+	get(offline, nil, "")
+	get(readThrough, url.Values{"page": {"2"}}, "response 6")
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("error clearing: %v", err)
+	}
+	get(offline, url.Values{"page": {"2"}}, "")
+	if requests != 6 {
+		t.Errorf("wrong number of requests to the server: %d", requests)
+	}
+}
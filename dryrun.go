@@ -0,0 +1,63 @@
+package goride
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ErrDryRun is returned in a dry run by writes that need the server's
+// response, like creating a route, since there isn't one.
+var ErrDryRun = errors.New("dry run, request not sent")
+
+// DryRunRequest is a write that wasn't sent because of WithDryRun.
+type DryRunRequest struct {
+	Method string
+	Path   string
+	Args   url.Values
+	Body   string
+}
+
+// WithDryRun stops the client from sending any POST, PUT or DELETE request.
+// They're logged and added to the DryRunJournal instead. Writes that don't
+// need a response succeed, and the others fail with ErrDryRun. GET requests
+// are sent as usual.
+func WithDryRun(on bool) Option {
+	return func(r *RWGPS) {
+		r.dryRun = on
+	}
+}
+
+// DryRunJournal returns the requests that weren't sent in a dry run, in the
+// order they were made.
+func (r *RWGPS) DryRunJournal() []DryRunRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DryRunRequest(nil), r.journal...)
+}
+
+// skipWrite records the request instead of sending it, if it's a write in a
+// dry run.
+func (r *RWGPS) skipWrite(verb, path string, args url.Values, body []byte) bool {
+	if !r.dryRun || verb == http.MethodGet || verb == http.MethodHead {
+		return false
+	}
+
+	r.log().Info("dry run, not sending request", "method", verb, "path", path)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.journal = append(r.journal, DryRunRequest{Method: verb, Path: path, Args: args, Body: string(body)})
+
+	return true
+}
+
+// send is do, for writes that don't need the response, so they succeed in a
+// dry run.
+func (r *RWGPS) send(verb, path string, args url.Values, body []byte) error {
+	_, err := r.do(verb, path, args, body)
+	if errors.Is(err, ErrDryRun) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,66 @@
+package goride
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDryRun(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+	WithDryRun(true)(r)
+
+	name := "Renamed"
+	if err := r.UpdateRide(1, RideUpdate{Name: &name}); err != nil {
+		t.Errorf("UpdateRide: %v", err)
+	}
+	if err := r.DeleteRide(2); err != nil {
+		t.Errorf("DeleteRide: %v", err)
+	}
+	if _, err := r.CreateRoute("New", []LatLng{{45.5, -122.6}, {45.6, -122.7}}, RouteCreateOptions{}); !errors.Is(err, ErrDryRun) {
+		t.Errorf("CreateRoute: want ErrDryRun, got %v", err)
+	}
+
+	// Reads still go to the server, and see nothing changed.
+	ride, err := r.GetRide(1)
+	if err != nil {
+		t.Fatalf("GetRide: %v", err)
+	}
+	if ride.Name != "Morning Ride" {
+		t.Errorf("ride was renamed to %q", ride.Name)
+	}
+	if _, err := r.GetRide(2); err != nil {
+		t.Errorf("ride was deleted: %v", err)
+	}
+	if w := f.writes(); len(w) > 0 {
+		t.Errorf("writes were sent: %v", w)
+	}
+
+	want := []DryRunRequest{
+		{Method: "PUT", Path: "/trips/1.json", Body: `{"trip":{"name":"Renamed"}}`},
+		{Method: "DELETE", Path: "/trips/2.json"},
+		{Method: "POST", Path: "/routes.json"},
+	}
+	got := r.DryRunJournal()
+	if len(got) == 3 {
+		// The route's body is checked by TestCreateRoute.
+		got[2].Body = ""
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad journal: -want +got\n%s", diff)
+	}
+
+	// Without a dry run, the writes are sent.
+	WithDryRun(false)(r)
+	if err := r.UpdateRide(1, RideUpdate{Name: &name}); err != nil {
+		t.Fatalf("UpdateRide: %v", err)
+	}
+	if got := f.ride(1).Name; got != name {
+		t.Errorf("want name %q, got %q", name, got)
+	}
+	if n := len(r.DryRunJournal()); n != 3 {
+		t.Errorf("sent request added to the journal, %d entries", n)
+	}
+}
@@ -0,0 +1,93 @@
+package goride
+
+import "time"
+
+// StandardDurations are the usual windows for BestEfforts.
+var StandardDurations = []time.Duration{
+	5 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	20 * time.Minute,
+	time.Hour,
+}
+
+// Windows with a longer gap between points, e.g. from auto-pause, are skipped
+// by BestEfforts.
+const effortMaxGap = 10 * time.Second
+
+// Effort is the best average speed and power held for Duration. The start
+// offsets are from the first point's time. Values are zero if the ride has no
+// window that long, or no power data.
+type Effort struct {
+	Duration   time.Duration
+	Speed      float64
+	SpeedStart time.Duration
+	Power      float64
+	PowerStart time.Duration
+}
+
+// BestEfforts finds the mean-maximal speed (km/h) and power (watts) for each
+// duration. Windows start at a point, and end at the first point at least the
+// duration later. Each point's power is held until the next point. Windows
+// that span a gap in recording are skipped. The points must be in time order.
+func BestEfforts(points []TrackPoint, durations []time.Duration) []Effort {
+	res := make([]Effort, len(durations))
+	for i, d := range durations {
+		res[i].Duration = d
+	}
+	if len(points) < 2 {
+		return res
+	}
+
+	// Prefix sums, so the totals over any window are a subtraction.
+	dist := trackDistances(points)
+	gaps := make([]int, len(points))
+	energy := make([]float64, len(points))
+	hasPower := false
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Time.Sub(points[i-1].Time)
+		gaps[i] = gaps[i-1]
+		if dt > effortMaxGap {
+			gaps[i]++
+		}
+		energy[i] = energy[i-1] + points[i-1].Power*dt.Seconds()
+		if points[i-1].Power > 0 {
+			hasPower = true
+		}
+	}
+
+	start := points[0].Time
+	for n, d := range durations {
+		if d <= 0 {
+			continue
+		}
+		e := &res[n]
+		j := 0
+		for i := range points {
+			for j < len(points) && points[j].Time.Sub(points[i].Time) < d {
+				j++
+			}
+			if j == len(points) {
+				break
+			}
+			if gaps[j] != gaps[i] {
+				continue
+			}
+
+			secs := points[j].Time.Sub(points[i].Time).Seconds()
+			if speed := (dist[j] - dist[i]) / secs * 3.6; speed > e.Speed {
+				e.Speed = speed
+				e.SpeedStart = points[i].Time.Sub(start)
+			}
+			if !hasPower {
+				continue
+			}
+			if power := (energy[j] - energy[i]) / secs; power > e.Power {
+				e.Power = power
+				e.PowerStart = points[i].Time.Sub(start)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,97 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// effortTrack builds a track of 1s points. Each point carries the power for
+// the second that follows it.
+type effortTrack struct {
+	points []TrackPoint
+	p      TrackPoint
+}
+
+func (e *effortTrack) ride(secs int, speed, power float64) {
+	for i := 0; i < secs; i++ {
+		e.p.Power = power
+		e.points = append(e.points, e.p)
+		e.p.Time = e.p.Time.Add(time.Second)
+		e.p.Distance += speed / 3.6
+	}
+}
+
+func (e *effortTrack) pause(d time.Duration) {
+	e.p.Power = 0
+	e.points = append(e.points, e.p)
+	e.p.Time = e.p.Time.Add(d)
+}
+
+func (e *effortTrack) done() []TrackPoint {
+	e.p.Power = 0
+	return append(e.points, e.p)
+}
+
+// synthEffortTrack is 30m at 20km/h and 150W with a 5s sprint at 10m, 5m at
+// 40km/h and 350W, a 15m pause, and an hour at 24km/h and 190W.
+func synthEffortTrack() []TrackPoint {
+	e := &effortTrack{p: TrackPoint{Time: sensorStart}}
+	e.ride(600, 20, 150)
+	e.ride(5, 50, 900)
+	e.ride(1195, 20, 150)
+	e.ride(300, 40, 350)
+	e.pause(15 * time.Minute)
+	e.ride(3600, 24, 190)
+
+	return e.done()
+}
+
+func TestBestEfforts(t *testing.T) {
+	durations := append(append([]time.Duration{}, StandardDurations...), 2*time.Hour)
+	want := []Effort{
+		{Duration: 5 * time.Second, Speed: 50, SpeedStart: 600 * time.Second, Power: 900, PowerStart: 600 * time.Second},
+		{Duration: time.Minute, Speed: 40, SpeedStart: 1800 * time.Second, Power: 350, PowerStart: 1800 * time.Second},
+		{Duration: 5 * time.Minute, Speed: 40, SpeedStart: 1800 * time.Second, Power: 350, PowerStart: 1800 * time.Second},
+		// Ending with the 40km/h block, as the pause can't be crossed.
+		{Duration: 20 * time.Minute, Speed: 25, SpeedStart: 900 * time.Second, Power: 200, PowerStart: 900 * time.Second},
+		{Duration: time.Hour, Speed: 24, SpeedStart: 3000 * time.Second, Power: 190, PowerStart: 3000 * time.Second},
+		{Duration: 2 * time.Hour},
+	}
+
+	got := BestEfforts(synthEffortTrack(), durations)
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("bad efforts: -want +got\n%s", diff)
+	}
+
+	// Without power data, only speed is found.
+	track := synthEffortTrack()
+	for i := range track {
+		track[i].Power = 0
+	}
+	got = BestEfforts(track, StandardDurations[:1])
+	want = []Effort{{Duration: 5 * time.Second, Speed: 50, SpeedStart: 600 * time.Second}}
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("bad efforts without power: -want +got\n%s", diff)
+	}
+
+	if got := BestEfforts(nil, StandardDurations); len(got) != len(StandardDurations) || got[0].Speed != 0 {
+		t.Errorf("bad efforts for an empty track: %v", got)
+	}
+}
+
+func BenchmarkBestEfforts(b *testing.B) {
+	// An 8 hour ride, recorded every second.
+	e := &effortTrack{p: TrackPoint{Time: sensorStart}}
+	for i := 0; i < 8*60; i++ {
+		e.ride(60, float64(20+i%15), float64(150+i%100))
+	}
+	track := e.done()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BestEfforts(track, StandardDurations)
+	}
+}
@@ -0,0 +1,103 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ElevationStatus is the server's answer to an elevation correction request.
+type ElevationStatus string
+
+const (
+	// ElevationQueued means the correction was started.
+	ElevationQueued ElevationStatus = "queued"
+	// ElevationAlreadyCorrected means the ride's elevation was already
+	// corrected, so nothing will change.
+	ElevationAlreadyCorrected ElevationStatus = "already_corrected"
+	// ElevationUnavailable means the ride can't be corrected, e.g. because
+	// it was entered manually and has no track.
+	ElevationUnavailable ElevationStatus = "unavailable"
+)
+
+// ElevationTask is an elevation correction request.
+type ElevationTask struct {
+	ID     int             `json:"id"`
+	RideID int             `json:"trip_id"`
+	Status ElevationStatus `json:"status"`
+	// QueuedAt is when the correction was started.
+	QueuedAt time.Time `json:"queued_at"`
+	// Message is the server's explanation, if it didn't queue a correction.
+	Message string `json:"message"`
+}
+
+// Queued reports whether a correction was started, so the ride will change.
+func (t *ElevationTask) Queued() bool {
+	return t.Status == ElevationQueued
+}
+
+// RequestElevationCorrection asks the server to recalculate a ride's
+// elevation from its track, replacing what the device recorded. Rides that
+// are already corrected, or can't be, aren't an error; check the task's
+// Status. Use WaitForElevationCorrection to wait for the new metrics.
+func (r *RWGPS) RequestElevationCorrection(rideID int) (*ElevationTask, error) {
+	sent := time.Now()
+	res, err := r.do(http.MethodPost, fmt.Sprintf("/trips/%d/recalculate_elevation.json", rideID), nil, nil)
+	if task := elevationStatus(err); task != nil {
+		task.RideID = rideID
+		return task, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error requesting elevation correction for ride %d: %w", rideID, err)
+	}
+
+	var resStruct struct {
+		Task ElevationTask
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	task := &resStruct.Task
+	task.RideID = rideID
+	if task.Status == "" {
+		task.Status = ElevationQueued
+	}
+	if task.QueuedAt.IsZero() {
+		task.QueuedAt = sent
+	}
+
+	return task, nil
+}
+
+// elevationStatus returns the task described by a rejected request, if the
+// server said why.
+func elevationStatus(err error) *ElevationTask {
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusUnprocessableEntity {
+		return nil
+	}
+
+	var task ElevationTask
+	if json.Unmarshal([]byte(se.body), &task) != nil {
+		return nil
+	}
+	switch task.Status {
+	case ElevationAlreadyCorrected, ElevationUnavailable:
+		return &task
+	}
+
+	return nil
+}
+
+// WaitForElevationCorrection waits until a queued correction is done, and
+// returns the updated ride. Tasks that weren't queued just get the ride.
+func (r *RWGPS) WaitForElevationCorrection(task *ElevationTask, opts WaitOptions) (*Ride, error) {
+	if !task.Queued() {
+		return r.GetRide(task.RideID)
+	}
+	opts.UpdatedAfter = task.QueuedAt
+
+	return r.WaitForRide(task.RideID, opts)
+}
@@ -0,0 +1,140 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequestElevationCorrection(t *testing.T) {
+	queuedAt := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc    string
+		code    int
+		body    string
+		want    *ElevationTask
+		wantErr bool
+		// wantIs, if set, is what the error should match.
+		wantIs error
+	}{
+		{
+			desc: "queued",
+			code: http.StatusAccepted,
+			body: `{"task":{"id":12,"status":"queued","queued_at":"2021-08-01T09:00:00Z"}}`,
+			want: &ElevationTask{ID: 12, RideID: 5, Status: ElevationQueued, QueuedAt: queuedAt},
+		},
+		{
+			desc: "already corrected",
+			code: http.StatusUnprocessableEntity,
+			body: `{"status":"already_corrected","message":"Elevation was already corrected"}`,
+			want: &ElevationTask{RideID: 5, Status: ElevationAlreadyCorrected, Message: "Elevation was already corrected"},
+		},
+		{
+			desc: "manual trip",
+			code: http.StatusUnprocessableEntity,
+			body: `{"status":"unavailable","message":"Manual trips have no track"}`,
+			want: &ElevationTask{RideID: 5, Status: ElevationUnavailable, Message: "Manual trips have no track"},
+		},
+		{
+			desc:    "other rejection",
+			code:    http.StatusUnprocessableEntity,
+			body:    `{"errors":["something else"]}`,
+			wantErr: true,
+		},
+		{
+			desc:    "missing ride",
+			code:    http.StatusNotFound,
+			body:    `{}`,
+			wantErr: true,
+			wantIs:  ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t)
+			f.handle("/trips/5/recalculate_elevation.json", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.code)
+				fmt.Fprint(w, tc.body)
+			})
+
+			task, err := testObj(f.URL).RequestElevationCorrection(5)
+			if diff := cmp.Diff([]string{"POST /trips/5/recalculate_elevation.json"}, f.writes()); diff != "" {
+				t.Errorf("bad requests: -want +got\n%s", diff)
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", task)
+				}
+				if tc.wantIs != nil && !errors.Is(err, tc.wantIs) {
+					t.Errorf("bad error: want %v, got %v", tc.wantIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, task); diff != "" {
+				t.Errorf("bad task: -want +got\n%s", diff)
+			}
+			if task.Queued() != (tc.want.Status == ElevationQueued) {
+				t.Errorf("bad Queued() for %q", task.Status)
+			}
+		})
+	}
+}
+
+func TestWaitForElevationCorrection(t *testing.T) {
+	queuedAt := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc      string
+		task      ElevationTask
+		wantPolls int
+		wantGain  float32
+	}{
+		{
+			desc:      "queued",
+			task:      ElevationTask{RideID: 5, Status: ElevationQueued, QueuedAt: queuedAt},
+			wantPolls: 3,
+			wantGain:  120,
+		},
+		{
+			desc:      "already corrected",
+			task:      ElevationTask{RideID: 5, Status: ElevationAlreadyCorrected},
+			wantPolls: 1,
+			wantGain:  100,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t)
+			// The ride is updated with the new elevation after three polls.
+			n := 0
+			f.handle("/trips/5.json", func(w http.ResponseWriter, _ *http.Request) {
+				n++
+				updated, gain := queuedAt.Add(-time.Hour), 100
+				if n >= 3 {
+					updated, gain = queuedAt.Add(time.Minute), 120
+				}
+				fmt.Fprintf(w, `{"type":"trip","trip":{"id":5,"processed":true,"metrics":{"ele_gain":%d},"updated_at":%q}}`,
+					gain, updated.Format(time.RFC3339))
+			})
+
+			ride, err := testObj(f.URL).WaitForElevationCorrection(&tc.task, WaitOptions{Interval: time.Millisecond})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != tc.wantPolls {
+				t.Errorf("expected %d polls, got %d", tc.wantPolls, n)
+			}
+			if ride.Metrics.ElevationGain != tc.wantGain {
+				t.Errorf("bad elevation gain: want %v, got %v", tc.wantGain, ride.Metrics.ElevationGain)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package goride
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Errors from talking to the server can be checked with errors.Is. Any call to
+// the server can fail with ErrAuthFailed, ErrRateLimited, ErrResponseTooLarge
+// or ErrDecode. Getting
+// a single object (a ride, route, user, club or event) can also fail with
+// ErrNotFound or ErrPrivate.
+var (
+	// ErrAuthFailed is returned when logging in fails, or the server rejects
+	// the credentials.
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrNotFound is returned when the requested object doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrPrivate is returned when the object exists, but isn't visible to
+	// the logged in user.
+	ErrPrivate = errors.New("private")
+	// ErrRateLimited is returned when the server asks us to slow down.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrDecode is returned when a response isn't what we expected.
+	ErrDecode = errors.New("error decoding json")
+	// ErrResponseTooLarge is returned when a response is over the size limit,
+	// see WithMaxResponseSize.
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+// Is matches status errors to the sentinel errors.
+func (e *statusError) Is(target error) bool {
+	switch e.code {
+	case http.StatusUnauthorized:
+		return target == ErrAuthFailed
+	case http.StatusForbidden:
+		return target == ErrPrivate
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	f := newFakeRWGPS(t)
+	status := func(code int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(code)
+			fmt.Fprint(w, body)
+		}
+	}
+	f.handle("/trips/401.json", status(http.StatusUnauthorized, `{"error": "Invalid apikey"}`))
+	f.handle("/trips/403.json", status(http.StatusForbidden, "private"))
+	f.handle("/trips/429.json", status(http.StatusTooManyRequests, "slow down"))
+	f.handle("/trips/500.json", status(http.StatusInternalServerError, "oops"))
+	f.handle("/trips/1.json", status(http.StatusOK, `{"type": "trip", "trip": [}`))
+	f.handle("/routes/1.json", status(http.StatusNotFound, "no such route"))
+
+	login := func(password string) func(r *RWGPS) error {
+		return func(r *RWGPS) error {
+			r.authUser = nil
+			r.config.Password = password
+			_, err := r.GetCurrentUser()
+			return err
+		}
+	}
+
+	tests := []struct {
+		desc  string
+		call  func(r *RWGPS) error
+		want  []error
+		wantN []error
+	}{
+		{
+			desc: "bad password",
+			// The fake server sends a 200 with an error instead of a user.
+			call:  login("12345"),
+			want:  []error{ErrAuthFailed},
+			wantN: []error{ErrDecode},
+		},
+		{
+			desc:  "good password",
+			call:  login("supers3cret"),
+			wantN: []error{ErrAuthFailed, ErrDecode},
+		},
+		{
+			desc: "rejected key",
+			call: func(r *RWGPS) error { _, err := r.GetRide(401); return err },
+			want: []error{ErrAuthFailed},
+		},
+		{
+			desc:  "private",
+			call:  func(r *RWGPS) error { _, err := r.GetRide(403); return err },
+			want:  []error{ErrPrivate},
+			wantN: []error{ErrNotFound},
+		},
+		{
+			desc:  "missing ride",
+			call:  func(r *RWGPS) error { _, err := r.GetRide(404); return err },
+			want:  []error{ErrNotFound},
+			wantN: []error{ErrPrivate},
+		},
+		{
+			desc: "missing route",
+			call: func(r *RWGPS) error { _, err := r.GetRoute(1); return err },
+			want: []error{ErrNotFound},
+		},
+		{
+			desc: "missing ride delete",
+			call: func(r *RWGPS) error { return r.DeleteRide(404) },
+			want: []error{ErrNotFound},
+		},
+		{
+			desc: "rate limited",
+			call: func(r *RWGPS) error { _, err := r.GetRide(429); return err },
+			want: []error{ErrRateLimited},
+		},
+		{
+			desc:  "server error",
+			call:  func(r *RWGPS) error { _, err := r.GetRide(500); return err },
+			wantN: []error{ErrAuthFailed, ErrNotFound, ErrPrivate, ErrRateLimited, ErrDecode},
+		},
+		{
+			desc:  "bad json",
+			call:  func(r *RWGPS) error { _, err := r.GetRide(1); return err },
+			want:  []error{ErrDecode},
+			wantN: []error{ErrAuthFailed},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := testObj(f.URL)
+			err := tc.call(r)
+			if len(tc.want) > 0 && err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, want := range tc.want {
+				if !errors.Is(err, want) {
+					t.Errorf("want %v, got %v", want, err)
+				}
+			}
+			for _, want := range tc.wantN {
+				if errors.Is(err, want) {
+					t.Errorf("didn't want %v, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCurrentUserNoUser(t *testing.T) {
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/users/current.json": func(string, url.Values) string { return `{"error": "bad auth"}` },
+	})
+	defer server.Close()
+	r := testObj(server.URL)
+
+	u, err := r.GetCurrentUser()
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("want ErrAuthFailed, got %v", err)
+	}
+	if u != nil {
+		t.Errorf("unexpected user: %+v", u)
+	}
+}
+
+func TestGetCurrentUserBadJSON(t *testing.T) {
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/users/current.json": func(string, url.Values) string { return `{"user": "not a user"}` },
+	})
+	defer server.Close()
+	r := testObj(server.URL)
+
+	// A response that doesn't decode isn't a sign of bad credentials.
+	_, err := r.GetCurrentUser()
+	if !errors.Is(err, ErrDecode) || errors.Is(err, ErrAuthFailed) {
+		t.Errorf("want ErrDecode and not ErrAuthFailed, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Event is a club event. StartsAt is in UTC, use LocalStartsAt for the
+// event's own timezone.
+type Event struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	StartsAt    time.Time
+	TimeZone    string `json:"time_zone"`
+	AllDay      bool   `json:"all_day"`
+	Location    string `json:"location"`
+	RouteIDs    []int  `json:"route_ids"`
+	Organizer   struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"organizer"`
+	ParticipantCount int `json:"participants_count"`
+	InterestedCount  int `json:"interested_count"`
+	// Nil for one-off events.
+	Recurrence *Recurrence `json:"recurrence"`
+}
+
+// Recurrence is how often an event repeats, e.g. every 2 "weekly". Until is
+// zero if it repeats forever.
+type Recurrence struct {
+	Frequency string    `json:"frequency"`
+	Interval  int       `json:"interval"`
+	Until     time.Time `json:"until"`
+}
+
+type event Event
+
+// UnmarshalJSON decodes starts_at, which may not have an offset, in which case
+// it's in the event's timezone.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	w := struct {
+		*event
+		StartsAt string `json:"starts_at"`
+	}{event: (*event)(e)}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	if w.StartsAt == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, w.StartsAt)
+	if err != nil {
+		loc := time.UTC
+		if e.TimeZone != "" {
+			if loc, err = loadLocation(e.TimeZone); err != nil {
+				return fmt.Errorf("bad timezone %q for event %d: %w", e.TimeZone, e.ID, err)
+			}
+		}
+		if t, err = time.ParseInLocation("2006-01-02T15:04:05", w.StartsAt, loc); err != nil {
+			return fmt.Errorf("bad start time %q for event %d: %w", w.StartsAt, e.ID, err)
+		}
+	}
+	e.StartsAt = t.UTC()
+
+	return nil
+}
+
+// LocalStartsAt returns StartsAt in the event's own timezone.
+func (e *Event) LocalStartsAt() time.Time {
+	return localTime(e.StartsAt, e.TimeZone, 0)
+}
+
+type RSVPStatus string
+
+const (
+	RSVPGoing      RSVPStatus = "going"
+	RSVPInterested RSVPStatus = "interested"
+	RSVPNotGoing   RSVPStatus = "not_going"
+)
+
+// GetClubEvents lists a club's events. Private clubs the user isn't a member
+// of return ErrPrivate.
+func (r *RWGPS) GetClubEvents(clubID int) ([]*Event, error) {
+	res, err := r.Get(fmt.Sprintf("/clubs/%d/events.json", clubID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting events for club %d: %w", clubID, err)
+	}
+
+	var resStruct struct {
+		Results []*Event
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return resStruct.Results, nil
+}
+
+// GetEvent gets an event. Events that don't exist return ErrNotFound.
+func (r *RWGPS) GetEvent(id int) (*Event, error) {
+	res, err := r.Get(fmt.Sprintf("/events/%d.json", id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting event %d: %w", id, err)
+	}
+
+	var resStruct struct {
+		Event *Event
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	if resStruct.Event == nil {
+		return nil, fmt.Errorf("error getting event %d: %w", id, ErrNotFound)
+	}
+
+	return resStruct.Event, nil
+}
+
+// RSVP sets the current user's response to an event.
+func (r *RWGPS) RSVP(eventID int, status RSVPStatus) error {
+	switch status {
+	case RSVPGoing, RSVPInterested, RSVPNotGoing:
+	default:
+		return fmt.Errorf("invalid RSVP status %q", status)
+	}
+
+	body, err := json.Marshal(map[string]map[string]RSVPStatus{"rsvp": {"status": status}})
+	if err != nil {
+		return fmt.Errorf("can't encode RSVP for event %d: %w", eventID, err)
+	}
+	if err := r.send(http.MethodPost, fmt.Sprintf("/events/%d/rsvp.json", eventID), nil, body); err != nil {
+		return fmt.Errorf("error sending RSVP for event %d: %w", eventID, err)
+	}
+
+	return nil
+}
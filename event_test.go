@@ -0,0 +1,122 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetClubEvents(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/clubs/501/events.json": getTestData("events.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, err := r.GetClubEvents(501)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("wrong number of events: %d", len(got))
+	}
+
+	// A recurring event, with a start time in its own timezone.
+	e := got[1]
+	if want := time.Date(2021, 9, 7, 13, 30, 0, 0, time.UTC); !e.StartsAt.Equal(want) || e.StartsAt.Location() != time.UTC {
+		t.Errorf("bad start: want %s, got %s", want, e.StartsAt)
+	}
+	if local := e.LocalStartsAt().Format("15:04 MST"); local != "06:30 PDT" {
+		t.Errorf("bad local start: %s", local)
+	}
+	want := &Recurrence{Frequency: "weekly", Interval: 1}
+	if diff := cmp.Diff(want, e.Recurrence); diff != "" {
+		t.Errorf("bad recurrence: -want +got\n%s", diff)
+	}
+	if got[0].Recurrence != nil {
+		t.Errorf("unexpected recurrence for a one-off event: %+v", got[0].Recurrence)
+	}
+}
+
+func TestGetEvent(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/events/9001.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("event.json"))
+	})
+	r := testObj(f.URL)
+
+	got, err := r.GetEvent(9001)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &Event{
+		ID:               9001,
+		Name:             "Saturday Brevet",
+		Description:      "200k, bring lights.",
+		StartsAt:         time.Date(2021, 9, 4, 14, 0, 0, 0, time.UTC),
+		TimeZone:         "America/Los_Angeles",
+		Location:         "Rockridge BART",
+		RouteIDs:         []int{31330404, 31330405},
+		ParticipantCount: 23,
+		InterestedCount:  8,
+	}
+	want.Organizer.ID = 1268590
+	want.Organizer.Name = "zigdon"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad event: -want +got\n%s", diff)
+	}
+
+	if _, err := r.GetEvent(9002); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing event: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestRSVP(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var bodies []string
+	f.handle("/events/9001/rsvp.json", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "bad method", http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		fmt.Fprint(w, "{}")
+	})
+	r := testObj(f.URL)
+
+	for _, s := range []RSVPStatus{RSVPGoing, RSVPNotGoing} {
+		if err := r.RSVP(9001, s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := r.RSVP(9001, "yes please"); err == nil {
+		t.Errorf("expected an error for a bad status")
+	}
+	if err := r.RSVP(9002, RSVPGoing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing event: want ErrNotFound, got %v", err)
+	}
+
+	var got []map[string]map[string]string
+	for _, b := range bodies {
+		var m map[string]map[string]string
+		if err := json.Unmarshal([]byte(b), &m); err != nil {
+			t.Fatalf("bad body %q: %v", b, err)
+		}
+		got = append(got, m)
+	}
+	want := []map[string]map[string]string{
+		{"rsvp": {"status": "going"}},
+		{"rsvp": {"status": "not_going"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad RSVP bodies: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,173 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRWGPS is a test server that keeps state, so writes can be checked.
+type fakeRWGPS struct {
+	*httptest.Server
+	mu       sync.Mutex
+	rides    map[int]*Ride
+	requests []string
+	// bodies are the bodies of the PUT requests.
+	bodies []string
+	// handlers serve other paths, called with the lock held.
+	handlers map[string]http.HandlerFunc
+	// logins counts the requests to /users/current.json, which take
+	// authDelay.
+	logins    atomic.Int32
+	authDelay time.Duration
+	// trackGets counts the ride GETs that didn't exclude the track points.
+	trackGets int
+}
+
+// slowAuth makes logins take d.
+func (f *fakeRWGPS) slowAuth(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authDelay = d
+}
+
+var tripPath = regexp.MustCompile(`^/trips/(\d+)\.json$`)
+
+func newFakeRWGPS(t *testing.T, rides ...*Ride) *fakeRWGPS {
+	f := &fakeRWGPS{rides: make(map[int]*Ride), handlers: make(map[string]http.HandlerFunc)}
+	for _, r := range rides {
+		f.rides[r.ID] = r
+	}
+	f.Server = httptest.NewServer(f)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeRWGPS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path
+	if path == "/users/current.json" {
+		f.logins.Add(1)
+		f.mu.Lock()
+		delay := f.authDelay
+		f.mu.Unlock()
+		time.Sleep(delay)
+		fmt.Fprint(w, defaultAuth(path, req.URL.Query()))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req.Method+" "+path)
+	if h, ok := f.handlers[path]; ok {
+		h(w, req)
+		return
+	}
+
+	m := tripPath.FindStringSubmatch(path)
+	if m == nil {
+		http.NotFound(w, req)
+		return
+	}
+	id, _ := strconv.Atoi(m[1])
+	ride, ok := f.rides[id]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("exclude") != "track_points" {
+			f.trackGets++
+		}
+		json.NewEncoder(w).Encode(struct {
+			Type string `json:"type"`
+			Trip *Ride  `json:"trip"`
+		}{"trip", ride})
+	case http.MethodPut:
+		raw, _ := io.ReadAll(req.Body)
+		f.bodies = append(f.bodies, string(raw))
+		var body struct{ Trip RideUpdate }
+		if err := json.Unmarshal(raw, &body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		u := body.Trip
+		if u.Name != nil {
+			ride.Name = *u.Name
+		}
+		if u.Description != nil {
+			ride.Description = *u.Description
+		}
+		if u.GearID != nil {
+			ride.Gear = &Gear{ID: *u.GearID}
+		}
+		if u.Visibility != nil {
+			ride.Visibility = *u.Visibility
+		}
+		if u.Tags != nil {
+			ride.Tags = *u.Tags
+		}
+		f.touch(ride)
+		fmt.Fprint(w, "{}")
+	case http.MethodDelete:
+		delete(f.rides, id)
+		fmt.Fprint(w, "{}")
+	default:
+		http.Error(w, "bad method", http.StatusMethodNotAllowed)
+	}
+}
+
+// touch marks a ride as changed. Must be called with the lock held.
+func (f *fakeRWGPS) touch(r *Ride) {
+	r.UpdatedAt = r.UpdatedAt.Add(time.Minute)
+}
+
+// handle serves path with h.
+func (f *fakeRWGPS) handle(path string, h http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[path] = h
+}
+
+func (f *fakeRWGPS) ride(id int) *Ride {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rides[id]
+}
+
+// trackPointGets is how many ride GETs included the track points.
+func (f *fakeRWGPS) trackPointGets() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.trackGets
+}
+
+// writes returns the non-GET requests made so far.
+func (f *fakeRWGPS) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []string
+	for _, r := range f.requests {
+		if r[:4] != "GET " {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// putBodies returns the bodies of the PUT requests so far.
+func (f *fakeRWGPS) putBodies() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.bodies...)
+}
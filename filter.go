@@ -0,0 +1,141 @@
+package goride
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Filter picks rides out of a list, e.g. a cached one. Combine filters with
+// And, Or and Not.
+type Filter func(*RideSlim) bool
+
+// And matches rides that match all the filters.
+func And(fs ...Filter) Filter {
+	return func(r *RideSlim) bool {
+		for _, f := range fs {
+			if !f(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches rides that match any of the filters.
+func Or(fs ...Filter) Filter {
+	return func(r *RideSlim) bool {
+		for _, f := range fs {
+			if f(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches rides that don't match f.
+func Not(f Filter) Filter {
+	return func(r *RideSlim) bool { return !f(r) }
+}
+
+// NameContains matches rides with s in their name, ignoring case and accents,
+// so "cote" matches "Côte".
+func NameContains(s string) Filter {
+	s = foldText(s)
+	return func(r *RideSlim) bool { return strings.Contains(foldText(r.Name), s) }
+}
+
+// DistanceAtLeast matches rides at least meters long.
+func DistanceAtLeast(meters float64) Filter {
+	return func(r *RideSlim) bool { return float64(r.Distance) >= meters }
+}
+
+// DistanceAtMost matches rides at most meters long.
+func DistanceAtMost(meters float64) Filter {
+	return func(r *RideSlim) bool { return float64(r.Distance) <= meters }
+}
+
+// InYear matches rides that left during year, in their own timezone.
+func InYear(year int) Filter {
+	return func(r *RideSlim) bool { return r.LocalDepartedAt().Year() == year }
+}
+
+// WithGear matches rides on the gear with the given ID.
+func WithGear(id int) Filter {
+	return func(r *RideSlim) bool { return r.GearID == id }
+}
+
+// InCountry matches rides by their two letter country code, ignoring case.
+func InCountry(code string) Filter {
+	return func(r *RideSlim) bool { return strings.EqualFold(r.CountryCode, code) }
+}
+
+// FilterRides returns the rides f matches, in their original order. A nil
+// filter matches everything.
+func FilterRides(rides []*RideSlim, f Filter) []*RideSlim {
+	var res []*RideSlim
+	for _, r := range rides {
+		if f == nil || f(r) {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// RideOrder reports whether ride a sorts before ride b.
+type RideOrder func(a, b *RideSlim) bool
+
+var (
+	ByDistance RideOrder = func(a, b *RideSlim) bool { return a.Distance < b.Distance }
+	ByDate     RideOrder = func(a, b *RideSlim) bool { return a.DepartedAt.Before(b.DepartedAt) }
+	ByDuration RideOrder = func(a, b *RideSlim) bool { return a.Duration < b.Duration }
+)
+
+// Descending reverses an order.
+func Descending(o RideOrder) RideOrder {
+	return func(a, b *RideSlim) bool { return o(b, a) }
+}
+
+// SortRides sorts rides in place. Rides that are equal in the order keep their
+// original order.
+func SortRides(rides []*RideSlim, o RideOrder) {
+	sort.SliceStable(rides, func(i, j int) bool { return o(rides[i], rides[j]) })
+}
+
+// accents maps lower case Latin letters with diacritics to the plain ones.
+var accents = map[rune]string{}
+
+func init() {
+	for plain, letters := range map[string]string{
+		"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđ", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő", "r": "ŕŗř",
+		"s": "śŝşš", "t": "ţťŧ", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ",
+		"z": "źżž", "ae": "æ", "oe": "œ", "ss": "ß", "th": "þ",
+	} {
+		for _, c := range letters {
+			accents[c] = plain
+		}
+	}
+}
+
+// foldRune lower cases c and strips its accent, for loose matching.
+func foldRune(c rune) string {
+	c = unicode.ToLower(c)
+	if plain, ok := accents[c]; ok {
+		return plain
+	}
+	return string(c)
+}
+
+// foldText lower cases s and strips the accents, for loose matching.
+func foldText(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		b.WriteString(foldRune(c))
+	}
+
+	return b.String()
+}
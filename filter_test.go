@@ -0,0 +1,88 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func filterRides() []*RideSlim {
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 16, 0, 0, 0, time.UTC) }
+	return []*RideSlim{
+		{ID: 1, Name: "SFR Brevet 200", Distance: 203000, Duration: 36000, DepartedAt: day(2023, 3, 4), GearID: 7, CountryCode: "US"},
+		{ID: 2, Name: "Commute to Łódź", Distance: 12000, Duration: 2400, DepartedAt: day(2023, 3, 6), GearID: 7, CountryCode: "US"},
+		{ID: 3, Name: "Paris-Brest-Paris brevet", Distance: 1219000, Duration: 300000, DepartedAt: day(2023, 8, 20), GearID: 7, CountryCode: "FR"},
+		{ID: 4, Name: "Davis BRÉVET 300", Distance: 305000, Duration: 54000, DepartedAt: day(2023, 4, 15), GearID: 9, CountryCode: "us"},
+		{ID: 5, Name: "Brevet 200", Distance: 203000, Duration: 39000, DepartedAt: day(2022, 5, 1), GearID: 7, CountryCode: "US"},
+		{ID: 6, Name: "Côte de Brevet", Distance: 203000, Duration: 36000, DepartedAt: day(2023, 6, 1), GearID: 7, CountryCode: "US"},
+	}
+}
+
+func TestFilterRides(t *testing.T) {
+	tests := []struct {
+		desc   string
+		filter Filter
+		want   []int
+	}{
+		{desc: "nil", want: []int{1, 2, 3, 4, 5, 6}},
+		{desc: "name ignores case and accents", filter: NameContains("brevet"), want: []int{1, 3, 4, 5, 6}},
+		{desc: "accented query", filter: NameContains("cÔte"), want: []int{6}},
+		{desc: "letters with strokes", filter: NameContains("lodz"), want: []int{2}},
+		{
+			desc:   "three predicates",
+			filter: And(NameContains("brevet"), DistanceAtLeast(200000), InYear(2023)),
+			want:   []int{1, 3, 4, 6},
+		},
+		{
+			desc:   "gear and country",
+			filter: And(NameContains("brevet"), DistanceAtLeast(200000), InYear(2023), WithGear(7), InCountry("US")),
+			want:   []int{1, 6},
+		},
+		{
+			desc:   "or and not",
+			filter: Or(DistanceAtMost(20000), And(InCountry("us"), Not(WithGear(7)))),
+			want:   []int{2, 4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got []int
+			for _, r := range FilterRides(filterRides(), tc.filter) {
+				got = append(got, r.ID)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad rides: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSortRides(t *testing.T) {
+	tests := []struct {
+		desc  string
+		order RideOrder
+		want  []int
+	}{
+		// 1, 5 and 6 are the same distance, and stay in their original order.
+		{desc: "distance", order: ByDistance, want: []int{2, 1, 5, 6, 4, 3}},
+		{desc: "distance descending", order: Descending(ByDistance), want: []int{3, 4, 1, 5, 6, 2}},
+		{desc: "date", order: ByDate, want: []int{5, 1, 2, 4, 6, 3}},
+		{desc: "duration descending", order: Descending(ByDuration), want: []int{3, 4, 5, 1, 6, 2}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rides := filterRides()
+			SortRides(rides, tc.order)
+			var got []int
+			for _, r := range rides {
+				got = append(got, r.ID)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad order: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package goride
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// FITMetadata is the file info and summaries from a FIT file.
+type FITMetadata struct {
+	Manufacturer int
+	Created      time.Time
+	Sessions     []FITSummary
+	Laps         []FITSummary
+}
+
+// FITSummary is a session or lap summary. Distances are in meters.
+type FITSummary struct {
+	Start       time.Time
+	ElapsedTime time.Duration
+	TimerTime   time.Duration
+	Distance    float64
+	Ascent      float64
+	Descent     float64
+}
+
+// FIT timestamps are seconds since 1989-12-31 00:00:00 UTC.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Global message numbers.
+const (
+	fitFileID  = 0
+	fitSession = 18
+	fitLap     = 19
+	fitRecord  = 20
+)
+
+const fitTimestamp = 253
+
+type fitField struct {
+	num, size, baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	order     binary.ByteOrder
+	fields    []fitField
+	devFields []fitField
+}
+
+// ParseFIT reads the records, sessions and laps from a FIT activity file.
+// Positions are converted from semicircles to degrees, and speeds to km/h.
+// Other messages and developer fields are skipped.
+func ParseFIT(r io.Reader) ([]TrackPoint, FITMetadata, error) {
+	var meta FITMetadata
+	br := bufio.NewReader(r)
+
+	hdr := make([]byte, 12)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, meta, fmt.Errorf("error reading FIT header: %w", err)
+	}
+	size := int(hdr[0])
+	if (size != 12 && size != 14) || string(hdr[8:12]) != ".FIT" {
+		return nil, meta, fmt.Errorf("not a FIT file")
+	}
+	if size == 14 {
+		crc := make([]byte, 2)
+		if _, err := io.ReadFull(br, crc); err != nil {
+			return nil, meta, fmt.Errorf("error reading FIT header: %w", err)
+		}
+		hdr = append(hdr, crc...)
+	}
+	dataSize := int(binary.LittleEndian.Uint32(hdr[4:8]))
+
+	// The size comes from the file, so the data is read as it comes rather
+	// than allocated up front.
+	data, err := io.ReadAll(io.LimitReader(br, int64(dataSize)+2))
+	if err != nil {
+		return nil, meta, fmt.Errorf("error reading FIT data: %w", err)
+	}
+	if len(data) < dataSize+2 {
+		return nil, meta, fmt.Errorf("error reading FIT data: truncated file, want %d bytes, got %d", dataSize+2, len(data))
+	}
+	if want := binary.LittleEndian.Uint16(data[dataSize:]); want != 0 {
+		if got := fitCRC(fitCRC(0, hdr), data[:dataSize]); got != want {
+			return nil, meta, fmt.Errorf("bad FIT checksum: want %04x, got %04x", want, got)
+		}
+	}
+
+	var res []TrackPoint
+	defs := make(map[byte]*fitDefinition)
+	var lastTimestamp uint32
+	for pos := 0; pos < dataSize; {
+		h := data[pos]
+		pos++
+
+		local := h & 0x0F
+		var compressed bool
+		var offset uint32
+		switch {
+		case h&0x80 != 0:
+			// Compressed timestamp header.
+			compressed = true
+			local = (h >> 5) & 0x03
+			offset = uint32(h & 0x1F)
+		case h&0x40 != 0:
+			def, n, err := parseFITDefinition(data[pos:dataSize], h&0x20 != 0)
+			if err != nil {
+				return nil, meta, fmt.Errorf("error in FIT definition at byte %d: %w", pos+size, err)
+			}
+			defs[local] = def
+			pos += n
+			continue
+		}
+
+		def, ok := defs[local]
+		if !ok {
+			return nil, meta, fmt.Errorf("FIT data message at byte %d uses undefined local type %d", pos+size, local)
+		}
+
+		values := make(map[byte]float64)
+		for _, f := range def.fields {
+			if pos+int(f.size) > dataSize {
+				return nil, meta, fmt.Errorf("FIT data message at byte %d is truncated", pos+size)
+			}
+			if v, ok := fitValue(data[pos:pos+int(f.size)], f.baseType, def.order); ok {
+				values[f.num] = v
+			}
+			pos += int(f.size)
+		}
+		for _, f := range def.devFields {
+			pos += int(f.size)
+		}
+		if pos > dataSize {
+			return nil, meta, fmt.Errorf("FIT data message at byte %d is truncated", pos+size)
+		}
+
+		if ts, ok := values[fitTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressed {
+			lastTimestamp += (offset - lastTimestamp&0x1F) & 0x1F
+			values[fitTimestamp] = float64(lastTimestamp)
+		}
+
+		switch def.global {
+		case fitFileID:
+			meta.Manufacturer = int(values[1])
+			if v, ok := values[4]; ok {
+				meta.Created = fitTime(v)
+			}
+		case fitRecord:
+			res = append(res, fitRecordPoint(values))
+		case fitSession:
+			meta.Sessions = append(meta.Sessions, fitSummary(values, 22, 23))
+		case fitLap:
+			meta.Laps = append(meta.Laps, fitSummary(values, 21, 22))
+		}
+	}
+
+	return res, meta, nil
+}
+
+func parseFITDefinition(b []byte, dev bool) (*fitDefinition, int, error) {
+	if len(b) < 5 {
+		return nil, 0, fmt.Errorf("truncated")
+	}
+	def := &fitDefinition{order: binary.LittleEndian}
+	if b[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(b[2:4])
+	n := int(b[4])
+	pos := 5
+	if len(b) < pos+3*n {
+		return nil, 0, fmt.Errorf("truncated")
+	}
+	for i := 0; i < n; i++ {
+		def.fields = append(def.fields, fitField{num: b[pos], size: b[pos+1], baseType: b[pos+2]})
+		pos += 3
+	}
+
+	if dev {
+		if len(b) < pos+1 {
+			return nil, 0, fmt.Errorf("truncated")
+		}
+		n := int(b[pos])
+		pos++
+		if len(b) < pos+3*n {
+			return nil, 0, fmt.Errorf("truncated")
+		}
+		for i := 0; i < n; i++ {
+			def.devFields = append(def.devFields, fitField{num: b[pos], size: b[pos+1]})
+			pos += 3
+		}
+	}
+
+	return def, pos, nil
+}
+
+// fitValue decodes a numeric field, returning false for the type's invalid
+// value. Only the first element of array fields is used.
+func fitValue(b []byte, baseType byte, order binary.ByteOrder) (float64, bool) {
+	switch baseType & 0x1F {
+	case 0x00, 0x02, 0x0A, 0x0D: // enum, uint8, uint8z, byte
+		if len(b) < 1 {
+			return 0, false
+		}
+		invalid := byte(0xFF)
+		if baseType&0x1F == 0x0A {
+			invalid = 0
+		}
+		return float64(b[0]), b[0] != invalid
+	case 0x01: // sint8
+		if len(b) < 1 {
+			return 0, false
+		}
+		return float64(int8(b[0])), b[0] != 0x7F
+	case 0x03: // sint16
+		if len(b) < 2 {
+			return 0, false
+		}
+		v := order.Uint16(b)
+		return float64(int16(v)), v != 0x7FFF
+	case 0x04, 0x0B: // uint16, uint16z
+		if len(b) < 2 {
+			return 0, false
+		}
+		v := order.Uint16(b)
+		if baseType&0x1F == 0x0B {
+			return float64(v), v != 0
+		}
+		return float64(v), v != 0xFFFF
+	case 0x05: // sint32
+		if len(b) < 4 {
+			return 0, false
+		}
+		v := order.Uint32(b)
+		return float64(int32(v)), v != 0x7FFFFFFF
+	case 0x06, 0x0C: // uint32, uint32z
+		if len(b) < 4 {
+			return 0, false
+		}
+		v := order.Uint32(b)
+		if baseType&0x1F == 0x0C {
+			return float64(v), v != 0
+		}
+		return float64(v), v != 0xFFFFFFFF
+	case 0x08: // float32
+		if len(b) < 4 {
+			return 0, false
+		}
+		v := order.Uint32(b)
+		return float64(math.Float32frombits(v)), v != 0xFFFFFFFF
+	case 0x09: // float64
+		if len(b) < 8 {
+			return 0, false
+		}
+		v := order.Uint64(b)
+		return math.Float64frombits(v), v != 0xFFFFFFFFFFFFFFFF
+	}
+
+	// Strings, 64 bit ints, and anything unknown aren't used.
+	return 0, false
+}
+
+func fitTime(v float64) time.Time {
+	return fitEpoch.Add(time.Duration(v) * time.Second)
+}
+
+func fitRecordPoint(v map[byte]float64) TrackPoint {
+	const semicircles = 180 / float64(1<<31)
+	p := TrackPoint{
+		Lat:         v[0] * semicircles,
+		Lng:         v[1] * semicircles,
+		HeartRate:   v[3],
+		Cadence:     v[4],
+		Distance:    v[5] / 100,
+		Power:       v[7],
+		Temperature: v[13],
+	}
+	if ts, ok := v[fitTimestamp]; ok {
+		p.Time = fitTime(ts)
+	}
+	// Prefer the enhanced fields, which have a larger range.
+	if alt, ok := v[78]; ok {
+		p.Elevation = alt/5 - 500
+	} else if alt, ok := v[2]; ok {
+		p.Elevation = alt/5 - 500
+	}
+	if speed, ok := v[73]; ok {
+		p.Speed = speed / 1000 * 3.6
+	} else if speed, ok := v[6]; ok {
+		p.Speed = speed / 1000 * 3.6
+	}
+
+	return p
+}
+
+// fitSummary decodes a session or lap, which have the ascent and descent in
+// different fields.
+func fitSummary(v map[byte]float64, ascent, descent byte) FITSummary {
+	s := FITSummary{
+		ElapsedTime: time.Duration(v[7] / 1000 * float64(time.Second)),
+		TimerTime:   time.Duration(v[8] / 1000 * float64(time.Second)),
+		Distance:    v[9] / 100,
+		Ascent:      v[ascent],
+		Descent:     v[descent],
+	}
+	if start, ok := v[2]; ok {
+		s.Start = fitTime(start)
+	}
+
+	return s
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+func fitCRC(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+
+	return crc
+}
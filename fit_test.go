@@ -0,0 +1,217 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fitBuilder hand-builds FIT files for tests.
+type fitBuilder struct {
+	data  []byte
+	order map[byte]binary.AppendByteOrder
+}
+
+func newFITBuilder() *fitBuilder {
+	return &fitBuilder{order: make(map[byte]binary.AppendByteOrder)}
+}
+
+// define adds a definition message. Fields are (number, size, base type).
+func (b *fitBuilder) define(local byte, global uint16, order binary.AppendByteOrder, fields [][3]byte, devFields ...[3]byte) {
+	h := 0x40 | local
+	if len(devFields) > 0 {
+		h |= 0x20
+	}
+	arch := byte(0)
+	if order == binary.BigEndian {
+		arch = 1
+	}
+	b.data = append(b.data, h, 0, arch)
+	b.data = order.AppendUint16(b.data, global)
+	b.data = append(b.data, byte(len(fields)))
+	for _, f := range fields {
+		b.data = append(b.data, f[:]...)
+	}
+	if len(devFields) > 0 {
+		b.data = append(b.data, byte(len(devFields)))
+		for _, f := range devFields {
+			b.data = append(b.data, f[:]...)
+		}
+	}
+	b.order[local] = order
+}
+
+// record adds a data message. Values are encoded with the definition's byte
+// order, using their Go type for the size.
+func (b *fitBuilder) record(header byte, values ...interface{}) {
+	order := b.order[header&0x0F]
+	if header&0x80 != 0 {
+		order = b.order[(header>>5)&0x03]
+	}
+	b.data = append(b.data, header)
+	for _, v := range values {
+		switch v := v.(type) {
+		case uint8:
+			b.data = append(b.data, v)
+		case int8:
+			b.data = append(b.data, byte(v))
+		case uint16:
+			b.data = order.AppendUint16(b.data, v)
+		case uint32:
+			b.data = order.AppendUint32(b.data, v)
+		case int32:
+			b.data = order.AppendUint32(b.data, uint32(v))
+		case []byte:
+			b.data = append(b.data, v...)
+		}
+	}
+}
+
+func (b *fitBuilder) bytes() []byte {
+	hdr := []byte{14, 0x20}
+	hdr = binary.LittleEndian.AppendUint16(hdr, 2132)
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(b.data)))
+	hdr = append(hdr, ".FIT"...)
+	hdr = binary.LittleEndian.AppendUint16(hdr, fitCRC(0, hdr))
+
+	res := append(hdr, b.data...)
+	return binary.LittleEndian.AppendUint16(res, fitCRC(0, res))
+}
+
+func fitSeconds(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch) / time.Second)
+}
+
+func degrees(d float64) int32 {
+	return int32(d / 180 * (1 << 31))
+}
+
+// buildTestFIT is a short ride: a full record, one with a compressed timestamp
+// and no position, one from a big-endian definition with developer fields,
+// and a lap and session.
+func buildTestFIT() []byte {
+	start := sensorStart
+	b := newFITBuilder()
+
+	b.define(0, fitFileID, binary.LittleEndian, [][3]byte{{1, 2, 0x84}, {4, 4, 0x86}})
+	b.record(0, uint16(32), fitSeconds(start))
+
+	b.define(1, fitRecord, binary.LittleEndian, [][3]byte{
+		{253, 4, 0x86}, {0, 4, 0x85}, {1, 4, 0x85}, {2, 2, 0x84}, {3, 1, 0x02},
+		{4, 1, 0x02}, {5, 4, 0x86}, {6, 2, 0x84}, {7, 2, 0x84}, {13, 1, 0x01},
+	})
+	b.record(1, fitSeconds(start), degrees(45.5), degrees(-122.6), uint16((100+500)*5),
+		uint8(140), uint8(90), uint32(0), uint16(8333), uint16(250), int8(-3))
+
+	// Compressed timestamp, 3s later, with invalid position and HR.
+	b.define(2, fitRecord, binary.LittleEndian, [][3]byte{
+		{0, 4, 0x85}, {1, 4, 0x85}, {3, 1, 0x02}, {5, 4, 0x86}, {7, 2, 0x84},
+	})
+	offset := (fitSeconds(start) + 3) & 0x1F
+	b.record(0x80|2<<5|byte(offset), int32(0x7FFFFFFF), int32(0x7FFFFFFF), uint8(0xFF), uint32(2500), uint16(260))
+
+	// Big-endian, enhanced altitude and speed, and a developer field.
+	b.define(3, fitRecord, binary.BigEndian, [][3]byte{
+		{253, 4, 0x86}, {0, 4, 0x85}, {1, 4, 0x85}, {78, 4, 0x86}, {73, 4, 0x86},
+	}, [3]byte{0, 3, 0})
+	b.record(3, fitSeconds(start)+10, degrees(45.501), degrees(-122.6), uint32((110+500)*5), uint32(10000), []byte{1, 2, 3})
+
+	b.define(4, fitLap, binary.LittleEndian, [][3]byte{
+		{2, 4, 0x86}, {7, 4, 0x86}, {8, 4, 0x86}, {9, 4, 0x86}, {21, 2, 0x84}, {22, 2, 0x84},
+	})
+	b.record(4, fitSeconds(start), uint32(10000), uint32(9500), uint32(11100), uint16(10), uint16(0))
+	b.define(5, fitSession, binary.LittleEndian, [][3]byte{
+		{2, 4, 0x86}, {7, 4, 0x86}, {8, 4, 0x86}, {9, 4, 0x86}, {22, 2, 0x84}, {23, 2, 0x84},
+	})
+	b.record(5, fitSeconds(start), uint32(10000), uint32(9500), uint32(11100), uint16(10), uint16(0))
+
+	return b.bytes()
+}
+
+func TestParseFIT(t *testing.T) {
+	checkGolden(t, "fit/ride.fit", buildTestFIT())
+	f, err := os.Open(filepath.Join("testdata", "fit", "ride.fit"))
+	if err != nil {
+		t.Fatalf("can't open fixture: %v", err)
+	}
+	defer f.Close()
+
+	points, meta, err := ParseFIT(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	approx := cmp.Comparer(func(a, b float64) bool {
+		return a-b < 1e-6 && b-a < 1e-6
+	})
+	want := []TrackPoint{
+		{
+			Time: sensorStart, Lat: 45.5, Lng: -122.6, Elevation: 100,
+			HeartRate: 140, Cadence: 90, Speed: 8.333 * 3.6, Power: 250, Temperature: -3,
+		},
+		{Time: sensorStart.Add(3 * time.Second), Distance: 25, Power: 260},
+		{Time: sensorStart.Add(10 * time.Second), Lat: 45.501, Lng: -122.6, Elevation: 110, Speed: 36},
+	}
+	if diff := cmp.Diff(want, points, approx); diff != "" {
+		t.Errorf("bad points: -want +got\n%s", diff)
+	}
+
+	summary := FITSummary{
+		Start:       sensorStart,
+		ElapsedTime: 10 * time.Second,
+		TimerTime:   9500 * time.Millisecond,
+		Distance:    111,
+		Ascent:      10,
+	}
+	wantMeta := FITMetadata{
+		Manufacturer: 32,
+		Created:      sensorStart,
+		Sessions:     []FITSummary{summary},
+		Laps:         []FITSummary{summary},
+	}
+	if diff := cmp.Diff(wantMeta, meta); diff != "" {
+		t.Errorf("bad metadata: -want +got\n%s", diff)
+	}
+}
+
+func TestParseFITErrors(t *testing.T) {
+	good := buildTestFIT()
+	badCRC := append([]byte{}, good...)
+	badCRC[len(badCRC)-3] ^= 0xFF
+	// A data size of 4 GiB, with no data.
+	hugeSize := append([]byte{}, good[:14]...)
+	binary.LittleEndian.PutUint32(hugeSize[4:8], 0xFFFFFFFF)
+	undefined := newFITBuilder()
+	undefined.record(7, uint8(1))
+
+	tests := []struct {
+		desc string
+		data []byte
+		want string
+	}{
+		{desc: "empty", want: "error reading FIT header"},
+		{desc: "not FIT", data: []byte("<gpx version=\"1.1\"></gpx>"), want: "not a FIT file"},
+		{desc: "truncated", data: good[:len(good)-10], want: "truncated"},
+		{desc: "huge size", data: hugeSize, want: "truncated"},
+		{desc: "bad checksum", data: badCRC, want: "bad FIT checksum"},
+		{desc: "undefined type", data: undefined.bytes(), want: "undefined local type 7"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, _, err := ParseFIT(bytes.NewReader(tc.data))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q doesn't mention %q", err, tc.want)
+			}
+		})
+	}
+}
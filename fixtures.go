@@ -0,0 +1,285 @@
+package goride
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+// captureEnv is the environment variable that turns on WithCapture, with the
+// directory to write the fixtures to.
+const captureEnv = "GORIDE_CAPTURE_DIR"
+
+// redacted replaces credentials in captured fixtures.
+const redacted = "REDACTED"
+
+// maxFixtureName is the longest fixture file name. Longer names have their
+// args replaced by a hash.
+const maxFixtureName = 150
+
+// secretKeys are the JSON keys whose values are always scrubbed from
+// fixtures.
+var secretKeys = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"apikey":        true,
+	"auth_token":    true,
+	"email":         true,
+	"password":      true,
+	"refresh_token": true,
+}
+
+// fixtureName is the file a response is captured to: the path, with any args
+// that aren't credentials (see uncachedArgs), e.g.
+// "users_1_trips-limit=10-offset=0.json". Requests other than GET start with
+// the method, e.g. "put-trips_94.json".
+func fixtureName(method, base string, args url.Values) string {
+	if u, err := url.Parse(base); err == nil && u.IsAbs() {
+		base = u.Path
+	}
+	ext := path.Ext(base)
+	if ext == "" {
+		ext = ".json"
+	}
+	name := strings.ReplaceAll(strings.Trim(strings.TrimSuffix(base, path.Ext(base)), "/"), "/", "_")
+	if method != http.MethodGet {
+		name = strings.ToLower(method) + "-" + name
+	}
+
+	var keys []string
+	for k := range args {
+		if !uncachedArgs[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range args[k] {
+			params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	if len(params) > 0 {
+		query := strings.Join(params, "-")
+		if len(name)+len(query)+len(ext) >= maxFixtureName {
+			sum := sha256.Sum256([]byte(query))
+			query = hex.EncodeToString(sum[:8])
+		}
+		name += "-" + query
+	}
+
+	return name + ext
+}
+
+// fixtureRecorder writes responses to fixture files, see WithCapture.
+type fixtureRecorder struct {
+	dir string
+	// configSecrets returns the credentials from the config.
+	configSecrets func() []string
+
+	mu sync.Mutex
+	// seen are the credentials seen in requests and responses so far.
+	seen map[string]bool
+}
+
+// WithCapture writes every response, scrubbed of credentials, to a fixture file
+// in dir, named by fixtureName, for WithReplay. Setting GORIDE_CAPTURE_DIR
+// does the same. Requests fail if their fixture can't be written.
+func WithCapture(dir string) Option {
+	return func(r *RWGPS) {
+		r.client.capture = &fixtureRecorder{
+			dir:  dir,
+			seen: make(map[string]bool),
+			configSecrets: func() []string {
+				if r.config == nil {
+					return nil
+				}
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				return []string{r.config.Email, r.config.Password, r.config.AuthToken, r.config.KeyName}
+			},
+		}
+	}
+}
+
+// secrets returns all the credentials known so far, longest first, so one
+// that contains another is scrubbed whole.
+func (f *fixtureRecorder) secrets() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []string
+	for s := range f.seen {
+		res = append(res, s)
+	}
+	for _, s := range f.configSecrets() {
+		if s != "" && !f.seen[s] {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return len(res[i]) > len(res[j]) })
+
+	return res
+}
+
+func (f *fixtureRecorder) remember(s string) {
+	if s == "" || s == redacted {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seen[s] = true
+}
+
+// record writes a response to its fixture. Responses that aren't a success
+// have their status in the name, e.g. "trips_42.404.json".
+func (f *fixtureRecorder) record(method, base string, args url.Values, code int, body []byte) error {
+	for k, vs := range args {
+		if uncachedArgs[k] && k != "version" {
+			for _, v := range vs {
+				f.remember(v)
+			}
+		}
+	}
+	body = scrubFixture(body, f.remember, f.secrets)
+
+	name := fixtureName(method, base, args)
+	if code < 200 || code > 299 {
+		ext := path.Ext(name)
+		name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(name, ext), code, ext)
+	}
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return fmt.Errorf("can't capture %s %q: %w", method, base, err)
+	}
+	if err := atomicfile.WriteFile(filepath.Join(f.dir, name), body); err != nil {
+		return fmt.Errorf("can't capture %s %q: %w", method, base, err)
+	}
+
+	return nil
+}
+
+// scrubFixture removes credentials from a response body. In JSON, the values
+// of secretKeys are replaced, and passed to found. Then every known secret is
+// replaced wherever it is, as is or URL encoded.
+func scrubFixture(body []byte, found func(string), secrets func() []string) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		v = scrubJSON(v, found)
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err == nil {
+			body = buf.Bytes()
+		}
+	}
+
+	text := string(body)
+	for _, s := range secrets() {
+		for _, form := range []string{s, url.QueryEscape(s), strings.Trim(strconv.Quote(s), `"`)} {
+			text = strings.ReplaceAll(text, form, redacted)
+		}
+	}
+
+	return []byte(text)
+}
+
+func scrubJSON(v interface{}, found func(string)) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if s, ok := val.(string); ok && secretKeys[strings.ToLower(k)] && s != "" {
+				found(s)
+				v[k] = redacted
+				continue
+			}
+			v[k] = scrubJSON(val, found)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = scrubJSON(val, found)
+		}
+	}
+
+	return v
+}
+
+// ReplayClient serves the fixtures written by WithCapture, without any
+// network. Requests without a fixture return ErrNotFound, and are listed by
+// Misses.
+type ReplayClient struct {
+	dir string
+
+	mu     sync.Mutex
+	misses []string
+}
+
+func NewReplayClient(dir string) *ReplayClient {
+	return &ReplayClient{dir: dir}
+}
+
+// WithReplay answers every request from the replay client's fixtures, rather
+// than the server.
+func WithReplay(c *ReplayClient) Option {
+	return func(r *RWGPS) {
+		r.client.replay = c
+	}
+}
+
+// Misses returns the requests that didn't have a fixture, as their fixture
+// names.
+func (c *ReplayClient) Misses() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.misses...)
+}
+
+// open returns the fixture for a request, or the error it was captured with.
+func (c *ReplayClient) open(method, base string, args url.Values) (io.ReadCloser, error) {
+	name := fixtureName(method, base, args)
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err == nil {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("can't replay %s %q: %w", method, base, err)
+	}
+
+	ext := path.Ext(name)
+	failed, _ := filepath.Glob(filepath.Join(c.dir, glob(strings.TrimSuffix(name, ext))+".[0-9][0-9][0-9]"+glob(ext)))
+	if len(failed) > 0 {
+		data, err := os.ReadFile(failed[0])
+		if err != nil {
+			return nil, fmt.Errorf("can't replay %s %q: %w", method, base, err)
+		}
+		status := strings.TrimPrefix(path.Ext(strings.TrimSuffix(failed[0], ext)), ".")
+		code, _ := strconv.Atoi(status)
+		return nil, &statusError{method: method, path: base, code: code, status: status + " " + http.StatusText(code), body: string(data)}
+	}
+
+	c.mu.Lock()
+	c.misses = append(c.misses, name)
+	c.mu.Unlock()
+	return nil, &statusError{method: method, path: base, code: http.StatusNotFound, status: "404 no fixture " + name}
+}
+
+// glob escapes the glob metacharacters in s.
+func glob(s string) string {
+	r := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return r.Replace(s)
+}
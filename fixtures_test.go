@@ -0,0 +1,243 @@
+package goride
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFixtureName(t *testing.T) {
+	tests := []struct {
+		desc   string
+		method string
+		base   string
+		args   url.Values
+		want   string
+	}{
+		{
+			desc:   "path",
+			method: "GET",
+			base:   "/trips/94.json",
+			want:   "trips_94.json",
+		},
+		{
+			desc:   "args sorted, credentials dropped",
+			method: "GET",
+			base:   "/users/1/trips.json",
+			args: url.Values{
+				"offset": {"0"}, "limit": {"10"},
+				"auth_token": {"beef1337"}, "apikey": {"test key"}, "version": {"2"},
+			},
+			want: "users_1_trips-limit=10-offset=0.json",
+		},
+		{
+			desc:   "escaped args",
+			method: "GET",
+			base:   "/find/search.json",
+			args:   url.Values{"keywords": {"bay bridge/loop"}},
+			want:   "find_search-keywords=bay+bridge%2Floop.json",
+		},
+		{
+			desc:   "other extension",
+			method: "GET",
+			base:   "/trips/94.gpx",
+			args:   url.Values{"sub_format": {"track"}},
+			want:   "trips_94-sub_format=track.gpx",
+		},
+		{
+			desc:   "no extension",
+			method: "GET",
+			base:   "/users/current",
+			want:   "users_current.json",
+		},
+		{
+			desc:   "method",
+			method: "PUT",
+			base:   "/trips/94.json",
+			want:   "put-trips_94.json",
+		},
+		{
+			desc:   "absolute URL",
+			method: "GET",
+			base:   "https://photos.example.com/photos/1.jpg",
+			want:   "photos_1.jpg",
+		},
+		{
+			desc:   "long args",
+			method: "GET",
+			base:   "/trips.json",
+			args:   url.Values{"ids": {strings.Repeat("12345,", 30)}},
+			want:   "trips-46f4d4eb983b4157.json",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := fixtureName(tc.method, tc.base, tc.args)
+			if got != tc.want {
+				t.Errorf("fixtureName(...): want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestScrubFixture(t *testing.T) {
+	tests := []struct {
+		desc      string
+		body      string
+		secrets   []string
+		want      string
+		wantFound []string
+	}{
+		{
+			desc:      "json keys",
+			body:      `{"user":{"id":1,"email":"dan@example.com","auth_token":"ffffff","name":"<dan>"}}`,
+			want:      `{"user":{"auth_token":"REDACTED","email":"REDACTED","id":1,"name":"<dan>"}}` + "\n",
+			wantFound: []string{"dan@example.com", "ffffff"},
+		},
+		{
+			desc:      "nested in lists",
+			body:      `[{"api_key":"k1"},{"Password":"p1","n":1.50}]`,
+			want:      `[{"api_key":"REDACTED"},{"Password":"REDACTED","n":1.50}]` + "\n",
+			wantFound: []string{"k1", "p1"},
+		},
+		{
+			desc:    "known secrets anywhere",
+			body:    `{"note":"logged in as test@example.com","url":"/x?key=test+key"}`,
+			secrets: []string{"test@example.com", "test key"},
+			want:    `{"note":"logged in as REDACTED","url":"/x?key=REDACTED"}` + "\n",
+		},
+		{
+			desc:    "not json",
+			body:    "401 bad auth for test@example.com",
+			secrets: []string{"test@example.com"},
+			want:    "401 bad auth for REDACTED",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var found []string
+			got := scrubFixture([]byte(tc.body),
+				func(s string) { found = append(found, s) },
+				func() []string { return tc.secrets })
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("scrubFixture(...): -want +got:\n%s", diff)
+			}
+			if len(found) > 1 && found[0] > found[1] {
+				found[0], found[1] = found[1], found[0]
+			}
+			if diff := cmp.Diff(tc.wantFound, found); diff != "" {
+				t.Errorf("scrubFixture(...) found: -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	server := startServer(t,
+		map[string]string{
+			"/trips/94.json":            getTestData("trip.json"),
+			"/users/1268590/trips.json": getTestData("trips0-2.json"),
+		},
+		nil)
+	defer server.Close()
+	dir := t.TempDir()
+
+	r := testObj(server.URL)
+	WithCapture(dir)(r)
+	user, err := r.GetCurrentUser()
+	if err != nil {
+		t.Fatalf("GetCurrentUser(): %v", err)
+	}
+	rides, _, err := r.GetRides(user.ID, 0, 2)
+	if err != nil {
+		t.Fatalf("GetRides(): %v", err)
+	}
+	ride, err := r.GetRide(94)
+	if err != nil {
+		t.Fatalf("GetRide(): %v", err)
+	}
+	if _, err := r.GetRide(95); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetRide(95): want ErrNotFound, got %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("can't read fixtures: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	want := []string{
+		"trips_94.json",
+		"trips_95.404.json",
+		"users_1268590_trips-limit=2-offset=0.json",
+		"users_current.json",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("fixtures: -want +got:\n%s", diff)
+	}
+
+	// The config's credentials, and the email and token of the user.
+	secrets := []string{"test@example.com", "supers3cret", "test key", "dan@peeron.com", "ffffff"}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("can't read %s: %v", name, err)
+		}
+		for _, s := range secrets {
+			for _, form := range []string{s, url.QueryEscape(s)} {
+				if strings.Contains(string(data), form) {
+					t.Errorf("%s has %q", name, form)
+				}
+			}
+		}
+	}
+
+	replay := NewReplayClient(dir)
+	r = testObj("")
+	WithReplay(replay)(r)
+	gotUser, err := r.GetCurrentUser()
+	if err != nil {
+		t.Fatalf("replayed GetCurrentUser(): %v", err)
+	}
+	// The token is scrubbed too.
+	user.AuthToken = redacted
+	if diff := cmp.Diff(user, gotUser); diff != "" {
+		t.Errorf("replayed GetCurrentUser(): -want +got:\n%s", diff)
+	}
+	gotRides, _, err := r.GetRides(user.ID, 0, 2)
+	if err != nil {
+		t.Fatalf("replayed GetRides(): %v", err)
+	}
+	if diff := cmp.Diff(rides, gotRides); diff != "" {
+		t.Errorf("replayed GetRides(): -want +got:\n%s", diff)
+	}
+	gotRide, err := r.GetRide(94)
+	if err != nil {
+		t.Fatalf("replayed GetRide(): %v", err)
+	}
+	if diff := cmp.Diff(ride, gotRide); diff != "" {
+		t.Errorf("replayed GetRide(): -want +got:\n%s", diff)
+	}
+	if _, err := r.GetRide(95); !errors.Is(err, ErrNotFound) {
+		t.Errorf("replayed GetRide(95): want ErrNotFound, got %v", err)
+	}
+	if diff := cmp.Diff([]string(nil), replay.Misses()); diff != "" {
+		t.Errorf("Misses(): -want +got:\n%s", diff)
+	}
+
+	if _, err := r.GetRide(96); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRide(96) without a fixture: want ErrNotFound, got %v", err)
+	}
+	if diff := cmp.Diff([]string{"trips_96.json"}, replay.Misses()); diff != "" {
+		t.Errorf("Misses(): -want +got:\n%s", diff)
+	}
+}
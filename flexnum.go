@@ -0,0 +1,208 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Some endpoints, and older rides, send numbers as strings and missing times
+// as empty strings. Types that can get those decode through unmarshalFlex.
+
+// The fields of the flex types, without their UnmarshalJSON.
+type (
+	rideSlimFields RideSlim
+	rideFields     Ride
+	metricsFields  Metrics
+)
+
+func (r *RideSlim) UnmarshalJSON(data []byte) error {
+	return unmarshalFlex(data, (*rideSlimFields)(r), false)
+}
+
+func (r *Ride) UnmarshalJSON(data []byte) error {
+	return unmarshalFlex(data, (*rideFields)(r), false)
+}
+
+func (m *Metrics) UnmarshalJSON(data []byte) error {
+	return unmarshalFlex(data, (*metricsFields)(m), false)
+}
+
+// flexTypes are the types decoded by unmarshalFlex, and what their fields are
+// decoded into. UnmarshalJSON can't know the client is strict, so strict
+// decoding checks them again, with checkFlex.
+var flexTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(RideSlim{}):    reflect.TypeOf(rideSlimFields{}),
+	reflect.TypeOf(Ride{}):        reflect.TypeOf(rideFields{}),
+	reflect.TypeOf(rideSummary{}): reflect.TypeOf(rideFields{}),
+	reflect.TypeOf(Metrics{}):     reflect.TypeOf(metricsFields{}),
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// unmarshalFlex is json.Unmarshal, also accepting numbers in strings, and
+// empty strings for numbers and times, which decode as null. If strict,
+// unknown fields are an error. v must not have its own UnmarshalJSON.
+func unmarshalFlex(data []byte, v interface{}, strict bool) error {
+	if err := decodeFlex(data, v, strict); err == nil {
+		return nil
+	}
+
+	// Most responses don't need this, so only clean up the ones that failed.
+	fixed, err := normalizeJSON(data, reflect.TypeOf(v).Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	return decodeFlex(fixed, v, strict)
+}
+
+func decodeFlex(data []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+// checkFlex decodes the flex types in raw, which is going into t, again,
+// failing on unknown fields.
+func checkFlex(raw json.RawMessage, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if fields, ok := flexTypes[t]; ok {
+		if err := unmarshalFlex(raw, reflect.New(fields).Interface(), true); err != nil {
+			return err
+		}
+		// Their UnmarshalJSON didn't check the flex types in them.
+		t = fields
+	} else if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil
+		}
+		for k, v := range fields {
+			if f, ok := jsonField(t, k); ok {
+				if err := checkFlex(v, f.Type); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil
+		}
+		for _, v := range items {
+			if err := checkFlex(v, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil
+		}
+		for _, v := range items {
+			if err := checkFlex(v, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeJSON returns raw with the strings going into numeric fields of t
+// replaced by the numbers in them, and empty strings going into numbers or
+// times replaced by null. Other fields with their own UnmarshalJSON are left
+// alone.
+func normalizeJSON(raw json.RawMessage, t reflect.Type, path string) (json.RawMessage, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		if s := strings.TrimSpace(string(raw)); s == `""` {
+			return json.RawMessage("null"), nil
+		}
+		return raw, nil
+	}
+	if path != "" && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return raw, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return raw, nil
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return json.RawMessage("null"), nil
+		}
+		if !json.Valid([]byte(s)) || !(s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) {
+			return nil, fmt.Errorf("bad number %q for %s", s, path)
+		}
+		return json.RawMessage(s), nil
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return raw, nil
+		}
+		for k, v := range fields {
+			f, ok := jsonField(t, k)
+			if !ok {
+				continue
+			}
+			fixed, err := normalizeJSON(v, f.Type, strings.TrimPrefix(path+"."+k, "."))
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = fixed
+		}
+		return json.Marshal(fields)
+	}
+
+	return raw, nil
+}
+
+// jsonField finds the field of t that encoding/json would decode key into.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var fold reflect.StructField
+	found := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			fold, found = f, true
+		}
+	}
+
+	return fold, found
+}
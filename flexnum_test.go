@@ -0,0 +1,201 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuotedNumbers(t *testing.T) {
+	// The same ride, with its numbers quoted and then not.
+	var rides struct{ Results []*RideSlim }
+	if err := decodeJSON(getTestData("trips_quoted.json"), &rides); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rides.Results) != 2 {
+		t.Fatalf("wrong number of rides: %d", len(rides.Results))
+	}
+	if diff := cmp.Diff(rides.Results[1], rides.Results[0]); diff != "" {
+		t.Errorf("quoted numbers decoded differently: -plain +quoted\n%s", diff)
+	}
+	if got := rides.Results[0].Distance; got != 12353.3 {
+		t.Errorf("bad distance: %f", got)
+	}
+
+	var ride struct{ Trip Ride }
+	data := `{"trip": {"id": "94", "distance": " 42990.7 ", "utc_offset": null,
+		"metrics": {"duration": "6586", "movingTime": "6475", "speed": {"avg": "23.9", "max": 65.54}, "hr": {"avg": ""}}}}`
+	if err := decodeJSON(data, &ride); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Ride{ID: 94, Distance: 42990.7}
+	want.Metrics.Duration = 6586
+	want.Metrics.MovingTime = 6475
+	want.Metrics.Speed.Avg = 23.9
+	want.Metrics.Speed.Max = 65.54
+	if diff := cmp.Diff(want, ride.Trip); diff != "" {
+		t.Errorf("bad ride: -want +got\n%s", diff)
+	}
+}
+
+func TestQuotedNumbersErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		data string
+		obj  interface{}
+		want string
+	}{
+		{
+			desc: "ride slim",
+			data: `{"id": 1, "distance": "far"}`,
+			obj:  &RideSlim{},
+			want: `bad number "far" for distance`,
+		},
+		{
+			desc: "ride",
+			data: `{"id": 1, "Distance": "12km"}`,
+			obj:  &Ride{},
+			want: `bad number "12km" for Distance`,
+		},
+		{
+			desc: "metrics",
+			data: `{"speed": {"avg": "NaN"}}`,
+			obj:  &Metrics{},
+			want: `bad number "NaN" for speed.avg`,
+		},
+		{
+			desc: "nested metrics",
+			data: `{"id": 1, "metrics": {"ele_gain": "true"}}`,
+			obj:  &Ride{},
+			want: `bad number "true" for ele_gain`,
+		},
+		{
+			desc: "fraction for an int",
+			data: `{"id": 1, "moving_time": "12.5"}`,
+			obj:  &RideSlim{},
+			want: "moving_time",
+		},
+		{
+			desc: "string field",
+			data: `{"id": 1, "name": 12}`,
+			obj:  &RideSlim{},
+			want: "name",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tc.data), tc.obj)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("want error with %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestNullTimestamps(t *testing.T) {
+	deleted := time.Date(2020, 1, 5, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc    string
+		data    string
+		want    *time.Time
+		wantErr bool
+	}{
+		{desc: "missing", data: `{"id": 1}`},
+		{desc: "null", data: `{"id": 1, "deleted_at": null, "updated_at": null}`},
+		{desc: "blank", data: `{"id": 1, "deleted_at": "", "updated_at": " "}`, wantErr: true},
+		{desc: "empty strings", data: `{"id": 1, "deleted_at": "", "updated_at": ""}`},
+		{desc: "deleted", data: `{"id": 1, "deleted_at": "2020-01-05T18:00:00Z", "updated_at": ""}`, want: &deleted},
+		{desc: "bad", data: `{"id": 1, "deleted_at": "yesterday"}`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var slim RideSlim
+			var ride Ride
+			for _, obj := range []interface{}{&slim, &ride} {
+				err := json.Unmarshal([]byte(tc.data), obj)
+				if tc.wantErr {
+					if err == nil {
+						t.Errorf("%T: expected an error", obj)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%T: unexpected error: %v", obj, err)
+				}
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, slim.DeletedAt); diff != "" {
+				t.Errorf("bad ride slim deleted at: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, ride.DeletedAt); diff != "" {
+				t.Errorf("bad ride deleted at: -want +got\n%s", diff)
+			}
+			if slim.IsDeleted() != (tc.want != nil) || ride.IsDeleted() != (tc.want != nil) {
+				t.Errorf("bad IsDeleted: %v, %v", slim.IsDeleted(), ride.IsDeleted())
+			}
+			if !slim.UpdatedAt.IsZero() || !ride.UpdatedAt.IsZero() {
+				t.Errorf("expected zero updated at: %s, %s", slim.UpdatedAt, ride.UpdatedAt)
+			}
+		})
+	}
+}
+
+func FuzzQuotedNumbers(f *testing.F) {
+	f.Add(12353.3, int64(1973))
+	f.Add(-0.5, int64(-28800))
+	f.Add(1e21, int64(0))
+	f.Fuzz(func(t *testing.T, dist float64, secs int64) {
+		if math.IsNaN(dist) || math.IsInf(dist, 0) || math.Abs(dist) > math.MaxFloat32 {
+			t.Skip()
+		}
+		d := fmt.Sprint(dist)
+		s := fmt.Sprint(secs)
+		var plain, quoted Ride
+		if err := json.Unmarshal([]byte(fmt.Sprintf(`{"distance": %s, "metrics": {"duration": %s}}`, d, s)), &plain); err != nil {
+			t.Fatalf("can't decode plain numbers: %v", err)
+		}
+		if err := json.Unmarshal([]byte(fmt.Sprintf(`{"distance": %q, "metrics": {"duration": %q}}`, d, s)), &quoted); err != nil {
+			t.Fatalf("can't decode quoted numbers: %v", err)
+		}
+		if diff := cmp.Diff(plain, quoted); diff != "" {
+			t.Errorf("quoted numbers decoded differently: -plain +quoted\n%s", diff)
+		}
+	})
+}
+
+func FuzzUnmarshalFlex(f *testing.F) {
+	f.Add(`{"id": "1", "distance": "12.5", "departed_at": "2019-07-23T23:19:14Z"}`)
+	f.Add(`{"id": 1, "metrics": {"speed": {"avg": "x"}}}`)
+	f.Add(`{"distance": ["1"]}`)
+	f.Add(`{"duration": "-"}`)
+	f.Fuzz(func(t *testing.T, data string) {
+		// Only checking nothing panics, and that valid results decode the
+		// same after encoding.
+		var r RideSlim
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return
+		}
+		enc, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("can't encode %+v: %v", r, err)
+		}
+		var again RideSlim
+		if err := json.Unmarshal(enc, &again); err != nil {
+			t.Fatalf("can't decode %s: %v", enc, err)
+		}
+		if diff := cmp.Diff(r, again, cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })); diff != "" {
+			t.Errorf("round trip changed the ride: -want +got\n%s", diff)
+		}
+	})
+}
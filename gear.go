@@ -0,0 +1,198 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// GetDefaultGear returns the ID of the gear new rides get by default, or 0 if
+// there's none.
+func (r *RWGPS) GetDefaultGear() (int, error) {
+	u, err := r.GetCurrentUser()
+	if err != nil {
+		return 0, fmt.Errorf("error getting default gear: %w", err)
+	}
+
+	return u.Preferences.DefaultGearID, nil
+}
+
+// SetDefaultGear changes the gear new rides get by default. 0 clears it.
+func (r *RWGPS) SetDefaultGear(id int) error {
+	if id < 0 {
+		return fmt.Errorf("bad gear ID %d", id)
+	}
+	// Like when it's read, the gear ID is a string, and empty for none.
+	gear := ""
+	if id != 0 {
+		gear = strconv.Itoa(id)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"user": map[string]interface{}{
+			"preferences": map[string]string{"default_gear_id": gear},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("can't encode default gear: %w", err)
+	}
+
+	if err := r.send(http.MethodPut, "/users/current.json", nil, body); err != nil {
+		return fmt.Errorf("error setting default gear: %w", err)
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	// The logged in user is shared, so it's replaced rather than changed.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.authUser != nil {
+		u := *r.authUser
+		u.Preferences.DefaultGearID = id
+		r.authUser = &u
+	}
+
+	return nil
+}
+
+// GearRule picks the gear for the rides it matches. A ride matches when it
+// passes every condition that's set; a rule with none matches every ride, as
+// a fallback.
+type GearRule struct {
+	// Tags matches rides with any of the tags, ignoring case.
+	Tags []string
+	// MinDistance and MaxDistance, in meters, bound the ride's distance.
+	// Zero means no bound.
+	MinDistance float64
+	MaxDistance float64
+	// MinAvgSpeed and MaxAvgSpeed, in km/h, bound the ride's average speed.
+	// Zero means no bound.
+	MinAvgSpeed float64
+	MaxAvgSpeed float64
+	// Near, if set, matches rides starting within RadiusMeters of it. Rides
+	// without coordinates, like trainer rides, don't match.
+	Near         *LatLng
+	RadiusMeters float64
+	// GearID is the gear the matching rides get.
+	GearID int
+}
+
+func (g GearRule) validate() error {
+	switch {
+	case g.GearID <= 0:
+		return fmt.Errorf("bad gear ID %d", g.GearID)
+	case g.MinDistance < 0 || g.MaxDistance < 0 || (g.MaxDistance > 0 && g.MinDistance > g.MaxDistance):
+		return fmt.Errorf("bad distance range %v-%vm", g.MinDistance, g.MaxDistance)
+	case g.MinAvgSpeed < 0 || g.MaxAvgSpeed < 0 || (g.MaxAvgSpeed > 0 && g.MinAvgSpeed > g.MaxAvgSpeed):
+		return fmt.Errorf("bad speed range %v-%vkm/h", g.MinAvgSpeed, g.MaxAvgSpeed)
+	case g.Near != nil && g.RadiusMeters <= 0:
+		return fmt.Errorf("bad radius %vm", g.RadiusMeters)
+	}
+	return nil
+}
+
+func (g GearRule) matches(r *RideSlim) bool {
+	if len(g.Tags) > 0 {
+		tagged := false
+		for _, t := range g.Tags {
+			if hasTag(r.Tags, t) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+	if !inRange(float64(r.Distance), g.MinDistance, g.MaxDistance) {
+		return false
+	}
+	if !inRange(rideSlimAvgSpeed(r), g.MinAvgSpeed, g.MaxAvgSpeed) {
+		return false
+	}
+	if g.Near != nil {
+		if r.FirstLat == 0 && r.FirstLng == 0 {
+			return false
+		}
+		if haversine(r.FirstLat, r.FirstLng, float64(g.Near.Lat), float64(g.Near.Lng)) > g.RadiusMeters {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange reports whether v is between lo and hi, where zero is no bound.
+func inRange(v, lo, hi float64) bool {
+	return (lo == 0 || v >= lo) && (hi == 0 || v <= hi)
+}
+
+// rideSlimAvgSpeed is the server's average speed, in km/h, or the distance
+// over the moving time if it's missing.
+func rideSlimAvgSpeed(r *RideSlim) float64 {
+	if r.AvgSpeed != 0 {
+		return float64(r.AvgSpeed)
+	}
+	if r.MovingTime > 0 {
+		return float64(r.Distance) / 1000 / (float64(r.MovingTime) / 3600)
+	}
+	return 0
+}
+
+// GearAssignment is a ride whose gear AssignGear would change.
+type GearAssignment struct {
+	RideID int
+	// Rule is the index of the rule that matched.
+	Rule int
+	// From is the ride's gear now, 0 if it has none, and To is its new gear.
+	From int
+	To   int
+}
+
+func (a GearAssignment) String() string {
+	return fmt.Sprintf("ride %d: gear %d -> %d (rule %d)", a.RideID, a.From, a.To, a.Rule)
+}
+
+// AssignGear matches the rides against the rules, in order, and returns the
+// rides whose gear the first matching rule would change, as a report to check
+// before passing it to ApplyGearAssignments. Rides no rule matches, and ones
+// already on their rule's gear, aren't included.
+func AssignGear(rides []*RideSlim, rules []GearRule) ([]GearAssignment, error) {
+	for i, g := range rules {
+		if err := g.validate(); err != nil {
+			return nil, fmt.Errorf("bad gear rule %d: %w", i, err)
+		}
+	}
+
+	var res []GearAssignment
+	for _, r := range rides {
+		for i, g := range rules {
+			if !g.matches(r) {
+				continue
+			}
+			if r.GearID != g.GearID {
+				res = append(res, GearAssignment{RideID: r.ID, Rule: i, From: r.GearID, To: g.GearID})
+			}
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// ApplyGearAssignments updates the rides' gear, as BulkUpdateRides does.
+func (r *RWGPS) ApplyGearAssignments(assignments []GearAssignment, opts BulkOptions) (BulkResult, error) {
+	gear := make(map[int]int, len(assignments))
+	var ids []int
+	for _, a := range assignments {
+		if _, ok := gear[a.RideID]; !ok {
+			ids = append(ids, a.RideID)
+		}
+		gear[a.RideID] = a.To
+	}
+
+	return r.bulkUpdateRides(ids, func(id int) RideUpdate {
+		g := gear[id]
+		return RideUpdate{GearID: &g}
+	}, opts)
+}
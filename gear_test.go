@@ -0,0 +1,191 @@
+package goride
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAssignGear(t *testing.T) {
+	home := LatLng{Lat: 37.8680, Lng: -122.2852}
+	rides := []*RideSlim{
+		{ID: 1, Name: "Commute", Distance: 8000, AvgSpeed: 20, GearID: 7, FirstLat: 37.8681, FirstLng: -122.2850},
+		{ID: 2, Name: "Brevet", Distance: 200000, AvgSpeed: 24, GearID: 7, FirstLat: 38.5, FirstLng: -121.7},
+		{ID: 3, Name: "Tandem", Distance: 60000, AvgSpeed: 22, Tags: []string{"Tandem"}},
+		{ID: 4, Name: "Trainer", Distance: 30000, MovingTime: 3600, GearID: 9},
+		{ID: 5, Name: "Race", Distance: 40000, AvgSpeed: 38, GearID: 5},
+		{ID: 6, Name: "Long commute", Distance: 15000, AvgSpeed: 18, FirstLat: 37.87, FirstLng: -122.28},
+	}
+
+	tests := []struct {
+		desc    string
+		rules   []GearRule
+		want    []GearAssignment
+		wantErr bool
+	}{
+		{
+			desc: "first match wins",
+			rules: []GearRule{
+				{Tags: []string{"tandem"}, GearID: 3},
+				{Near: &home, RadiusMeters: 1000, MaxDistance: 10000, GearID: 7},
+				{MinAvgSpeed: 35, GearID: 5},
+				{MinDistance: 100000, GearID: 2},
+				{MinDistance: 20000, GearID: 4},
+			},
+			want: []GearAssignment{
+				{RideID: 2, Rule: 3, From: 7, To: 2},
+				{RideID: 3, Rule: 0, From: 0, To: 3},
+				{RideID: 4, Rule: 4, From: 9, To: 4},
+			},
+		},
+		{
+			desc: "broad rule first",
+			rules: []GearRule{
+				{MinDistance: 20000, GearID: 4},
+				{MinAvgSpeed: 30, GearID: 5},
+			},
+			want: []GearAssignment{
+				{RideID: 2, Rule: 0, From: 7, To: 4},
+				{RideID: 3, Rule: 0, From: 0, To: 4},
+				{RideID: 4, Rule: 0, From: 9, To: 4},
+				{RideID: 5, Rule: 0, From: 5, To: 4},
+			},
+		},
+		{
+			desc: "speed from moving time",
+			rules: []GearRule{
+				{MinAvgSpeed: 25, MaxAvgSpeed: 35, GearID: 8},
+			},
+			want: []GearAssignment{
+				{RideID: 4, Rule: 0, From: 9, To: 8},
+			},
+		},
+		{
+			desc: "fallback",
+			rules: []GearRule{
+				{Near: &home, RadiusMeters: 2000, GearID: 7},
+				{GearID: 9},
+			},
+			want: []GearAssignment{
+				{RideID: 2, Rule: 1, From: 7, To: 9},
+				{RideID: 3, Rule: 1, From: 0, To: 9},
+				{RideID: 5, Rule: 1, From: 5, To: 9},
+				{RideID: 6, Rule: 0, From: 0, To: 7},
+			},
+		},
+		{
+			desc:  "nothing matches",
+			rules: []GearRule{{Tags: []string{"gravel"}, GearID: 6}},
+		},
+		{
+			desc:    "no gear",
+			rules:   []GearRule{{Tags: []string{"tandem"}}},
+			wantErr: true,
+		},
+		{
+			desc:    "no radius",
+			rules:   []GearRule{{Near: &home, GearID: 1}},
+			wantErr: true,
+		},
+		{
+			desc:    "backwards range",
+			rules:   []GearRule{{MinDistance: 100, MaxDistance: 10, GearID: 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := AssignGear(rides, tc.rules)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("want an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad assignments: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyGearAssignments(t *testing.T) {
+	f := newFakeRWGPS(t, bulkRides()...)
+	r := testObj(f.URL)
+	assignments := []GearAssignment{
+		{RideID: 1, From: 17, To: 23},
+		{RideID: 2, From: 17, To: 31},
+	}
+
+	got, err := r.ApplyGearAssignments(assignments, BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := map[int][]string{1: {"gear: 17 -> 23"}, 2: {"gear: 17 -> 31"}}
+	if diff := cmp.Diff(want, got.Changes); diff != "" {
+		t.Errorf("bad dry run: -want +got\n%s", diff)
+	}
+	if w := f.writes(); len(w) != 0 {
+		t.Errorf("dry run wrote: %v", w)
+	}
+
+	if _, err := r.ApplyGearAssignments(assignments, BulkOptions{}); err != nil {
+		t.Fatalf("ApplyGearAssignments: %v", err)
+	}
+	for id, want := range map[int]int{1: 23, 2: 31, 3: 23, 4: 17} {
+		if g := f.ride(id).Gear; g == nil || g.ID != want {
+			t.Errorf("ride %d: want gear %d, got %v", id, want, g)
+		}
+	}
+}
+
+func TestDefaultGear(t *testing.T) {
+	var puts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			body, _ := io.ReadAll(req.Body)
+			puts = append(puts, string(body))
+			fmt.Fprint(w, "{}")
+			return
+		}
+		fmt.Fprint(w, defaultAuth(req.URL.Path, req.URL.Query()))
+	}))
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, err := r.GetDefaultGear()
+	if err != nil {
+		t.Fatalf("GetDefaultGear(): %v", err)
+	}
+	if got != 239758 {
+		t.Errorf("GetDefaultGear(): want 239758, got %d", got)
+	}
+
+	for _, id := range []int{255732, 0} {
+		if err := r.SetDefaultGear(id); err != nil {
+			t.Fatalf("SetDefaultGear(%d): %v", id, err)
+		}
+		if u := r.user(); u.Preferences.DefaultGearID != id {
+			t.Errorf("SetDefaultGear(%d): logged in user has %d", id, u.Preferences.DefaultGearID)
+		}
+	}
+	want := []string{
+		`{"user":{"preferences":{"default_gear_id":"255732"}}}`,
+		`{"user":{"preferences":{"default_gear_id":""}}}`,
+	}
+	if diff := cmp.Diff(want, puts); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+
+	if err := r.SetDefaultGear(-1); err == nil {
+		t.Errorf("SetDefaultGear(-1): want an error")
+	}
+}
@@ -0,0 +1,87 @@
+package goride
+
+import "math"
+
+// Mean earth radius, in meters.
+const earthRadius = 6371000
+
+// DistanceTo returns the great circle distance to o, in meters.
+func (l LatLng) DistanceTo(o LatLng) float64 {
+	return haversine(float64(l.Lat), float64(l.Lng), float64(o.Lat), float64(o.Lng))
+}
+
+func haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// BoundingBox is the area between its south-west and north-east corners. A box
+// with SW north or east of NE is empty.
+type BoundingBox struct {
+	SW LatLng
+	NE LatLng
+}
+
+// emptyBox is the empty box the server sends for rides without any points.
+var emptyBox = BoundingBox{SW: LatLng{Lat: 90, Lng: 180}, NE: LatLng{Lat: -90, Lng: -180}}
+
+// Bounds returns the ride's bounding box, which is empty if it has none.
+func (r *Ride) Bounds() BoundingBox {
+	if len(r.BoundingBox) != 2 {
+		return emptyBox
+	}
+
+	return BoundingBox{SW: r.BoundingBox[0], NE: r.BoundingBox[1]}
+}
+
+// Bounds returns the ride's bounding box, which is empty if it has none.
+func (r *RideSlim) Bounds() BoundingBox {
+	if r.SwLat == 0 && r.SwLng == 0 && r.NeLat == 0 && r.NeLng == 0 {
+		return emptyBox
+	}
+
+	return BoundingBox{
+		SW: LatLng{Lat: r.SwLat, Lng: r.SwLng},
+		NE: LatLng{Lat: r.NeLat, Lng: r.NeLng},
+	}
+}
+
+func (b BoundingBox) Empty() bool {
+	return b.SW.Lat > b.NE.Lat || b.SW.Lng > b.NE.Lng
+}
+
+func (b BoundingBox) Contains(p LatLng) bool {
+	return !b.Empty() &&
+		p.Lat >= b.SW.Lat && p.Lat <= b.NE.Lat &&
+		p.Lng >= b.SW.Lng && p.Lng <= b.NE.Lng
+}
+
+func (b BoundingBox) Intersects(o BoundingBox) bool {
+	if b.Empty() || o.Empty() {
+		return false
+	}
+
+	return o.SW.Lat <= b.NE.Lat && o.NE.Lat >= b.SW.Lat &&
+		o.SW.Lng <= b.NE.Lng && o.NE.Lng >= b.SW.Lng
+}
+
+// FilterRidesNear returns the rides that started within radius meters of pt.
+// Rides without a starting position are skipped.
+func FilterRidesNear(rides []*RideSlim, pt LatLng, radius float64) []*RideSlim {
+	var res []*RideSlim
+	for _, r := range rides {
+		if r.FirstLat == 0 && r.FirstLng == 0 {
+			continue
+		}
+		if haversine(r.FirstLat, r.FirstLng, float64(pt.Lat), float64(pt.Lng)) <= radius {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,135 @@
+package goride
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDistanceTo(t *testing.T) {
+	tests := []struct {
+		desc string
+		a, b LatLng
+		want float64
+	}{
+		{
+			desc: "same point",
+			a:    LatLng{Lat: 45.384904, Lng: -122.758382},
+			b:    LatLng{Lat: 45.384904, Lng: -122.758382},
+			want: 0,
+		},
+		{
+			desc: "London to Paris",
+			a:    LatLng{Lat: 51.5074, Lng: -0.1278},
+			b:    LatLng{Lat: 48.8566, Lng: 2.3522},
+			want: 343556,
+		},
+		{
+			desc: "San Francisco to Los Angeles",
+			a:    LatLng{Lat: 37.7749, Lng: -122.4194},
+			b:    LatLng{Lat: 34.0522, Lng: -118.2437},
+			want: 559121,
+		},
+		{
+			desc: "New York to Sydney",
+			a:    LatLng{Lat: 40.7128, Lng: -74.0060},
+			b:    LatLng{Lat: -33.8688, Lng: 151.2093},
+			want: 15988756,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := tc.a.DistanceTo(tc.b)
+			if math.Abs(got-tc.want) > 10 {
+				t.Errorf("bad distance: want %f, got %f", tc.want, got)
+			}
+			if back := tc.b.DistanceTo(tc.a); math.Abs(got-back) > 1e-6 {
+				t.Errorf("distance isn't symmetric: %f != %f", got, back)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := BoundingBox{
+		SW: LatLng{Lat: 45.354292, Lng: -122.774802},
+		NE: LatLng{Lat: 45.438351, Lng: -122.678425},
+	}
+	ride := &Ride{BoundingBox: []LatLng{box.SW, box.NE}}
+	if diff := cmp.Diff(box, ride.Bounds()); diff != "" {
+		t.Errorf("bad ride bounds: -want +got\n%s", diff)
+	}
+
+	for _, empty := range []BoundingBox{
+		(&RideSlim{SwLng: 180, SwLat: 90, NeLng: -180, NeLat: -90}).Bounds(),
+		(&RideSlim{}).Bounds(),
+		(&Ride{}).Bounds(),
+	} {
+		if !empty.Empty() {
+			t.Errorf("expected %v to be empty", empty)
+		}
+		if empty.Contains(LatLng{}) || empty.Intersects(box) {
+			t.Errorf("expected %v to contain nothing", empty)
+		}
+	}
+
+	contains := []struct {
+		desc string
+		pt   LatLng
+		want bool
+	}{
+		{"inside", LatLng{Lat: 45.4, Lng: -122.7}, true},
+		{"corner", box.SW, true},
+		{"north", LatLng{Lat: 45.5, Lng: -122.7}, false},
+		{"west", LatLng{Lat: 45.4, Lng: -122.8}, false},
+	}
+	for _, tc := range contains {
+		t.Run("contains "+tc.desc, func(t *testing.T) {
+			if got := box.Contains(tc.pt); got != tc.want {
+				t.Errorf("Contains(%v) = %v, want %v", tc.pt, got, tc.want)
+			}
+		})
+	}
+
+	intersects := []struct {
+		desc  string
+		other BoundingBox
+		want  bool
+	}{
+		{"same", box, true},
+		{"overlap", BoundingBox{SW: LatLng{Lat: 45.4, Lng: -122.7}, NE: LatLng{Lat: 45.5, Lng: -122.6}}, true},
+		{"inside", BoundingBox{SW: LatLng{Lat: 45.4, Lng: -122.72}, NE: LatLng{Lat: 45.41, Lng: -122.71}}, true},
+		{"disjoint", BoundingBox{SW: LatLng{Lat: 46, Lng: -122.7}, NE: LatLng{Lat: 47, Lng: -122.6}}, false},
+		{"empty", emptyBox, false},
+	}
+	for _, tc := range intersects {
+		t.Run("intersects "+tc.desc, func(t *testing.T) {
+			if got := box.Intersects(tc.other); got != tc.want {
+				t.Errorf("Intersects(%v) = %v, want %v", tc.other, got, tc.want)
+			}
+			if got := tc.other.Intersects(box); got != tc.want {
+				t.Errorf("reverse Intersects(%v) = %v, want %v", tc.other, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilterRidesNear(t *testing.T) {
+	rides := []*RideSlim{
+		{ID: 1, FirstLat: 45.59210039, FirstLng: -122.74444825},
+		{ID: 2, FirstLat: 45.5925, FirstLng: -122.7450},
+		{ID: 3, FirstLat: 45.384904, FirstLng: -122.758382},
+		{ID: 4},
+	}
+
+	var got []int
+	for _, r := range FilterRidesNear(rides, LatLng{Lat: 45.592, Lng: -122.744}, 500) {
+		got = append(got, r.ID)
+	}
+
+	if diff := cmp.Diff([]int{1, 2}, got); diff != "" {
+		t.Errorf("bad rides: -want +got\n%s", diff)
+	}
+}
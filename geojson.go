@@ -0,0 +1,129 @@
+package goride
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// GeoJSONOptions are the properties and extra features written by
+// WriteGeoJSON.
+type GeoJSONOptions struct {
+	Name string
+	// Distance and ElevationGain are in meters.
+	Distance      float64
+	ElevationGain float64
+	// If set, the stops are added as a MultiPoint feature.
+	Stops []Stop
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// WriteGeoJSON writes the track as a GeoJSON FeatureCollection, with a
+// LineString feature for the track. Coordinates are [lng, lat, elevation].
+// JSON has no NaN or infinity, so points and stops with such a position are
+// left out, and so are such elevations. The points are streamed to w, so big
+// tracks aren't held in memory twice.
+func WriteGeoJSON(w io.Writer, points []TrackPoint, opts GeoJSONOptions) error {
+	props, err := json.Marshal(map[string]interface{}{
+		"name":           opts.Name,
+		"distance":       opts.Distance,
+		"elevation_gain": opts.ElevationGain,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding properties: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"type":"FeatureCollection","features":[`)
+	bw.WriteString(`{"type":"Feature","properties":`)
+	bw.Write(props)
+	bw.WriteString(`,"geometry":{"type":"LineString","coordinates":[`)
+	var buf []byte
+	first := true
+	skip := sensorOnly(points)
+	for _, p := range points {
+		if skip(p) || !finite(p.Lat) || !finite(p.Lng) {
+			continue
+		}
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		buf = append(buf[:0], '[')
+		buf = strconv.AppendFloat(buf, p.Lng, 'f', -1, 64)
+		buf = append(buf, ',')
+		buf = strconv.AppendFloat(buf, p.Lat, 'f', -1, 64)
+		if finite(p.Elevation) {
+			buf = append(buf, ',')
+			buf = strconv.AppendFloat(buf, p.Elevation, 'f', -1, 64)
+		}
+		buf = append(buf, ']')
+		bw.Write(buf)
+	}
+	bw.WriteString(`]}}`)
+
+	if len(opts.Stops) > 0 {
+		var coords [][2]json.Number
+		var durations []float64
+		for _, s := range opts.Stops {
+			if !finite(float64(s.Location.Lat)) || !finite(float64(s.Location.Lng)) {
+				continue
+			}
+			coords = append(coords, [2]json.Number{
+				json.Number(strconv.FormatFloat(float64(s.Location.Lng), 'f', -1, 32)),
+				json.Number(strconv.FormatFloat(float64(s.Location.Lat), 'f', -1, 32)),
+			})
+			durations = append(durations, s.Duration.Seconds())
+		}
+		f := geoJSONFeature{
+			Type:       "Feature",
+			Properties: map[string]interface{}{"name": "stops", "durations": durations},
+		}
+		f.Geometry.Type = "MultiPoint"
+		f.Geometry.Coordinates = coords
+		stops, err := json.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("error encoding stops: %w", err)
+		}
+		bw.WriteByte(',')
+		bw.Write(stops)
+	}
+	bw.WriteString("]}\n")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error writing GeoJSON: %w", err)
+	}
+
+	return nil
+}
+
+// ExportRideGeoJSON writes a ride's track and stops as GeoJSON.
+func (r *RWGPS) ExportRideGeoJSON(id int, w io.Writer) error {
+	ride, err := r.GetRide(id)
+	if err != nil {
+		return err
+	}
+
+	return WriteGeoJSON(w, ride.TrackPoints, GeoJSONOptions{
+		Name:          ride.Name,
+		Distance:      float64(ride.Distance),
+		ElevationGain: float64(ride.Metrics.ElevationGain),
+		Stops:         DetectStops(ride.TrackPoints, StopOptions{}),
+	})
+}
+
+// finite is true if v can be written as a JSON number.
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
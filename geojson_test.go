@@ -0,0 +1,218 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got to testdata/name, or updates the file with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		writeTestFile(t, path, string(got))
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("output doesn't match %s: -want +got\n%s", path, diff)
+	}
+}
+
+// validateGeoJSON checks the parts of the GeoJSON spec WriteGeoJSON uses, and
+// returns the features.
+func validateGeoJSON(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+	var fc struct {
+		Type     string
+		Features []map[string]interface{}
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		t.Errorf("bad type: %q", fc.Type)
+	}
+
+	position := func(c interface{}) {
+		p, ok := c.([]interface{})
+		if !ok || len(p) < 2 || len(p) > 3 {
+			t.Errorf("bad position: %v", c)
+			return
+		}
+		for _, v := range p {
+			if _, ok := v.(float64); !ok {
+				t.Errorf("bad position: %v", c)
+				return
+			}
+		}
+		if lng, lat := p[0].(float64), p[1].(float64); lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			t.Errorf("position out of range: %v", c)
+		}
+	}
+
+	for _, f := range fc.Features {
+		if f["type"] != "Feature" {
+			t.Errorf("bad feature type: %v", f["type"])
+		}
+		if _, ok := f["properties"].(map[string]interface{}); !ok {
+			t.Errorf("bad properties: %v", f["properties"])
+		}
+		g, ok := f["geometry"].(map[string]interface{})
+		if !ok {
+			t.Errorf("bad geometry: %v", f["geometry"])
+			continue
+		}
+		coords, ok := g["coordinates"].([]interface{})
+		if !ok {
+			t.Errorf("bad coordinates: %v", g["coordinates"])
+			continue
+		}
+		switch g["type"] {
+		case "LineString":
+			if len(coords) < 2 {
+				t.Errorf("LineString with %d positions", len(coords))
+			}
+		case "MultiPoint":
+		case "Polygon":
+			for _, ring := range coords {
+				ring, ok := ring.([]interface{})
+				if !ok || len(ring) < 4 {
+					t.Errorf("bad polygon ring: %v", ring)
+					continue
+				}
+				if !cmp.Equal(ring[0], ring[len(ring)-1]) {
+					t.Errorf("polygon ring isn't closed: %v", ring)
+				}
+				for _, c := range ring {
+					position(c)
+				}
+			}
+			continue
+		default:
+			t.Errorf("unexpected geometry type: %v", g["type"])
+		}
+		for _, c := range coords {
+			position(c)
+		}
+	}
+
+	return fc.Features
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	track := []TrackPoint{
+		{Time: sensorStart},
+		{Time: sensorStart.Add(time.Second), Lat: 45.5, Lng: -122.6, Elevation: 10},
+		{Time: sensorStart.Add(2 * time.Second), Lat: 45.501, Lng: -122.6005, Elevation: 12.5},
+		{Time: sensorStart.Add(3 * time.Second), Lat: 45.502, Lng: -122.601, Elevation: 11},
+	}
+	opts := GeoJSONOptions{
+		Name:          `Coffee & "hills"`,
+		Distance:      270.5,
+		ElevationGain: 2.5,
+		Stops: []Stop{
+			{Duration: time.Minute, Location: LatLng{Lat: 45.501, Lng: -122.6005}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGeoJSON(&buf, track, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkGolden(t, "track.geojson", buf.Bytes())
+
+	features := validateGeoJSON(t, buf.Bytes())
+	if len(features) != 2 {
+		t.Fatalf("wrong number of features: %d", len(features))
+	}
+	got := features[0]["geometry"].(map[string]interface{})["coordinates"].([]interface{})[0]
+	if diff := cmp.Diff([]interface{}{-122.6, 45.5, 10.0}, got); diff != "" {
+		t.Errorf("bad first position: -want +got\n%s", diff)
+	}
+}
+
+func TestWriteGeoJSONNonFinite(t *testing.T) {
+	track := []TrackPoint{
+		{Lat: 45.5, Lng: -122.6, Elevation: 10},
+		{Lat: math.NaN(), Lng: -122.6005, Elevation: 12},
+		{Lat: 45.502, Lng: math.Inf(1), Elevation: 12},
+		{Lat: 45.503, Lng: -122.601, Elevation: math.Inf(-1)},
+	}
+	opts := GeoJSONOptions{
+		Stops: []Stop{
+			{Duration: time.Minute, Location: LatLng{Lat: float32(math.NaN()), Lng: -122.6}},
+			{Duration: time.Hour, Location: LatLng{Lat: 45.5, Lng: -122.6}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGeoJSON(&buf, track, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	features := validateGeoJSON(t, buf.Bytes())
+	if len(features) != 2 {
+		t.Fatalf("wrong number of features: %d", len(features))
+	}
+	got := features[0]["geometry"].(map[string]interface{})["coordinates"]
+	want := []interface{}{
+		[]interface{}{-122.6, 45.5, 10.0},
+		[]interface{}{-122.601, 45.503},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad positions: -want +got\n%s", diff)
+	}
+	if got := features[1]["properties"].(map[string]interface{})["durations"]; !cmp.Equal(got, []interface{}{3600.0}) {
+		t.Errorf("bad stops: %v", got)
+	}
+}
+
+func TestExportRideGeoJSON(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/trips/94.json": getTestData("trip.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	var buf bytes.Buffer
+	if err := r.ExportRideGeoJSON(94, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	features := validateGeoJSON(t, buf.Bytes())
+	if len(features) != 2 {
+		t.Fatalf("wrong number of features: %d", len(features))
+	}
+	if name := features[0]["properties"].(map[string]interface{})["name"]; name != "Peak To Peak" {
+		t.Errorf("bad name: %v", name)
+	}
+
+	ride := getTestRide(t)
+	skip := sensorOnly(ride.TrackPoints)
+	var want int
+	for _, p := range ride.TrackPoints {
+		if !skip(p) {
+			want++
+		}
+	}
+	coords := features[0]["geometry"].(map[string]interface{})["coordinates"].([]interface{})
+	if len(coords) != want {
+		t.Errorf("wrong number of positions: want %d, got %d", want, len(coords))
+	}
+
+	if err := r.ExportRideGeoJSON(1, &buf); err == nil {
+		t.Errorf("expected an error exporting a missing ride")
+	}
+}
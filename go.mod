@@ -1,6 +1,6 @@
 module github.com/zigdon/goride
 
-go 1.15
+go 1.21
 
 require (
 	github.com/google/go-cmp v0.5.6
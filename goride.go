@@ -1,143 +1,272 @@
 package goride
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/zigdon/goride/units"
 	"gopkg.in/ini.v1"
 )
 
+const defaultServer = "https://ridewithgps.com"
+
+// serverEnv is the environment variable that overrides the config's
+// ServerURL.
+const serverEnv = "GORIDE_SERVER"
+
+// authTokenEnv is the environment variable that overrides the config's
+// AuthToken.
+const authTokenEnv = "RWGPS_AUTH_TOKEN"
+
 type Client struct {
 	server string
+	http   *http.Client
+	cache  ResponseCache
+	disk   *DiskCache
+	// capture writes responses to fixtures, and replay serves them instead of
+	// the server.
+	capture *fixtureRecorder
+	replay  *ReplayClient
+	metrics MetricsSink
+	// maxResponseSize is the largest body read, defaultMaxResponseSize if 0.
+	maxResponseSize int64
 }
 
+// RWGPS is a client for the RWGPS API. It's safe for concurrent use, and
+// goroutines that need to log in at the same time share a single login.
 type RWGPS struct {
+	config *Config
+	client *Client
+	hints  bool
+	strict bool
+	dryRun bool
+	units  units.System
+	logger *slog.Logger
+
+	// mu guards the fields below.
+	mu       sync.Mutex
 	authUser *User
-	config   *Config
-	client   *Client
+	// login is the login in progress, if any.
+	login   *loginCall
+	store   RideStore
+	journal []DryRunRequest
+	// previews are the tracks GetRidePreviewTrack returned.
+	previews map[previewKey]ridePreview
 }
 
+// loginCall is a login that other goroutines can wait for.
+type loginCall struct {
+	done chan struct{}
+	user *User
+	err  error
+}
+
+type Option func(*RWGPS)
+
 type Config struct {
 	Email    string
 	Password string
 	KeyName  string
 	CfgPath  string
+	// AuthToken, if set, is used instead of logging in with the email and
+	// password, which can then be left out.
+	AuthToken string
+	// PasswordCommand and AuthTokenCommand are shell commands that print the
+	// password or auth token, e.g. "pass show rwgps", for when they aren't
+	// set directly. They're only run when the credential is needed.
+	PasswordCommand  string
+	AuthTokenCommand string
+	// ServerURL is the API's base URL, which can have a path prefix, for
+	// proxies. Empty for the default.
+	ServerURL string
+	// HeartRateZones are where each heart rate zone after the first starts,
+	// in bpm. If they aren't set, they're derived from MaxHeartRate. See
+	// RWGPS.HeartRateZones.
+	HeartRateZones []int
+	MaxHeartRate   int
+	// FTP is the functional threshold power in watts, for PowerSummary.
+	FTP int
 }
 
 type Gear struct {
-	ID   int
-	Name string
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 type User struct {
-	ID         int
-	Name       string
-	AuthToken  string `json:"auth_token"`
-	Gear       []Gear
-	TotalTrips int `json:"trips_included_in_totals_count"`
+	ID          int
+	Name        string
+	AuthToken   string `json:"auth_token"`
+	Gear        []Gear
+	TotalTrips  int `json:"trips_included_in_totals_count"`
+	Preferences Preferences
 }
 
 type Metrics struct {
-	AscentTime    int
-	DescentTime   int
-	Calories      int
-	Distance      float32
-	Duration      time.Duration
-	ElevationGain float32 `json:"ele_gain"`
-	ElevationLoss float32 `json:"ele_loss"`
+	AscentTime    int           `json:"ascentTime"`
+	DescentTime   int           `json:"descentTime"`
+	Calories      int           `json:"calories"`
+	Distance      float32       `json:"distance"`
+	Duration      time.Duration `json:"duration"`
+	ElevationGain float32       `json:"ele_gain"`
+	ElevationLoss float32       `json:"ele_loss"`
 	Grade         struct {
-		Avg float32
-		Max float32
-		Min float32
-	}
-	MovingTime int
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"grade"`
+	MovingTime int `json:"movingTime"`
 	Speed      struct {
-		Avg float32
-		Max float32
-		Min float32
-	}
-	Stationary bool
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"speed"`
+	Stationary bool `json:"stationary"`
+
+	// Sensor summaries, left as zero when the ride wasn't recorded with the
+	// sensor.
+	HR struct {
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"hr"`
+	Watts struct {
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"watts"`
+	Cadence struct {
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"cad"`
+	Temperature struct {
+		Avg float32 `json:"avg"`
+		Max float32 `json:"max"`
+		Min float32 `json:"min"`
+	} `json:"temperature"`
+
+	FirstTime      int64   `json:"firstTime"`
+	StartElevation float32 `json:"startElevation"`
+	EndElevation   float32 `json:"endElevation"`
 }
 
 type LatLng struct {
-	Lat float32
-	Lng float32
+	Lat float32 `json:"lat"`
+	Lng float32 `json:"lng"`
 }
 
 type RideSlim struct {
-	ID                       int       `json:"id"`
-	GroupMembershipID        int       `json:"group_membership_id"`
-	RouteID                  int       `json:"route_id"`
-	CreatedAt                time.Time `json:"created_at"`
-	GearID                   int       `json:"gear_id"`
-	DepartedAt               time.Time `json:"departed_at"`
-	Duration                 int       `json:"duration"`
-	Distance                 float32   `json:"distance"`
-	ElevationGain            float32   `json:"elevation_gain"`
-	ElevationLoss            float32   `json:"elevation_loss"`
-	Visibility               int       `json:"visibility"`
-	Description              string    `json:"description"`
-	IsGps                    bool      `json:"is_gps"`
-	Name                     string    `json:"name"`
-	MaxHr                    float32   `json:"max_hr"`
-	MinHr                    float32   `json:"min_hr"`
-	AvgHr                    float32   `json:"avg_hr"`
-	MaxCad                   float32   `json:"max_cad"`
-	MinCad                   float32   `json:"min_cad"`
-	AvgCad                   float32   `json:"avg_cad"`
-	AvgSpeed                 float32   `json:"avg_speed"`
-	MaxSpeed                 float32   `json:"max_speed"`
-	MovingTime               int       `json:"moving_time"`
-	Processed                bool      `json:"processed"`
-	AvgWatts                 float32   `json:"avg_watts"`
-	MaxWatts                 float32   `json:"max_watts"`
-	MinWatts                 float32   `json:"min_watts"`
-	IsStationary             bool      `json:"is_stationary"`
-	Calories                 int       `json:"calories"`
-	UpdatedAt                time.Time `json:"updated_at"`
-	TimeZone                 string    `json:"time_zone"`
-	FirstLng                 float64   `json:"first_lng"`
-	FirstLat                 float64   `json:"first_lat"`
-	LastLng                  float64   `json:"last_lng"`
-	LastLat                  float64   `json:"last_lat"`
-	UserID                   int       `json:"user_id"`
-	DeletedAt                time.Time `json:"deleted_at"`
-	SwLng                    float32   `json:"sw_lng"`
-	SwLat                    float32   `json:"sw_lat"`
-	NeLng                    float32   `json:"ne_lng"`
-	NeLat                    float32   `json:"ne_lat"`
-	TrackID                  string    `json:"track_id"`
-	PostalCode               string    `json:"postal_code"`
-	Locality                 string    `json:"locality"`
-	AdministrativeArea       string    `json:"administrative_area"`
-	CountryCode              string    `json:"country_code"`
-	SourceType               string    `json:"source_type"`
-	LikesCount               int       `json:"likes_count"`
-	HighlightedPhotoID       int       `json:"highlighted_photo_id"`
-	HighlightedPhotoChecksum string    `json:"highlighted_photo_checksum"`
-	UtcOffset                int       `json:"utc_offset"`
+	ID                       int        `json:"id"`
+	GroupMembershipID        int        `json:"group_membership_id"`
+	RouteID                  int        `json:"route_id"`
+	CreatedAt                time.Time  `json:"created_at"`
+	GearID                   int        `json:"gear_id"`
+	DepartedAt               time.Time  `json:"departed_at"`
+	Duration                 int        `json:"duration"`
+	Distance                 float32    `json:"distance"`
+	ElevationGain            float32    `json:"elevation_gain"`
+	ElevationLoss            float32    `json:"elevation_loss"`
+	Visibility               Visibility `json:"visibility"`
+	Description              string     `json:"description"`
+	IsGps                    bool       `json:"is_gps"`
+	Name                     string     `json:"name"`
+	MaxHr                    float32    `json:"max_hr"`
+	MinHr                    float32    `json:"min_hr"`
+	AvgHr                    float32    `json:"avg_hr"`
+	MaxCad                   float32    `json:"max_cad"`
+	MinCad                   float32    `json:"min_cad"`
+	AvgCad                   float32    `json:"avg_cad"`
+	AvgSpeed                 float32    `json:"avg_speed"`
+	MaxSpeed                 float32    `json:"max_speed"`
+	MovingTime               int        `json:"moving_time"`
+	Processed                bool       `json:"processed"`
+	AvgWatts                 float32    `json:"avg_watts"`
+	MaxWatts                 float32    `json:"max_watts"`
+	MinWatts                 float32    `json:"min_watts"`
+	IsStationary             bool       `json:"is_stationary"`
+	Calories                 int        `json:"calories"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+	TimeZone                 string     `json:"time_zone"`
+	FirstLng                 float64    `json:"first_lng"`
+	FirstLat                 float64    `json:"first_lat"`
+	LastLng                  float64    `json:"last_lng"`
+	LastLat                  float64    `json:"last_lat"`
+	UserID                   int        `json:"user_id"`
+	DeletedAt                *time.Time `json:"deleted_at"`
+	SwLng                    float32    `json:"sw_lng"`
+	SwLat                    float32    `json:"sw_lat"`
+	NeLng                    float32    `json:"ne_lng"`
+	NeLat                    float32    `json:"ne_lat"`
+	TrackID                  string     `json:"track_id"`
+	PostalCode               string     `json:"postal_code"`
+	Locality                 string     `json:"locality"`
+	AdministrativeArea       string     `json:"administrative_area"`
+	CountryCode              string     `json:"country_code"`
+	SourceType               string     `json:"source_type"`
+	LikesCount               int        `json:"likes_count"`
+	HighlightedPhotoID       int        `json:"highlighted_photo_id"`
+	HighlightedPhotoChecksum string     `json:"highlighted_photo_checksum"`
+	UtcOffset                int        `json:"utc_offset"`
+	Tags                     []string   `json:"tag_names"`
 }
 
 type Ride struct {
-	ID          int
-	Started     time.Time `json:"departed_at"`
-	Metrics     Metrics
-	Distance    float32
-	Description string
-	Name        string
-	BoundingBox []LatLng `json:"bounding_box"`
+	ID          int        `json:"id"`
+	Started     time.Time  `json:"departed_at"`
+	Metrics     Metrics    `json:"metrics"`
+	Distance    float32    `json:"distance"`
+	Description string     `json:"description"`
+	Name        string     `json:"name"`
+	Visibility  Visibility `json:"visibility"`
+	Processed   bool       `json:"processed"`
+	TimeZone    string     `json:"time_zone"`
+	UtcOffset   int        `json:"utc_offset"`
+	Gear        *Gear      `json:"gear"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+	BoundingBox []LatLng   `json:"bounding_box"`
+	Photos      []Photo    `json:"photos"`
+	LikesCount  int        `json:"likes_count"`
+	// Weather is only set by EnrichRide.
+	Weather     *RideWeather `json:"weather,omitempty"`
+	Tags        []string     `json:"tag_names"`
+	TrackPoints []TrackPoint `json:"track_points"`
+}
+
+// IsDeleted returns true if the ride was deleted.
+func (r *RideSlim) IsDeleted() bool {
+	return r.DeletedAt != nil
+}
+
+// IsDeleted returns true if the ride was deleted.
+func (r *Ride) IsDeleted() bool {
+	return r.DeletedAt != nil
 }
 
 func NewConfig(path string) (*Config, error) {
+	return newConfig(path, slog.Default())
+}
+
+func newConfig(path string, logger *slog.Logger) (*Config, error) {
 	iniData, err := ini.LoadSources(ini.LoadOptions{UnescapeValueDoubleQuotes: true}, path)
 	if err != nil {
-		return nil, fmt.Errorf("error loading ini file from %q: %v", path, err)
+		return nil, fmt.Errorf("error loading ini file from %q: %w", path, err)
 	}
 	cfg := &Config{
 		CfgPath: path,
@@ -149,149 +278,690 @@ func NewConfig(path string) (*Config, error) {
 			cfg.Email = iniData.Section("Auth").Key("email").String()
 			cfg.Password = iniData.Section("Auth").Key("password").String()
 			cfg.KeyName = iniData.Section("Auth").Key("name").String()
+			cfg.AuthToken = iniData.Section("Auth").Key("auth_token").String()
+			cfg.PasswordCommand = iniData.Section("Auth").Key("password_command").String()
+			cfg.AuthTokenCommand = iniData.Section("Auth").Key("auth_token_command").String()
+		case "Server":
+			cfg.ServerURL = iniData.Section("Server").Key("url").String()
+		case "Athlete":
+			sec := iniData.Section("Athlete")
+			if k := sec.Key("max_hr"); k.String() != "" {
+				if cfg.MaxHeartRate, err = k.Int(); err != nil {
+					return nil, fmt.Errorf("bad max_hr in %q: %w", path, err)
+				}
+			}
+			if k := sec.Key("ftp"); k.String() != "" {
+				if cfg.FTP, err = k.Int(); err != nil {
+					return nil, fmt.Errorf("bad ftp in %q: %w", path, err)
+				}
+			}
+			if k := sec.Key("hr_zones"); k.String() != "" {
+				zones, err := k.StrictInts(",")
+				if err != nil {
+					return nil, fmt.Errorf("bad hr_zones in %q: %w", path, err)
+				}
+				if err := checkZones(zones); err != nil {
+					return nil, fmt.Errorf("bad hr_zones in %q: %w", path, err)
+				}
+				cfg.HeartRateZones = zones
+			}
+		case ini.DefaultSection:
 		default:
-			log.Printf("Bad section in ini: %q", name)
+			logger.Warn("bad section in ini", "section", name, "path", path)
 		}
 	}
+	if token := os.Getenv(authTokenEnv); token != "" {
+		cfg.AuthToken = token
+	}
 
 	return cfg, nil
 }
 
-func decodeJSON(data string, obj interface{}) error {
-	dec := json.NewDecoder(strings.NewReader(data))
+// password returns the configured password, running PasswordCommand the
+// first time if it's not set.
+func (r *RWGPS) password() (string, error) {
+	return r.credential(&r.config.Password, "password_command", r.config.PasswordCommand)
+}
 
-	if err := dec.Decode(obj); err != nil {
-		return fmt.Errorf("error decoding json: %v\n%s", err, data)
+// authToken returns the configured auth token, running AuthTokenCommand the
+// first time if it's not set.
+func (r *RWGPS) authToken() (string, error) {
+	return r.credential(&r.config.AuthToken, "auth_token_command", r.config.AuthTokenCommand)
+}
+
+// credential returns the config's credential in field, running command to set
+// it if it's empty. The credentials are read by other goroutines, e.g. to
+// scrub fixtures, so they're only read and written with r.mu held.
+func (r *RWGPS) credential(field *string, key, command string) (string, error) {
+	if v := r.configValue(field); v != "" || command == "" {
+		return v, nil
+	}
+	v, err := runCredentialCommand(key, command)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if *field == "" {
+		*field = v
+	}
+	return *field, nil
 }
 
-func New(cfgPath string) (*RWGPS, error) {
-	cfg, err := NewConfig(cfgPath)
+// configValue reads one of the config's credentials.
+func (r *RWGPS) configValue(field *string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *field
+}
+
+// runCredentialCommand runs a shell command from the config, and returns its
+// output without the trailing whitespace. key is the ini key it came from.
+func runCredentialCommand(key, command string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("can't load config from %q: %v", cfgPath, err)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s %q failed: %w: %s", key, command, err, msg)
+		}
+		return "", fmt.Errorf("%s %q failed: %w", key, command, err)
+	}
+	res := strings.TrimRightFunc(string(out), unicode.IsSpace)
+	if res == "" {
+		return "", fmt.Errorf("%s %q printed nothing", key, command)
+	}
+
+	return res, nil
+}
+
+// New creates a client with the config at cfgPath. The server is, in order of
+// precedence, the one set with WithServer, the GORIDE_SERVER environment
+// variable, the config's ServerURL, or ridewithgps.com. Setting
+// GORIDE_CAPTURE_DIR captures responses, see WithCapture.
+func New(cfgPath string, opts ...Option) (*RWGPS, error) {
+	r := &RWGPS{client: &Client{}, hints: true}
+	if dir := os.Getenv(captureEnv); dir != "" {
+		opts = append([]Option{WithCapture(dir)}, opts...)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	cfg, err := newConfig(cfgPath, r.log())
+	if err != nil {
+		return nil, fmt.Errorf("can't load config from %q: %w", cfgPath, err)
+	}
+	r.config = cfg
+
+	server := r.client.server
+	for _, s := range []string{os.Getenv(serverEnv), cfg.ServerURL, defaultServer} {
+		if server == "" {
+			server = s
+		}
+	}
+	if r.client.server, err = parseServer(server); err != nil {
+		return nil, err
 	}
-	r := &RWGPS{config: cfg, client: &Client{server: "https://ridewithgps.com"}}
 
 	return r, nil
 }
 
+// WithServer sets the API's base URL. It can have a path prefix, e.g.
+// "https://gateway.example.com/rwgps", which is added to every request.
+func WithServer(u string) Option {
+	return func(r *RWGPS) {
+		r.client.server = u
+	}
+}
+
+// parseServer checks that s is a usable base URL, and drops any trailing
+// slash.
+func parseServer(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("bad server URL %q: %w", s, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("bad server URL %q: needs to be an http or https URL with a host", s)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("bad server URL %q: can't have a query or fragment", s)
+	}
+
+	return strings.TrimRight(s, "/"), nil
+}
+
+// joinURL adds path to the server's base URL, with exactly one slash between
+// them.
+func joinURL(server, path string) string {
+	return strings.TrimRight(server, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// GetCurrentUser gets the logged in user, logging in first if needed. If the
+// config has an auth token, it's used instead of the email and password.
+// Fails with ErrAuthFailed if the server doesn't accept the credentials.
+//
+// With a disk cache that's offline, or can't reach the server, the config's
+// auth token is trusted without being checked, so cached responses are still
+// available. The user then only has its AuthToken set.
 func (r *RWGPS) GetCurrentUser() (*User, error) {
 	var res string
 	var err error
-	if r.authUser == nil || r.authUser.AuthToken == "" {
-		log.Printf("No auth token found, logging in...")
+	login := r.user() == nil
+	tokenLogin := login && (r.configValue(&r.config.AuthToken) != "" || r.config.AuthTokenCommand != "")
+	// Password logins get hints about the email and password.
+	login = login && !tokenLogin
+	var token string
+	switch {
+	case tokenLogin:
+		r.log().Debug("no auth token found, using the configured one")
+		if token, err = r.authToken(); err != nil {
+			return nil, fmt.Errorf("can't get auth token: %w", err)
+		}
+		if r.client.disk.offline() {
+			r.log().Debug("disk cache is offline, trusting the configured auth token")
+			return &User{AuthToken: token}, nil
+		}
+		args := url.Values{
+			"auth_token": []string{token},
+			"apikey":     []string{r.config.KeyName},
+			"version":    []string{"2"},
+		}
+		res, err = r.client.Get("/users/current.json", args)
+		if err != nil && r.client.disk != nil && networkFailure(err) {
+			r.log().Warn("can't check the auth token, trusting it for the disk cache", "err", err)
+			return &User{AuthToken: token}, nil
+		}
+	case login:
+		r.log().Debug("no auth token found, logging in")
+		var password string
+		if password, err = r.password(); err != nil {
+			return nil, fmt.Errorf("can't get password: %w", err)
+		}
 		args := url.Values{
 			"email":    []string{r.config.Email},
-			"password": []string{r.config.Password},
+			"password": []string{password},
 			"apikey":   []string{r.config.KeyName},
 			"version":  []string{"2"},
 		}
 		res, err = r.client.Get("/users/current.json", args)
-	} else {
+	default:
 		res, err = r.Get("/users/current.json", nil)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting current user: %v", err)
+		return nil, fmt.Errorf("error getting current user: %w", r.withHints(err, login))
 	}
 
 	var resStruct struct{ User User }
-	err = decodeJSON(res, &resStruct)
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting current user: %w", r.withHints(err, login))
+	}
+	// A failed login can still be a 200, with an error instead of a user.
+	if resStruct.User.ID == 0 || (login && resStruct.User.AuthToken == "") {
+		return nil, fmt.Errorf("error getting current user: %w", r.withHints(ErrAuthFailed, login))
+	}
+	if tokenLogin && resStruct.User.AuthToken == "" {
+		resStruct.User.AuthToken = token
+	}
 
-	return &resStruct.User, err
+	return &resStruct.User, nil
+}
+
+// GetUser gets another user's public profile. Public profiles don't include
+// the auth token, and may not include gear. Users that don't exist return
+// ErrNotFound, and ones that hide their profile return ErrPrivate.
+func (r *RWGPS) GetUser(id int) (*User, error) {
+	res, err := r.Get(fmt.Sprintf("/users/%d.json", id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user %d: %w", id, err)
+	}
+
+	var resStruct struct{ User *User }
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	if resStruct.User == nil || resStruct.User.ID == 0 {
+		return nil, fmt.Errorf("error getting user %d: %w", id, ErrNotFound)
+	}
+
+	return resStruct.User, nil
 }
 
 func (r *RWGPS) Get(method string, args url.Values) (string, error) {
-	if r.authUser == nil || r.authUser.AuthToken == "" {
-		err := r.Auth()
-		if err != nil {
-			return "", fmt.Errorf("can't auth: %v", err)
-		}
+	return r.do(http.MethodGet, method, args, nil)
+}
+
+func (r *RWGPS) do(verb, method string, args url.Values, body []byte) (string, error) {
+	return r.doWithType(verb, method, args, body, "application/json")
+}
+
+// doWithType is do, for bodies that aren't JSON.
+func (r *RWGPS) doWithType(verb, method string, args url.Values, body []byte, contentType string) (string, error) {
+	if r.skipWrite(verb, method, args, body) {
+		return "", ErrDryRun
+	}
+	args, err := r.authArgs(args)
+	if err != nil {
+		return "", err
+	}
+	res, err := r.client.DoWithType(verb, method, args, body, contentType)
+
+	return res, r.withHints(err, false)
+}
+
+// getJSON decodes a GET response straight from the connection, for endpoints
+// with large responses.
+func (r *RWGPS) getJSON(method string, args url.Values, obj interface{}) error {
+	return r.getJSONWithOpts(method, args, obj, RequestOptions{})
+}
+
+// getJSONWithOpts is getJSON, with the timeout and size limit from opts.
+func (r *RWGPS) getJSONWithOpts(method string, args url.Values, obj interface{}, opts RequestOptions) error {
+	args, err := r.authArgs(args)
+	if err != nil {
+		return err
+	}
+	body, err := r.client.getStream(method, args, opts.limits())
+	if err != nil {
+		return r.withHints(err, false)
+	}
+	defer body.Close()
+
+	return r.decodeReader(body, obj)
+}
+
+// authArgs adds the auth arguments to args, logging in if needed.
+func (r *RWGPS) authArgs(args url.Values) (url.Values, error) {
+	u, err := r.loggedInUser()
+	if err != nil {
+		return nil, fmt.Errorf("can't auth: %w", err)
 	}
 	if args == nil {
 		args = url.Values{}
 	}
 	args.Add("apikey", r.config.KeyName)
 	args.Add("version", "2")
-	args.Add("auth_token", r.authUser.AuthToken)
-	return r.client.Get(method, args)
+	args.Add("auth_token", u.AuthToken)
+
+	return args, nil
+}
+
+// user returns the logged in user, or nil if no one is logged in yet.
+func (r *RWGPS) user() *User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.authUser == nil || r.authUser.AuthToken == "" {
+		return nil
+	}
+	return r.authUser
+}
+
+// loggedInUser returns the logged in user, logging in first if needed.
+func (r *RWGPS) loggedInUser() (*User, error) {
+	if u := r.user(); u != nil {
+		return u, nil
+	}
+	return r.doLogin()
 }
 
+// Auth logs in, validating the config's auth token if it has one, or with its
+// email and password otherwise. If another goroutine is already logging in,
+// it waits for that login instead.
 func (r *RWGPS) Auth() error {
+	_, err := r.doLogin()
+	return err
+}
+
+// doLogin logs in, or waits for the login in progress.
+func (r *RWGPS) doLogin() (*User, error) {
+	r.mu.Lock()
+	if c := r.login; c != nil {
+		r.mu.Unlock()
+		<-c.done
+		return c.user, c.err
+	}
+	c := &loginCall{done: make(chan struct{})}
+	r.login = c
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		if c.err == nil {
+			r.authUser = c.user
+		}
+		r.login = nil
+		r.mu.Unlock()
+		close(c.done)
+	}()
+
 	u, err := r.GetCurrentUser()
 	if err != nil {
-		return fmt.Errorf("can't log in: %v", err)
+		r.log().Warn("login failed", "err", err)
+		c.err = fmt.Errorf("can't log in: %w", err)
+		return nil, c.err
 	}
-	log.Printf("Logged in as %q (%d)", u.Name, u.ID)
-	r.authUser = u
+	r.log().Debug("logged in", "name", u.Name, "id", u.ID)
+	c.user = u
 
-	return nil
+	return u, nil
 }
 
 func (r *RWGPS) GetRides(user, offset, limit int) ([]*RideSlim, int, error) {
-	res, err := r.Get(fmt.Sprintf("/users/%d/trips.json", user),
-		url.Values{
-			"offset": []string{fmt.Sprintf("%d", offset)},
-			"limit":  []string{fmt.Sprintf("%d", limit)},
-		})
+	return r.GetRidesWithOpts(user, offset, limit, GetRidesOpts{})
+}
+
+// GetRidesWithOpts is GetRides, sorted and filtered on the server. The zero
+// GetRidesOpts returns the same rides as GetRides.
+func (r *RWGPS) GetRidesWithOpts(user, offset, limit int, opts GetRidesOpts) ([]*RideSlim, int, error) {
+	args, err := opts.values()
 	if err != nil {
-		return nil, 0, fmt.Errorf("error getting rides %d+%d for %d: %v", offset, limit, user, err)
+		return nil, 0, err
 	}
 
-	var resStruct struct {
-		Count int         `json:"results_count"`
-		Rides []*RideSlim `json:"results"`
+	rides, count, err := r.getRidesPage(fmt.Sprintf("/users/%d/trips.json", user), offset, limit, args)
+	if u := r.user(); isStatus(err, http.StatusForbidden) && u != nil && u.ID != user {
+		// Another user's rides, that we're not allowed to see.
+		r.log().Debug("rides aren't visible", "user", user)
+		return []*RideSlim{}, 0, nil
 	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting rides %d+%d for %d: %w", offset, limit, user, err)
+	}
+
+	return rides, count, nil
+}
 
-	err = decodeJSON(res, &resStruct)
+func (r *RWGPS) getRidesPage(path string, offset, limit int, args url.Values) ([]*RideSlim, int, error) {
+	var rides []*RideSlim
+	count, err := r.getPage(path, offset, limit, args, &rides)
 
-	return resStruct.Rides, resStruct.Count, err
+	return rides, count, err
 }
 
-func (r *RWGPS) GetRide(id int) (*Ride, error) {
-	res, err := r.Get(fmt.Sprintf("/trips/%d.json", id), nil)
-	if err != nil {
-		return nil, fmt.Errorf("error getting ride id %d: %v", id, err)
+// getPage gets a page from a list endpoint, decoding the results into
+// results, and returning the total count.
+func (r *RWGPS) getPage(path string, offset, limit int, args url.Values, results interface{}) (int, error) {
+	if args == nil {
+		args = url.Values{}
 	}
+	args.Set("offset", fmt.Sprintf("%d", offset))
+	args.Set("limit", fmt.Sprintf("%d", limit))
+
+	resStruct := struct {
+		Count   int         `json:"results_count"`
+		Results interface{} `json:"results"`
+	}{Results: results}
+	err := r.getJSON(path, args, &resStruct)
+
+	return resStruct.Count, err
+}
 
+// GetRide gets a ride, with its track points. Rides that don't exist return
+// ErrNotFound, and ones the user can't see return ErrPrivate.
+func (r *RWGPS) GetRide(id int) (*Ride, error) {
+	return r.GetRideWithOpts(id, RequestOptions{})
+}
+
+// GetRideSummary is GetRide, without the track points.
+func (r *RWGPS) GetRideSummary(id int) (*Ride, error) {
+	return r.GetRideWithOpts(id, RequestOptions{NoTrackPoints: true})
+}
+
+// GetRideWithOpts is GetRide, asking the server for less of the ride.
+func (r *RWGPS) GetRideWithOpts(id int, opts RequestOptions) (*Ride, error) {
 	var resStruct struct {
 		Type string
-		Trip Ride
+		Trip rideSummary
 	}
+	resStruct.Trip.noTrackPoints = opts.NoTrackPoints
 
-	err = decodeJSON(res, &resStruct)
+	err := r.getJSONWithOpts(fmt.Sprintf("/trips/%d.json", id), opts.values(nil), &resStruct, opts)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error getting ride id %d: %w", id, err)
 	}
 
 	if resStruct.Type != "trip" {
 		return nil, fmt.Errorf("unexpected result type %q", resStruct.Type)
 	}
 
-	return &resStruct.Trip, nil
+	return &resStruct.Trip.Ride, nil
 }
 
 func (c *Client) Get(base string, args url.Values) (string, error) {
-	var uri string
-	if c.server != "" {
-		uri = c.server + base
-	} else {
-		uri = base
+	return c.Do(http.MethodGet, base, args, nil)
+}
+
+// GetStream is Get, returning the body without reading it first. The caller
+// must close it.
+func (c *Client) GetStream(base string, args url.Values) (io.ReadCloser, error) {
+	return c.getStream(base, args, callLimits{})
+}
+
+func (c *Client) getStream(base string, args url.Values, lim callLimits) (io.ReadCloser, error) {
+	if c.disk != nil {
+		// The disk cache needs the whole body anyway.
+		res, err := c.doWithType(http.MethodGet, base, args, nil, "", lim)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(strings.NewReader(res)), nil
+	}
+
+	return c.fetchStream(http.MethodGet, base, args, nil, "", lim)
+}
+
+// Do sends a request with an optional JSON body.
+func (c *Client) Do(method, base string, args url.Values, body []byte) (string, error) {
+	return c.DoWithType(method, base, args, body, "application/json")
+}
+
+// DoWithType is Do, sending the body with the given content type.
+func (c *Client) DoWithType(method, base string, args url.Values, body []byte, contentType string) (string, error) {
+	return c.doWithType(method, base, args, body, contentType, callLimits{})
+}
+
+func (c *Client) doWithType(method, base string, args url.Values, body []byte, contentType string, lim callLimits) (string, error) {
+	if c.disk == nil || method != http.MethodGet {
+		return c.fetch(method, base, args, body, contentType, lim)
+	}
+	if !diskCacheable(base, args) {
+		if c.disk.mode == DiskOffline {
+			return "", fmt.Errorf("%q can't be cached, and the cache is offline", base)
+		}
+		return c.fetch(method, base, args, body, contentType, lim)
+	}
+
+	var fetched bool
+	var fetchErr error
+	res, err := c.disk.get(base, args, func() (string, error) {
+		fetched = true
+		res, err := c.fetch(method, base, args, body, contentType, lim)
+		fetchErr = err
+		return res, err
+	})
+	if err == nil {
+		// Falling back to the cache after a failure is a hit too.
+		c.countCache("disk", !fetched || fetchErr != nil)
+	}
+
+	return res, err
+}
+
+func (c *Client) fetch(method, base string, args url.Values, body []byte, contentType string, lim callLimits) (string, error) {
+	rc, err := c.fetchStream(method, base, args, body, contentType, lim)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	res, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s %q: %w", method, base, err)
+	}
+
+	return string(res), nil
+}
+
+// fetchStream sends the request, and returns the response body unread unless
+// it has to be cached. Reading more of the body than the size limit fails
+// with ErrResponseTooLarge.
+func (c *Client) fetchStream(method, base string, args url.Values, body []byte, contentType string, lim callLimits) (io.ReadCloser, error) {
+	if c.replay != nil {
+		return c.replay.open(method, base, args)
+	}
+	// Absolute URLs, like photos, can be on another host.
+	uri := base
+	if c.server != "" && !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		uri = joinURL(c.server, base)
 	}
 	if len(args) > 0 {
 		uri += "?" + args.Encode()
 	}
-	resp, err := http.Get(uri)
-	if err != nil || resp.StatusCode != 200 {
-		if resp != nil {
-			return "", fmt.Errorf("error in GET %q: %q %v", base, resp.Status, err)
-		} else {
-			return "", fmt.Errorf("error in GET %q: %v", base, err)
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, uri, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("can't create %s request for %q: %w", method, base, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	var cached CachedResponse
+	var isCached bool
+	if c.cache != nil && method == http.MethodGet {
+		cached, isCached = c.cache.Get(uri)
+		if isCached {
+			cached.setValidators(req)
+		}
+	}
+
+	endpoint := endpointLabel(base)
+	start := time.Now()
+	resp, err := c.httpClientFor(lim).Do(req)
+	c.observe(MetricRequestSeconds, Labels{"method": method, "endpoint": endpoint}, time.Since(start).Seconds())
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	c.count(MetricRequests, Labels{"method": method, "endpoint": endpoint, "status": status})
+	if err != nil {
+		return nil, fmt.Errorf("error in %s %q: %w", method, base, err)
+	}
+	if c.cache != nil && method == http.MethodGet {
+		c.countCache("memory", resp.StatusCode == http.StatusNotModified && isCached)
+	}
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		resp.Body.Close()
+		if c.capture != nil {
+			if err := c.capture.record(method, base, args, http.StatusOK, []byte(cached.Body)); err != nil {
+				return nil, err
+			}
 		}
+		return ioutil.NopCloser(strings.NewReader(cached.Body)), nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		// The error body is only for debugging, a failure to read it
+		// doesn't matter.
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, snippetSize))
+		if c.capture != nil {
+			if err := c.capture.record(method, base, args, resp.StatusCode, body); err != nil {
+				return nil, err
+			}
+		}
+		return nil, &statusError{method: method, path: base, code: resp.StatusCode, status: resp.Status, body: string(body)}
+	}
+	resp.Body = newLimitedBody(resp.Body, c.maxSize(lim), method, base)
+
+	if (c.cache != nil && method == http.MethodGet) || c.capture != nil {
+		defer resp.Body.Close()
+		res, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s %q: %w", method, base, err)
+		}
+		if c.cache != nil && method == http.MethodGet {
+			c.store(uri, resp, string(res))
+		}
+		if c.capture != nil {
+			if err := c.capture.record(method, base, args, resp.StatusCode, res); err != nil {
+				return nil, err
+			}
+		}
+		return ioutil.NopCloser(bytes.NewReader(res)), nil
+	}
+
+	return resp.Body, nil
+}
+
+type statusError struct {
+	method string
+	path   string
+	code   int
+	status string
+	body   string
+}
+
+func (e *statusError) Error() string {
+	if body := strings.TrimSpace(e.body); body != "" {
+		return fmt.Sprintf("error in %s %q: %q: %s", e.method, e.path, e.status, body)
+	}
+	return fmt.Sprintf("error in %s %q: %q", e.method, e.path, e.status)
+}
+
+func isStatus(err error, code int) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == code
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.http != nil {
+		return c.http
+	}
+	return http.DefaultClient
+}
+
+// Warmup resolves the server's host and opens a connection to it, so the TLS
+// handshake is out of the way before the first real request. It does nothing
+// for plain HTTP servers.
+func (c *Client) Warmup(ctx context.Context) error {
+	u, err := url.Parse(c.server)
+	if err != nil {
+		return fmt.Errorf("can't parse server %q: %w", c.server, err)
+	}
+	if u.Scheme != "https" {
+		return nil
+	}
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+		return fmt.Errorf("can't resolve %q: %w", u.Hostname(), err)
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, joinURL(c.server, "/"), nil)
+	if err != nil {
+		return fmt.Errorf("can't create warmup request: %w", err)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error warming up %q: %w", c.server, err)
+	}
 	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-	return string(body), nil
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		return fmt.Errorf("error warming up %q: %w", c.server, err)
+	}
+
+	return nil
+}
+
+// Warmup pre-connects to the client's server, through its own transport, so
+// the first request doesn't wait for it. It can run alongside other startup
+// work. Errors are logged, not returned.
+func (r *RWGPS) Warmup(ctx context.Context) {
+	if err := r.client.Warmup(ctx); err != nil {
+		r.log().Warn("warmup failed", "server", r.client.server, "err", err)
+	}
 }
@@ -1,19 +1,31 @@
 package goride
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/zigdon/goride/units"
 )
 
 type rwgpsHandler struct {
@@ -36,7 +48,7 @@ func (h rwgpsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else if hasDynamic {
 		fmt.Fprintf(w, f(r.URL.Path, r.URL.Query()))
 	} else {
-		w.Header().Add("status", "404 not found")
+		w.WriteHeader(http.StatusNotFound)
 		fmt.Fprintf(w, "404 Not found: %q", path)
 	}
 }
@@ -48,7 +60,7 @@ func defaultAuth(p string, v url.Values) string {
 	} else if v.Get("email") == "test@example.com" && v.Get("password") == "supers3cret" {
 		return u
 	} else {
-		return "401 bad auth"
+		return `{"error": "401 bad auth"}`
 	}
 }
 
@@ -78,7 +90,7 @@ func testConfig(path string) *Config {
 }
 
 func testObj(server string) *RWGPS {
-	return &RWGPS{config: testConfig(""), client: &Client{server: server}}
+	return &RWGPS{config: testConfig(""), client: &Client{server: server}, hints: true}
 }
 
 func getTestData(name string) string {
@@ -90,6 +102,12 @@ func getTestData(name string) string {
 	return string(data)
 }
 
+func writeTestFile(t *testing.T, path, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("can't write %q: %v", path, err)
+	}
+}
+
 func TestGet(t *testing.T) {
 	server := startServer(t,
 		map[string]string{
@@ -132,6 +150,259 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestDoStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/created", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "new")
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, strings.Repeat("stack trace ", 1000), http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/truncated", func(w http.ResponseWriter, _ *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("can't hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\nonly ten b")
+		buf.Flush()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	c := &Client{server: server.URL}
+
+	tests := []struct {
+		desc    string
+		method  string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{desc: "201", method: http.MethodPost, path: "/created", want: "new"},
+		{desc: "204", method: http.MethodDelete, path: "/empty"},
+		{desc: "500", method: http.MethodGet, path: "/broken", wantErr: "500 Internal Server Error\": stack trace stack trace"},
+		{desc: "truncated", method: http.MethodGet, path: "/truncated", wantErr: "unexpected EOF"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := c.Do(tc.method, tc.path, nil, nil)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error with %q, got %v", tc.wantErr, err)
+				}
+				if len(err.Error()) > 2*snippetSize {
+					t.Errorf("error too long: %d bytes", len(err.Error()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("bad body: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDoErrorsDontLeak(t *testing.T) {
+	var open int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, strings.Repeat("oops ", 10000), http.StatusInternalServerError)
+	}))
+	server.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		switch s {
+		case http.StateNew:
+			atomic.AddInt32(&open, 1)
+		case http.StateClosed, http.StateHijacked:
+			atomic.AddInt32(&open, -1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	c := &Client{server: server.URL, http: &http.Client{}}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 200; i++ {
+		if _, err := c.Get("/", nil); !isStatus(err, http.StatusInternalServerError) {
+			t.Fatalf("want a 500, got %v", err)
+		}
+	}
+
+	// Connections with unread bodies are closed instead of reused, give the
+	// server a moment to notice.
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&open) > 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&open); n > 2 {
+		t.Errorf("%d connections left open", n)
+	}
+	if n := runtime.NumGoroutine(); n > before+10 {
+		t.Errorf("goroutines grew from %d to %d", before, n)
+	}
+}
+
+func TestGetStream(t *testing.T) {
+	server := startServer(t, map[string]string{"/path": "something"}, nil)
+	defer server.Close()
+
+	for _, cache := range []bool{false, true} {
+		c := &Client{server: server.URL}
+		if cache {
+			c.cache = NewMemoryCache(10)
+		}
+		rc, err := c.GetStream("/path", nil)
+		if err != nil {
+			t.Fatalf("cache=%v: unexpected error: %v", cache, err)
+		}
+		got, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(got) != "something" {
+			t.Errorf("cache=%v: bad body: %q", cache, got)
+		}
+
+		if _, err := c.GetStream("/missing", nil); !isStatus(err, http.StatusNotFound) {
+			t.Errorf("cache=%v: want a 404, got %v", cache, err)
+		}
+	}
+}
+
+// BenchmarkGetRide compares reading the whole body before decoding it with
+// decoding straight from the connection, and with skipping the track points,
+// on a ride with 100k track points.
+func BenchmarkGetRide(b *testing.B) {
+	var trip struct{ Trip map[string]interface{} }
+	if err := decodeJSON(getTestData("trip.json"), &trip); err != nil {
+		b.Fatal(err)
+	}
+	points := trip.Trip["track_points"].([]interface{})
+	var many []interface{}
+	for len(many) < 100000 {
+		many = append(many, points...)
+	}
+	trip.Trip["track_points"] = many[:100000]
+	data, err := json.Marshal(map[string]interface{}{"type": "trip", "trip": trip.Trip})
+	if err != nil {
+		b.Fatal(err)
+	}
+	server := startServer(nil, nil, map[string]func(string, url.Values) string{
+		"/trips/1.json": func(string, url.Values) string { return string(data) },
+	})
+	defer server.Close()
+	r := testObj(server.URL)
+	if err := r.Auth(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			res, err := r.Get("/trips/1.json", nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var got struct{ Trip Ride }
+			if err := decodeJSON(res, &got); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.GetRide(1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// The server ignores the hint, so this only measures skipping the track
+	// points while decoding.
+	b.Run("summary", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.GetRideSummary(1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestWarmup(t *testing.T) {
+	tests := []struct {
+		desc      string
+		tls       bool
+		wantConns int32
+	}{
+		{
+			desc:      "https reuses the warm connection",
+			tls:       true,
+			wantConns: 1,
+		},
+		{
+			desc:      "plain http is a no-op",
+			wantConns: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var conns int32
+			server := httptest.NewUnstartedServer(rwgpsHandler{
+				static: map[string]string{"/": "test", "/path": "something"},
+				mu:     &sync.Mutex{},
+				t:      t,
+			})
+			server.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+				if s == http.StateNew {
+					atomic.AddInt32(&conns, 1)
+				}
+			}
+			if tc.tls {
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			c := &Client{server: server.URL, http: server.Client()}
+			if err := c.Warmup(context.Background()); err != nil {
+				t.Fatalf("error warming up: %v", err)
+			}
+			if got := atomic.LoadInt32(&conns); got != tc.wantConns {
+				t.Errorf("wrong connections after warmup: want %d, got %d", tc.wantConns, got)
+			}
+
+			if _, err := c.Get("/path", nil); err != nil {
+				t.Fatalf("error getting /path: %v", err)
+			}
+			if got := atomic.LoadInt32(&conns); got != 1 {
+				t.Errorf("wrong connections after request: want 1, got %d", got)
+			}
+		})
+	}
+}
+
+func TestRWGPSWarmup(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	addr := server.URL
+	server.Close()
+
+	r := testObj(addr)
+	logs := &logRecorder{}
+	WithLogger(slog.New(logs))(r)
+	r.Warmup(context.Background())
+	if !logs.has(slog.LevelWarn, "warmup failed") {
+		t.Errorf("expected the failed warmup to be logged, got %v", logs.messages())
+	}
+}
+
 func TestConfig(t *testing.T) {
 	cfg := strings.Join([]string{
 		"[Auth]",
@@ -158,6 +429,95 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func TestServerURL(t *testing.T) {
+	writeCfg := func(t *testing.T, server string) string {
+		lines := []string{"[Auth]", "email = test@example.com"}
+		if server != "" {
+			lines = append(lines, "[Server]", "url = "+server)
+		}
+		path := filepath.Join(t.TempDir(), "cfg.ini")
+		writeTestFile(t, path, strings.Join(lines, "\n"))
+		return path
+	}
+
+	tests := []struct {
+		desc    string
+		option  string
+		env     string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{desc: "default", want: "https://ridewithgps.com"},
+		{desc: "config", config: "https://gateway.example.com/rwgps", want: "https://gateway.example.com/rwgps"},
+		{desc: "env", env: "http://localhost:8080/", config: "https://gateway.example.com", want: "http://localhost:8080"},
+		{desc: "option", option: "https://a.example.com/x/", env: "http://localhost:8080", want: "https://a.example.com/x"},
+		{desc: "no scheme", option: "gateway.example.com/rwgps", wantErr: true},
+		{desc: "query", config: "https://gateway.example.com/?a=b", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv(serverEnv, tc.env)
+			var opts []Option
+			if tc.option != "" {
+				opts = append(opts, WithServer(tc.option))
+			}
+			r, err := New(writeCfg(t, tc.config), opts...)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got server %q", r.client.server)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.client.server != tc.want {
+				t.Errorf("want server %q, got %q", tc.want, r.client.server)
+			}
+			if r.config.ServerURL != tc.config {
+				t.Errorf("want config ServerURL %q, got %q", tc.config, r.config.ServerURL)
+			}
+		})
+	}
+}
+
+func TestServerPrefix(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		desc   string
+		server string
+		path   string
+		want   string
+	}{
+		{desc: "no prefix", server: server.URL, path: "/trips/1.json", want: "/trips/1.json"},
+		{desc: "trailing slash", server: server.URL + "/", path: "/trips/1.json", want: "/trips/1.json"},
+		{desc: "prefix", server: server.URL + "/rwgps", path: "/trips/1.json", want: "/rwgps/trips/1.json"},
+		{desc: "prefix with slash", server: server.URL + "/rwgps/", path: "/trips/1.json", want: "/rwgps/trips/1.json"},
+		{desc: "relative path", server: server.URL + "/rwgps", path: "trips/1.json", want: "/rwgps/trips/1.json"},
+		{desc: "root", server: server.URL + "/rwgps/", path: "/", want: "/rwgps/"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got = nil
+			c := &Client{server: tc.server}
+			if _, err := c.Get(tc.path, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff([]string{tc.want}, got); diff != "" {
+				t.Errorf("bad request path: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestAuth(t *testing.T) {
 	server := startServer(t, nil, nil)
 	defer server.Close()
@@ -210,6 +570,256 @@ func TestAuth(t *testing.T) {
 	}
 }
 
+func TestTokenAuth(t *testing.T) {
+	var logins []url.Values
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/users/current.json": func(p string, v url.Values) string {
+			logins = append(logins, v)
+			return defaultAuth(p, v)
+		},
+	})
+	defer server.Close()
+
+	tests := []struct {
+		desc    string
+		token   string
+		env     string
+		wantErr bool
+	}{
+		{desc: "good token", token: "beef1337"},
+		{desc: "token from env", token: "stale", env: "beef1337"},
+		{desc: "rejected token", token: "dead", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			logins = nil
+			t.Setenv(authTokenEnv, tc.env)
+			path := filepath.Join(t.TempDir(), "cfg.ini")
+			writeTestFile(t, path, strings.Join([]string{
+				"[Auth]",
+				"name = \"test key\"",
+				"auth_token = " + tc.token,
+			}, "\n"))
+			r, err := New(path, WithServer(server.URL))
+			if err != nil {
+				t.Fatalf("can't create client: %v", err)
+			}
+
+			err = r.Auth()
+			if tc.wantErr {
+				if !errors.Is(err, ErrAuthFailed) {
+					t.Errorf("want ErrAuthFailed, got %v", err)
+				}
+				if r.authUser != nil {
+					t.Errorf("logged in with a rejected token")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if r.authUser.ID == 0 || r.authUser.AuthToken == "" {
+					t.Errorf("bad user: %+v", r.authUser)
+				}
+			}
+
+			// Only the token was tried, never the password.
+			if len(logins) != 1 {
+				t.Fatalf("want 1 login, got %d: %v", len(logins), logins)
+			}
+			for _, k := range []string{"email", "password"} {
+				if logins[0].Has(k) {
+					t.Errorf("login sent %s: %v", k, logins[0])
+				}
+			}
+			if got := logins[0].Get("apikey"); got != "test key" {
+				t.Errorf("want apikey %q, got %q", "test key", got)
+			}
+		})
+	}
+}
+
+func TestCredentialCommands(t *testing.T) {
+	server := startServer(t, nil, nil)
+	defer server.Close()
+
+	tests := []struct {
+		desc     string
+		auth     []string
+		cached   bool
+		wantRun  bool
+		wantErr  string
+		wantUser bool
+	}{
+		{
+			desc:     "password",
+			auth:     []string{"email = test@example.com", `password_command = "touch $RAN; printf 'supers3cret\n\n'"`},
+			wantRun:  true,
+			wantUser: true,
+		},
+		{
+			desc:     "token",
+			auth:     []string{`auth_token_command = "touch $RAN; echo beef1337"`},
+			wantRun:  true,
+			wantUser: true,
+		},
+		{
+			desc:     "token already cached",
+			auth:     []string{`auth_token_command = "touch $RAN; echo beef1337"`},
+			cached:   true,
+			wantUser: true,
+		},
+		{
+			desc:     "password set directly",
+			auth:     []string{"email = test@example.com", "password = supers3cret", `password_command = "touch $RAN; echo nope"`},
+			wantUser: true,
+		},
+		{
+			desc:    "failing command",
+			auth:    []string{`password_command = "touch $RAN; echo no such secret >&2; exit 3"`},
+			wantRun: true,
+			wantErr: `password_command "touch $RAN; echo no such secret >&2; exit 3" failed: exit status 3: no such secret`,
+		},
+		{
+			desc:    "no output",
+			auth:    []string{`auth_token_command = "touch $RAN; echo"`},
+			wantRun: true,
+			wantErr: `auth_token_command "touch $RAN; echo" printed nothing`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Setenv(authTokenEnv, "")
+			dir := t.TempDir()
+			// The commands touch $RAN when they run.
+			t.Setenv("RAN", filepath.Join(dir, "ran"))
+			path := filepath.Join(dir, "cfg.ini")
+			writeTestFile(t, path, strings.Join(append([]string{"[Auth]", `name = "test key"`}, tc.auth...), "\n"))
+
+			r, err := New(path, WithServer(server.URL))
+			if err != nil {
+				t.Fatalf("can't create client: %v", err)
+			}
+			ran := func() bool {
+				_, err := os.Stat(filepath.Join(dir, "ran"))
+				return err == nil
+			}
+			if ran() {
+				t.Fatalf("command ran when loading the config")
+			}
+			if tc.cached {
+				r.authUser = &User{AuthToken: "beef1337"}
+			}
+
+			err = r.Auth()
+			if got := ran(); got != tc.wantRun {
+				t.Errorf("command ran: want %v, got %v", tc.wantRun, got)
+			}
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("want error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.authUser == nil || r.authUser.ID == 0 {
+				t.Errorf("not logged in: %+v", r.authUser)
+			}
+		})
+	}
+}
+
+func TestCredentialCommandCapture(t *testing.T) {
+	server := startServer(t, nil, nil)
+	defer server.Close()
+	t.Setenv(authTokenEnv, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cfg.ini")
+	writeTestFile(t, path, strings.Join([]string{
+		"[Auth]", `name = "test key"`, "email = test@example.com", `password_command = "echo supers3cret"`,
+	}, "\n"))
+
+	// The fixture recorder reads the password while other logins set it.
+	r, err := New(path, WithServer(server.URL), WithCapture(filepath.Join(dir, "fixtures")))
+	if err != nil {
+		t.Fatalf("can't create client: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.GetCurrentUser(); err != nil {
+				t.Errorf("GetCurrentUser(): %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentLogin(t *testing.T) {
+	f := newFakeRWGPS(t, &Ride{ID: 1, Name: "one"}, &Ride{ID: 2, Name: "two"})
+	f.slowAuth(50 * time.Millisecond)
+	r := testObj(f.URL)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			ride, err := r.GetRide(id)
+			if err == nil && ride.ID != id {
+				err = fmt.Errorf("asked for ride %d, got %d", id, ride.ID)
+			}
+			errs <- err
+		}(i%2 + 1)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetRide: %v", err)
+		}
+	}
+	if got := f.logins.Load(); got != 1 {
+		t.Errorf("want 1 login, got %d", got)
+	}
+}
+
+func TestConcurrentLoginFailure(t *testing.T) {
+	f := newFakeRWGPS(t, &Ride{ID: 1})
+	f.slowAuth(50 * time.Millisecond)
+	r := testObj(f.URL)
+	r.config.Password = "wrong"
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.GetRide(1); errors.Is(err, ErrAuthFailed) {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := failed.Load(); got != 10 {
+		t.Errorf("want 10 ErrAuthFailed, got %d", got)
+	}
+	// Waiters share the failed login, so there are at most a couple, not one
+	// per call.
+	if got := f.logins.Load(); got > 2 {
+		t.Errorf("want at most 2 logins, got %d", got)
+	}
+}
+
 func TestGetRide(t *testing.T) {
 	ride := getTestData("trip.json")
 	server := startServer(t,
@@ -229,7 +839,55 @@ func TestGetRide(t *testing.T) {
 	}
 
 	if got == nil {
-		t.Error("missing expected ride")
+		t.Fatal("missing expected ride")
+	}
+
+	if got.Visibility != Public {
+		t.Errorf("bad visibility: %v", got.Visibility)
+	}
+	if diff := cmp.Diff([]string{"hills", "Portland"}, got.Tags); diff != "" {
+		t.Errorf("bad tags: -want +got\n%s", diff)
+	}
+
+	m := got.Metrics
+	if m.HR.Avg == 0 || m.HR.Max != 196 || m.HR.Min != 106 {
+		t.Errorf("bad HR: %+v", m.HR)
+	}
+	if m.Cadence.Avg == 0 || m.Cadence.Max != 133 || m.Cadence.Min != 10 {
+		t.Errorf("bad cadence: %+v", m.Cadence)
+	}
+	if m.Temperature.Avg != 23.05 || m.Temperature.Max != 29.4 || m.Temperature.Min != 17.8 {
+		t.Errorf("bad temperature: %+v", m.Temperature)
+	}
+	// Recorded without a power meter.
+	if m.Watts.Avg != 0 || m.Watts.Max != 0 {
+		t.Errorf("bad watts: %+v", m.Watts)
+	}
+	if m.FirstTime != 1216570739 || m.StartElevation != 41.37622 || m.EndElevation != 108.766235 {
+		t.Errorf("bad first time or elevations: %d, %f, %f", m.FirstTime, m.StartElevation, m.EndElevation)
+	}
+}
+
+func TestMetricsMissingSensors(t *testing.T) {
+	tests := []struct {
+		desc string
+		data string
+	}{
+		{desc: "nulls", data: `{"hr": null, "watts": null, "cad": null, "temperature": null, "startElevation": null}`},
+		{desc: "missing", data: `{}`},
+		{desc: "empty", data: `{"hr": {}, "watts": {}, "cad": {}, "temperature": {}}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got Metrics
+			if err := decodeJSON(tc.data, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(Metrics{}, got); diff != "" {
+				t.Errorf("bad metrics: -want +got\n%s", diff)
+			}
+		})
 	}
 }
 
@@ -269,22 +927,26 @@ func validRideSlim(r *RideSlim) error {
 
 func TestGetRides(t *testing.T) {
 	tests := []struct {
-		desc    string
-		offset  int
-		limit   int
-		wantIDs []int
+		desc        string
+		offset      int
+		limit       int
+		wantIDs     []int
+		wantDeleted []int
+		wantTagged  []int
 	}{
 		{
-			desc:    "0, 2",
-			offset:  0,
-			limit:   2,
-			wantIDs: []int{38045212, 37648524},
+			desc:       "0, 2",
+			offset:     0,
+			limit:      2,
+			wantIDs:    []int{38045212, 37648524},
+			wantTagged: []int{38045212},
 		},
 		{
-			desc:    "1, 3",
-			offset:  1,
-			limit:   3,
-			wantIDs: []int{37648524, 37120067, 27521845},
+			desc:        "1, 3",
+			offset:      1,
+			limit:       3,
+			wantIDs:     []int{37648524, 37120067, 27521845},
+			wantDeleted: []int{27521845},
 		},
 	}
 
@@ -313,21 +975,85 @@ func TestGetRides(t *testing.T) {
 				t.Errorf("wrong count: %d", count)
 			}
 
-			var gotIDs []int
+			var gotIDs, gotDeleted, gotTagged []int
 			for _, ride := range got {
 				if err := validRideSlim(ride); err != nil {
 					t.Errorf("Bad ride data: %v", err)
 				}
 				gotIDs = append(gotIDs, ride.ID)
+				if ride.IsDeleted() {
+					gotDeleted = append(gotDeleted, ride.ID)
+				}
+				if len(ride.Tags) > 0 {
+					gotTagged = append(gotTagged, ride.ID)
+				}
 			}
 
 			if diff := cmp.Diff(gotIDs, tc.wantIDs); diff != "" {
 				t.Errorf("bad ride IDs: -want +got\n%s", diff)
 			}
+			if diff := cmp.Diff(gotDeleted, tc.wantDeleted); diff != "" {
+				t.Errorf("bad deleted rides: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(gotTagged, tc.wantTagged); diff != "" {
+				t.Errorf("bad tagged rides: -want +got\n%s", diff)
+			}
 		})
 	}
 }
 
+func TestGetUser(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/2.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("user.json"))
+	})
+	f.handle("/users/3.json", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "this profile is private", http.StatusForbidden)
+	})
+	f.handle("/users/5.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"user": null}`)
+	})
+	r := testObj(f.URL)
+
+	got, err := r.GetUser(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &User{ID: 2, Name: "Club Mate", TotalTrips: 412}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad user: -want +got\n%s", diff)
+	}
+
+	for id, want := range map[int]error{3: ErrPrivate, 4: ErrNotFound, 5: ErrNotFound} {
+		if _, err := r.GetUser(id); !errors.Is(err, want) {
+			t.Errorf("user %d: want %v, got %v", id, want, err)
+		}
+	}
+}
+
+func TestGetRidesHidden(t *testing.T) {
+	f := newFakeRWGPS(t)
+	hidden := func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "friends only", http.StatusForbidden)
+	}
+	f.handle("/users/3/trips.json", hidden)
+	f.handle("/users/1268590/trips.json", hidden)
+	r := testObj(f.URL)
+
+	rides, count, err := r.GetRides(3, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rides) != 0 || count != 0 {
+		t.Errorf("expected no rides, got %d of %d", len(rides), count)
+	}
+
+	// Our own rides being hidden is an error.
+	if _, _, err := r.GetRides(1268590, 0, 10); err == nil {
+		t.Errorf("expected an error for hidden rides of the current user")
+	}
+}
+
 func TestGetCurrentUser(t *testing.T) {
 	server := startServer(t, nil, nil)
 	defer server.Close()
@@ -349,6 +1075,12 @@ func TestGetCurrentUser(t *testing.T) {
 			{256907, "Folder"},
 			{256908, "Surly w/Trailer"},
 		},
+		Preferences: Preferences{
+			Units:           units.Imperial,
+			TripVisibility:  Private,
+			RouteVisibility: FriendsOnly,
+			DefaultGearID:   239758,
+		},
 	}
 
 	if diff := cmp.Diff(want, u); diff != "" {
@@ -356,3 +1088,110 @@ func TestGetCurrentUser(t *testing.T) {
 	}
 
 }
+
+// wireNamesMatch checks that every key of got that's in want, ignoring case,
+// has the same case there, in nested objects too.
+func wireNamesMatch(t *testing.T, path string, want, got interface{}) {
+	t.Helper()
+	switch g := got.(type) {
+	case map[string]interface{}:
+		w, ok := want.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range g {
+			for wk, wv := range w {
+				if !strings.EqualFold(k, wk) {
+					continue
+				}
+				if k != wk {
+					t.Errorf("%s: marshaled as %q, decoded from %q", path, k, wk)
+				}
+				wireNamesMatch(t, path+"."+wk, wv, v)
+			}
+		}
+	case []interface{}:
+		w, ok := want.([]interface{})
+		if ok && len(w) > 0 && len(g) > 0 {
+			wireNamesMatch(t, path+"[0]", w[0], g[0])
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		desc    string
+		fixture string
+		obj     func() interface{}
+	}{
+		{
+			desc:    "ride",
+			fixture: "trip.json",
+			obj: func() interface{} {
+				return &struct {
+					Trip *Ride `json:"trip"`
+				}{}
+			},
+		},
+		{
+			desc:    "rides",
+			fixture: "trips0-2.json",
+			obj: func() interface{} {
+				return &struct {
+					Results []*RideSlim `json:"results"`
+				}{}
+			},
+		},
+		{
+			desc:    "track points with surfaces",
+			fixture: "route_surface.json",
+			obj: func() interface{} {
+				return &struct {
+					Route struct {
+						TrackPoints []TrackPoint `json:"track_points"`
+					} `json:"route"`
+				}{}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			data := getTestData(tc.fixture)
+			want := tc.obj()
+			if err := decodeJSON(data, want); err != nil {
+				t.Fatalf("can't decode %s: %v", tc.fixture, err)
+			}
+
+			encoded, err := json.Marshal(want)
+			if err != nil {
+				t.Fatalf("can't marshal: %v", err)
+			}
+			got := tc.obj()
+			if err := decodeJSON(string(encoded), got); err != nil {
+				t.Fatalf("can't decode the marshaled JSON: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("JSON round trip: -want +got:\n%s", diff)
+			}
+
+			var wantRaw, gotRaw interface{}
+			json.Unmarshal([]byte(data), &wantRaw)
+			json.Unmarshal(encoded, &gotRaw)
+			wireNamesMatch(t, tc.fixture, wantRaw, gotRaw)
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+				t.Fatalf("can't gob encode: %v", err)
+			}
+			got = tc.obj()
+			if err := gob.NewDecoder(&buf).Decode(got); err != nil {
+				t.Fatalf("can't gob decode: %v", err)
+			}
+			// Gob doesn't keep empty slices.
+			if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("gob round trip: -want +got:\n%s", diff)
+			}
+		})
+	}
+}
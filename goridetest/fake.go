@@ -0,0 +1,463 @@
+// Package goridetest provides a fake goride.Service for testing code that uses
+// the RWGPS API.
+package goridetest
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/zigdon/goride"
+)
+
+// Call is a single method call made on the fake.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fake is an in-memory goride.Service. Seed it with data, optionally inject
+// errors, and inspect the calls made once done. It keeps copies of what it's
+// seeded with and returns copies, so neither side can change the other's
+// data. It's safe for concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	user   *goride.User
+	users  map[int]*goride.User
+	rides  map[int]*goride.Ride
+	lists  map[int][]*goride.RideSlim
+	routes map[int]*goride.Route
+	errors map[string]error
+	calls  []Call
+}
+
+var _ goride.Service = (*Fake)(nil)
+
+func New() *Fake {
+	return &Fake{
+		users:  make(map[int]*goride.User),
+		rides:  make(map[int]*goride.Ride),
+		lists:  make(map[int][]*goride.RideSlim),
+		routes: make(map[int]*goride.Route),
+		errors: make(map[string]error),
+	}
+}
+
+// SetUser sets the user returned by GetCurrentUser.
+func (f *Fake) SetUser(u *goride.User) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.user = copyUser(u)
+}
+
+// AddUser adds a user to be returned by GetUser.
+func (f *Fake) AddUser(u *goride.User) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[u.ID] = copyUser(u)
+}
+
+// AddRides adds rides to a user's ride list, as returned by GetRides.
+func (f *Fake) AddRides(user int, rides ...*goride.RideSlim) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range rides {
+		f.lists[user] = append(f.lists[user], copyRideSlim(r))
+	}
+}
+
+// SetRides replaces a user's ride list, e.g. to change or delete rides the
+// way the server would.
+func (f *Fake) SetRides(user int, rides ...*goride.RideSlim) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists[user] = nil
+	for _, r := range rides {
+		f.lists[user] = append(f.lists[user], copyRideSlim(r))
+	}
+}
+
+// AddRide adds a ride to be returned by GetRide.
+func (f *Fake) AddRide(ride *goride.Ride) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rides[ride.ID] = copyRide(ride)
+}
+
+// AddRoute adds a route to be returned by GetRoute.
+func (f *Fake) AddRoute(route *goride.Route) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[route.ID] = copyRoute(route)
+}
+
+// SetError makes every call to method return err, until cleared with a nil
+// error.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+// Calls returns the calls made so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to a single method.
+func (f *Fake) CallsTo(method string) []Call {
+	var res []Call
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// record logs the call, and returns the injected error for it, if any. It
+// must be called with the lock held.
+func (f *Fake) record(method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	return f.errors[method]
+}
+
+func (f *Fake) GetCurrentUser() (*goride.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetCurrentUser"); err != nil {
+		return nil, err
+	}
+	if f.user == nil {
+		return nil, fmt.Errorf("no current user: %w", goride.ErrAuthFailed)
+	}
+
+	return copyUser(f.user), nil
+}
+
+func (f *Fake) GetUser(id int) (*goride.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetUser", id); err != nil {
+		return nil, err
+	}
+	u, ok := f.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user %d: %w", id, goride.ErrNotFound)
+	}
+
+	return copyUser(u), nil
+}
+
+func (f *Fake) GetRide(id int) (*goride.Ride, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRide", id); err != nil {
+		return nil, err
+	}
+
+	return f.ride(id, false)
+}
+
+// GetRideSummary is GetRide, without the track points.
+func (f *Fake) GetRideSummary(id int) (*goride.Ride, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRideSummary", id); err != nil {
+		return nil, err
+	}
+
+	return f.ride(id, true)
+}
+
+// GetRideWithOpts is GetRide, without the track points if NoTrackPoints is
+// set. Like the server may, it ignores Fields.
+func (f *Fake) GetRideWithOpts(id int, opts goride.RequestOptions) (*goride.Ride, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRideWithOpts", id, opts); err != nil {
+		return nil, err
+	}
+
+	return f.ride(id, opts.NoTrackPoints)
+}
+
+// ride returns a copy of a ride added with AddRide. f.mu must be held.
+func (f *Fake) ride(id int, noTrackPoints bool) (*goride.Ride, error) {
+	ride, ok := f.rides[id]
+	if !ok {
+		return nil, fmt.Errorf("ride %d: %w", id, goride.ErrNotFound)
+	}
+	res := copyRide(ride)
+	if noTrackPoints {
+		res.TrackPoints = nil
+	}
+
+	return res, nil
+}
+
+func (f *Fake) GetRides(user, offset, limit int) ([]*goride.RideSlim, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRides", user, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	return page(f.lists[user], offset, limit)
+}
+
+// AllRides returns all the rides added with AddRides for the user.
+func (f *Fake) AllRides(user int) ([]*goride.RideSlim, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("AllRides", user); err != nil {
+		return nil, err
+	}
+
+	res := make([]*goride.RideSlim, 0, len(f.lists[user]))
+	for _, r := range f.lists[user] {
+		res = append(res, copyRideSlim(r))
+	}
+	return res, nil
+}
+
+// GetRidesWithOpts filters and sorts the rides added with AddRides. Rides are
+// sorted in ascending order unless OrderDesc is given, and stay in the order
+// they were added if SortBy is empty.
+func (f *Fake) GetRidesWithOpts(user, offset, limit int, opts goride.GetRidesOpts) ([]*goride.RideSlim, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRidesWithOpts", user, offset, limit, opts); err != nil {
+		return nil, 0, err
+	}
+
+	var rides []*goride.RideSlim
+	for _, r := range f.lists[user] {
+		if !opts.DepartedAfter.IsZero() && r.DepartedAt.Before(opts.DepartedAfter) {
+			continue
+		}
+		if !opts.DepartedBefore.IsZero() && !r.DepartedAt.Before(opts.DepartedBefore) {
+			continue
+		}
+		if opts.GearID != 0 && r.GearID != opts.GearID {
+			continue
+		}
+		rides = append(rides, r)
+	}
+
+	if opts.SortBy != "" {
+		less, ok := sortBy[opts.SortBy]
+		if !ok {
+			return nil, 0, fmt.Errorf("invalid sort field %q", opts.SortBy)
+		}
+		sort.SliceStable(rides, func(i, j int) bool {
+			if opts.Order == goride.OrderDesc {
+				return less(rides[j], rides[i])
+			}
+			return less(rides[i], rides[j])
+		})
+	}
+
+	return page(rides, offset, limit)
+}
+
+var sortBy = map[string]func(a, b *goride.RideSlim) bool{
+	"created_at":     func(a, b *goride.RideSlim) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"departed_at":    func(a, b *goride.RideSlim) bool { return a.DepartedAt.Before(b.DepartedAt) },
+	"distance":       func(a, b *goride.RideSlim) bool { return a.Distance < b.Distance },
+	"duration":       func(a, b *goride.RideSlim) bool { return a.Duration < b.Duration },
+	"elevation_gain": func(a, b *goride.RideSlim) bool { return a.ElevationGain < b.ElevationGain },
+	"moving_time":    func(a, b *goride.RideSlim) bool { return a.MovingTime < b.MovingTime },
+	"name":           func(a, b *goride.RideSlim) bool { return a.Name < b.Name },
+	"updated_at":     func(a, b *goride.RideSlim) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+}
+
+func (f *Fake) GetRidesForRoute(routeID, offset, limit int) ([]*goride.RideSlim, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRidesForRoute", routeID, offset, limit); err != nil {
+		return nil, 0, err
+	}
+
+	var users []int
+	for u := range f.lists {
+		users = append(users, u)
+	}
+	sort.Ints(users)
+
+	var rides []*goride.RideSlim
+	for _, u := range users {
+		for _, r := range f.lists[u] {
+			if r.RouteID == routeID {
+				rides = append(rides, r)
+			}
+		}
+	}
+
+	return page(rides, offset, limit)
+}
+
+func (f *Fake) GetRoute(id int) (*goride.Route, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetRoute", id); err != nil {
+		return nil, err
+	}
+	route, ok := f.routes[id]
+	if !ok {
+		return nil, fmt.Errorf("route %d: %w", id, goride.ErrNotFound)
+	}
+
+	return copyRoute(route), nil
+}
+
+// UpdateRide changes a ride added with AddRide, and its summary if it was
+// added with AddRides.
+func (f *Fake) UpdateRide(id int, u goride.RideUpdate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpdateRide", id, u); err != nil {
+		return err
+	}
+	ride, ok := f.rides[id]
+	if !ok {
+		return fmt.Errorf("ride %d: %w", id, goride.ErrNotFound)
+	}
+
+	if u.Name != nil {
+		ride.Name = *u.Name
+	}
+	if u.Description != nil {
+		ride.Description = *u.Description
+	}
+	if u.GearID != nil {
+		ride.Gear = &goride.Gear{ID: *u.GearID}
+	}
+	if u.Visibility != nil {
+		ride.Visibility = *u.Visibility
+	}
+	if u.Tags != nil {
+		ride.Tags = slices.Clone(*u.Tags)
+	}
+	for _, list := range f.lists {
+		for _, r := range list {
+			if r.ID != id {
+				continue
+			}
+			r.Name = ride.Name
+			r.Description = ride.Description
+			r.Visibility = ride.Visibility
+			r.Tags = slices.Clone(ride.Tags)
+			if ride.Gear != nil {
+				r.GearID = ride.Gear.ID
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteRide removes a ride, along with its summary.
+func (f *Fake) DeleteRide(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DeleteRide", id); err != nil {
+		return err
+	}
+	_, found := f.rides[id]
+	delete(f.rides, id)
+	for user, list := range f.lists {
+		var keep []*goride.RideSlim
+		for _, r := range list {
+			if r.ID == id {
+				found = true
+				continue
+			}
+			keep = append(keep, r)
+		}
+		f.lists[user] = keep
+	}
+	if !found {
+		return fmt.Errorf("ride %d: %w", id, goride.ErrNotFound)
+	}
+
+	return nil
+}
+
+func page(rides []*goride.RideSlim, offset, limit int) ([]*goride.RideSlim, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid page %d+%d: offset and limit can't be negative", offset, limit)
+	}
+	count := len(rides)
+	if offset > count {
+		offset = count
+	}
+	end := offset + limit
+	if end > count {
+		end = count
+	}
+
+	res := make([]*goride.RideSlim, 0, end-offset)
+	for _, r := range rides[offset:end] {
+		res = append(res, copyRideSlim(r))
+	}
+	return res, count, nil
+}
+
+func copyUser(u *goride.User) *goride.User {
+	if u == nil {
+		return nil
+	}
+	res := *u
+	res.Gear = slices.Clone(u.Gear)
+	return &res
+}
+
+func copyRideSlim(r *goride.RideSlim) *goride.RideSlim {
+	res := *r
+	res.DeletedAt = copyPtr(r.DeletedAt)
+	res.Tags = slices.Clone(r.Tags)
+	return &res
+}
+
+func copyRide(r *goride.Ride) *goride.Ride {
+	res := *r
+	res.Gear = copyPtr(r.Gear)
+	res.DeletedAt = copyPtr(r.DeletedAt)
+	res.BoundingBox = slices.Clone(r.BoundingBox)
+	res.Photos = slices.Clone(r.Photos)
+	if r.Weather != nil {
+		w := *r.Weather
+		w.Start, w.Mid, w.End = copyPtr(w.Start), copyPtr(w.Mid), copyPtr(w.End)
+		res.Weather = &w
+	}
+	res.Tags = slices.Clone(r.Tags)
+	res.TrackPoints = slices.Clone(r.TrackPoints)
+	return &res
+}
+
+func copyRoute(r *goride.Route) *goride.Route {
+	res := *r
+	res.UnpavedPct = copyPtr(r.UnpavedPct)
+	res.Tags = slices.Clone(r.Tags)
+	res.TrackPoints = slices.Clone(r.TrackPoints)
+	res.CoursePoints = slices.Clone(r.CoursePoints)
+	res.POIs = slices.Clone(r.POIs)
+	return &res
+}
+
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
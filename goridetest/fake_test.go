@@ -0,0 +1,287 @@
+package goridetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride"
+)
+
+// longestRide is the kind of code that'd use the fake: it only knows about
+// the goride.Service interface.
+func longestRide(s goride.Service) (*goride.Ride, error) {
+	u, err := s.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	var longest *goride.RideSlim
+	for offset := 0; ; offset += 2 {
+		rides, count, err := s.GetRides(u.ID, offset, 2)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rides {
+			if longest == nil || r.Distance > longest.Distance {
+				longest = r
+			}
+		}
+		if offset+2 >= count {
+			break
+		}
+	}
+	if longest == nil {
+		return nil, nil
+	}
+
+	return s.GetRide(longest.ID)
+}
+
+func TestFake(t *testing.T) {
+	f := New()
+	f.SetUser(&goride.User{ID: 7, Name: "test"})
+	f.AddRides(7,
+		&goride.RideSlim{ID: 1, Distance: 1000},
+		&goride.RideSlim{ID: 2, Distance: 3000},
+		&goride.RideSlim{ID: 3, Distance: 2000},
+	)
+	f.AddRides(8, &goride.RideSlim{ID: 4, Distance: 9000})
+	f.AddRide(&goride.Ride{ID: 2, Name: "long one"})
+
+	got, err := longestRide(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "long one" {
+		t.Errorf("bad longest ride: %+v", got)
+	}
+
+	want := []Call{
+		{Method: "GetCurrentUser"},
+		{Method: "GetRides", Args: []interface{}{7, 0, 2}},
+		{Method: "GetRides", Args: []interface{}{7, 2, 2}},
+		{Method: "GetRide", Args: []interface{}{2}},
+	}
+	if diff := cmp.Diff(want, f.Calls()); diff != "" {
+		t.Errorf("bad calls: -want +got\n%s", diff)
+	}
+	if n := len(f.CallsTo("GetRides")); n != 2 {
+		t.Errorf("expected 2 calls to GetRides, got %d", n)
+	}
+}
+
+func TestFakeCopies(t *testing.T) {
+	f := New()
+	slim := &goride.RideSlim{ID: 1, Name: "ride", Tags: []string{"commute"}}
+	f.AddRides(7, slim)
+	ride := &goride.Ride{ID: 1, Name: "ride", TrackPoints: []goride.TrackPoint{{Lat: 1}, {Lat: 2}}}
+	f.AddRide(ride)
+
+	// Changing what was added doesn't change the fake.
+	slim.Name = "changed"
+	ride.TrackPoints[0].Lat = 3
+
+	rides, err := f.AllRides(7)
+	if err != nil || len(rides) != 1 || rides[0].Name != "ride" {
+		t.Fatalf("bad rides: %+v, %v", rides, err)
+	}
+	// Nor does changing what it returns.
+	rides[0].Tags[0] = "changed"
+	got, err := f.GetRide(1)
+	if err != nil || got.TrackPoints[0].Lat != 1 {
+		t.Fatalf("bad ride: %+v, %v", got, err)
+	}
+	got.Name = "changed"
+
+	if rides, _, _ := f.GetRides(7, 0, 10); rides[0].Tags[0] != "commute" {
+		t.Errorf("returned ride summary was shared: %+v", rides[0])
+	}
+	summary, err := f.GetRideSummary(1)
+	if err != nil || summary.Name != "ride" || summary.TrackPoints != nil {
+		t.Errorf("bad ride summary: %+v, %v", summary, err)
+	}
+	if got, _ := f.GetRideWithOpts(1, goride.RequestOptions{}); len(got.TrackPoints) != 2 {
+		t.Errorf("want the track points without NoTrackPoints, got %+v", got)
+	}
+
+	// Nor does changing the tags passed to UpdateRide.
+	tags := []string{"gravel"}
+	if err := f.UpdateRide(1, goride.RideUpdate{Tags: &tags}); err != nil {
+		t.Fatalf("UpdateRide: %v", err)
+	}
+	tags[0] = "changed"
+	if got, _ := f.GetRide(1); got.Tags[0] != "gravel" {
+		t.Errorf("updated ride tags were shared: %+v", got.Tags)
+	}
+	if rides, _, _ := f.GetRides(7, 0, 10); rides[0].Tags[0] != "gravel" {
+		t.Errorf("updated ride summary tags were shared: %+v", rides[0].Tags)
+	}
+}
+
+func TestFakeUsers(t *testing.T) {
+	f := New()
+	f.AddUser(&goride.User{ID: 8, Name: "friend"})
+
+	got, err := f.GetUser(8)
+	if err != nil || got.Name != "friend" {
+		t.Errorf("bad user: %+v, %v", got, err)
+	}
+	if _, err := f.GetUser(9); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFakeErrors(t *testing.T) {
+	f := New()
+	f.SetUser(&goride.User{ID: 7})
+	f.AddRides(7, &goride.RideSlim{ID: 1})
+
+	boom := errors.New("boom")
+	f.SetError("GetRides", boom)
+	if _, err := longestRide(f); !errors.Is(err, boom) {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+
+	f.SetError("GetRides", nil)
+	if _, err := longestRide(f); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a ride that wasn't added, got %v", err)
+	}
+
+	if _, err := f.GetRoute(5); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing route, got %v", err)
+	}
+}
+
+func TestFakeRidesForRoute(t *testing.T) {
+	f := New()
+	f.AddRides(8, &goride.RideSlim{ID: 3, RouteID: 5})
+	f.AddRides(7, &goride.RideSlim{ID: 1, RouteID: 5}, &goride.RideSlim{ID: 2})
+
+	rides, count, err := f.GetRidesForRoute(5, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []int
+	for _, r := range rides {
+		ids = append(ids, r.ID)
+	}
+	if count != 2 || !cmp.Equal(ids, []int{1, 3}) {
+		t.Errorf("bad rides for route: %v of %d", ids, count)
+	}
+
+	for _, p := range [][2]int{{-1, 10}, {0, -1}} {
+		if _, _, err := f.GetRidesForRoute(5, p[0], p[1]); err == nil {
+			t.Errorf("GetRidesForRoute(5, %d, %d): want an error", p[0], p[1])
+		}
+	}
+}
+
+func TestFakeRidesWithOpts(t *testing.T) {
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	f := New()
+	f.AddRides(7,
+		&goride.RideSlim{ID: 1, DepartedAt: day, Distance: 3000, GearID: 1},
+		&goride.RideSlim{ID: 2, DepartedAt: day.AddDate(0, 0, 1), Distance: 1000},
+		&goride.RideSlim{ID: 3, DepartedAt: day.AddDate(0, 0, 2), Distance: 2000, GearID: 1},
+	)
+
+	tests := []struct {
+		desc  string
+		opts  goride.GetRidesOpts
+		want  []int
+		count int
+	}{
+		{desc: "no options", want: []int{1, 2}, count: 3},
+		{
+			desc:  "sorted",
+			opts:  goride.GetRidesOpts{SortBy: "distance", Order: goride.OrderDesc},
+			want:  []int{1, 3},
+			count: 3,
+		},
+		{
+			desc:  "filtered",
+			opts:  goride.GetRidesOpts{DepartedAfter: day.AddDate(0, 0, 1), GearID: 1},
+			want:  []int{3},
+			count: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rides, count, err := f.GetRidesWithOpts(7, 0, 2, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []int
+			for _, r := range rides {
+				ids = append(ids, r.ID)
+			}
+			if count != tc.count || !cmp.Equal(ids, tc.want) {
+				t.Errorf("bad rides: want %v of %d, got %v of %d", tc.want, tc.count, ids, count)
+			}
+		})
+	}
+
+	if _, _, err := f.GetRidesWithOpts(7, 0, 2, goride.GetRidesOpts{SortBy: "bogus"}); err == nil {
+		t.Errorf("expected an error for a bad sort field")
+	}
+}
+
+func TestFakeWrites(t *testing.T) {
+	f := New()
+	f.AddRides(7, &goride.RideSlim{ID: 1, Name: "Morning Ride"}, &goride.RideSlim{ID: 2})
+	f.AddRide(&goride.Ride{ID: 1, Name: "Morning Ride"})
+
+	name := "Commute"
+	if err := f.UpdateRide(1, goride.RideUpdate{Name: &name}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.DeleteRide(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.DeleteRide(2); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing ride, got %v", err)
+	}
+
+	ride, _ := f.GetRide(1)
+	rides, count, _ := f.GetRides(7, 0, 10)
+	if ride.Name != "Commute" || count != 1 || rides[0].Name != "Commute" {
+		t.Errorf("bad state after writes: %+v, %d rides", ride, count)
+	}
+
+	calls := f.CallsTo("UpdateRide")
+	if len(calls) != 1 || calls[0].Args[0] != 1 {
+		t.Errorf("bad UpdateRide calls: %+v", calls)
+	}
+}
+
+func TestWeather(t *testing.T) {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	ride := &goride.Ride{TrackPoints: []goride.TrackPoint{
+		{Time: start, Lat: 37.5, Lng: -122.25},
+		{Time: start.Add(time.Hour), Lat: 37.75, Lng: -122.25},
+	}}
+
+	w := &Weather{Err: errors.New("offline")}
+	if err := goride.EnrichRide(context.Background(), ride, w); err == nil {
+		t.Errorf("expected an error from an offline provider")
+	}
+	if ride.Weather != nil {
+		t.Errorf("failed lookups set weather: %+v", ride.Weather)
+	}
+
+	w = &Weather{Conditions: goride.Weather{Temperature: 21, Conditions: "sunny"}}
+	if err := goride.EnrichRide(context.Background(), ride, w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ride.Weather == nil || ride.Weather.End == nil || ride.Weather.End.Temperature != 21 || !ride.Weather.End.Time.Equal(start.Add(time.Hour)) {
+		t.Errorf("bad weather: %+v", ride.Weather)
+	}
+	if got := len(w.Asked()); got != 3 {
+		t.Errorf("want 3 lookups, got %d", got)
+	}
+}
@@ -0,0 +1,42 @@
+package goridetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zigdon/goride"
+)
+
+// Weather is a goride.WeatherProvider that returns the same conditions
+// everywhere, or Err if it's set, and records where it was asked about.
+type Weather struct {
+	Conditions goride.Weather
+	Err        error
+
+	mu    sync.Mutex
+	asked []time.Time
+}
+
+var _ goride.WeatherProvider = (*Weather)(nil)
+
+func (w *Weather) Weather(ctx context.Context, at goride.LatLng, t time.Time) (goride.Weather, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.asked = append(w.asked, t)
+	if w.Err != nil {
+		return goride.Weather{}, w.Err
+	}
+	res := w.Conditions
+	res.Time = t
+	res.Location = at
+
+	return res, nil
+}
+
+// Asked returns the times the weather was asked for.
+func (w *Weather) Asked() []time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]time.Time(nil), w.asked...)
+}
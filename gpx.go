@@ -0,0 +1,302 @@
+package goride
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// GPXMetadata is everything in a GPX file besides the track points.
+type GPXMetadata struct {
+	Version     string
+	Creator     string
+	Name        string
+	Description string
+	Time        time.Time
+	// SegmentStarts is the index of the first point of each track segment.
+	// Segments are recorded separately, so there's a gap before each one.
+	SegmentStarts []int
+	Waypoints     []Waypoint
+}
+
+// Waypoint is a named point that isn't part of the track.
+type Waypoint struct {
+	Name      string
+	Lat       float64
+	Lng       float64
+	Elevation float64
+	Time      time.Time
+}
+
+type gpxPoint struct {
+	Lat       float64   `xml:"lat,attr"`
+	Lng       float64   `xml:"lon,attr"`
+	Elevation float64   `xml:"ele"`
+	Time      time.Time `xml:"time"`
+	Name      string    `xml:"name"`
+	// GPX 1.0 only.
+	Speed float64 `xml:"speed"`
+	// Garmin's TrackPointExtension, in any namespace.
+	HeartRate   float64 `xml:"extensions>TrackPointExtension>hr"`
+	Cadence     float64 `xml:"extensions>TrackPointExtension>cad"`
+	Temperature float64 `xml:"extensions>TrackPointExtension>atemp"`
+	Power       float64 `xml:"extensions>power"`
+}
+
+type gpxFile struct {
+	XMLName  xml.Name `xml:"gpx"`
+	Version  string   `xml:"version,attr"`
+	Creator  string   `xml:"creator,attr"`
+	Metadata struct {
+		Name        string    `xml:"name"`
+		Description string    `xml:"desc"`
+		Time        time.Time `xml:"time"`
+	} `xml:"metadata"`
+	// GPX 1.0 has these at the top level.
+	Name        string     `xml:"name"`
+	Description string     `xml:"desc"`
+	Time        time.Time  `xml:"time"`
+	Waypoints   []gpxPoint `xml:"wpt"`
+	Tracks      []struct {
+		Name     string `xml:"name"`
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// ParseGPX reads a GPX 1.0 or 1.1 file. All the track segments, from all the
+// tracks, are concatenated into one track. Distances are computed from the
+// coordinates. Waypoints are returned in the metadata.
+func ParseGPX(r io.Reader) ([]TrackPoint, GPXMetadata, error) {
+	var meta GPXMetadata
+	var f gpxFile
+	d := xml.NewDecoder(r)
+	if err := d.Decode(&f); err != nil {
+		var syntax *xml.SyntaxError
+		if errors.As(err, &syntax) {
+			return nil, meta, fmt.Errorf("error parsing GPX at line %d: %s", syntax.Line, syntax.Msg)
+		}
+		line, _ := d.InputPos()
+		return nil, meta, fmt.Errorf("error parsing GPX near line %d: %w", line, err)
+	}
+	if f.Version != "1.0" && f.Version != "1.1" {
+		return nil, meta, fmt.Errorf("unsupported GPX version %q", f.Version)
+	}
+
+	meta = GPXMetadata{
+		Version:     f.Version,
+		Creator:     f.Creator,
+		Name:        f.Metadata.Name,
+		Description: f.Metadata.Description,
+		Time:        f.Metadata.Time,
+	}
+	if f.Version == "1.0" {
+		meta.Name, meta.Description, meta.Time = f.Name, f.Description, f.Time
+	}
+	for _, w := range f.Waypoints {
+		meta.Waypoints = append(meta.Waypoints, Waypoint{
+			Name:      w.Name,
+			Lat:       w.Lat,
+			Lng:       w.Lng,
+			Elevation: w.Elevation,
+			Time:      w.Time,
+		})
+	}
+
+	var res []TrackPoint
+	for _, trk := range f.Tracks {
+		if meta.Name == "" {
+			meta.Name = trk.Name
+		}
+		for _, seg := range trk.Segments {
+			if len(seg.Points) == 0 {
+				continue
+			}
+			meta.SegmentStarts = append(meta.SegmentStarts, len(res))
+			for _, p := range seg.Points {
+				res = append(res, TrackPoint{
+					Time:        p.Time,
+					Lat:         p.Lat,
+					Lng:         p.Lng,
+					Elevation:   p.Elevation,
+					Speed:       p.Speed * 3.6,
+					HeartRate:   p.HeartRate,
+					Cadence:     p.Cadence,
+					Power:       p.Power,
+					Temperature: p.Temperature,
+				})
+			}
+		}
+	}
+
+	for i, d := range trackDistances(res) {
+		res[i].Distance = d
+	}
+
+	return res, meta, nil
+}
+
+type gpxOut struct {
+	XMLName   xml.Name      `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Name      string        `xml:"metadata>name,omitempty"`
+	Waypoints []gpxOutPoint `xml:"wpt"`
+	Route     *gpxOutRoute  `xml:"rte"`
+	Track     *gpxOutTrack  `xml:"trk"`
+}
+
+type gpxOutRoute struct {
+	Name   string        `xml:"name,omitempty"`
+	Points []gpxOutPoint `xml:"rtept"`
+}
+
+type gpxOutTrack struct {
+	Name   string        `xml:"name,omitempty"`
+	Points []gpxOutPoint `xml:"trkseg>trkpt"`
+}
+
+type gpxOutPoint struct {
+	Lat        float64        `xml:"lat,attr"`
+	Lng        float64        `xml:"lon,attr"`
+	Elevation  *float64       `xml:"ele"`
+	Time       *time.Time     `xml:"time,omitempty"`
+	Name       string         `xml:"name,omitempty"`
+	Symbol     string         `xml:"sym,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxExtensions struct {
+	Power float64 `xml:"power,omitempty"`
+	TPX   *gpxTPX `xml:"http://www.garmin.com/xmlschemas/TrackPointExtension/v1 TrackPointExtension,omitempty"`
+}
+
+type gpxTPX struct {
+	HeartRate   float64 `xml:"hr,omitempty"`
+	Cadence     float64 `xml:"cad,omitempty"`
+	Temperature float64 `xml:"atemp,omitempty"`
+}
+
+// hasElevation is true if any of the points have an elevation. Then all of
+// them get one, as 0 m is a real elevation at sea level.
+func hasElevation(points []TrackPoint) bool {
+	for _, p := range points {
+		if p.Elevation != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gpxElevation is the point's ele, if the track has elevations.
+func gpxElevation(withElevation bool, p TrackPoint) *float64 {
+	if !withElevation {
+		return nil
+	}
+	return &p.Elevation
+}
+
+// ExportGPX writes the track as a GPX 1.1 file, with one track segment. Heart
+// rate, cadence and temperature use Garmin's TrackPointExtension. Points
+// without a position are left out.
+func ExportGPX(w io.Writer, name string, points []TrackPoint) error {
+	f := gpxOut{Version: "1.1", Creator: "goride", Name: name}
+	f.Track = &gpxOutTrack{Name: name}
+	withElevation := hasElevation(points)
+	for _, p := range points {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		gp := gpxOutPoint{Lat: p.Lat, Lng: p.Lng, Elevation: gpxElevation(withElevation, p)}
+		if !p.Time.IsZero() {
+			t := p.Time.UTC()
+			gp.Time = &t
+		}
+		if p.Power != 0 {
+			gp.Extensions = &gpxExtensions{Power: p.Power}
+		}
+		if p.HeartRate != 0 || p.Cadence != 0 || p.Temperature != 0 {
+			if gp.Extensions == nil {
+				gp.Extensions = &gpxExtensions{}
+			}
+			gp.Extensions.TPX = &gpxTPX{HeartRate: p.HeartRate, Cadence: p.Cadence, Temperature: p.Temperature}
+		}
+		f.Track.Points = append(f.Track.Points, gp)
+	}
+
+	return writeGPX(w, f)
+}
+
+func writeGPX(w io.Writer, f gpxOut) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("error writing GPX: %w", err)
+	}
+
+	return nil
+}
+
+// RouteGPXOptions control ExportRouteGPX.
+type RouteGPXOptions struct {
+	// AsRoute writes the route as a <rte> instead of a <trk>, for devices
+	// that only navigate routes.
+	AsRoute bool
+}
+
+// cueSymbols are the RWGPS cue types that GPX devices also use as symbols.
+// Other cues get the "Generic" symbol.
+var cueSymbols = map[string]bool{
+	"Left": true, "Right": true, "Straight": true,
+	"Slight Left": true, "Slight Right": true,
+	"Sharp Left": true, "Sharp Right": true, "U Turn": true,
+	"Summit": true, "Valley": true, "Water": true, "Food": true,
+	"Danger": true, "First Aid": true,
+}
+
+// ExportRouteGPX writes a route as a GPX 1.1 file, with its cues as
+// waypoints, so devices can announce the turns. Cues without a note are named
+// after their type.
+func (r *RWGPS) ExportRouteGPX(id int, w io.Writer, opts RouteGPXOptions) error {
+	route, err := r.GetRoute(id)
+	if err != nil {
+		return err
+	}
+
+	f := gpxOut{Version: "1.1", Creator: "goride", Name: route.Name}
+	withElevation := hasElevation(route.TrackPoints)
+	for _, c := range route.CoursePoints {
+		wp := gpxOutPoint{Lat: c.Lat, Lng: c.Lng, Name: c.Notes, Symbol: "Generic"}
+		if wp.Name == "" {
+			wp.Name = c.Type
+		}
+		if cueSymbols[c.Type] {
+			wp.Symbol = c.Type
+		}
+		if c.Index >= 0 && c.Index < len(route.TrackPoints) {
+			wp.Elevation = gpxElevation(withElevation, route.TrackPoints[c.Index])
+		}
+		f.Waypoints = append(f.Waypoints, wp)
+	}
+
+	var points []gpxOutPoint
+	for _, p := range route.TrackPoints {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		points = append(points, gpxOutPoint{Lat: p.Lat, Lng: p.Lng, Elevation: gpxElevation(withElevation, p)})
+	}
+	if opts.AsRoute {
+		f.Route = &gpxOutRoute{Name: route.Name, Points: points}
+	} else {
+		f.Track = &gpxOutTrack{Name: route.Name, Points: points}
+	}
+
+	return writeGPX(w, f)
+}
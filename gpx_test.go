@@ -0,0 +1,219 @@
+package goride
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGPX(t *testing.T) {
+	at := func(s string) time.Time {
+		res, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("bad time %q: %v", s, err)
+		}
+		return res
+	}
+	step := haversine(45.5, -122.6, 45.501, -122.6)
+
+	tests := []struct {
+		file       string
+		wantPoints []TrackPoint
+		wantMeta   GPXMetadata
+	}{
+		{
+			file: "minimal.gpx",
+			wantPoints: []TrackPoint{
+				{Time: at("2021-08-01T09:00:00Z"), Lat: 45.5, Lng: -122.6, Elevation: 10},
+				{Time: at("2021-08-01T09:00:10Z"), Lat: 45.501, Lng: -122.6, Elevation: 12.5, Distance: step},
+			},
+			wantMeta: GPXMetadata{
+				Version:       "1.1",
+				Creator:       "goride",
+				Name:          "Minimal",
+				SegmentStarts: []int{0},
+			},
+		},
+		{
+			file: "extensions.gpx",
+			wantPoints: []TrackPoint{
+				{
+					Time: at("2021-08-01T09:00:00Z"), Lat: 45.5, Lng: -122.6, Elevation: 10,
+					HeartRate: 140, Cadence: 88, Temperature: 21.5, Power: 210,
+				},
+				{
+					Time: at("2021-08-01T09:00:01Z"), Lat: 45.501, Lng: -122.6, Elevation: 11,
+					HeartRate: 142, Distance: step,
+				},
+			},
+			wantMeta: GPXMetadata{
+				Version:       "1.1",
+				Creator:       "Garmin Connect",
+				Name:          "Morning Ride",
+				Description:   "Coffee & hills",
+				Time:          at("2021-08-01T08:59:00Z"),
+				SegmentStarts: []int{0},
+				Waypoints:     []Waypoint{{Name: "Coffee", Lat: 45.52, Lng: -122.65, Elevation: 30}},
+			},
+		},
+		{
+			file: "multiseg.gpx",
+			wantPoints: []TrackPoint{
+				{Time: at("2008-07-20T17:00:00Z"), Lat: 45.4, Lng: -122.7, Elevation: 50, Speed: 18},
+				{Time: at("2008-07-20T17:00:20Z"), Lat: 45.401, Lng: -122.7, Elevation: 51, Speed: 19.8, Distance: step},
+				{Time: at("2008-07-20T17:30:00Z"), Lat: 45.401, Lng: -122.7, Elevation: 52, Distance: step},
+				{Time: at("2008-07-20T17:31:00Z"), Lat: 45.402, Lng: -122.7, Elevation: 53, Distance: 2 * step},
+			},
+			wantMeta: GPXMetadata{
+				Version:       "1.0",
+				Creator:       "an old GPS",
+				Name:          "Two parts",
+				Time:          at("2008-07-20T17:00:00Z"),
+				SegmentStarts: []int{0, 2, 3},
+				Waypoints:     []Waypoint{{Name: "Lunch", Lat: 45.4, Lng: -122.7}},
+			},
+		},
+	}
+
+	approx := cmp.Comparer(func(a, b float64) bool {
+		return a-b < 1e-6 && b-a < 1e-6
+	})
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", "gpx", tc.file))
+			if err != nil {
+				t.Fatalf("can't open fixture: %v", err)
+			}
+			defer f.Close()
+
+			points, meta, err := ParseGPX(f)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantPoints, points, approx); diff != "" {
+				t.Errorf("bad points: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantMeta, meta); diff != "" {
+				t.Errorf("bad metadata: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseGPXErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		data string
+		want string
+	}{
+		{
+			desc: "malformed",
+			data: getTestData("gpx/malformed.gpx"),
+			want: "line 6",
+		},
+		{
+			desc: "not GPX",
+			data: `<kml></kml>`,
+			want: "expected element type <gpx>",
+		},
+		{
+			desc: "bad version",
+			data: `<gpx version="2.0"></gpx>`,
+			want: `unsupported GPX version "2.0"`,
+		},
+		{
+			desc: "bad time",
+			data: "<gpx version=\"1.1\">\n<trk><trkseg>\n<trkpt><time>yesterday</time></trkpt>\n</trkseg></trk></gpx>",
+			want: "line 3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, _, err := ParseGPX(strings.NewReader(tc.data))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q doesn't mention %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestExportGPX(t *testing.T) {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	points := []TrackPoint{
+		{Time: start, HeartRate: 90},
+		{Time: start.Add(time.Second), Lat: 37.5, Lng: -122.25, Elevation: 12, HeartRate: 100, Cadence: 80, Power: 200},
+		{Time: start.Add(2 * time.Second), Lat: 37.501, Lng: -122.25},
+	}
+
+	var buf strings.Builder
+	if err := ExportGPX(&buf, "Morning <Ride>", points); err != nil {
+		t.Fatalf("can't export: %v", err)
+	}
+
+	got, meta, err := ParseGPX(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("can't parse the export: %v\n%s", err, buf.String())
+	}
+	if meta.Name != "Morning <Ride>" || meta.Version != "1.1" {
+		t.Errorf("bad metadata: %+v", meta)
+	}
+	want := []TrackPoint{points[1], points[2]}
+	want[1].Distance = got[1].Distance
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad round trip: -want +got\n%s", diff)
+	}
+	// The last point is at sea level, which is still an elevation.
+	if n := strings.Count(buf.String(), "<ele>"); n != 2 {
+		t.Errorf("want 2 elevations, got %d:\n%s", n, buf.String())
+	}
+
+	buf.Reset()
+	if err := ExportGPX(&buf, "Trainer", []TrackPoint{{Lat: 37.5, Lng: -122.25}}); err != nil {
+		t.Fatalf("can't export: %v", err)
+	}
+	if strings.Contains(buf.String(), "<ele>") {
+		t.Errorf("want no elevations for a track without any, got:\n%s", buf.String())
+	}
+}
+
+func TestExportRouteGPX(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/routes/31330404.json": getTestData("route.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	tests := []struct {
+		desc   string
+		opts   RouteGPXOptions
+		golden string
+	}{
+		{desc: "track", golden: "route.gpx"},
+		{desc: "route", opts: RouteGPXOptions{AsRoute: true}, golden: "route_rte.gpx"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := r.ExportRouteGPX(31330404, &buf, tc.opts); err != nil {
+				t.Fatalf("can't export: %v", err)
+			}
+			checkGolden(t, tc.golden, buf.Bytes())
+		})
+	}
+
+	if err := r.ExportRouteGPX(1, io.Discard, RouteGPXOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for a missing route, got %v", err)
+	}
+}
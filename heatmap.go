@@ -0,0 +1,226 @@
+package goride
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// heatmapMaxGap is the longest segment, in meters, that Heatmap fills in
+// between points. Longer ones are GPS gaps, and only their ends count.
+const heatmapMaxGap = 1000
+
+// metersPerDegree is the length of a degree of latitude.
+const metersPerDegree = earthRadius * math.Pi / 180
+
+// HeatmapOptions tune Heatmap.
+type HeatmapOptions struct {
+	// OncePerRide counts each track at most once per cell, even if it passed
+	// through it several times.
+	OncePerRide bool
+}
+
+// HeatCell is a cell in a HeatGrid. Rows count north from the equator, and
+// columns east from the prime meridian.
+type HeatCell struct {
+	Row int32
+	Col int32
+}
+
+// HeatGrid is the number of visits to each cell of a grid. Cells that were
+// never visited aren't in Counts.
+type HeatGrid struct {
+	// CellSize is the size of the cells' sides, in meters. Cells are square
+	// at RefLat, and get narrower away from the equator.
+	CellSize float64
+	RefLat   float64
+	Counts   map[HeatCell]int
+	// Max is the highest count in any cell.
+	Max int
+	// Bounds is the box around all the tracks' points.
+	Bounds BoundingBox
+}
+
+// Heatmap counts how often the tracks visited each cell of a grid, with cells
+// cellSizeMeters square at the whole degree of latitude the first track
+// starts at. A track entering a cell is a visit, so a stop
+// or a slow climb doesn't count more than riding through. Segments between
+// points are filled in, so cells smaller than the distance between points
+// still make a continuous line. Points without a position are ignored.
+func Heatmap(tracks [][]TrackPoint, cellSizeMeters float64, opts HeatmapOptions) (*HeatGrid, error) {
+	if cellSizeMeters <= 0 {
+		return nil, fmt.Errorf("invalid cell size %f", cellSizeMeters)
+	}
+
+	g := &HeatGrid{
+		CellSize: cellSizeMeters,
+		Counts:   map[HeatCell]int{},
+		Bounds:   BoundingBox{SW: LatLng{Lat: 1, Lng: 1}},
+	}
+	var seen map[HeatCell]bool
+	if opts.OncePerRide {
+		seen = map[HeatCell]bool{}
+	}
+	for _, track := range tracks {
+		clear(seen)
+		var prev *TrackPoint
+		last := HeatCell{math.MaxInt32, math.MaxInt32}
+		visit := func(c HeatCell) {
+			if c == last {
+				return
+			}
+			last = c
+			if seen != nil {
+				if seen[c] {
+					return
+				}
+				seen[c] = true
+			}
+			g.Counts[c]++
+			g.Max = max(g.Max, g.Counts[c])
+		}
+
+		for i := range track {
+			p := &track[i]
+			if p.Lat == 0 && p.Lng == 0 {
+				continue
+			}
+			if g.Bounds.Empty() {
+				g.RefLat = math.Round(p.Lat)
+			}
+			g.extend(p.Lat, p.Lng)
+			if prev != nil {
+				d := haversine(prev.Lat, prev.Lng, p.Lat, p.Lng)
+				if d <= heatmapMaxGap {
+					// Step at most half a cell at a time, so no cell is skipped.
+					n := int(math.Ceil(2 * d / cellSizeMeters))
+					for k := 1; k < n; k++ {
+						f := float64(k) / float64(n)
+						visit(g.Cell(prev.Lat+f*(p.Lat-prev.Lat), prev.Lng+f*(p.Lng-prev.Lng)))
+					}
+				}
+			}
+			visit(g.Cell(p.Lat, p.Lng))
+			prev = p
+		}
+	}
+
+	return g, nil
+}
+
+func (g *HeatGrid) extend(lat, lng float64) {
+	p := LatLng{Lat: float32(lat), Lng: float32(lng)}
+	if g.Bounds.Empty() {
+		g.Bounds = BoundingBox{SW: p, NE: p}
+		return
+	}
+	g.Bounds.SW.Lat = min(g.Bounds.SW.Lat, p.Lat)
+	g.Bounds.SW.Lng = min(g.Bounds.SW.Lng, p.Lng)
+	g.Bounds.NE.Lat = max(g.Bounds.NE.Lat, p.Lat)
+	g.Bounds.NE.Lng = max(g.Bounds.NE.Lng, p.Lng)
+}
+
+// rowHeight is the height of the grid's rows, in degrees.
+func (g *HeatGrid) rowHeight() float64 {
+	return g.CellSize / metersPerDegree
+}
+
+// colWidth is the width of the grid's columns, in degrees.
+func (g *HeatGrid) colWidth() float64 {
+	lat := math.Max(math.Min(g.RefLat, 89), -89)
+	return g.rowHeight() / math.Cos(lat*math.Pi/180)
+}
+
+// Cell returns the cell a position is in.
+func (g *HeatGrid) Cell(lat, lng float64) HeatCell {
+	return HeatCell{
+		Row: int32(math.Floor(lat / g.rowHeight())),
+		Col: int32(math.Floor(lng / g.colWidth())),
+	}
+}
+
+// CellBounds returns the corners of a cell.
+func (g *HeatGrid) CellBounds(c HeatCell) BoundingBox {
+	h, w := g.rowHeight(), g.colWidth()
+	return BoundingBox{
+		SW: LatLng{Lat: float32(float64(c.Row) * h), Lng: float32(float64(c.Col) * w)},
+		NE: LatLng{Lat: float32(float64(c.Row+1) * h), Lng: float32(float64(c.Col+1) * w)},
+	}
+}
+
+// cells returns the visited cells, south to north and west to east, so
+// exports are stable.
+func (g *HeatGrid) cells() []HeatCell {
+	res := make([]HeatCell, 0, len(g.Counts))
+	for c := range g.Counts {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Row != res[j].Row {
+			return res[i].Row < res[j].Row
+		}
+		return res[i].Col < res[j].Col
+	})
+
+	return res
+}
+
+// WriteGeoJSON writes the visited cells as a FeatureCollection of Polygons,
+// with "count" properties.
+func (g *HeatGrid) WriteGeoJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"type":"FeatureCollection","features":[`)
+	for i, c := range g.cells() {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		var f geoJSONFeature
+		f.Type = "Feature"
+		f.Properties = map[string]interface{}{"count": g.Counts[c]}
+		f.Geometry.Type = "Polygon"
+		b := g.CellBounds(c)
+		f.Geometry.Coordinates = [][][2]float32{{
+			{b.SW.Lng, b.SW.Lat},
+			{b.NE.Lng, b.SW.Lat},
+			{b.NE.Lng, b.NE.Lat},
+			{b.SW.Lng, b.NE.Lat},
+			{b.SW.Lng, b.SW.Lat},
+		}}
+		data, err := json.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("error encoding cell %v: %w", c, err)
+		}
+		bw.Write(data)
+	}
+	bw.WriteString("]}\n")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error writing GeoJSON: %w", err)
+	}
+
+	return nil
+}
+
+// WriteCSV writes the visited cells, one per line, with their corners and
+// count.
+func (g *HeatGrid) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"sw_lat", "sw_lng", "ne_lat", "ne_lng", "count"})
+	f := func(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) }
+	for _, c := range g.cells() {
+		b := g.CellBounds(c)
+		cw.Write([]string{f(b.SW.Lat), f(b.SW.Lng), f(b.NE.Lat), f(b.NE.Lng), strconv.Itoa(g.Counts[c])})
+	}
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return nil
+}
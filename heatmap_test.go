@@ -0,0 +1,163 @@
+package goride
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeatmap(t *testing.T) {
+	grid := &HeatGrid{CellSize: 100, RefLat: 46}
+	// at is the middle of a cell, near Portland.
+	at := func(row, col int32) TrackPoint {
+		c := grid.CellBounds(HeatCell{Row: row, Col: col}).Center()
+		return TrackPoint{Lat: float64(c.Lat), Lng: float64(c.Lng)}
+	}
+	const r, c = 50600, -94670
+	line := []TrackPoint{at(r, c), at(r, c+1), at(r, c+2), at(r, c+3)}
+	outAndBack := []TrackPoint{at(r, c), at(r+1, c), at(r+2, c), at(r+1, c), at(r, c)}
+
+	tests := []struct {
+		desc    string
+		tracks  [][]TrackPoint
+		opts    HeatmapOptions
+		want    map[HeatCell]int
+		wantMax int
+	}{
+		{
+			desc:   "overlapping",
+			tracks: [][]TrackPoint{line, line[1:3]},
+			want: map[HeatCell]int{
+				{r, c}: 1, {r, c + 1}: 2, {r, c + 2}: 2, {r, c + 3}: 1,
+			},
+			wantMax: 2,
+		},
+		{
+			desc:    "out and back",
+			tracks:  [][]TrackPoint{outAndBack},
+			want:    map[HeatCell]int{{r, c}: 2, {r + 1, c}: 2, {r + 2, c}: 1},
+			wantMax: 2,
+		},
+		{
+			desc:    "out and back once per ride",
+			tracks:  [][]TrackPoint{outAndBack, outAndBack},
+			opts:    HeatmapOptions{OncePerRide: true},
+			want:    map[HeatCell]int{{r, c}: 2, {r + 1, c}: 2, {r + 2, c}: 2},
+			wantMax: 2,
+		},
+		{
+			desc:    "stopped",
+			tracks:  [][]TrackPoint{{at(r, c), at(r, c), at(r, c), at(r, c+1)}},
+			want:    map[HeatCell]int{{r, c}: 1, {r, c + 1}: 1},
+			wantMax: 1,
+		},
+		{
+			desc:   "gaps filled",
+			tracks: [][]TrackPoint{{at(r, c), {}, at(r, c+4)}},
+			want: map[HeatCell]int{
+				{r, c}: 1, {r, c + 1}: 1, {r, c + 2}: 1, {r, c + 3}: 1, {r, c + 4}: 1,
+			},
+			wantMax: 1,
+		},
+		{
+			desc:    "long gap",
+			tracks:  [][]TrackPoint{{at(r, c), at(r, c+20)}},
+			want:    map[HeatCell]int{{r, c}: 1, {r, c + 20}: 1},
+			wantMax: 1,
+		},
+		{
+			desc:   "no positions",
+			tracks: [][]TrackPoint{{{}, {}}},
+			want:   map[HeatCell]int{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := Heatmap(tc.tracks, 100, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got.Counts); diff != "" {
+				t.Errorf("bad counts: -want +got\n%s", diff)
+			}
+			if got.Max != tc.wantMax {
+				t.Errorf("want max %d, got %d", tc.wantMax, got.Max)
+			}
+		})
+	}
+
+	if _, err := Heatmap(nil, 0, HeatmapOptions{}); err == nil {
+		t.Errorf("expected an error for a zero cell size")
+	}
+}
+
+func TestHeatGridCells(t *testing.T) {
+	tests := []struct {
+		desc     string
+		lat, lng float64
+	}{
+		{desc: "portland", lat: 45.5, lng: -122.6},
+		{desc: "equator", lat: 0.0001, lng: 0.0001},
+		{desc: "south", lat: -33.9, lng: 18.4},
+		{desc: "north", lat: 78.2, lng: 15.6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			g := &HeatGrid{CellSize: 250, RefLat: math.Round(tc.lat)}
+			b := g.CellBounds(g.Cell(tc.lat, tc.lng))
+			if !b.Contains(LatLng{Lat: float32(tc.lat), Lng: float32(tc.lng)}) {
+				t.Errorf("%v isn't in its cell %v", []float64{tc.lat, tc.lng}, b)
+			}
+			// Cells are about square near the reference latitude.
+			h := b.SW.DistanceTo(LatLng{Lat: b.NE.Lat, Lng: b.SW.Lng})
+			w := b.SW.DistanceTo(LatLng{Lat: b.SW.Lat, Lng: b.NE.Lng})
+			for _, d := range []float64{h, w} {
+				if d < 240 || d > 260 {
+					t.Errorf("cell %v isn't 250m square: %.1f x %.1f", b, w, h)
+				}
+			}
+		})
+	}
+}
+
+func TestHeatGridExport(t *testing.T) {
+	tracks := [][]TrackPoint{
+		{{Lat: 45.5, Lng: -122.6}, {Lat: 45.5015, Lng: -122.6}},
+		{{Lat: 45.5015, Lng: -122.6}},
+	}
+	g, err := Heatmap(tracks, 100, HeatmapOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(BoundingBox{SW: LatLng{45.5, -122.6}, NE: LatLng{45.5015, -122.6}}, g.Bounds); diff != "" {
+		t.Errorf("bad bounds: -want +got\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	checkGolden(t, "heatmap.csv", buf.Bytes())
+	if n := strings.Count(buf.String(), "\n"); n != len(g.Counts)+1 {
+		t.Errorf("want %d CSV lines, got %d", len(g.Counts)+1, n)
+	}
+
+	buf.Reset()
+	if err := g.WriteGeoJSON(&buf); err != nil {
+		t.Fatalf("WriteGeoJSON: %v", err)
+	}
+	checkGolden(t, "heatmap.geojson", buf.Bytes())
+	features := validateGeoJSON(t, buf.Bytes())
+	var total float64
+	for _, f := range features {
+		total += f["properties"].(map[string]interface{})["count"].(float64)
+	}
+	if want := 3; len(features) != want || total != 4 {
+		t.Errorf("want %d features with 4 visits, got %d with %.0f", want, len(features), total)
+	}
+}
@@ -0,0 +1,67 @@
+package goride
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithHints controls whether errors from common misconfigurations get a hint
+// on how to fix them appended. Hints are on by default.
+func WithHints(on bool) Option {
+	return func(r *RWGPS) {
+		r.hints = on
+	}
+}
+
+type hintedError struct {
+	err   error
+	hints []string
+}
+
+func (e *hintedError) Error() string {
+	return fmt.Sprintf("%v (hint: %s)", e.err, strings.Join(e.hints, "; "))
+}
+
+func (e *hintedError) Unwrap() error {
+	return e.err
+}
+
+// withHints recognizes errors caused by common setup problems, and adds hints
+// to them. login is set if err came from logging in with the configured
+// credentials.
+func (r *RWGPS) withHints(err error, login bool) error {
+	if err == nil || !r.hints {
+		return err
+	}
+
+	var hints []string
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusUnauthorized {
+		body := strings.ToLower(se.body)
+		if strings.Contains(body, "apikey") || strings.Contains(body, "api key") {
+			hints = append(hints, "the API key was rejected, check that the name in the [Auth] section of your ini matches your RWGPS API key")
+		}
+	}
+
+	var certErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) && certErr.Reason == x509.Expired {
+		hints = append(hints, "the server's certificate looks expired or not yet valid, check that your system clock is correct")
+	}
+
+	if login && r.config != nil {
+		for _, kv := range [][2]string{{"email", r.config.Email}, {"password", r.configValue(&r.config.Password)}} {
+			if strings.ContainsAny(kv[1], `"'`) {
+				hints = append(hints, fmt.Sprintf("your ini value for %s appears to contain unescaped quotes, wrap it in double quotes", kv[0]))
+			}
+		}
+	}
+
+	if len(hints) == 0 {
+		return err
+	}
+
+	return &hintedError{err: err, hints: hints}
+}
@@ -0,0 +1,106 @@
+package goride
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHints(t *testing.T) {
+	badKey := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": "Invalid apikey"}`)
+	}))
+	defer badKey.Close()
+
+	server := startServer(t, nil, nil)
+	defer server.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, getTestData("current.json"))
+	}))
+	defer tlsServer.Close()
+
+	tests := []struct {
+		desc     string
+		setup    func() *RWGPS
+		wantHint string
+	}{
+		{
+			desc:     "bad API key name",
+			setup:    func() *RWGPS { return testObj(badKey.URL) },
+			wantHint: "[Auth] section",
+		},
+		{
+			desc: "stray quotes in the password",
+			setup: func() *RWGPS {
+				r := testObj(server.URL)
+				r.config.Password = `"supers3cret`
+				return r
+			},
+			wantHint: "ini value for password appears to contain unescaped quotes",
+		},
+		{
+			desc: "skewed clock",
+			setup: func() *RWGPS {
+				r := testObj(tlsServer.URL)
+				r.client.http = tlsServer.Client()
+				transport := r.client.http.Transport.(*http.Transport)
+				transport.TLSClientConfig.Time = func() time.Time {
+					return time.Date(2150, 1, 1, 0, 0, 0, 0, time.UTC)
+				}
+				return r
+			},
+			wantHint: "system clock",
+		},
+		{
+			desc: "hints disabled",
+			setup: func() *RWGPS {
+				r := testObj(badKey.URL)
+				WithHints(false)(r)
+				return r
+			},
+		},
+		{
+			desc: "bad password without quotes",
+			setup: func() *RWGPS {
+				r := testObj(server.URL)
+				r.config.Password = "12345"
+				return r
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := tc.setup()
+			_, err := r.GetCurrentUser()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if tc.wantHint == "" {
+				if strings.Contains(err.Error(), "hint:") {
+					t.Errorf("unexpected hint: %v", err)
+				}
+				return
+			}
+			if !strings.Contains(err.Error(), "hint:") || !strings.Contains(err.Error(), tc.wantHint) {
+				t.Errorf("expected hint %q, got %v", tc.wantHint, err)
+			}
+		})
+	}
+
+	// Make sure the clock hint wasn't a fluke of the TLS setup.
+	r := testObj(tlsServer.URL)
+	r.client.http = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs: tlsServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+	}}}
+	if _, err := r.GetCurrentUser(); err != nil {
+		t.Errorf("unexpected error with the correct time: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Fractions of the max heart rate where zones 2 to 5 start.
+var maxHRZones = []float64{0.6, 0.7, 0.8, 0.9}
+
+// ZonesFromMaxHR returns the usual five heart rate zones for a max heart
+// rate, starting at 60%, 70%, 80% and 90% of it.
+func ZonesFromMaxHR(maxHR int) []int {
+	res := make([]int, len(maxHRZones))
+	for i, f := range maxHRZones {
+		res[i] = int(math.Round(f * float64(maxHR)))
+	}
+	return res
+}
+
+// HeartRateZones returns the zones from the config: HeartRateZones if set,
+// otherwise ZonesFromMaxHR for MaxHeartRate. It's nil if neither is set.
+func (r *RWGPS) HeartRateZones() []int {
+	switch {
+	case len(r.config.HeartRateZones) > 0:
+		return append([]int(nil), r.config.HeartRateZones...)
+	case r.config.MaxHeartRate > 0:
+		return ZonesFromMaxHR(r.config.MaxHeartRate)
+	}
+	return nil
+}
+
+// TimeInZones returns how long a track spent in each heart rate zone. zones
+// are where each zone after the first starts, in bpm, so there's one more
+// zone than boundaries: zones of 120 and 150 count time below 120, from 120
+// to 149, and from 150 up. Each stretch between points counts towards the
+// zone of the heart rate at its start. Stretches that start without a heart
+// rate, or are longer than a minute because of a gap in the recording, aren't
+// counted.
+func TimeInZones(points []TrackPoint, zones []int) ([]time.Duration, error) {
+	if err := checkZones(zones); err != nil {
+		return nil, err
+	}
+
+	res := make([]time.Duration, len(zones)+1)
+	for i := 1; i < len(points); i++ {
+		hr := points[i-1].HeartRate
+		dt := points[i].Time.Sub(points[i-1].Time)
+		if hr <= 0 || dt <= 0 || dt > splitMaxGap {
+			continue
+		}
+		res[zoneFor(hr, zones)] += dt
+	}
+
+	return res, nil
+}
+
+// checkZones returns an error if zones aren't positive and increasing.
+func checkZones(zones []int) error {
+	if len(zones) == 0 {
+		return fmt.Errorf("no heart rate zones")
+	}
+	for i, z := range zones {
+		if z <= 0 || (i > 0 && z <= zones[i-1]) {
+			return fmt.Errorf("bad heart rate zones %v: must be positive and increasing", zones)
+		}
+	}
+	return nil
+}
+
+// zoneFor returns the index of hr's zone.
+func zoneFor(hr float64, zones []int) int {
+	for i, z := range zones {
+		if hr < float64(z) {
+			return i
+		}
+	}
+	return len(zones)
+}
+
+// ZonePercentages returns each zone's share of the total time, in percent.
+// They're all 0 if there's no time at all.
+func ZonePercentages(times []time.Duration) []float64 {
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	res := make([]float64, len(times))
+	if total == 0 {
+		return res
+	}
+	for i, t := range times {
+		res[i] = float64(t) / float64(total) * 100
+	}
+	return res
+}
+
+// HeartRateStats returns the average and max heart rate of the points that
+// have one, to check against the ride's Metrics. Both are 0 if none do.
+func HeartRateStats(points []TrackPoint) (avg, maxHR float64) {
+	var sum float64
+	var n int
+	for _, p := range points {
+		if p.HeartRate <= 0 {
+			continue
+		}
+		sum += p.HeartRate
+		n++
+		maxHR = math.Max(maxHR, p.HeartRate)
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / float64(n), maxHR
+}
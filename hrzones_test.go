@@ -0,0 +1,191 @@
+package goride
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// hrStep is a heart rate held for a while.
+type hrStep struct {
+	hr  float64
+	dur time.Duration
+}
+
+// hrTrace is a track with a point every 10 seconds, going through the steps.
+func hrTrace(steps ...hrStep) []TrackPoint {
+	at := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	var res []TrackPoint
+	for _, s := range steps {
+		for d := time.Duration(0); d < s.dur; d += 10 * time.Second {
+			res = append(res, TrackPoint{Time: at, HeartRate: s.hr})
+			at = at.Add(10 * time.Second)
+		}
+	}
+	return append(res, TrackPoint{Time: at})
+}
+
+func TestTimeInZones(t *testing.T) {
+	zones := []int{120, 140, 160, 175}
+	// One, two, three, four and five minutes in zones 1 to 5.
+	steady := hrTrace(
+		hrStep{100, time.Minute},
+		hrStep{130, 2 * time.Minute},
+		hrStep{150, 3 * time.Minute},
+		hrStep{165, 4 * time.Minute},
+		hrStep{180, 5 * time.Minute},
+	)
+	withGaps := append([]TrackPoint(nil), steady...)
+	// A 10 minute gap in the recording, at the end of the first minute.
+	for i := 6; i < len(withGaps); i++ {
+		withGaps[i].Time = withGaps[i].Time.Add(10 * time.Minute)
+	}
+	// And a minute without heart rate in zone 3.
+	for i := 18; i < 24; i++ {
+		withGaps[i].HeartRate = 0
+	}
+
+	tests := []struct {
+		desc    string
+		points  []TrackPoint
+		zones   []int
+		want    []time.Duration
+		wantPct []float64
+		wantErr bool
+	}{
+		{
+			desc:    "each zone",
+			points:  steady,
+			zones:   zones,
+			want:    []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute},
+			wantPct: []float64{100.0 / 15, 200.0 / 15, 20, 400.0 / 15, 500.0 / 15},
+		},
+		{
+			desc:    "gaps and missing heart rate",
+			points:  withGaps,
+			zones:   zones,
+			want:    []time.Duration{50 * time.Second, 2 * time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute},
+			wantPct: []float64{50 / 8.3, 120 / 8.3, 120 / 8.3, 240 / 8.3, 300 / 8.3},
+		},
+		{
+			desc:    "boundaries",
+			points:  hrTrace(hrStep{119, time.Minute}, hrStep{120, time.Minute}, hrStep{175, time.Minute}),
+			zones:   zones,
+			want:    []time.Duration{time.Minute, time.Minute, 0, 0, time.Minute},
+			wantPct: []float64{100.0 / 3, 100.0 / 3, 0, 0, 100.0 / 3},
+		},
+		{
+			desc:    "no heart rate",
+			points:  hrTrace(hrStep{0, time.Minute}),
+			zones:   zones,
+			want:    []time.Duration{0, 0, 0, 0, 0},
+			wantPct: []float64{0, 0, 0, 0, 0},
+		},
+		{
+			desc:    "no zones",
+			points:  steady,
+			wantErr: true,
+		},
+		{
+			desc:    "zones out of order",
+			points:  steady,
+			zones:   []int{120, 110},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := TimeInZones(tc.points, tc.zones)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad time in zones: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantPct, ZonePercentages(got), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad percentages: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHeartRateStats(t *testing.T) {
+	tests := []struct {
+		desc    string
+		points  []TrackPoint
+		wantAvg float64
+		wantMax float64
+	}{
+		{
+			desc:    "trace",
+			points:  hrTrace(hrStep{100, time.Minute}, hrStep{0, time.Minute}, hrStep{160, 2 * time.Minute}),
+			wantAvg: 140,
+			wantMax: 160,
+		},
+		{
+			desc:   "no heart rate",
+			points: hrTrace(hrStep{0, time.Minute}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			avg, max := HeartRateStats(tc.points)
+			if avg != tc.wantAvg || max != tc.wantMax {
+				t.Errorf("want %v/%v, got %v/%v", tc.wantAvg, tc.wantMax, avg, max)
+			}
+		})
+	}
+}
+
+func TestHeartRateZonesConfig(t *testing.T) {
+	tests := []struct {
+		desc    string
+		athlete []string
+		want    []int
+		wantErr bool
+	}{
+		{desc: "none"},
+		{desc: "max", athlete: []string{"max_hr = 190"}, want: []int{114, 133, 152, 171}},
+		{desc: "zones", athlete: []string{"max_hr = 190", "hr_zones = 120, 140, 160"}, want: []int{120, 140, 160}},
+		{desc: "bad max", athlete: []string{"max_hr = fast"}, wantErr: true},
+		{desc: "bad zones", athlete: []string{"hr_zones = 140, 120"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			lines := []string{"[Auth]", "email = test@example.com"}
+			if tc.athlete != nil {
+				lines = append(lines, "[Athlete]")
+				lines = append(lines, tc.athlete...)
+			}
+			path := filepath.Join(t.TempDir(), "cfg.ini")
+			writeTestFile(t, path, strings.Join(lines, "\n"))
+
+			r, err := New(path)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got zones %v", r.HeartRateZones())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, r.HeartRateZones()); diff != "" {
+				t.Errorf("bad zones: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package goride
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zigdon/goride/units"
+)
+
+const (
+	icsMaxLine    = 75
+	icsDateTime   = "20060102T150405"
+	icsDate       = "20060102"
+	icsWebsiteURL = "https://ridewithgps.com"
+	// icsOpenEndedYears is how far ahead a timezone's changes are written for
+	// events that repeat forever. Clients keep the last offset after that.
+	icsOpenEndedYears = 5
+)
+
+// ExportICS writes the rides as an iCalendar file, one event per ride. Times
+// are in the ride's own timezone where it's known, which the file describes,
+// and UTC otherwise.
+func ExportICS(w io.Writer, rides []*RideSlim) error {
+	return writeICS(w, func(ics *icsWriter) {
+		for _, r := range rides {
+			start := r.LocalDepartedAt()
+			ics.prop("BEGIN", "VEVENT")
+			ics.prop("UID", fmt.Sprintf("trip-%d@ridewithgps.com", r.ID))
+			ics.prop("DTSTAMP", icsUTC(firstSet(r.UpdatedAt, r.CreatedAt, r.DepartedAt)))
+			ics.timeProp("DTSTART", start, r.TimeZone)
+			ics.timeProp("DTEND", start.Add(time.Duration(r.Duration)*time.Second), r.TimeZone)
+			ics.prop("SUMMARY", icsEscape(fmt.Sprintf("%s (%s)", r.Name, units.Metric.FormatDistance(units.Distance(r.Distance)))))
+			if r.Description != "" {
+				ics.prop("DESCRIPTION", icsEscape(r.Description))
+			}
+			if r.FirstLat != 0 || r.FirstLng != 0 {
+				ics.prop("GEO", fmt.Sprintf("%.6f;%.6f", r.FirstLat, r.FirstLng))
+			}
+			ics.prop("URL", fmt.Sprintf("%s/trips/%d", icsWebsiteURL, r.ID))
+			ics.prop("END", "VEVENT")
+		}
+	})
+}
+
+// ExportEventsICS writes club events as an iCalendar file. All day events
+// take up their whole day, and repeating events get a recurrence rule.
+func ExportEventsICS(w io.Writer, events []*Event) error {
+	return writeICS(w, func(ics *icsWriter) {
+		for _, e := range events {
+			start := e.LocalStartsAt()
+			ics.prop("BEGIN", "VEVENT")
+			ics.prop("UID", fmt.Sprintf("event-%d@ridewithgps.com", e.ID))
+			ics.prop("DTSTAMP", icsUTC(e.StartsAt))
+			if e.AllDay {
+				ics.prop("DTSTART;VALUE=DATE", start.Format(icsDate))
+				ics.prop("DTEND;VALUE=DATE", start.AddDate(0, 0, 1).Format(icsDate))
+			} else {
+				ics.timeProp("DTSTART", start, e.TimeZone)
+			}
+			if rule := icsRRule(e.Recurrence, e.AllDay); rule != "" {
+				ics.prop("RRULE", rule)
+				if !e.AllDay {
+					until := e.Recurrence.Until
+					if until.IsZero() {
+						until = start.AddDate(icsOpenEndedYears, 0, 0)
+					}
+					ics.useZone(e.TimeZone, until)
+				}
+			}
+			ics.prop("SUMMARY", icsEscape(e.Name))
+			if e.Description != "" {
+				ics.prop("DESCRIPTION", icsEscape(e.Description))
+			}
+			if e.Location != "" {
+				ics.prop("LOCATION", icsEscape(e.Location))
+			}
+			ics.prop("URL", fmt.Sprintf("%s/events/%d", icsWebsiteURL, e.ID))
+			ics.prop("END", "VEVENT")
+		}
+	})
+}
+
+func writeICS(w io.Writer, body func(*icsWriter)) error {
+	// The timezones go before the events, but are only known after them.
+	var buf bytes.Buffer
+	events := &icsWriter{bw: bufio.NewWriter(&buf), zones: make(map[string]*icsZone)}
+	body(events)
+	events.bw.Flush()
+
+	ics := &icsWriter{bw: bufio.NewWriter(w)}
+	ics.prop("BEGIN", "VCALENDAR")
+	ics.prop("VERSION", "2.0")
+	ics.prop("PRODID", "-//zigdon//goride//EN")
+	ics.prop("CALSCALE", "GREGORIAN")
+	var names []string
+	for name := range events.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ics.timezone(name, events.zones[name])
+	}
+	ics.bw.Write(buf.Bytes())
+	ics.prop("END", "VCALENDAR")
+
+	if err := ics.bw.Flush(); err != nil {
+		return fmt.Errorf("error writing iCalendar: %w", err)
+	}
+
+	return nil
+}
+
+type icsWriter struct {
+	bw *bufio.Writer
+	// zones are the timezones the times were written in, by name.
+	zones map[string]*icsZone
+}
+
+// icsZone is a timezone, and the times it's needed for.
+type icsZone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// prop writes a content line, folded so no line is longer than 75 octets.
+// Folds never split a UTF-8 character.
+func (w *icsWriter) prop(name, value string) {
+	line := name + ":" + value
+	limit := icsMaxLine
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.bw.WriteString(line[:cut])
+		w.bw.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation's length.
+		limit = icsMaxLine - 1
+	}
+	w.bw.WriteString(line)
+	w.bw.WriteString("\r\n")
+}
+
+// timeProp writes t as a local time in the named timezone, or in UTC if the
+// name isn't one Go knows.
+func (w *icsWriter) timeProp(name string, t time.Time, tz string) {
+	if z := w.useZone(tz, t); z != nil {
+		w.prop(name+";TZID="+tz, t.In(z.loc).Format(icsDateTime))
+		return
+	}
+	w.prop(name, icsUTC(t))
+}
+
+// useZone notes that the named timezone is needed up to t, and returns it, or
+// nil if it isn't one Go knows.
+func (w *icsWriter) useZone(tz string, t time.Time) *icsZone {
+	if tz == "" {
+		return nil
+	}
+	z, ok := w.zones[tz]
+	if !ok {
+		loc, err := loadLocation(tz)
+		if err != nil {
+			return nil
+		}
+		z = &icsZone{loc: loc, from: t, to: t}
+		w.zones[tz] = z
+	}
+	if t.Before(z.from) {
+		z.from = t
+	}
+	if t.After(z.to) {
+		z.to = t
+	}
+
+	return z
+}
+
+// timezone writes a VTIMEZONE with the offsets in effect from z.from to z.to,
+// each from the change that started it, as every TZID has to have one.
+func (w *icsWriter) timezone(name string, z *icsZone) {
+	w.prop("BEGIN", "VTIMEZONE")
+	w.prop("TZID", name)
+	t := z.from.In(z.loc)
+	for {
+		start, end := t.ZoneBounds()
+		abbrev, offset := t.Zone()
+		from := offset
+		if start.IsZero() {
+			// The zone never changed, so it's in effect from any time.
+			start = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+		} else {
+			_, from = start.Add(-time.Second).In(z.loc).Zone()
+		}
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		w.prop("BEGIN", kind)
+		// The start is in the local time before the change.
+		w.prop("DTSTART", start.In(time.FixedZone("", from)).Format(icsDateTime))
+		w.prop("TZOFFSETFROM", icsOffset(from))
+		w.prop("TZOFFSETTO", icsOffset(offset))
+		w.prop("TZNAME", abbrev)
+		w.prop("END", kind)
+		if end.IsZero() || end.After(z.to) {
+			break
+		}
+		t = end.In(z.loc)
+	}
+	w.prop("END", "VTIMEZONE")
+}
+
+// icsOffset formats a UTC offset in seconds, e.g. "-0700".
+func icsOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	if s := offset % 60; s != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, offset/3600, offset/60%60, s)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset/60%60)
+}
+
+func icsUTC(t time.Time) string {
+	return t.UTC().Format(icsDateTime) + "Z"
+}
+
+var icsEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// icsEscape escapes a TEXT value.
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// icsRRule returns the recurrence rule for r, or "" if it doesn't repeat in a
+// way iCalendar knows about. UNTIL has to match the start's type, so it's a
+// date for all day events.
+func icsRRule(r *Recurrence, allDay bool) string {
+	if r == nil {
+		return ""
+	}
+	freq := strings.ToUpper(r.Frequency)
+	switch freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return ""
+	}
+	rule := "FREQ=" + freq
+	if r.Interval > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", r.Interval)
+	}
+	switch {
+	case r.Until.IsZero():
+	case allDay:
+		rule += ";UNTIL=" + r.Until.Format(icsDate)
+	default:
+		rule += ";UNTIL=" + icsUTC(r.Until)
+	}
+
+	return rule
+}
+
+// firstSet returns the first of ts that isn't zero.
+func firstSet(ts ...time.Time) time.Time {
+	for _, t := range ts {
+		if !t.IsZero() {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,268 @@
+package goride
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var icsRides = []*RideSlim{
+	{
+		ID:          94,
+		Name:        "Coffee, donuts; then home",
+		Description: "Long loop past the lake and over the ridge, back through town. Crème brûlée at the café, then a headwind the whole way home.\nGood day.",
+		DepartedAt:  time.Date(2023, 6, 3, 15, 30, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2023, 6, 4, 1, 2, 3, 0, time.UTC),
+		TimeZone:    "America/Los_Angeles",
+		Duration:    3*3600 + 15*60,
+		Distance:    80467,
+		FirstLat:    45.396324,
+		FirstLng:    -122.726616,
+	},
+	{
+		ID:         95,
+		Name:       `Trainer \ zwift`,
+		DepartedAt: time.Date(2023, 12, 24, 18, 0, 0, 0, time.UTC),
+		CreatedAt:  time.Date(2023, 12, 24, 19, 0, 0, 0, time.UTC),
+		Duration:   3600,
+		Distance:   30000,
+	},
+}
+
+var icsEvents = []*Event{
+	{
+		ID:          7,
+		Name:        "Saturday social",
+		Description: "No drop, coffee after.",
+		StartsAt:    time.Date(2023, 6, 3, 16, 0, 0, 0, time.UTC),
+		TimeZone:    "America/Los_Angeles",
+		Location:    "Pioneer Square, Portland",
+		Recurrence:  &Recurrence{Frequency: "weekly", Interval: 2, Until: time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)},
+	},
+	{
+		ID:         8,
+		Name:       "Century",
+		StartsAt:   time.Date(2023, 7, 15, 7, 0, 0, 0, time.UTC),
+		TimeZone:   "America/Los_Angeles",
+		AllDay:     true,
+		Recurrence: &Recurrence{Frequency: "yearly", Interval: 1, Until: time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)},
+	},
+}
+
+// readICS is a minimal iCalendar reader: it unfolds lines and returns the
+// properties of each VEVENT, with TEXT values unescaped.
+func readICS(t *testing.T, data []byte) []map[string]string {
+	t.Helper()
+	text := string(data)
+	if !strings.HasSuffix(text, "\r\n") {
+		t.Fatalf("file doesn't end with CRLF")
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n") {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Fatalf("line not CRLF terminated: %q", line)
+		}
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	unescape := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n")
+	var events []map[string]string
+	var cur map[string]string
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			t.Fatalf("bad content line %q", line)
+		}
+		switch {
+		case line == "BEGIN:VEVENT":
+			cur = map[string]string{}
+		case line == "END:VEVENT":
+			events = append(events, cur)
+			cur = nil
+		case cur != nil:
+			cur[name] = unescape.Replace(value)
+		}
+	}
+
+	return events
+}
+
+func TestExportICS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportICS(&buf, icsRides); err != nil {
+		t.Fatalf("ExportICS: %v", err)
+	}
+	checkGolden(t, "rides.ics", buf.Bytes())
+
+	want := []map[string]string{
+		{
+			"UID":                              "trip-94@ridewithgps.com",
+			"DTSTAMP":                          "20230604T010203Z",
+			"DTSTART;TZID=America/Los_Angeles": "20230603T083000",
+			"DTEND;TZID=America/Los_Angeles":   "20230603T114500",
+			"SUMMARY":                          "Coffee, donuts; then home (80.5 km)",
+			"DESCRIPTION":                      icsRides[0].Description,
+			"GEO":                              "45.396324;-122.726616",
+			"URL":                              "https://ridewithgps.com/trips/94",
+		},
+		{
+			"UID":     "trip-95@ridewithgps.com",
+			"DTSTAMP": "20231224T190000Z",
+			"DTSTART": "20231224T180000Z",
+			"DTEND":   "20231224T190000Z",
+			"SUMMARY": `Trainer \ zwift (30.0 km)`,
+			"URL":     "https://ridewithgps.com/trips/95",
+		},
+	}
+	if diff := cmp.Diff(want, readICS(t, buf.Bytes())); diff != "" {
+		t.Errorf("bad round trip: -want +got\n%s", diff)
+	}
+}
+
+func TestExportEventsICS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportEventsICS(&buf, icsEvents); err != nil {
+		t.Fatalf("ExportEventsICS: %v", err)
+	}
+	checkGolden(t, "events.ics", buf.Bytes())
+
+	want := []map[string]string{
+		{
+			"UID":                              "event-7@ridewithgps.com",
+			"DTSTAMP":                          "20230603T160000Z",
+			"DTSTART;TZID=America/Los_Angeles": "20230603T090000",
+			"RRULE":                            "FREQ=WEEKLY;INTERVAL=2;UNTIL=20230930T000000Z",
+			"SUMMARY":                          "Saturday social",
+			"DESCRIPTION":                      "No drop, coffee after.",
+			"LOCATION":                         "Pioneer Square, Portland",
+			"URL":                              "https://ridewithgps.com/events/7",
+		},
+		{
+			"UID":                "event-8@ridewithgps.com",
+			"DTSTAMP":            "20230715T070000Z",
+			"DTSTART;VALUE=DATE": "20230715",
+			"DTEND;VALUE=DATE":   "20230716",
+			"RRULE":              "FREQ=YEARLY;UNTIL=20250715",
+			"SUMMARY":            "Century",
+			"URL":                "https://ridewithgps.com/events/8",
+		},
+	}
+	if diff := cmp.Diff(want, readICS(t, buf.Bytes())); diff != "" {
+		t.Errorf("bad round trip: -want +got\n%s", diff)
+	}
+}
+
+func TestICSFolding(t *testing.T) {
+	tests := []struct {
+		desc  string
+		value string
+	}{
+		{desc: "short", value: "hello"},
+		{desc: "exactly 75", value: strings.Repeat("a", 75-len("SUMMARY:"))},
+		{desc: "long ascii", value: strings.Repeat("abcdefghij", 30)},
+		{desc: "multibyte", value: strings.Repeat("é", 100)},
+		{desc: "emoji", value: strings.Repeat("🚲", 50)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			ics := &icsWriter{bw: bufio.NewWriter(&buf)}
+			ics.prop("SUMMARY", tc.value)
+			ics.bw.Flush()
+
+			var unfolded strings.Builder
+			for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line %d is %d octets", i, len(line))
+				}
+				if i > 0 {
+					if !strings.HasPrefix(line, " ") {
+						t.Fatalf("continuation %d doesn't start with a space: %q", i, line)
+					}
+					line = line[1:]
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a character: %q", i, line)
+				}
+				unfolded.WriteString(line)
+			}
+			if diff := cmp.Diff("SUMMARY:"+tc.value, unfolded.String()); diff != "" {
+				t.Errorf("bad unfolding: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestICSTimezones(t *testing.T) {
+	rides := []*RideSlim{
+		{ID: 1, DepartedAt: time.Date(2023, 6, 3, 15, 30, 0, 0, time.UTC), TimeZone: "America/Los_Angeles"},
+		{ID: 2, DepartedAt: time.Date(2023, 12, 3, 15, 30, 0, 0, time.UTC), TimeZone: "America/Los_Angeles"},
+		{ID: 3, DepartedAt: time.Date(2023, 6, 3, 22, 0, 0, 0, time.UTC), TimeZone: "Asia/Kolkata"},
+		{ID: 4, DepartedAt: time.Date(2023, 6, 3, 22, 0, 0, 0, time.UTC), TimeZone: "Mars/Olympus_Mons"},
+	}
+	events := []*Event{
+		{ID: 1, StartsAt: time.Date(2023, 6, 3, 16, 0, 0, 0, time.UTC), TimeZone: "Europe/Paris", Recurrence: &Recurrence{Frequency: "weekly"}},
+	}
+
+	tests := []struct {
+		desc   string
+		export func(*bytes.Buffer) error
+		// want is the number of changes written for each zone.
+		want map[string]int
+	}{
+		{
+			desc:   "rides",
+			export: func(buf *bytes.Buffer) error { return ExportICS(buf, rides) },
+			want:   map[string]int{"America/Los_Angeles": 2, "Asia/Kolkata": 1},
+		},
+		{
+			desc:   "repeating forever",
+			export: func(buf *bytes.Buffer) error { return ExportEventsICS(buf, events) },
+			want:   map[string]int{"Europe/Paris": 2*icsOpenEndedYears + 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.export(&buf); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			got := map[string]int{}
+			used := map[string]bool{}
+			zone := ""
+			for _, line := range strings.Split(buf.String(), "\r\n") {
+				switch {
+				case strings.HasPrefix(line, "TZID:"):
+					zone = strings.TrimPrefix(line, "TZID:")
+				case line == "BEGIN:STANDARD" || line == "BEGIN:DAYLIGHT":
+					got[zone]++
+				case strings.Contains(line, ";TZID="):
+					name, _, _ := strings.Cut(strings.SplitN(line, ";TZID=", 2)[1], ":")
+					used[name] = true
+				}
+			}
+			for name := range used {
+				if got[name] == 0 {
+					t.Errorf("no VTIMEZONE for %q", name)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad timezones: -want +got\n%s", diff)
+			}
+		})
+	}
+}
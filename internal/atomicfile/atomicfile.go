@@ -0,0 +1,31 @@
+// Package atomicfile writes files so readers never see a partial write.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// TempPattern matches the temp files WriteFile uses, which are left behind if
+// it's interrupted, for cleaning them up.
+const TempPattern = "tmp-*"
+
+// WriteFile replaces path with data, through a temp file in the same
+// directory.
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), TempPattern)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,31 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.json")
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("WriteFile(%q): %v", data, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("can't read %q: %v", path, err)
+		}
+		if string(got) != data {
+			t.Errorf("want %q, got %q", data, got)
+		}
+	}
+
+	if tmps, _ := filepath.Glob(filepath.Join(dir, TempPattern)); len(tmps) != 0 {
+		t.Errorf("temp files left behind: %v", tmps)
+	}
+	if err := WriteFile(filepath.Join(dir, "missing", "file.json"), nil); err == nil {
+		t.Errorf("want an error for a missing dir")
+	}
+}
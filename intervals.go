@@ -0,0 +1,173 @@
+package goride
+
+import (
+	"fmt"
+	"time"
+)
+
+// IntervalOptions tune DetectIntervals. Zero durations use the defaults.
+type IntervalOptions struct {
+	// MinPower (watts) or MinHeartRate (bpm) is what an effort has to stay
+	// at or above. Power is used if both are set.
+	MinPower     float64
+	MinHeartRate float64
+	// MinDuration is the shortest effort that counts. Defaults to a minute.
+	MinDuration time.Duration
+	// Efforts with less than MaxRecovery between them are merged into one.
+	// Defaults to 10 seconds.
+	MaxRecovery time.Duration
+	// Laps, e.g. from ParseFIT, are used as the intervals instead if there
+	// are any, and the thresholds aren't needed.
+	Laps []FITSummary
+}
+
+func (o IntervalOptions) withDefaults() (IntervalOptions, error) {
+	if len(o.Laps) == 0 && o.MinPower <= 0 && o.MinHeartRate <= 0 {
+		return o, fmt.Errorf("invalid interval options: need a power or heart rate threshold, or laps")
+	}
+	if o.MinDuration < 0 || o.MaxRecovery < 0 {
+		return o, fmt.Errorf("invalid interval options %+v: durations can't be negative", o)
+	}
+	if o.MinDuration == 0 {
+		o.MinDuration = time.Minute
+	}
+	if o.MaxRecovery == 0 {
+		o.MaxRecovery = 10 * time.Second
+	}
+
+	return o, nil
+}
+
+// Interval is an effort or lap. Start and End are indexes into the track
+// points, Distance is in meters, and the averages are over time, with heart
+// rate only where there is one. Lap is set for intervals from lap markers.
+type Interval struct {
+	Start        int
+	End          int
+	StartTime    time.Time
+	Duration     time.Duration
+	Distance     float64
+	AvgPower     float64
+	AvgHeartRate float64
+	Lap          bool
+}
+
+// DetectIntervals finds the efforts in a track that stay above the power or
+// heart rate threshold for at least MinDuration, or returns the laps if there
+// are any. Each point's power and heart rate are held until the next point,
+// and stretches longer than a minute, from gaps in recording, end an effort.
+// The points must be in time order.
+func DetectIntervals(points []TrackPoint, opts IntervalOptions) ([]Interval, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	dist := trackDistances(points)
+	if len(opts.Laps) > 0 {
+		return lapIntervals(points, dist, opts.Laps), nil
+	}
+
+	above := func(p TrackPoint) bool {
+		if opts.MinPower > 0 {
+			return p.Power >= opts.MinPower
+		}
+		return p.HeartRate >= opts.MinHeartRate
+	}
+
+	// Find the runs of stretches above the threshold, merging the ones with
+	// short recoveries between them.
+	var runs [][2]int
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Time.Sub(points[i-1].Time)
+		if dt <= 0 || dt > splitMaxGap || !above(points[i-1]) {
+			continue
+		}
+		if n := len(runs); n > 0 {
+			last := &runs[n-1]
+			if last[1] == i-1 || points[i-1].Time.Sub(points[last[1]].Time) < opts.MaxRecovery {
+				last[1] = i
+				continue
+			}
+		}
+		runs = append(runs, [2]int{i - 1, i})
+	}
+
+	res := []Interval{}
+	for _, r := range runs {
+		if points[r[1]].Time.Sub(points[r[0]].Time) >= opts.MinDuration {
+			res = append(res, newInterval(points, dist, r[0], r[1]))
+		}
+	}
+
+	return res, nil
+}
+
+// lapIntervals returns an interval for each lap with points in it. Laps
+// without a start time start where the previous one ended.
+func lapIntervals(points []TrackPoint, dist []float64, laps []FITSummary) []Interval {
+	res := []Interval{}
+	var from time.Time
+	if len(points) > 0 {
+		from = points[0].Time
+	}
+	for _, l := range laps {
+		if !l.Start.IsZero() {
+			from = l.Start
+		}
+		to := from.Add(l.ElapsedTime)
+		start, end := -1, -1
+		for i, p := range points {
+			if p.Time.Before(from) {
+				continue
+			}
+			if p.Time.After(to) {
+				break
+			}
+			if start < 0 {
+				start = i
+			}
+			end = i
+		}
+		from = to
+		if start < 0 {
+			continue
+		}
+		in := newInterval(points, dist, start, end)
+		in.Lap = true
+		res = append(res, in)
+	}
+
+	return res
+}
+
+// newInterval returns the interval from point start to point end.
+func newInterval(points []TrackPoint, dist []float64, start, end int) Interval {
+	in := Interval{
+		Start:     start,
+		End:       end,
+		StartTime: points[start].Time,
+		Duration:  points[end].Time.Sub(points[start].Time),
+		Distance:  dist[end] - dist[start],
+	}
+
+	var energy, beats, total, hrTime float64
+	for i := start + 1; i <= end; i++ {
+		p := points[i-1]
+		dt := points[i].Time.Sub(p.Time).Seconds()
+		energy += p.Power * dt
+		total += dt
+		if p.HeartRate > 0 {
+			beats += p.HeartRate * dt
+			hrTime += dt
+		}
+	}
+	if total > 0 {
+		in.AvgPower = energy / total
+	}
+	if hrTime > 0 {
+		in.AvgHeartRate = beats / hrTime
+	}
+
+	return in
+}
@@ -0,0 +1,199 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// workoutStep is a stretch of a workout at a steady power and heart rate.
+type workoutStep struct {
+	dur   time.Duration
+	power float64
+	hr    float64
+}
+
+// workout is a track with a point a second going through the steps, at
+// 36km/h.
+func workout(steps ...workoutStep) []TrackPoint {
+	at := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	var res []TrackPoint
+	var dist float64
+	for _, s := range steps {
+		for d := time.Duration(0); d < s.dur; d += time.Second {
+			res = append(res, TrackPoint{Time: at, Distance: dist, Power: s.power, HeartRate: s.hr})
+			at = at.Add(time.Second)
+			dist += 10
+		}
+	}
+	return append(res, TrackPoint{Time: at, Distance: dist})
+}
+
+// fourByFive is 10 minutes of warmup, 4x5 minutes at 300W with 3 minutes of
+// recovery, and 10 minutes of cooldown.
+func fourByFive() []workoutStep {
+	easy := workoutStep{10 * time.Minute, 150, 120}
+	hard := workoutStep{5 * time.Minute, 300, 165}
+	rest := workoutStep{3 * time.Minute, 120, 130}
+	return []workoutStep{easy, hard, rest, hard, rest, hard, rest, hard, easy}
+}
+
+// workInterval is the n'th (from 0) hard interval of fourByFive.
+func workInterval(n int) Interval {
+	start := 600 + n*480
+	return Interval{
+		Start:        start,
+		End:          start + 300,
+		StartTime:    time.Date(2021, 8, 1, 9, 0, start, 0, time.UTC),
+		Duration:     5 * time.Minute,
+		Distance:     3000,
+		AvgPower:     300,
+		AvgHeartRate: 165,
+	}
+}
+
+func TestDetectIntervals(t *testing.T) {
+	steps := fourByFive()
+	// A 5 second dip in the second interval.
+	dip := workout(steps...)
+	for i := 1300; i < 1305; i++ {
+		dip[i].Power = 100
+	}
+	fourIntervals := []Interval{workInterval(0), workInterval(1), workInterval(2), workInterval(3)}
+	dipped := workInterval(1)
+	dipped.AvgPower = (295*300 + 5*100) / 300.0
+
+	var laps []FITSummary
+	at := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	for _, s := range steps {
+		laps = append(laps, FITSummary{Start: at, ElapsedTime: s.dur})
+		at = at.Add(s.dur)
+	}
+	var lapIntervals []Interval
+	start := 0
+	for _, s := range steps {
+		n := int(s.dur / time.Second)
+		lapIntervals = append(lapIntervals, Interval{
+			Start:        start,
+			End:          start + n,
+			StartTime:    time.Date(2021, 8, 1, 9, 0, start, 0, time.UTC),
+			Duration:     s.dur,
+			Distance:     float64(n * 10),
+			AvgPower:     s.power,
+			AvgHeartRate: s.hr,
+			Lap:          true,
+		})
+		start += n
+	}
+	// Laps without start times follow on from the previous one.
+	var untimedLaps []FITSummary
+	for _, l := range laps {
+		untimedLaps = append(untimedLaps, FITSummary{ElapsedTime: l.ElapsedTime})
+	}
+
+	tests := []struct {
+		desc    string
+		points  []TrackPoint
+		opts    IntervalOptions
+		want    []Interval
+		wantErr bool
+	}{
+		{
+			desc:   "power",
+			points: workout(steps...),
+			opts:   IntervalOptions{MinPower: 250},
+			want:   fourIntervals,
+		},
+		{
+			desc:   "heart rate",
+			points: workout(steps...),
+			opts:   IntervalOptions{MinHeartRate: 160},
+			want:   fourIntervals,
+		},
+		{
+			desc:   "short dip is merged",
+			points: dip,
+			opts:   IntervalOptions{MinPower: 250},
+			want:   []Interval{workInterval(0), dipped, workInterval(2), workInterval(3)},
+		},
+		{
+			desc:   "short dip splits",
+			points: dip,
+			opts:   IntervalOptions{MinPower: 250, MaxRecovery: 5 * time.Second},
+			want: []Interval{
+				workInterval(0),
+				{
+					Start: 1080, End: 1300,
+					StartTime: time.Date(2021, 8, 1, 9, 18, 0, 0, time.UTC),
+					Duration:  220 * time.Second, Distance: 2200,
+					AvgPower: 300, AvgHeartRate: 165,
+				},
+				{
+					Start: 1305, End: 1380,
+					StartTime: time.Date(2021, 8, 1, 9, 21, 45, 0, time.UTC),
+					Duration:  75 * time.Second, Distance: 750,
+					AvgPower: 300, AvgHeartRate: 165,
+				},
+				workInterval(2),
+				workInterval(3),
+			},
+		},
+		{
+			desc:   "long recovery merges everything",
+			points: workout(steps...),
+			opts:   IntervalOptions{MinPower: 250, MaxRecovery: 4 * time.Minute},
+			want: []Interval{
+				{
+					Start: 600, End: 2340,
+					StartTime: time.Date(2021, 8, 1, 9, 10, 0, 0, time.UTC),
+					Duration:  29 * time.Minute, Distance: 17400,
+					AvgPower:     (20*300 + 9*120) / 29.0,
+					AvgHeartRate: (20*165 + 9*130) / 29.0,
+				},
+			},
+		},
+		{
+			desc:   "too short",
+			points: workout(steps...),
+			opts:   IntervalOptions{MinPower: 250, MinDuration: 6 * time.Minute},
+			want:   []Interval{},
+		},
+		{
+			desc:   "laps",
+			points: workout(steps...),
+			opts:   IntervalOptions{MinPower: 250, Laps: laps},
+			want:   lapIntervals,
+		},
+		{
+			desc:   "laps without start times",
+			points: workout(steps...),
+			opts:   IntervalOptions{Laps: untimedLaps},
+			want:   lapIntervals,
+		},
+		{
+			desc:    "no threshold",
+			points:  workout(steps...),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := DetectIntervals(tc.points, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad intervals: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package goride
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const kmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+<name>%s</name>
+<Style id="track"><LineStyle><color>ff0000ff</color><width>4</width></LineStyle></Style>
+<Style id="start"><IconStyle><Icon><href>http://maps.google.com/mapfiles/kml/paddle/grn-circle.png</href></Icon></IconStyle></Style>
+<Style id="finish"><IconStyle><Icon><href>http://maps.google.com/mapfiles/kml/paddle/red-circle.png</href></Icon></IconStyle></Style>
+`
+
+// ExportKML writes the track as a KML document, for Google Earth. The track is
+// a LineString, split where it crosses the antimeridian, with Placemarks for
+// the start and finish. Altitudes are absolute if the track has elevations,
+// otherwise the line is clamped to the ground.
+func ExportKML(w io.Writer, name string, points []TrackPoint) error {
+	var track []TrackPoint
+	altitudeMode := "clampToGround"
+	skip := sensorOnly(points)
+	for _, p := range points {
+		if skip(p) {
+			continue
+		}
+		track = append(track, p)
+		if p.Elevation != 0 {
+			altitudeMode = "absolute"
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, kmlHeader, kmlEscape(name))
+	if len(track) > 0 {
+		bw.WriteString("<Placemark>\n<name>Track</name>\n<styleUrl>#track</styleUrl>\n<MultiGeometry>\n")
+		for _, line := range splitAntimeridian(track) {
+			fmt.Fprintf(bw, "<LineString><altitudeMode>%s</altitudeMode><coordinates>\n", altitudeMode)
+			for _, p := range line {
+				bw.WriteString(kmlCoordinates(p))
+				bw.WriteByte('\n')
+			}
+			bw.WriteString("</coordinates></LineString>\n")
+		}
+		bw.WriteString("</MultiGeometry>\n</Placemark>\n")
+
+		for _, pm := range []struct {
+			name, style string
+			p           TrackPoint
+		}{
+			{"Start", "start", track[0]},
+			{"Finish", "finish", track[len(track)-1]},
+		} {
+			fmt.Fprintf(bw, "<Placemark><name>%s</name><styleUrl>#%s</styleUrl><Point><altitudeMode>%s</altitudeMode><coordinates>%s</coordinates></Point></Placemark>\n",
+				pm.name, pm.style, altitudeMode, kmlCoordinates(pm.p))
+		}
+	}
+	bw.WriteString("</Document>\n</kml>\n")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("error writing KML: %w", err)
+	}
+
+	return nil
+}
+
+func kmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func kmlCoordinates(p TrackPoint) string {
+	return strconv.FormatFloat(p.Lng, 'f', -1, 64) + "," +
+		strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," +
+		strconv.FormatFloat(p.Elevation, 'f', -1, 64)
+}
+
+// splitAntimeridian splits the track wherever it jumps more than 180 degrees
+// of longitude between points, which means it crossed the antimeridian. Each
+// side gets a point interpolated onto the antimeridian, so the lines meet.
+func splitAntimeridian(track []TrackPoint) [][]TrackPoint {
+	var res [][]TrackPoint
+	line := []TrackPoint{track[0]}
+	for i := 1; i < len(track); i++ {
+		a, b := track[i-1], track[i]
+		if math.Abs(b.Lng-a.Lng) <= 180 {
+			line = append(line, b)
+			continue
+		}
+
+		// Unwrap b's longitude to be next to a, and find where the segment
+		// crosses +-180.
+		edge := 180.0
+		bLng := b.Lng + 360
+		if a.Lng < 0 {
+			edge = -180
+			bLng = b.Lng - 360
+		}
+		f := (edge - a.Lng) / (bLng - a.Lng)
+		cross := TrackPoint{
+			Lat:       a.Lat + f*(b.Lat-a.Lat),
+			Elevation: a.Elevation + f*(b.Elevation-a.Elevation),
+		}
+
+		cross.Lng = edge
+		res = append(res, append(line, cross))
+		cross.Lng = -edge
+		line = []TrackPoint{cross, b}
+	}
+
+	return append(res, line)
+}
@@ -0,0 +1,121 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// kmlLines parses a KML document, and returns the coordinates of each
+// LineString and the placemark names.
+func kmlLines(t *testing.T, data []byte) ([][]string, []string) {
+	t.Helper()
+	var lines [][]string
+	var names []string
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var path []string
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid XML: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			path = append(path, tok.Name.Local)
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			p := strings.Join(path, "/")
+			switch {
+			case strings.HasSuffix(p, "LineString/coordinates"):
+				lines = append(lines, strings.Fields(string(tok)))
+			case p == "kml/Document/name" || p == "kml/Document/Placemark/name":
+				names = append(names, string(tok))
+			}
+		}
+	}
+
+	return lines, names
+}
+
+func TestExportKML(t *testing.T) {
+	track := []TrackPoint{
+		{Time: sensorStart},
+		{Lat: 45.5, Lng: -122.6, Elevation: 10},
+		{Lat: 45.501, Lng: -122.6005, Elevation: 12.5},
+		{Lat: 45.502, Lng: -122.601, Elevation: 11},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportKML(&buf, "Coffee & <cake>", track); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkGolden(t, "track.kml", buf.Bytes())
+
+	lines, names := kmlLines(t, buf.Bytes())
+	if diff := cmp.Diff([]string{"Coffee & <cake>", "Track", "Start", "Finish"}, names); diff != "" {
+		t.Errorf("bad names: -want +got\n%s", diff)
+	}
+	want := [][]string{{"-122.6,45.5,10", "-122.6005,45.501,12.5", "-122.601,45.502,11"}}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("bad lines: -want +got\n%s", diff)
+	}
+}
+
+func TestExportKMLEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportKML(&buf, "empty", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines, names := kmlLines(t, buf.Bytes()); len(lines) != 0 || len(names) != 1 {
+		t.Errorf("expected an empty document, got %v, %v", lines, names)
+	}
+}
+
+func TestSplitAntimeridian(t *testing.T) {
+	tests := []struct {
+		desc  string
+		track []TrackPoint
+		want  [][]TrackPoint
+	}{
+		{
+			desc:  "no crossing",
+			track: []TrackPoint{{Lng: 170}, {Lng: 175, Lat: 1}},
+			want:  [][]TrackPoint{{{Lng: 170}, {Lng: 175, Lat: 1}}},
+		},
+		{
+			desc:  "eastward",
+			track: []TrackPoint{{Lng: 179, Lat: 10}, {Lng: -179, Lat: 12}},
+			want: [][]TrackPoint{
+				{{Lng: 179, Lat: 10}, {Lng: 180, Lat: 11}},
+				{{Lng: -180, Lat: 11}, {Lng: -179, Lat: 12}},
+			},
+		},
+		{
+			desc:  "westward and back",
+			track: []TrackPoint{{Lng: -179.5}, {Lng: 179.5, Elevation: 10}, {Lng: -179.5, Elevation: 20}},
+			want: [][]TrackPoint{
+				{{Lng: -179.5}, {Lng: -180, Elevation: 5}},
+				{{Lng: 180, Elevation: 5}, {Lng: 179.5, Elevation: 10}, {Lng: 180, Elevation: 15}},
+				{{Lng: -180, Elevation: 15}, {Lng: -179.5, Elevation: 20}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := splitAntimeridian(tc.track)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad split: -want +got\n%s", diff)
+			}
+		})
+	}
+}
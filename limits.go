@@ -0,0 +1,90 @@
+package goride
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultMaxResponseSize is the largest response body read, unless changed
+// with WithMaxResponseSize or RequestOptions.
+const defaultMaxResponseSize = 100 << 20
+
+// WithMaxResponseSize sets the largest response body, in bytes, that's read
+// before failing with ErrResponseTooLarge. The default is 100 MB; 0 or less
+// keeps it. Single calls can raise it, see RequestOptions.
+func WithMaxResponseSize(n int64) Option {
+	return func(r *RWGPS) {
+		r.client.maxResponseSize = n
+	}
+}
+
+// callLimits override the client's timeout and size limit for one call. Zero
+// values use the client's.
+type callLimits struct {
+	timeout time.Duration
+	maxSize int64
+}
+
+func (o RequestOptions) limits() callLimits {
+	return callLimits{timeout: o.Timeout, maxSize: o.MaxResponseSize}
+}
+
+func (c *Client) maxSize(lim callLimits) int64 {
+	switch {
+	case lim.maxSize > 0:
+		return lim.maxSize
+	case c.maxResponseSize > 0:
+		return c.maxResponseSize
+	}
+	return defaultMaxResponseSize
+}
+
+// httpClientFor is httpClient, with the call's timeout if it has one. The
+// timeout covers reading the body too.
+func (c *Client) httpClientFor(lim callLimits) *http.Client {
+	hc := c.httpClient()
+	if lim.timeout <= 0 {
+		return hc
+	}
+	withTimeout := *hc
+	withTimeout.Timeout = lim.timeout
+	return &withTimeout
+}
+
+// limitedBody fails with ErrResponseTooLarge when the body is longer than
+// limit.
+type limitedBody struct {
+	rc     io.ReadCloser
+	method string
+	path   string
+	limit  int64
+	// left is how much more can be read.
+	left int64
+}
+
+func newLimitedBody(rc io.ReadCloser, limit int64, method, path string) *limitedBody {
+	return &limitedBody{rc: rc, method: method, path: path, limit: limit, left: limit}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		// Only a body that goes on past the limit is too large.
+		var one [1]byte
+		if n, err := io.ReadFull(b.rc, one[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: %s %q is over %d bytes", ErrResponseTooLarge, b.method, b.path, b.limit)
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.rc.Read(p)
+	b.left -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
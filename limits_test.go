@@ -0,0 +1,124 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/endless":
+			// Streams until the client hangs up.
+			chunk := []byte(strings.Repeat("x", 4096))
+			for i := 0; i < 1<<14; i++ {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		case "/exact":
+			fmt.Fprint(w, strings.Repeat("x", 1024))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		desc    string
+		path    string
+		limit   int64
+		wantErr bool
+	}{
+		{desc: "endless", path: "/endless", limit: 1024, wantErr: true},
+		{desc: "at the limit", path: "/exact", limit: 1024},
+		{desc: "over the limit", path: "/exact", limit: 1023, wantErr: true},
+		{desc: "default limit", path: "/exact"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := testObj(server.URL)
+			WithMaxResponseSize(tc.limit)(r)
+
+			got, err := r.client.Get(tc.path, nil)
+			if tc.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("Get(%q): want ErrResponseTooLarge, got %v", tc.path, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get(%q): %v", tc.path, err)
+			}
+			if len(got) != 1024 {
+				t.Errorf("Get(%q): want 1024 bytes, got %d", tc.path, len(got))
+			}
+		})
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+	f := newFakeRWGPS(t)
+	big := fmt.Sprintf(`{"type":"trip","trip":{"id":1,"description":%q}}`, strings.Repeat("x", 8192))
+	f.handle("/trips/1.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, big)
+	})
+	f.handle("/trips/2.json", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, `{"type":"trip","trip":{"id":2}}`)
+	})
+
+	tests := []struct {
+		desc    string
+		id      int
+		timeout time.Duration
+		opts    RequestOptions
+		wantErr error
+	}{
+		{desc: "too large", id: 1, wantErr: ErrResponseTooLarge},
+		{desc: "limit raised", id: 1, opts: RequestOptions{MaxResponseSize: 1 << 20}},
+		{desc: "client timeout", id: 2, timeout: 20 * time.Millisecond, wantErr: errTimeout},
+		{desc: "timeout raised", id: 2, timeout: 20 * time.Millisecond, opts: RequestOptions{Timeout: 5 * time.Second}},
+		{desc: "timeout lowered", id: 2, opts: RequestOptions{Timeout: 20 * time.Millisecond}, wantErr: errTimeout},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := testObj(f.URL)
+			// Log in first, the login response is over the limit.
+			if _, err := r.loggedInUser(); err != nil {
+				t.Fatalf("can't log in: %v", err)
+			}
+			WithMaxResponseSize(4096)(r)
+			r.client.http = &http.Client{Timeout: tc.timeout}
+
+			ride, err := r.GetRideWithOpts(tc.id, tc.opts)
+			switch {
+			case tc.wantErr == errTimeout:
+				if !isTimeout(err) {
+					t.Errorf("GetRideWithOpts(%d): want a timeout, got %v", tc.id, err)
+				}
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("GetRideWithOpts(%d): want %v, got %v", tc.id, tc.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("GetRideWithOpts(%d): %v", tc.id, err)
+			case ride.ID != tc.id:
+				t.Errorf("GetRideWithOpts(%d): got ride %d", tc.id, ride.ID)
+			}
+		})
+	}
+}
+
+// errTimeout stands for any timeout error in the tests.
+var errTimeout = errors.New("timeout")
+
+func isTimeout(err error) bool {
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
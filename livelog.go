@@ -0,0 +1,120 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LiveLog is a ride being logged live, or one that has finished.
+type LiveLog struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is zero while the ride is still going.
+	FinishedAt time.Time `json:"finished_at"`
+	// TripID is the ride it was saved as, once it's finished.
+	TripID int `json:"trip_id"`
+	// Latest is the last known position, if any.
+	Latest *TrackPoint `json:"latest_position"`
+	// Breadcrumbs is the trail so far, oldest first.
+	Breadcrumbs []TrackPoint `json:"breadcrumbs"`
+}
+
+// Active reports whether the ride is still going.
+func (l *LiveLog) Active() bool {
+	return l.FinishedAt.IsZero()
+}
+
+// GetLiveLogs lists a user's live logs, active and finished.
+func (r *RWGPS) GetLiveLogs(userID int) ([]*LiveLog, error) {
+	var resStruct struct {
+		Results []*LiveLog
+	}
+	if err := r.getJSON(fmt.Sprintf("/users/%d/live_logs.json", userID), nil, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting live logs for user %d: %w", userID, err)
+	}
+
+	return resStruct.Results, nil
+}
+
+// GetLiveLog gets a live log. Ones that don't exist return ErrNotFound.
+func (r *RWGPS) GetLiveLog(id int) (*LiveLog, error) {
+	var resStruct struct {
+		LiveLog *LiveLog `json:"live_log"`
+	}
+	if err := r.getJSON(fmt.Sprintf("/live_logs/%d.json", id), nil, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting live log %d: %w", id, err)
+	}
+	if resStruct.LiveLog == nil {
+		return nil, fmt.Errorf("error getting live log %d: %w", id, ErrNotFound)
+	}
+
+	return resStruct.LiveLog, nil
+}
+
+// PollOptions control how PollLiveLog polls.
+type PollOptions struct {
+	// Context can stop polling. Defaults to context.Background().
+	Context context.Context
+	// Interval is the wait between polls. Defaults to 30 seconds.
+	Interval time.Duration
+}
+
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+	if o.Interval == 0 {
+		o.Interval = 30 * time.Second
+	}
+	return o
+}
+
+// PollLiveLog polls a live log, calling fn with the log and the breadcrumbs
+// that are newer than any seen before. It returns nil once the log is
+// finished, the context's error if that's done first, or fn's error if it
+// returns one. Rate limited polls are skipped; other errors end polling.
+func (r *RWGPS) PollLiveLog(id int, opts PollOptions, fn func(l *LiveLog, points []TrackPoint) error) error {
+	opts = opts.withDefaults()
+	var last time.Time
+	for {
+		l, err := r.GetLiveLog(id)
+		switch {
+		case err == nil:
+			points := newPoints(l.Breadcrumbs, last)
+			if len(points) > 0 {
+				last = points[len(points)-1].Time
+				if err := fn(l, points); err != nil {
+					return err
+				}
+			}
+			if !l.Active() {
+				return nil
+			}
+		case !errors.Is(err, ErrRateLimited):
+			return err
+		}
+
+		t := time.NewTimer(opts.Interval)
+		select {
+		case <-opts.Context.Done():
+			t.Stop()
+			return opts.Context.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// newPoints returns the points after last. Points are in time order, and the
+// server may drop old ones from the front.
+func newPoints(points []TrackPoint, last time.Time) []TrackPoint {
+	for i, p := range points {
+		if p.Time.After(last) {
+			return points[i:]
+		}
+	}
+	return nil
+}
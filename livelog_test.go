@@ -0,0 +1,249 @@
+package goride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var liveStart = time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+
+// livePoint is the i'th point of the test live log, a minute apart.
+func livePoint(i int) TrackPoint {
+	return TrackPoint{
+		Time: liveStart.Add(time.Duration(i) * time.Minute),
+		Lat:  45.5 + float64(i)/1000,
+		Lng:  -122.6,
+	}
+}
+
+// liveLogJSON is live log 7, with points from to to, finished if done.
+func liveLogJSON(t *testing.T, from, to int, done bool) string {
+	t.Helper()
+	l := map[string]interface{}{
+		"id":          7,
+		"user_id":     1,
+		"name":        "Club ride",
+		"started_at":  liveStart,
+		"finished_at": nil,
+		"breadcrumbs": []TrackPoint{},
+	}
+	var points []TrackPoint
+	for i := from; i < to; i++ {
+		points = append(points, livePoint(i))
+	}
+	if len(points) > 0 {
+		l["breadcrumbs"] = points
+		l["latest_position"] = points[len(points)-1]
+	}
+	if done {
+		l["finished_at"] = livePoint(to).Time
+		l["trip_id"] = 94
+	}
+	data, err := json.Marshal(map[string]interface{}{"live_log": l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGetLiveLog(t *testing.T) {
+	tests := []struct {
+		desc       string
+		body       string
+		wantActive bool
+		wantPoints int
+		wantErr    error
+	}{
+		{
+			desc:       "active",
+			body:       liveLogJSON(t, 0, 3, false),
+			wantActive: true,
+			wantPoints: 3,
+		},
+		{
+			desc:       "finished",
+			body:       liveLogJSON(t, 0, 5, true),
+			wantPoints: 5,
+		},
+		{
+			desc:       "not started",
+			body:       liveLogJSON(t, 0, 0, false),
+			wantActive: true,
+		},
+		{
+			desc:    "missing",
+			body:    `{}`,
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t)
+			f.handle("/live_logs/7.json", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprint(w, tc.body)
+			})
+
+			l, err := testObj(f.URL).GetLiveLog(7)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("bad error: want %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if l.ID != 7 || l.Name != "Club ride" || !l.StartedAt.Equal(liveStart) {
+				t.Errorf("bad live log: %+v", l)
+			}
+			if l.Active() != tc.wantActive {
+				t.Errorf("bad active: want %v, got %v", tc.wantActive, l.Active())
+			}
+			if !tc.wantActive && l.TripID != 94 {
+				t.Errorf("bad trip id for a finished log: %d", l.TripID)
+			}
+			if len(l.Breadcrumbs) != tc.wantPoints {
+				t.Fatalf("want %d breadcrumbs, got %d", tc.wantPoints, len(l.Breadcrumbs))
+			}
+			if tc.wantPoints == 0 {
+				if l.Latest != nil {
+					t.Errorf("unexpected latest position: %+v", l.Latest)
+				}
+				return
+			}
+			if diff := cmp.Diff(livePoint(tc.wantPoints-1), *l.Latest); diff != "" {
+				t.Errorf("bad latest position: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetLiveLogs(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/1/live_logs.json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"results":[{"id":7,"started_at":"2021-08-01T09:00:00Z"},`+
+			`{"id":6,"started_at":"2021-07-01T09:00:00Z","finished_at":"2021-07-01T12:00:00Z","trip_id":93}]}`)
+	})
+
+	logs, err := testObj(f.URL).GetLiveLogs(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, l := range logs {
+		got = append(got, fmt.Sprintf("%d active=%v trip=%d", l.ID, l.Active(), l.TripID))
+	}
+	want := []string{"7 active=true trip=0", "6 active=false trip=93"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad live logs: -want +got\n%s", diff)
+	}
+}
+
+func TestPollLiveLog(t *testing.T) {
+	tests := []struct {
+		desc string
+		// polls are the responses to each poll, after which the last one
+		// repeats. Empty ones are rate limited.
+		polls    []string
+		failWith error
+		want     [][]int
+		wantErr  error
+	}{
+		{
+			desc: "growing",
+			polls: []string{
+				liveLogJSON(t, 0, 2, false),
+				liveLogJSON(t, 0, 4, false),
+				liveLogJSON(t, 0, 5, true),
+			},
+			want: [][]int{{0, 1}, {2, 3}, {4}},
+		},
+		{
+			desc: "no new points",
+			polls: []string{
+				liveLogJSON(t, 0, 2, false),
+				liveLogJSON(t, 0, 2, false),
+				liveLogJSON(t, 0, 3, true),
+			},
+			want: [][]int{{0, 1}, {2}},
+		},
+		{
+			desc: "old points dropped",
+			polls: []string{
+				liveLogJSON(t, 0, 3, false),
+				liveLogJSON(t, 2, 5, false),
+				liveLogJSON(t, 4, 6, true),
+			},
+			want: [][]int{{0, 1, 2}, {3, 4}, {5}},
+		},
+		{
+			desc: "rate limited",
+			polls: []string{
+				liveLogJSON(t, 0, 2, false),
+				"",
+				liveLogJSON(t, 0, 3, true),
+			},
+			want: [][]int{{0, 1}, {2}},
+		},
+		{
+			desc: "never finishes",
+			polls: []string{
+				liveLogJSON(t, 0, 2, false),
+			},
+			want:    [][]int{{0, 1}},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			desc: "callback error",
+			polls: []string{
+				liveLogJSON(t, 0, 2, false),
+			},
+			failWith: errors.New("stop"),
+			want:     [][]int{{0, 1}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t)
+			n := 0
+			f.handle("/live_logs/7.json", func(w http.ResponseWriter, _ *http.Request) {
+				body := tc.polls[min(n, len(tc.polls)-1)]
+				n++
+				if body == "" {
+					http.Error(w, "slow down", http.StatusTooManyRequests)
+					return
+				}
+				fmt.Fprint(w, body)
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			var got [][]int
+			err := testObj(f.URL).PollLiveLog(7, PollOptions{Context: ctx, Interval: time.Millisecond},
+				func(l *LiveLog, points []TrackPoint) error {
+					var idx []int
+					for _, p := range points {
+						idx = append(idx, int(p.Time.Sub(liveStart)/time.Minute))
+					}
+					got = append(got, idx)
+					return tc.failWith
+				})
+			wantErr := tc.wantErr
+			if tc.failWith != nil {
+				wantErr = tc.failWith
+			}
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("bad error: want %v, got %v", wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad points: -want +got\n%s", diff)
+			}
+		})
+	}
+}
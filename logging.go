@@ -0,0 +1,30 @@
+package goride
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger sets where the client logs to. By default nothing is logged;
+// pass slog.Default() to log through the standard logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(r *RWGPS) {
+		r.logger = l
+	}
+}
+
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+func (r *RWGPS) log() *slog.Logger {
+	if r.logger == nil {
+		return discardLogger
+	}
+	return r.logger
+}
@@ -0,0 +1,112 @@
+package goride
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logRecorder is a slog.Handler that keeps every record it's given.
+type logRecorder struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (l *logRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (l *logRecorder) Handle(_ context.Context, r slog.Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+	return nil
+}
+
+func (l *logRecorder) WithAttrs([]slog.Attr) slog.Handler { return l }
+func (l *logRecorder) WithGroup(string) slog.Handler      { return l }
+
+func (l *logRecorder) has(level slog.Level, msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range l.records {
+		if r.Level == level && strings.Contains(r.Message, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *logRecorder) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var res []string
+	for _, r := range l.records {
+		res = append(res, fmt.Sprintf("%s %s", r.Level, r.Message))
+	}
+	return res
+}
+
+func TestLogger(t *testing.T) {
+	server := startServer(t, nil, nil)
+	defer server.Close()
+
+	tests := []struct {
+		desc     string
+		password string
+		want     []string
+	}{
+		{
+			desc:     "login",
+			password: "supers3cret",
+			want:     []string{"DEBUG no auth token found, logging in", "DEBUG logged in"},
+		},
+		{
+			desc:     "failed login",
+			password: "12345",
+			want:     []string{"DEBUG no auth token found, logging in", "WARN login failed"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			logs := &logRecorder{}
+			r := testObj(server.URL)
+			WithLogger(slog.New(logs))(r)
+			r.config.Password = tc.password
+			r.Auth()
+
+			got := logs.messages()
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("bad logs: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultLoggerIsSilent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.ini")
+	writeTestFile(t, path, "[Auth]\nemail = test@example.com\n[Bogus]\n")
+
+	logs := &logRecorder{}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(logs))
+
+	r, err := New(path)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	r.log().Warn("should be discarded")
+	if got := logs.messages(); len(got) != 0 {
+		t.Errorf("expected no logs by default, got %q", got)
+	}
+
+	if _, err := New(path, WithLogger(slog.Default())); err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	if !logs.has(slog.LevelWarn, "bad section in ini") {
+		t.Errorf("expected the bad section to be logged, got %q", logs.messages())
+	}
+}
@@ -0,0 +1,285 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MatchOptions tunes MatchRoute. Zero fields use the defaults.
+type MatchOptions struct {
+	// How far from the route, in meters, the ride can be and still be on it.
+	// Defaults to 25.
+	Corridor float64
+	// Also accept rides that followed the route backwards.
+	AllowReverse bool
+}
+
+func (o MatchOptions) withDefaults() MatchOptions {
+	if o.Corridor == 0 {
+		o.Corridor = 25
+	}
+
+	return o
+}
+
+// RouteSection is part of a route, from Start to End meters along it.
+type RouteSection struct {
+	Start float64
+	End   float64
+}
+
+// MatchResult is how well a ride followed a route. Coverage is the percent of
+// the route ridden, MaxDeviation the furthest the ride got from the route, in
+// meters. Skipped lists the parts of the route that weren't ridden, ignoring
+// gaps shorter than the corridor.
+type MatchResult struct {
+	Coverage     float64
+	MaxDeviation float64
+	Skipped      []RouteSection
+	Reversed     bool
+}
+
+// MatchRoute checks how closely a ride followed a route. Unless reverse is
+// allowed, riding a part of the route the wrong way doesn't count. Points
+// without a position are ignored.
+func MatchRoute(ridePoints, routePoints []TrackPoint, opts MatchOptions) (MatchResult, error) {
+	opts = opts.withDefaults()
+	if opts.Corridor < 0 {
+		return MatchResult{}, fmt.Errorf("invalid corridor width %f", opts.Corridor)
+	}
+
+	var lat0 float64
+	for _, p := range routePoints {
+		if p.Lat != 0 || p.Lng != 0 {
+			lat0 = p.Lat
+			break
+		}
+	}
+	route := projectTrack(routePoints, lat0)
+	if len(route) < 2 {
+		return MatchResult{}, fmt.Errorf("route needs at least 2 points with a position, got %d", len(route))
+	}
+	ride := projectTrack(ridePoints, lat0)
+	idx := newMatchIndex(route, math.Max(opts.Corridor, 25))
+	length := idx.cum[len(idx.cum)-1]
+
+	res := MatchResult{Skipped: []RouteSection{{0, length}}}
+	for _, reversed := range []bool{false, true} {
+		if reversed && !opts.AllowReverse {
+			break
+		}
+		covered := idx.cover(ride, opts.Corridor, reversed)
+		var sum float64
+		for _, c := range covered {
+			sum += c.End - c.Start
+		}
+		if pct := sum / length * 100; pct > res.Coverage {
+			res.Coverage = pct
+			res.Reversed = reversed
+			res.Skipped = routeGaps(covered, length, opts.Corridor)
+		}
+	}
+
+	// Each point is at most one step further from the route than the last,
+	// so only check the ones that could beat the current max.
+	bound := math.Inf(1)
+	for i, p := range ride {
+		if i > 0 {
+			bound += p.dist(ride[i-1])
+		}
+		if bound <= res.MaxDeviation {
+			continue
+		}
+		bound = idx.distance(p)
+		res.MaxDeviation = math.Max(res.MaxDeviation, bound)
+	}
+
+	return res, nil
+}
+
+// xy is a position in meters, on a flat projection around a reference latitude.
+type xy struct{ x, y float64 }
+
+func (a xy) dist(b xy) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+func projectTrack(points []TrackPoint, lat0 float64) []xy {
+	rad := math.Pi / 180
+	scale := math.Cos(lat0 * rad)
+	var res []xy
+	for _, p := range points {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		res = append(res, xy{p.Lng * rad * earthRadius * scale, p.Lat * rad * earthRadius})
+	}
+
+	return res
+}
+
+// matchIndex finds the route segments near a position. Each segment is filed
+// under the grid cells of points sampled along it, every cell meters, so
+// anything within a cell of a position is in the 5x5 cells around it.
+type matchIndex struct {
+	route []xy
+	// Distance along the route to each point.
+	cum  []float64
+	cell float64
+	grid map[[2]int][]int
+}
+
+func newMatchIndex(route []xy, cell float64) *matchIndex {
+	m := &matchIndex{
+		route: route,
+		cum:   make([]float64, len(route)),
+		cell:  cell,
+		grid:  make(map[[2]int][]int),
+	}
+	for j := 0; j+1 < len(route); j++ {
+		a, b := route[j], route[j+1]
+		l := a.dist(b)
+		m.cum[j+1] = m.cum[j] + l
+		steps := int(l / cell)
+		var last [2]int
+		for s := 0; s <= steps+1; s++ {
+			t := math.Min(float64(s)*cell/math.Max(l, cell), 1)
+			c := m.cellOf(xy{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t})
+			if s > 0 && c == last {
+				continue
+			}
+			m.grid[c] = append(m.grid[c], j)
+			last = c
+		}
+	}
+
+	return m
+}
+
+func (m *matchIndex) cellOf(p xy) [2]int {
+	return [2]int{int(math.Floor(p.x / m.cell)), int(math.Floor(p.y / m.cell))}
+}
+
+// near calls f with every segment that might be within a cell of p. Segments
+// can be seen more than once.
+func (m *matchIndex) near(p xy, f func(j int)) {
+	c := m.cellOf(p)
+	for dx := -2; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			for _, j := range m.grid[[2]int{c[0] + dx, c[1] + dy}] {
+				f(j)
+			}
+		}
+	}
+}
+
+// project returns how far p is from segment j, and how far along the route
+// the closest point of the segment is.
+func (m *matchIndex) project(p xy, j int) (float64, float64) {
+	d, t := closestOnSegment(p, m.route[j], m.route[j+1])
+
+	return d, m.cum[j] + t*(m.cum[j+1]-m.cum[j])
+}
+
+// closestOnSegment returns how far p is from the segment between a and b, and
+// where along it (0 at a, 1 at b) the closest point is.
+func closestOnSegment(p, a, b xy) (float64, float64) {
+	dx, dy := b.x-a.x, b.y-a.y
+	var t float64
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p.x-a.x)*dx+(p.y-a.y)*dy)/l2))
+	}
+
+	return p.dist(xy{a.x + dx*t, a.y + dy*t}), t
+}
+
+// distance returns how far p is from the route.
+func (m *matchIndex) distance(p xy) float64 {
+	best := math.Inf(1)
+	m.near(p, func(j int) {
+		d, _ := m.project(p, j)
+		best = math.Min(best, d)
+	})
+	if best <= m.cell {
+		return best
+	}
+
+	for j := 0; j+1 < len(m.route); j++ {
+		d, _ := m.project(p, j)
+		best = math.Min(best, d)
+	}
+
+	return best
+}
+
+// cover returns the merged parts of the route the ride followed, in the
+// route's direction or against it.
+func (m *matchIndex) cover(ride []xy, corridor float64, reversed bool) []RouteSection {
+	var res []RouteSection
+	var prev float64
+	matched := false
+	for i, p := range ride {
+		// Which way the ride is going here.
+		from, to := ride[max(i-1, 0)], ride[min(i+1, len(ride)-1)]
+		hx, hy := to.x-from.x, to.y-from.y
+
+		pos, best := 0.0, math.Inf(1)
+		m.near(p, func(j int) {
+			d, at := m.project(p, j)
+			if d > corridor {
+				return
+			}
+			a, b := m.route[j], m.route[j+1]
+			dot := hx*(b.x-a.x) + hy*(b.y-a.y)
+			if (dot < 0 && !reversed) || (dot > 0 && reversed) {
+				return
+			}
+			// Where the route crosses itself, stay on the part we were
+			// already following.
+			score := d
+			if matched {
+				score += math.Max(0, math.Abs(at-prev)-p.dist(ride[i-1]))
+			}
+			if score < best {
+				pos, best = at, score
+			}
+		})
+		if math.IsInf(best, 1) {
+			matched = false
+			continue
+		}
+
+		if matched && math.Abs(pos-prev) <= p.dist(ride[i-1])+2*corridor {
+			res = append(res, RouteSection{math.Min(pos, prev), math.Max(pos, prev)})
+		}
+		prev, matched = pos, true
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Start < res[j].Start })
+	var merged []RouteSection
+	for _, s := range res {
+		if n := len(merged); n > 0 && s.Start <= merged[n-1].End {
+			merged[n-1].End = math.Max(merged[n-1].End, s.End)
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// routeGaps returns the parts of a route of the given length that aren't
+// covered, if they're longer than minGap.
+func routeGaps(covered []RouteSection, length, minGap float64) []RouteSection {
+	var res []RouteSection
+	var at float64
+	for _, c := range append(covered, RouteSection{length, length}) {
+		if c.Start-at > minGap {
+			res = append(res, RouteSection{at, c.Start})
+		}
+		at = math.Max(at, c.End)
+	}
+
+	return res
+}
@@ -0,0 +1,147 @@
+package goride
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// synthPath returns points every step meters along a path, starting at
+// (37.8, -122.2) plus start and following each of the legs, given as east and
+// north meters.
+func synthPath(start [2]float64, step float64, legs ...[2]float64) []TrackPoint {
+	deg := 180 / math.Pi / earthRadius
+	toPoint := func(x, y float64) TrackPoint {
+		return TrackPoint{
+			Lat: 37.8 + y*deg,
+			Lng: -122.2 + x*deg/math.Cos(37.8*math.Pi/180),
+		}
+	}
+
+	x, y := start[0], start[1]
+	res := []TrackPoint{toPoint(x, y)}
+	for _, l := range legs {
+		n := int(math.Ceil(math.Hypot(l[0], l[1]) / step))
+		for i := 1; i <= n; i++ {
+			res = append(res, toPoint(x+l[0]*float64(i)/float64(n), y+l[1]*float64(i)/float64(n)))
+		}
+		x, y = x+l[0], y+l[1]
+	}
+
+	return res
+}
+
+func reverseTrack(points []TrackPoint) []TrackPoint {
+	res := make([]TrackPoint, len(points))
+	for i, p := range points {
+		res[len(points)-1-i] = p
+	}
+
+	return res
+}
+
+func TestMatchRoute(t *testing.T) {
+	// An L: 1km east, then 1km north.
+	route := synthPath([2]float64{}, 10, [2]float64{1000, 0}, [2]float64{0, 1000})
+
+	tests := []struct {
+		desc string
+		ride []TrackPoint
+		opts MatchOptions
+		want MatchResult
+	}{
+		{
+			desc: "exact",
+			ride: route,
+			want: MatchResult{Coverage: 100},
+		},
+		{
+			desc: "offset and sparse",
+			ride: synthPath([2]float64{0, 8}, 45, [2]float64{992, 0}, [2]float64{0, 992}),
+			want: MatchResult{Coverage: 99.6, MaxDeviation: 8},
+		},
+		{
+			desc: "detour",
+			// Leaves the route at 800m, rejoins at 1200m. The furthest
+			// point is the detour's corner, 100m past the route's corner.
+			ride: synthPath([2]float64{}, 10,
+				[2]float64{800, 0}, [2]float64{0, -100}, [2]float64{300, 0},
+				[2]float64{0, 300}, [2]float64{-100, 0}, [2]float64{0, 800}),
+			want: MatchResult{
+				Coverage:     80,
+				MaxDeviation: 100 * math.Sqrt2,
+				Skipped:      []RouteSection{{800, 1200}},
+			},
+		},
+		{
+			desc: "half",
+			ride: synthPath([2]float64{}, 10, [2]float64{1000, 0}),
+			want: MatchResult{Coverage: 50, MaxDeviation: 0, Skipped: []RouteSection{{1000, 2000}}},
+		},
+		{
+			desc: "reversed",
+			ride: reverseTrack(route),
+			want: MatchResult{Skipped: []RouteSection{{0, 2000}}},
+		},
+		{
+			desc: "reversed allowed",
+			ride: reverseTrack(route),
+			opts: MatchOptions{AllowReverse: true},
+			want: MatchResult{Coverage: 100, Reversed: true},
+		},
+		{
+			desc: "narrow corridor",
+			ride: synthPath([2]float64{0, 8}, 45, [2]float64{992, 0}, [2]float64{0, 992}),
+			opts: MatchOptions{Corridor: 5},
+			want: MatchResult{MaxDeviation: 8, Skipped: []RouteSection{{0, 2000}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := MatchRoute(tc.ride, route, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 0.5)); diff != "" {
+				t.Errorf("bad match: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchRouteErrors(t *testing.T) {
+	route := synthPath([2]float64{}, 10, [2]float64{100, 0})
+
+	if _, err := MatchRoute(route, route[:1], MatchOptions{}); err == nil {
+		t.Errorf("expected an error for a single point route")
+	}
+	if _, err := MatchRoute(route, route, MatchOptions{Corridor: -1}); err == nil {
+		t.Errorf("expected an error for a negative corridor")
+	}
+	got, err := MatchRoute(nil, route, MatchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error for an empty ride: %v", err)
+	}
+	if got.Coverage != 0 || len(got.Skipped) != 1 {
+		t.Errorf("bad match for an empty ride: %+v", got)
+	}
+}
+
+func BenchmarkMatchRoute(b *testing.B) {
+	// About 10k points each, with the ride a bit off to the side.
+	var legs [][2]float64
+	for i := 0; i < 50; i++ {
+		legs = append(legs, [2]float64{1000, 0}, [2]float64{0, 1000 * float64(1-2*(i%2))})
+	}
+	route := synthPath([2]float64{}, 10, legs...)
+	ride := synthPath([2]float64{3, 5}, 10, legs...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MatchRoute(ride, route, MatchOptions{AllowReverse: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
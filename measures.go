@@ -0,0 +1,52 @@
+package goride
+
+import "github.com/zigdon/goride/units"
+
+// Measures are a ride's numbers, typed so they can be converted and
+// formatted with the units package.
+type Measures struct {
+	Distance      units.Distance
+	ElevationGain units.Elevation
+	ElevationLoss units.Elevation
+	AvgSpeed      units.Speed
+	MaxSpeed      units.Speed
+}
+
+// Measures returns the ride's numbers with their units.
+func (r *RideSlim) Measures() Measures {
+	return Measures{
+		Distance:      units.Distance(r.Distance),
+		ElevationGain: units.Elevation(r.ElevationGain),
+		ElevationLoss: units.Elevation(r.ElevationLoss),
+		AvgSpeed:      units.Speed(r.AvgSpeed),
+		MaxSpeed:      units.Speed(r.MaxSpeed),
+	}
+}
+
+// Measures returns the ride's numbers with their units.
+func (m *Metrics) Measures() Measures {
+	return Measures{
+		Distance:      units.Distance(m.Distance),
+		ElevationGain: units.Elevation(m.ElevationGain),
+		ElevationLoss: units.Elevation(m.ElevationLoss),
+		AvgSpeed:      units.Speed(m.Speed.Avg),
+		MaxSpeed:      units.Speed(m.Speed.Max),
+	}
+}
+
+// WithUnits sets the unit system used before the user is logged in. Once
+// they are, their RWGPS preference is used.
+func WithUnits(s units.System) Option {
+	return func(r *RWGPS) {
+		r.units = s
+	}
+}
+
+// Units returns the logged in user's unit system, or the one set with
+// WithUnits (metric by default) if no one is logged in yet.
+func (r *RWGPS) Units() units.System {
+	if u := r.user(); u != nil {
+		return u.Preferences.Units
+	}
+	return r.units
+}
@@ -0,0 +1,50 @@
+package goride
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride/units"
+)
+
+func TestMeasures(t *testing.T) {
+	ride := getTestRide(t)
+	m := ride.Metrics.Measures()
+	got := []string{
+		units.Metric.FormatDistance(m.Distance),
+		units.Imperial.FormatDistance(m.Distance),
+		units.Metric.FormatSpeed(m.AvgSpeed),
+		units.Metric.FormatElevation(m.ElevationGain),
+	}
+	want := []string{"43.0 km", "26.7 mi", "23.9 km/h", "754 m"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad metrics: -want +got\n%s", diff)
+	}
+
+	slim := &RideSlim{Distance: 12345, ElevationGain: 100, AvgSpeed: 20}
+	if got := units.Imperial.FormatElevation(slim.Measures().ElevationGain); got != "328 ft" {
+		t.Errorf("bad elevation: %q", got)
+	}
+}
+
+func TestUnits(t *testing.T) {
+	server := startServer(t, nil, nil)
+
+	r := testObj(server.URL)
+	WithUnits(units.Imperial)(r)
+	if got := r.Units(); got != units.Imperial {
+		t.Errorf("expected the configured units before login, got %s", got)
+	}
+
+	r = testObj(server.URL)
+	if got := r.Units(); got != units.Metric {
+		t.Errorf("expected metric by default, got %s", got)
+	}
+	if err := r.Auth(); err != nil {
+		t.Fatalf("can't log in: %v", err)
+	}
+	// The test user doesn't use metric units.
+	if got := r.Units(); got != units.Imperial {
+		t.Errorf("expected the user's units after login, got %s", got)
+	}
+}
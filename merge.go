@@ -0,0 +1,277 @@
+package goride
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrOverlappingTracks is returned when merging tracks that were recorded at
+// the same time.
+var ErrOverlappingTracks = errors.New("tracks overlap")
+
+// MergeOptions tune MergeTracksWithOpts.
+type MergeOptions struct {
+	// Bridge fills the gaps between tracks with a straight line, with a
+	// point every BridgeInterval. The points are marked Interpolated and have
+	// no sensor values.
+	Bridge bool
+	// BridgeInterval defaults to 10 seconds.
+	BridgeInterval time.Duration
+}
+
+func (o MergeOptions) withDefaults() MergeOptions {
+	if o.BridgeInterval == 0 {
+		o.BridgeInterval = 10 * time.Second
+	}
+	return o
+}
+
+// MergeTracks joins tracks recorded one after the other, e.g. when a GPS died
+// mid-ride, into one. See MergeTracksWithOpts.
+func MergeTracks(tracks ...[]TrackPoint) ([]TrackPoint, error) {
+	return MergeTracksWithOpts(MergeOptions{}, tracks...)
+}
+
+// MergeTracksWithOpts joins the tracks in time order, whatever order they're
+// passed in. Every point must have a time, and the tracks can't overlap. The
+// cumulative Distance is recomputed across the merged track, see
+// TrackMetrics for the rest of the summary. The input isn't changed.
+func MergeTracksWithOpts(opts MergeOptions, tracks ...[]TrackPoint) ([]TrackPoint, error) {
+	opts = opts.withDefaults()
+	if opts.BridgeInterval < 0 {
+		return nil, fmt.Errorf("invalid bridge interval %s", opts.BridgeInterval)
+	}
+
+	var order []int
+	total := 0
+	for i, t := range tracks {
+		if len(t) == 0 {
+			continue
+		}
+		for j, p := range t {
+			if p.Time.IsZero() {
+				return nil, fmt.Errorf("point %d of track %d has no time", j, i)
+			}
+			if j > 0 && p.Time.Before(t[j-1].Time) {
+				return nil, fmt.Errorf("point %d of track %d is out of order: %s before %s", j, i, p.Time, t[j-1].Time)
+			}
+		}
+		order = append(order, i)
+		total += len(t)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return tracks[order[a]][0].Time.Before(tracks[order[b]][0].Time)
+	})
+	for n := 1; n < len(order); n++ {
+		prev, next := tracks[order[n-1]], tracks[order[n]]
+		if end := prev[len(prev)-1].Time; next[0].Time.Before(end) {
+			return nil, fmt.Errorf("track %d starts at %s, before track %d ends at %s: %w",
+				order[n], next[0].Time, order[n-1], end, ErrOverlappingTracks)
+		}
+	}
+
+	res := make([]TrackPoint, 0, total)
+	for n, i := range order {
+		t := tracks[i]
+		offset := 0.0
+		if n > 0 {
+			last := res[len(res)-1]
+			offset = last.Distance
+			if opts.Bridge {
+				var gap float64
+				res, gap = appendBridge(res, last, t[0], opts.BridgeInterval)
+				offset += gap
+			}
+		}
+		dist := trackDistances(t)
+		for j, p := range t {
+			p.Distance = offset + dist[j] - dist[0]
+			res = append(res, p)
+		}
+	}
+
+	return res, nil
+}
+
+// appendBridge appends points in a straight line from a to b, leaving out a
+// and b themselves, and returns the length of the line. Nothing is appended if
+// either doesn't have a position.
+func appendBridge(res []TrackPoint, a, b TrackPoint, interval time.Duration) ([]TrackPoint, float64) {
+	if (a.Lat == 0 && a.Lng == 0) || (b.Lat == 0 && b.Lng == 0) {
+		return res, 0
+	}
+	gap := haversine(a.Lat, a.Lng, b.Lat, b.Lng)
+	for t := a.Time.Add(interval); t.Before(b.Time); t = t.Add(interval) {
+		f := timeFraction(t, a.Time, b.Time)
+		res = append(res, TrackPoint{
+			Time:         t,
+			Lat:          lerp(a.Lat, b.Lat, f),
+			Lng:          lerp(a.Lng, b.Lng, f),
+			Elevation:    lerp(a.Elevation, b.Elevation, f),
+			Distance:     a.Distance + gap*f,
+			Interpolated: true,
+		})
+	}
+	return res, gap
+}
+
+// TrackMetrics computes a ride's summary from its track: the distance,
+// elapsed and moving time, elevation gain and loss, speed, and the sensor
+// averages and ranges. Sensor stats only count points that have the sensor.
+// Points must be in time order.
+func TrackMetrics(points []TrackPoint) Metrics {
+	var m Metrics
+	if len(points) == 0 {
+		return m
+	}
+
+	dist := trackDistances(points)
+	first, last := points[0], points[len(points)-1]
+	m.Distance = float32(dist[len(dist)-1] - dist[0])
+	if !first.Time.IsZero() {
+		m.FirstTime = first.Time.Unix()
+		// Metrics.Duration is a number of seconds, like the server sends.
+		m.Duration = time.Duration(last.Time.Sub(first.Time).Seconds())
+	}
+	m.StartElevation = float32(first.Elevation)
+	m.EndElevation = float32(last.Elevation)
+
+	var moving time.Duration
+	var movingDist, maxSpeed float64
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		d, dt := dist[i]-dist[i-1], q.Time.Sub(p.Time)
+		if rise := q.Elevation - p.Elevation; rise > 0 {
+			m.ElevationGain += float32(rise)
+		} else {
+			m.ElevationLoss -= float32(rise)
+		}
+		if dt <= 0 || dt > splitMaxGap {
+			continue
+		}
+		speed := d / 1000 / dt.Hours()
+		if speed < splitStopSpeed || speed > splitMaxSpeed {
+			continue
+		}
+		moving += dt
+		movingDist += d
+		maxSpeed = math.Max(maxSpeed, speed)
+	}
+	m.MovingTime = int(moving.Seconds())
+	if moving > 0 {
+		m.Speed.Avg = float32(movingDist / 1000 / moving.Hours())
+		m.Speed.Max = float32(maxSpeed)
+	}
+
+	sensorRange(points, func(p TrackPoint) float64 { return p.HeartRate }, &m.HR.Avg, &m.HR.Min, &m.HR.Max)
+	sensorRange(points, func(p TrackPoint) float64 { return p.Power }, &m.Watts.Avg, &m.Watts.Min, &m.Watts.Max)
+	sensorRange(points, func(p TrackPoint) float64 { return p.Cadence }, &m.Cadence.Avg, &m.Cadence.Min, &m.Cadence.Max)
+
+	return m
+}
+
+// sensorRange sets the average, min and max of the non-zero values of a
+// sensor.
+func sensorRange(points []TrackPoint, value func(TrackPoint) float64, avg, min, max *float32) {
+	var sum float64
+	n := 0
+	for _, p := range points {
+		v := value(p)
+		if v == 0 {
+			continue
+		}
+		if n == 0 || float32(v) < *min {
+			*min = float32(v)
+		}
+		if n == 0 || float32(v) > *max {
+			*max = float32(v)
+		}
+		sum += v
+		n++
+	}
+	if n > 0 {
+		*avg = float32(sum / float64(n))
+	}
+}
+
+// ExportRideGPX writes a ride's track as a GPX 1.1 file, see ExportGPX.
+func (r *RWGPS) ExportRideGPX(id int, w io.Writer) error {
+	ride, err := r.GetRide(id)
+	if err != nil {
+		return err
+	}
+
+	return ExportGPX(w, ride.Name, ride.TrackPoints)
+}
+
+// MergeRidesOptions control MergeRides.
+type MergeRidesOptions struct {
+	MergeOptions
+	// Upload sets the merged ride's details. The name defaults to the first
+	// ride's.
+	Upload UploadOptions
+	// DeleteFragments deletes the original rides once the merged one is
+	// uploaded.
+	DeleteFragments bool
+}
+
+// MergeRides merges the tracks of rides that are really one, see
+// MergeTracksWithOpts, and uploads the result as a new ride, returning its ID.
+// If the fragments are deleted and that fails, the new ride's ID is still
+// returned with the error.
+func (r *RWGPS) MergeRides(ids []int, opts MergeRidesOptions) (int, error) {
+	var tracks [][]TrackPoint
+	var first *Ride
+	for _, id := range ids {
+		ride, err := r.GetRide(id)
+		if err != nil {
+			return 0, err
+		}
+		if len(ride.TrackPoints) == 0 {
+			return 0, fmt.Errorf("can't merge ride %d: no track points", id)
+		}
+		if first == nil || ride.TrackPoints[0].Time.Before(first.TrackPoints[0].Time) {
+			first = ride
+		}
+		tracks = append(tracks, ride.TrackPoints)
+	}
+	if first == nil {
+		return 0, fmt.Errorf("no rides to merge")
+	}
+
+	points, err := MergeTracksWithOpts(opts.MergeOptions, tracks...)
+	if err != nil {
+		return 0, fmt.Errorf("can't merge rides %v: %w", ids, err)
+	}
+	upload := opts.Upload
+	if upload.Name == "" {
+		upload.Name = first.Name
+	}
+	var buf bytes.Buffer
+	if err := ExportGPX(&buf, upload.Name, points); err != nil {
+		return 0, err
+	}
+	newID, err := r.UploadRide(&buf, "merged.gpx", upload)
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.DeleteFragments {
+		var errs []error
+		for _, id := range ids {
+			if err := r.DeleteRide(id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return newID, err
+		}
+	}
+
+	return newID, nil
+}
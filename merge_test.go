@@ -0,0 +1,259 @@
+package goride
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var mergeStart = time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+
+// mergePoint is a point secs into the ride, m meters north of the start.
+func mergePoint(secs int, m, dist, hr, ele float64) TrackPoint {
+	return TrackPoint{
+		Time:      mergeStart.Add(time.Duration(secs) * time.Second),
+		Lat:       45 + m/metersPerDegree,
+		Lng:       -122,
+		Elevation: ele,
+		Distance:  dist,
+		HeartRate: hr,
+	}
+}
+
+// fragment is 3 points 10 seconds and 100m apart, heading north, starting
+// secs into the ride, m meters north of the start.
+func fragment(secs int, m, hr, ele float64) []TrackPoint {
+	var res []TrackPoint
+	for i := 0; i < 3; i++ {
+		res = append(res, mergePoint(secs+i*10, m+float64(i)*100, float64(i)*100, hr, ele))
+	}
+	return res
+}
+
+func bridgePoint(secs int, m float64) TrackPoint {
+	p := mergePoint(secs, m, m, 0, 0)
+	p.Interpolated = true
+	return p
+}
+
+func TestMergeTracks(t *testing.T) {
+	first := fragment(0, 0, 120, 0)
+	second := fragment(60, 500, 160, 0)
+	merged := []TrackPoint{
+		mergePoint(0, 0, 0, 120, 0),
+		mergePoint(10, 100, 100, 120, 0),
+		mergePoint(20, 200, 200, 120, 0),
+		mergePoint(60, 500, 200, 160, 0),
+		mergePoint(70, 600, 300, 160, 0),
+		mergePoint(80, 700, 400, 160, 0),
+	}
+	bridged := []TrackPoint{
+		mergePoint(0, 0, 0, 120, 0),
+		mergePoint(10, 100, 100, 120, 0),
+		mergePoint(20, 200, 200, 120, 0),
+		bridgePoint(30, 275),
+		bridgePoint(40, 350),
+		bridgePoint(50, 425),
+		mergePoint(60, 500, 500, 160, 0),
+		mergePoint(70, 600, 600, 160, 0),
+		mergePoint(80, 700, 700, 160, 0),
+	}
+	untimed := fragment(60, 500, 160, 0)
+	untimed[1].Time = time.Time{}
+
+	tests := []struct {
+		desc    string
+		tracks  [][]TrackPoint
+		opts    MergeOptions
+		want    []TrackPoint
+		wantErr bool
+	}{
+		{
+			desc:   "in order",
+			tracks: [][]TrackPoint{first, second},
+			want:   merged,
+		},
+		{
+			desc:   "out of order",
+			tracks: [][]TrackPoint{second, first},
+			want:   merged,
+		},
+		{
+			desc:   "empty tracks",
+			tracks: [][]TrackPoint{nil, first, {}, second},
+			want:   merged,
+		},
+		{
+			desc:   "bridge",
+			tracks: [][]TrackPoint{second, first},
+			opts:   MergeOptions{Bridge: true},
+			want:   bridged,
+		},
+		{
+			desc:   "bridge shorter than the interval",
+			tracks: [][]TrackPoint{first, second},
+			opts:   MergeOptions{Bridge: true, BridgeInterval: time.Minute},
+			want: []TrackPoint{
+				mergePoint(0, 0, 0, 120, 0),
+				mergePoint(10, 100, 100, 120, 0),
+				mergePoint(20, 200, 200, 120, 0),
+				mergePoint(60, 500, 500, 160, 0),
+				mergePoint(70, 600, 600, 160, 0),
+				mergePoint(80, 700, 700, 160, 0),
+			},
+		},
+		{
+			desc:    "overlap",
+			tracks:  [][]TrackPoint{first, fragment(15, 500, 160, 0)},
+			wantErr: true,
+		},
+		{
+			desc:    "missing time",
+			tracks:  [][]TrackPoint{first, untimed},
+			wantErr: true,
+		},
+		{
+			desc:   "nothing",
+			tracks: nil,
+			want:   []TrackPoint{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := MergeTracksWithOpts(tc.opts, tc.tracks...)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-6)); diff != "" {
+				t.Errorf("bad track: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := MergeTracks(first, fragment(15, 500, 160, 0)); !errors.Is(err, ErrOverlappingTracks) {
+		t.Errorf("want ErrOverlappingTracks, got %v", err)
+	}
+	// MergeTracks doesn't bridge, or change its input.
+	got, err := MergeTracks(second, first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(merged, got, cmpopts.EquateApprox(0, 1e-6)); diff != "" {
+		t.Errorf("bad track: -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(fragment(60, 500, 160, 0), second); diff != "" {
+		t.Errorf("input was changed: -want +got\n%s", diff)
+	}
+}
+
+func TestTrackMetrics(t *testing.T) {
+	points, err := MergeTracksWithOpts(MergeOptions{Bridge: true}, fragment(0, 0, 120, 100), fragment(60, 500, 160, 110))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Metrics{
+		Distance:       700,
+		Duration:       80,
+		MovingTime:     80,
+		ElevationGain:  10,
+		FirstTime:      mergeStart.Unix(),
+		StartElevation: 100,
+		EndElevation:   110,
+	}
+	want.Speed.Avg, want.Speed.Max = 31.5, 36
+	want.HR.Avg, want.HR.Min, want.HR.Max = 140, 120, 160
+	if diff := cmp.Diff(want, TrackMetrics(points), cmpopts.EquateApprox(0, 1e-3)); diff != "" {
+		t.Errorf("bad metrics: -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(Metrics{}, TrackMetrics(nil)); diff != "" {
+		t.Errorf("bad empty metrics: -want +got\n%s", diff)
+	}
+}
+
+func TestMergeRides(t *testing.T) {
+	f := newFakeRWGPS(t,
+		&Ride{ID: 10, Name: "Morning Ride", TrackPoints: fragment(0, 0, 120, 0)},
+		&Ride{ID: 11, Name: "Morning Ride (2)", TrackPoints: fragment(60, 500, 160, 0)},
+		&Ride{ID: 12, Name: "Overlap", TrackPoints: fragment(15, 500, 160, 0)},
+	)
+	var uploaded []TrackPoint
+	var name string
+	f.handle("/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		file, _, err := req.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		name = req.FormValue("trip[name]")
+		if uploaded, _, err = ParseGPX(file); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"success":1,"trip":{"id":20}}`)
+	})
+	r := testObj(f.URL)
+
+	if _, err := r.MergeRides([]int{10, 12}, MergeRidesOptions{}); !errors.Is(err, ErrOverlappingTracks) {
+		t.Errorf("want ErrOverlappingTracks, got %v", err)
+	}
+	if _, err := r.MergeRides([]int{10, 42}, MergeRidesOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound for a missing ride, got %v", err)
+	}
+
+	id, err := r.MergeRides([]int{11, 10}, MergeRidesOptions{MergeOptions: MergeOptions{Bridge: true}, DeleteFragments: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 20 {
+		t.Errorf("want ride 20, got %d", id)
+	}
+	if name != "Morning Ride" {
+		t.Errorf("want the first ride's name, got %q", name)
+	}
+	if len(uploaded) != 9 {
+		t.Errorf("want 9 points with the bridge, got %d", len(uploaded))
+	}
+	if f.ride(10) != nil || f.ride(11) != nil || f.ride(12) == nil {
+		t.Errorf("only the merged fragments should be deleted")
+	}
+	want := []string{"POST /trips.json", "DELETE /trips/11.json", "DELETE /trips/10.json"}
+	if diff := cmp.Diff(want, f.writes()); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+}
+
+func TestExportRideGPX(t *testing.T) {
+	f := newFakeRWGPS(t, &Ride{ID: 10, Name: "Morning Ride", TrackPoints: fragment(0, 0, 120, 0)})
+	r := testObj(f.URL)
+
+	var buf bytes.Buffer
+	if err := r.ExportRideGPX(10, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, meta, err := ParseGPX(&buf)
+	if err != nil {
+		t.Fatalf("can't parse exported GPX: %v", err)
+	}
+	if meta.Name != "Morning Ride" || len(got) != 3 || got[2].HeartRate != 120 {
+		t.Errorf("bad export: %+v %+v", meta, got)
+	}
+
+	if err := r.ExportRideGPX(42, &buf); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+}
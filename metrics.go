@@ -0,0 +1,159 @@
+package goride
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The metrics sent to a MetricsSink, named like Prometheus metrics.
+const (
+	// MetricRequests counts requests sent to the server, labeled with the
+	// "method", "endpoint" (see endpointLabel) and "status", which is "error"
+	// if there was no response.
+	MetricRequests = "goride_requests_total"
+	// MetricRequestSeconds is how long each request took to get a response,
+	// labeled with the "method" and "endpoint".
+	MetricRequestSeconds = "goride_request_duration_seconds"
+	// MetricRetries counts the calls retried after being rate limited.
+	MetricRetries = "goride_retries_total"
+	// MetricRateLimitSleepSeconds is how long each wait before a retry was.
+	MetricRateLimitSleepSeconds = "goride_rate_limit_sleep_seconds"
+	// MetricCacheRequests counts the GET requests that could come from a
+	// cache, labeled with the "cache" ("memory" for WithCache, "disk" for
+	// WithDiskCache) and the "result", "hit" or "miss".
+	MetricCacheRequests = "goride_cache_requests_total"
+)
+
+// Labels are a metric's dimensions, e.g. {"method": "GET"}. They must not be
+// changed after they're passed to a MetricsSink.
+type Labels map[string]string
+
+// MetricsSink receives the client's metrics, to export to something like
+// Prometheus. Counters only ever go up, by Add; Observe records one sample of
+// a histogram. Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	Add(name string, labels Labels, delta float64)
+	Observe(name string, labels Labels, value float64)
+}
+
+// WithMetrics sends the client's metrics to m. Off by default.
+func WithMetrics(m MetricsSink) Option {
+	return func(r *RWGPS) {
+		r.client.metrics = m
+	}
+}
+
+func (c *Client) count(name string, labels Labels) {
+	if c.metrics != nil {
+		c.metrics.Add(name, labels, 1)
+	}
+}
+
+func (c *Client) observe(name string, labels Labels, value float64) {
+	if c.metrics != nil {
+		c.metrics.Observe(name, labels, value)
+	}
+}
+
+func (c *Client) countCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.count(MetricCacheRequests, Labels{"cache": cache, "result": result})
+}
+
+var idSegment = regexp.MustCompile(`^\d+(\.\w+)?$`)
+
+// endpointLabel is a request's path with the IDs replaced, e.g.
+// "/trips/:id.json", so metrics don't get a new endpoint for every ride.
+// Absolute URLs, like photos, are only labeled by their host.
+func endpointLabel(base string) string {
+	if u, err := url.Parse(base); err == nil && u.IsAbs() {
+		return u.Host
+	}
+	parts := strings.Split(base, "/")
+	for i, p := range parts {
+		if m := idSegment.FindStringSubmatch(p); m != nil {
+			parts[i] = ":id" + m[1]
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// countRetry counts a rate limited call being retried, once it waited for
+// wait.
+func (r *RWGPS) countRetry(wait time.Duration) {
+	r.client.count(MetricRetries, nil)
+	r.client.observe(MetricRateLimitSleepSeconds, nil, wait.Seconds())
+}
+
+// MemoryMetrics is a MetricsSink keeping everything in memory, for tests and
+// debugging.
+type MemoryMetrics struct {
+	mu       sync.Mutex
+	counters map[string]float64
+	samples  map[string][]float64
+}
+
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{counters: make(map[string]float64), samples: make(map[string][]float64)}
+}
+
+// metricKey is how a metric is written by Prometheus, e.g.
+// `goride_requests_total{method="GET",status="200"}`, with the labels sorted.
+func metricKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	var pairs []string
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+strconv.Quote(v))
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (m *MemoryMetrics) Add(name string, labels Labels, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[metricKey(name, labels)] += delta
+}
+
+func (m *MemoryMetrics) Observe(name string, labels Labels, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := metricKey(name, labels)
+	m.samples[key] = append(m.samples[key], value)
+}
+
+// Counter returns a counter's value, 0 if it was never added to.
+func (m *MemoryMetrics) Counter(name string, labels Labels) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[metricKey(name, labels)]
+}
+
+// Samples returns a histogram's samples, in the order they were observed.
+func (m *MemoryMetrics) Samples(name string, labels Labels) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]float64(nil), m.samples[metricKey(name, labels)]...)
+}
+
+// Counters returns all the counters, by their Prometheus name, e.g.
+// `goride_requests_total{endpoint="/trips/:id.json",method="GET",status="200"}`.
+func (m *MemoryMetrics) Counters() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[string]float64, len(m.counters))
+	for k, v := range m.counters {
+		res[k] = v
+	}
+	return res
+}
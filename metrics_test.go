@@ -0,0 +1,110 @@
+package goride
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{base: "/trips/94.json", want: "/trips/:id.json"},
+		{base: "/users/1268590/trips.json", want: "/users/:id/trips.json"},
+		{base: "/users/current.json", want: "/users/current.json"},
+		{base: "/routes/42", want: "/routes/:id"},
+		{base: "https://photos.example.com/photos/1.jpg", want: "photos.example.com"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.base, func(t *testing.T) {
+			if got := endpointLabel(tc.base); got != tc.want {
+				t.Errorf("endpointLabel(%q): want %q, got %q", tc.base, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryMetrics(t *testing.T) {
+	f := newFakeRWGPS(t)
+	limited := 0
+	f.handle("/trips/2.json", func(w http.ResponseWriter, req *http.Request) {
+		if limited++; limited == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"type":"trip","trip":{"id":2}}`)
+	})
+	m := NewMemoryMetrics()
+	r := testObj(f.URL)
+	WithMetrics(m)(r)
+
+	err := r.retryRateLimited(context.Background(), RetryOptions{Retries: 2, RetryWait: time.Millisecond}, func() error {
+		_, err := r.GetRide(2)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetRide(2): %v", err)
+	}
+
+	want := map[string]float64{
+		`goride_requests_total{endpoint="/trips/:id.json",method="GET",status="429"}`:     1,
+		`goride_requests_total{endpoint="/trips/:id.json",method="GET",status="200"}`:     1,
+		`goride_requests_total{endpoint="/users/current.json",method="GET",status="200"}`: 1,
+		`goride_retries_total`: 1,
+	}
+	if diff := cmp.Diff(want, m.Counters()); diff != "" {
+		t.Errorf("bad counters: -want +got\n%s", diff)
+	}
+	if got := m.Samples(MetricRequestSeconds, Labels{"method": "GET", "endpoint": "/trips/:id.json"}); len(got) != 2 {
+		t.Errorf("want 2 latencies, got %v", got)
+	}
+	if diff := cmp.Diff([]float64{0.001}, m.Samples(MetricRateLimitSleepSeconds, nil)); diff != "" {
+		t.Errorf("bad rate limit sleeps: -want +got\n%s", diff)
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	s := &etagServer{version: 1}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	m := NewMemoryMetrics()
+	c := &Client{server: server.URL, cache: NewMemoryCache(10), metrics: m}
+	for _, path := range []string{"/a", "/a", "/b", "/a"} {
+		if _, err := c.Get(path, nil); err != nil {
+			t.Fatalf("Get(%q): %v", path, err)
+		}
+	}
+
+	dc, err := NewDiskCache(t.TempDir(), time.Hour, DiskReadThrough)
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	c = &Client{server: server.URL, disk: dc, metrics: m}
+	for _, path := range []string{"/a", "/a"} {
+		if _, err := c.Get(path, nil); err != nil {
+			t.Fatalf("Get(%q): %v", path, err)
+		}
+	}
+
+	want := map[string]float64{
+		`goride_cache_requests_total{cache="memory",result="hit"}`:       2,
+		`goride_cache_requests_total{cache="memory",result="miss"}`:      2,
+		`goride_cache_requests_total{cache="disk",result="hit"}`:         1,
+		`goride_cache_requests_total{cache="disk",result="miss"}`:        1,
+		`goride_requests_total{endpoint="/a",method="GET",status="200"}`: 2,
+		`goride_requests_total{endpoint="/a",method="GET",status="304"}`: 2,
+		`goride_requests_total{endpoint="/b",method="GET",status="200"}`: 1,
+	}
+	if diff := cmp.Diff(want, m.Counters()); diff != "" {
+		t.Errorf("bad counters: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,162 @@
+package goride
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/zigdon/goride/units"
+)
+
+// nameLoopRadius is how close, in meters, a ride has to end to where it
+// started to be named a loop.
+const nameLoopRadius = 500
+
+// defaultRideNames are the names rides get when no one names them, lower case.
+var defaultRideNames = map[string]bool{
+	"":               true,
+	"ride":           true,
+	"morning ride":   true,
+	"lunch ride":     true,
+	"afternoon ride": true,
+	"evening ride":   true,
+	"night ride":     true,
+}
+
+// IsDefaultRideName is true for names like "Morning Ride" that rides get when
+// no one names them.
+func IsDefaultRideName(name string) bool {
+	return defaultRideNames[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// NameOptions control SuggestRideName.
+type NameOptions struct {
+	// Units are the units for the distance and elevation. Metric if nil,
+	// except in RenameDefaultRides, which uses the user's, see RWGPS.Units.
+	Units *units.System
+	// Template, if set, is a text/template for the name, run with a NameData,
+	// e.g. "{{.TimeOfDay}} spin ({{.Distance}})".
+	Template string
+}
+
+// NameData is what a NameOptions template can use. Place is empty if the
+// ride's locality and area aren't known, and Elevation if it didn't climb.
+type NameData struct {
+	// TimeOfDay is "Morning", "Lunch", "Afternoon", "Evening" or "Night", from
+	// the ride's local departure time.
+	TimeOfDay string
+	// Kind is "loop" if the ride ended where it started, and "ride" otherwise.
+	Kind      string
+	Place     string
+	Distance  string
+	Elevation string
+	Ride      *RideSlim
+}
+
+// defaultNameTemplate makes names like "Lunch loop — Boulder, CO (42 km, 610 m)".
+const defaultNameTemplate = `{{.TimeOfDay}} {{.Kind}}` +
+	`{{with .Place}} — {{.}}{{end}}` +
+	` ({{.Distance}}{{with .Elevation}}, {{.}}{{end}})`
+
+func (o NameOptions) template() (*template.Template, error) {
+	text := o.Template
+	if text == "" {
+		text = defaultNameTemplate
+	}
+	tmpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("bad name template %q: %w", o.Template, err)
+	}
+	return tmpl, nil
+}
+
+// SuggestRideName makes a more useful name for a ride than "Morning Ride",
+// from when and where it started, its distance and its climbing. A bad
+// template, or one that fails on the ride, falls back to the default one.
+func SuggestRideName(ride *RideSlim, opts NameOptions) string {
+	tmpl, err := opts.template()
+	if err == nil {
+		if name, err := rideName(tmpl, ride, opts); err == nil {
+			return name
+		}
+	}
+	tmpl, _ = NameOptions{}.template()
+	name, _ := rideName(tmpl, ride, opts)
+	return name
+}
+
+func rideName(tmpl *template.Template, ride *RideSlim, opts NameOptions) (string, error) {
+	system := units.Metric
+	if opts.Units != nil {
+		system = *opts.Units
+	}
+	data := NameData{
+		TimeOfDay: timeOfDay(ride.LocalDepartedAt().Hour()),
+		Kind:      "ride",
+		Place:     rideLabel(ride),
+		Distance:  system.FormatShortDistance(units.Distance(ride.Distance)),
+		Ride:      ride,
+	}
+	if ride.FirstLat != 0 && ride.LastLat != 0 &&
+		haversine(ride.FirstLat, ride.FirstLng, ride.LastLat, ride.LastLng) <= nameLoopRadius {
+		data.Kind = "loop"
+	}
+	if e := units.Elevation(ride.ElevationGain); system.FormatElevation(e) != system.FormatElevation(0) {
+		data.Elevation = system.FormatElevation(e)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("can't name ride %d: %w", ride.ID, err)
+	}
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// timeOfDay is the name for the part of the day an hour is in.
+func timeOfDay(hour int) string {
+	switch {
+	case hour >= 5 && hour < 11:
+		return "Morning"
+	case hour >= 11 && hour < 14:
+		return "Lunch"
+	case hour >= 14 && hour < 17:
+		return "Afternoon"
+	case hour >= 17 && hour < 21:
+		return "Evening"
+	}
+	return "Night"
+}
+
+// RenameDefaultRides renames the rides that still have a default name, see
+// IsDefaultRideName, to the one SuggestRideName makes for them. Rides someone
+// already named are never touched. Updates are done like BulkUpdateRides, and
+// the result only lists the rides that were renamed.
+func (r *RWGPS) RenameDefaultRides(rides []*RideSlim, opts NameOptions, bulk BulkOptions) (BulkResult, error) {
+	if _, err := opts.template(); err != nil {
+		return BulkResult{}, err
+	}
+	if opts.Units == nil {
+		system := r.Units()
+		opts.Units = &system
+	}
+
+	names := make(map[int]string)
+	var ids []int
+	for _, ride := range rides {
+		if !IsDefaultRideName(ride.Name) {
+			continue
+		}
+		name := SuggestRideName(ride, opts)
+		if name == ride.Name {
+			continue
+		}
+		names[ride.ID] = name
+		ids = append(ids, ride.ID)
+	}
+	r.log().Debug("renaming rides with default names", "rides", len(ids), "total", len(rides))
+
+	return r.bulkUpdateRides(ids, func(id int) RideUpdate {
+		name := names[id]
+		return RideUpdate{Name: &name}
+	}, bulk)
+}
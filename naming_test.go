@@ -0,0 +1,188 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride/units"
+)
+
+// boulderLoop is a 42km loop from Boulder, leaving at 12:30 local time.
+func boulderLoop() *RideSlim {
+	return &RideSlim{
+		ID:                 1,
+		Name:               "Lunch Ride",
+		DepartedAt:         time.Date(2021, 8, 1, 18, 30, 0, 0, time.UTC),
+		TimeZone:           "America/Denver",
+		Distance:           42300,
+		ElevationGain:      610,
+		FirstLat:           40.0150,
+		FirstLng:           -105.2705,
+		LastLat:            40.0160,
+		LastLng:            -105.2700,
+		Locality:           "Boulder",
+		AdministrativeArea: "CO",
+	}
+}
+
+func TestSuggestRideName(t *testing.T) {
+	imperial := units.Imperial
+	ride := func(f func(r *RideSlim)) *RideSlim {
+		r := boulderLoop()
+		f(r)
+		return r
+	}
+
+	tests := []struct {
+		desc string
+		ride *RideSlim
+		opts NameOptions
+		want string
+	}{
+		{
+			desc: "loop",
+			ride: boulderLoop(),
+			want: "Lunch loop — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "point to point",
+			ride: ride(func(r *RideSlim) {
+				r.DepartedAt = time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+				r.LastLat, r.LastLng = 39.7392, -104.9903
+			}),
+			want: "Morning ride — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "offset without a timezone",
+			ride: ride(func(r *RideSlim) {
+				r.TimeZone, r.UtcOffset = "", -7*3600
+				r.DepartedAt = time.Date(2021, 8, 2, 1, 0, 0, 0, time.UTC)
+			}),
+			want: "Evening loop — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "night",
+			ride: ride(func(r *RideSlim) { r.DepartedAt = time.Date(2021, 8, 2, 5, 0, 0, 0, time.UTC) }),
+			want: "Night loop — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "no place",
+			ride: ride(func(r *RideSlim) { r.Locality, r.AdministrativeArea = "", "" }),
+			want: "Lunch loop (42 km, 610 m)",
+		},
+		{
+			desc: "flat",
+			ride: ride(func(r *RideSlim) { r.ElevationGain = 0.4 }),
+			want: "Lunch loop — Boulder, CO (42 km)",
+		},
+		{
+			desc: "no start or end",
+			ride: ride(func(r *RideSlim) { r.FirstLat, r.FirstLng, r.LastLat, r.LastLng = 0, 0, 0, 0 }),
+			want: "Lunch ride — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "imperial",
+			ride: boulderLoop(),
+			opts: NameOptions{Units: &imperial},
+			want: "Lunch loop — Boulder, CO (26 mi, 2001 ft)",
+		},
+		{
+			desc: "template",
+			ride: boulderLoop(),
+			opts: NameOptions{Template: "{{.TimeOfDay}} spin in {{.Place}}, {{.Distance}}"},
+			want: "Lunch spin in Boulder, CO, 42 km",
+		},
+		{
+			desc: "template with the ride",
+			ride: boulderLoop(),
+			opts: NameOptions{Template: "{{.Kind}} #{{.Ride.ID}} {{with .Place}}in {{.}}{{end}}"},
+			want: "loop #1 in Boulder, CO",
+		},
+		{
+			desc: "bad template",
+			ride: boulderLoop(),
+			opts: NameOptions{Template: "{{.TimeOfDay"},
+			want: "Lunch loop — Boulder, CO (42 km, 610 m)",
+		},
+		{
+			desc: "template that fails",
+			ride: boulderLoop(),
+			opts: NameOptions{Template: "{{.Weather}} ride", Units: &imperial},
+			want: "Lunch loop — Boulder, CO (26 mi, 2001 ft)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := SuggestRideName(tc.ride, tc.opts); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestIsDefaultRideName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Morning Ride":         true,
+		"Afternoon Ride":       true,
+		" evening ride ":       true,
+		"":                     true,
+		"Morning Ride to work": false,
+		"Lunch loop":           false,
+		"Tuesday worlds":       false,
+	} {
+		if got := IsDefaultRideName(name); got != want {
+			t.Errorf("IsDefaultRideName(%q): want %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestRenameDefaultRides(t *testing.T) {
+	f := newFakeRWGPS(t,
+		&Ride{ID: 1, Name: "Lunch Ride"},
+		&Ride{ID: 2, Name: "Gravel with Sam"},
+		&Ride{ID: 3, Name: "Evening Ride"},
+	)
+	r := testObj(f.URL)
+	WithUnits(units.Imperial)(r)
+
+	named := boulderLoop()
+	named.ID, named.Name = 2, "Gravel with Sam"
+	evening := boulderLoop()
+	evening.ID, evening.Name = 3, "Evening Ride"
+	evening.DepartedAt = evening.DepartedAt.Add(6 * time.Hour)
+	rides := []*RideSlim{boulderLoop(), named, evening}
+
+	got, err := r.RenameDefaultRides(rides, NameOptions{}, BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[int][]string{
+		1: {`name: "Lunch Ride" -> "Lunch loop — Boulder, CO (26 mi, 2001 ft)"`},
+		3: {`name: "Evening Ride" -> "Evening loop — Boulder, CO (26 mi, 2001 ft)"`},
+	}
+	if diff := cmp.Diff(want, got.Changes); diff != "" {
+		t.Errorf("bad dry run: -want +got\n%s", diff)
+	}
+	if w := f.writes(); len(w) != 0 {
+		t.Errorf("dry run wrote: %v", w)
+	}
+
+	got, err = r.RenameDefaultRides(rides, NameOptions{Template: "{{.TimeOfDay}} {{.Kind}}"}, BulkOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 3}, got.Updated); diff != "" {
+		t.Errorf("bad renamed rides: -want +got\n%s", diff)
+	}
+	for id, want := range map[int]string{1: "Lunch loop", 2: "Gravel with Sam", 3: "Evening loop"} {
+		if got := f.ride(id).Name; got != want {
+			t.Errorf("ride %d: want name %q, got %q", id, want, got)
+		}
+	}
+
+	if _, err := r.RenameDefaultRides(rides, NameOptions{Template: "{{"}, BulkOptions{}); err == nil {
+		t.Errorf("expected an error for a bad template")
+	}
+}
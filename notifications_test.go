@@ -0,0 +1,135 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGetNotifications(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/notifications.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("notifications.json"))
+	})
+	r := testObj(f.URL)
+
+	got, count, err := r.GetNotifications(0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("wrong count: %d", count)
+	}
+
+	pdt := time.FixedZone("", -7*3600)
+	want := []*Notification{
+		{
+			ID:        905,
+			Type:      NotificationComment,
+			CreatedAt: time.Date(2021, 8, 3, 18, 22, 10, 0, pdt),
+			Actor:     NotificationActor{ID: 2, Name: "Club Mate"},
+			Subject:   &NotificationSubject{Type: "trip", ID: 94, Name: "Peak To Peak"},
+		},
+		{
+			ID:        904,
+			Type:      NotificationFollow,
+			CreatedAt: time.Date(2021, 8, 3, 9, 1, 44, 0, pdt),
+			Actor:     NotificationActor{ID: 3, Name: "New Friend"},
+		},
+		{
+			ID:        903,
+			Type:      "club_invite",
+			CreatedAt: time.Date(2021, 8, 2, 12, 0, 0, 0, pdt),
+		},
+		{
+			ID:        902,
+			Type:      NotificationLike,
+			Read:      true,
+			CreatedAt: time.Date(2021, 8, 1, 20, 15, 0, 0, pdt),
+			Actor:     NotificationActor{ID: 2, Name: "Club Mate"},
+			Subject:   &NotificationSubject{Type: "route", ID: 31330404, Name: "Grizzly Peak"},
+		},
+	}
+	opts := []cmp.Option{cmpopts.IgnoreFields(Notification{}, "Raw"), cmpopts.EquateApproxTime(0)}
+	if diff := cmp.Diff(want, got, opts...); diff != "" {
+		t.Errorf("bad notifications: -want +got\n%s", diff)
+	}
+	if len(got) == 4 && !json.Valid(got[2].Raw) {
+		t.Errorf("unknown notification doesn't keep its raw entry: %s", got[2].Raw)
+	}
+}
+
+func TestNotificationsSince(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	// IDs 120 down to 1, newest first.
+	f.handle("/notifications.json", func(w http.ResponseWriter, req *http.Request) {
+		offset, _ := strconv.Atoi(req.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		fmt.Fprint(w, `{"results_count":120,"results":[`)
+		for i := offset; i < offset+limit && i < 120; i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":%d,"type":"like"}`, 120-i)
+		}
+		fmt.Fprint(w, "]}")
+	})
+
+	tests := []struct {
+		desc      string
+		after     int
+		wantFirst int
+		wantLen   int
+	}{
+		{desc: "nothing new", after: 120},
+		{desc: "one page", after: 110, wantFirst: 120, wantLen: 10},
+		{desc: "two pages", after: 60, wantFirst: 120, wantLen: 60},
+		{desc: "everything", after: 0, wantFirst: 120, wantLen: 120},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := r.NotificationsSince(tc.after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tc.wantLen {
+				t.Fatalf("want %d notifications, got %d", tc.wantLen, len(got))
+			}
+			if len(got) > 0 && (got[0].ID != tc.wantFirst || got[len(got)-1].ID != tc.after+1) {
+				t.Errorf("bad range: %d to %d", got[0].ID, got[len(got)-1].ID)
+			}
+		})
+	}
+}
+
+func TestMarkNotificationRead(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	var body string
+	f.handle("/notifications/905.json", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		fmt.Fprint(w, "{}")
+	})
+
+	if err := r.MarkNotificationRead(905); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"notification":{"read":true}}`; body != want {
+		t.Errorf("bad body: want %s, got %s", want, body)
+	}
+	if diff := cmp.Diff([]string{"PUT /notifications/905.json"}, f.writes()); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,199 @@
+package goride
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Area is a place rides can pass through, like a bridge or a climb, see
+// RidesThrough.
+type Area interface {
+	// Bounds is a box around the whole area.
+	Bounds() BoundingBox
+	Contains(p LatLng) bool
+}
+
+// Bounds returns the box itself, so it can be used as an Area.
+func (b BoundingBox) Bounds() BoundingBox {
+	return b
+}
+
+// Corridor is the area within Width meters of a line, like a road or a
+// bridge. Use NewCorridor to make one.
+type Corridor struct {
+	Line  []LatLng
+	Width float64
+
+	lat0 float64
+	line []xy
+}
+
+// NewCorridor returns the corridor width meters either side of the line.
+func NewCorridor(line []LatLng, width float64) *Corridor {
+	c := &Corridor{Line: line, Width: width}
+	points := make([]TrackPoint, len(line))
+	for i, p := range line {
+		c.lat0 += float64(p.Lat) / float64(len(line))
+		points[i] = TrackPoint{Lat: float64(p.Lat), Lng: float64(p.Lng)}
+	}
+	c.line = projectTrack(points, c.lat0)
+
+	return c
+}
+
+// Bounds is the line's bounding box, grown by the corridor's width.
+func (c *Corridor) Bounds() BoundingBox {
+	if len(c.Line) == 0 {
+		// An empty box.
+		return BoundingBox{SW: LatLng{Lat: 1}}
+	}
+	b := BoundingBox{SW: c.Line[0], NE: c.Line[0]}
+	for _, p := range c.Line[1:] {
+		b.SW.Lat, b.SW.Lng = min(b.SW.Lat, p.Lat), min(b.SW.Lng, p.Lng)
+		b.NE.Lat, b.NE.Lng = max(b.NE.Lat, p.Lat), max(b.NE.Lng, p.Lng)
+	}
+	dLat := float32(c.Width / metersPerDegree)
+	dLng := float32(c.Width / (metersPerDegree * math.Cos(c.lat0*math.Pi/180)))
+	b.SW.Lat, b.SW.Lng = b.SW.Lat-dLat, b.SW.Lng-dLng
+	b.NE.Lat, b.NE.Lng = b.NE.Lat+dLat, b.NE.Lng+dLng
+
+	return b
+}
+
+// Contains is true if p is within the corridor's width of its line.
+func (c *Corridor) Contains(p LatLng) bool {
+	if len(c.line) == 0 {
+		return false
+	}
+	pt := projectTrack([]TrackPoint{{Lat: float64(p.Lat), Lng: float64(p.Lng)}}, c.lat0)
+	if len(pt) == 0 {
+		return false
+	}
+	if len(c.line) == 1 {
+		return pt[0].dist(c.line[0]) <= c.Width
+	}
+	for j := 0; j+1 < len(c.line); j++ {
+		if d, _ := closestOnSegment(pt[0], c.line[j], c.line[j+1]); d <= c.Width {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CandidateRidesThrough returns the rides whose bounding box overlaps the
+// area's, without fetching anything. Only those can pass through the area,
+// but many of them won't; see RidesThrough. Rides without a bounding box are
+// skipped.
+func CandidateRidesThrough(rides []*RideSlim, area Area) []*RideSlim {
+	bounds := area.Bounds()
+	var res []*RideSlim
+	for _, r := range rides {
+		if r.Bounds().Intersects(bounds) {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// FirstPointIn returns the index of the first track point in the area, or -1
+// if none of them are.
+func FirstPointIn(points []TrackPoint, area Area) int {
+	bounds := area.Bounds()
+	for i, p := range points {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		ll := LatLng{Lat: float32(p.Lat), Lng: float32(p.Lng)}
+		if bounds.Contains(ll) && area.Contains(ll) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// PassThrough is a ride that went through an area. Index is its first track
+// point in the area, and Time when it got there.
+type PassThrough struct {
+	Ride  *RideSlim
+	Index int
+	Time  time.Time
+}
+
+// RidesThrough returns the rides that have a track point in the area, in the
+// order they were given. Only the candidates (see CandidateRidesThrough) are
+// fetched, at most concurrency at a time, using GetRide so responses can come
+// from the client's cache. Rate limited fetches are retried; any other error,
+// or ctx being done, stops once the fetches in flight return.
+func (r *RWGPS) RidesThrough(ctx context.Context, rides []*RideSlim, area Area, concurrency int) ([]PassThrough, error) {
+	candidates := CandidateRidesThrough(rides, area)
+	r.log().Debug("checking rides through area", "rides", len(rides), "candidates", len(candidates))
+	matches := make([]*PassThrough, len(candidates))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var fetchErr error
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < max(concurrency, 1) && i < len(candidates); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				if ctx.Err() != nil {
+					continue
+				}
+				var ride *Ride
+				err := r.retryRateLimited(ctx, RetryOptions{Retries: pageRetries, RetryWait: pageRetryWait}, func() error {
+					var err error
+					ride, err = r.GetRide(candidates[c].ID)
+					return err
+				})
+				if err != nil {
+					mu.Lock()
+					if fetchErr == nil {
+						fetchErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				if i := FirstPointIn(ride.TrackPoints, area); i >= 0 {
+					matches[c] = &PassThrough{Ride: candidates[c], Index: i, Time: ride.TrackPoints[i].Time}
+				}
+			}
+		}()
+	}
+dispatch:
+	for c := range candidates {
+		select {
+		case work <- c:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if fetchErr == nil {
+		fetchErr = ctx.Err()
+	}
+	if fetchErr != nil {
+		return nil, fmt.Errorf("error checking rides through area: %w", fetchErr)
+	}
+
+	res := []PassThrough{}
+	for _, m := range matches {
+		if m != nil {
+			res = append(res, *m)
+		}
+	}
+
+	return res, nil
+}
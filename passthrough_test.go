@@ -0,0 +1,152 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// goldenGate is the Golden Gate bridge, from the south end to the north one.
+var goldenGate = []LatLng{{37.8080, -122.4750}, {37.8324, -122.4795}}
+
+func TestCorridor(t *testing.T) {
+	c := NewCorridor(goldenGate, 30)
+	tests := []struct {
+		desc string
+		p    LatLng
+		want bool
+	}{
+		{desc: "end", p: goldenGate[0], want: true},
+		{desc: "middle", p: LatLng{37.8200, -122.4772}, want: true},
+		{desc: "off to the side", p: LatLng{37.8200, -122.4760}},
+		{desc: "past the end", p: LatLng{37.8060, -122.4746}},
+		{desc: "far", p: LatLng{37.8044, -122.2712}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := c.Contains(tc.p); got != tc.want {
+				t.Errorf("Contains(%v): want %v, got %v", tc.p, tc.want, got)
+			}
+			if tc.want && !c.Bounds().Contains(tc.p) {
+				t.Errorf("bounds %v don't contain %v", c.Bounds(), tc.p)
+			}
+		})
+	}
+
+	if !NewCorridor(nil, 30).Bounds().Empty() {
+		t.Errorf("a corridor without a line should have empty bounds")
+	}
+}
+
+func TestRidesThrough(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/1/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("trips_bridge.json"))
+	})
+	var fetched []int
+	for _, id := range []int{1, 2, 3, 5} {
+		id := id
+		f.handle(fmt.Sprintf("/trips/%d.json", id), func(w http.ResponseWriter, req *http.Request) {
+			fetched = append(fetched, id)
+			fmt.Fprint(w, getTestData(fmt.Sprintf("trip_bridge%d.json", id)))
+		})
+	}
+	r := testObj(f.URL)
+	rides, _, err := r.GetRides(1, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	at := func(min int) time.Time { return time.Date(2021, 8, 1, 9, min, 0, 0, time.UTC) }
+	box := BoundingBox{SW: LatLng{37.8100, -122.4800}, NE: LatLng{37.8300, -122.4740}}
+
+	tests := []struct {
+		desc       string
+		area       Area
+		candidates []int
+		want       map[int]PassThrough
+	}{
+		{
+			desc: "box",
+			area: box,
+			// The ferry ride's box overlaps, but it goes around.
+			candidates: []int{1, 2, 5},
+			want: map[int]PassThrough{
+				1: {Index: 2, Time: at(2)},
+				5: {Index: 1, Time: at(1)},
+			},
+		},
+		{
+			desc: "corridor",
+			area: NewCorridor(goldenGate, 30),
+			// The vista point is next to the bridge, not on it.
+			candidates: []int{1, 2, 5},
+			want:       map[int]PassThrough{1: {Index: 1, Time: at(1)}},
+		},
+		{
+			desc: "nowhere near",
+			area: BoundingBox{SW: LatLng{40, -120}, NE: LatLng{41, -119}},
+			want: map[int]PassThrough{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var candidates []int
+			for _, ride := range CandidateRidesThrough(rides, tc.area) {
+				candidates = append(candidates, ride.ID)
+			}
+			if diff := cmp.Diff(tc.candidates, candidates); diff != "" {
+				t.Errorf("bad candidates: -want +got\n%s", diff)
+			}
+
+			f.mu.Lock()
+			fetched = nil
+			f.mu.Unlock()
+			got, err := r.RidesThrough(context.Background(), rides, tc.area, 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			gotByID := make(map[int]PassThrough)
+			for _, p := range got {
+				if p.Ride == nil {
+					t.Fatalf("match without a ride: %+v", p)
+				}
+				gotByID[p.Ride.ID] = PassThrough{Index: p.Index, Time: p.Time}
+			}
+			if diff := cmp.Diff(tc.want, gotByID); diff != "" {
+				t.Errorf("bad matches: -want +got\n%s", diff)
+			}
+
+			// Only the candidates are fetched.
+			f.mu.Lock()
+			sort.Ints(fetched)
+			if diff := cmp.Diff(tc.candidates, fetched); diff != "" {
+				t.Errorf("bad fetched rides: -want +got\n%s", diff)
+			}
+			f.mu.Unlock()
+		})
+	}
+}
+
+func TestRidesThroughErrors(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+	rides := []*RideSlim{{ID: 42, SwLat: 37.8, SwLng: -122.5, NeLat: 37.9, NeLng: -122.4}}
+	area := BoundingBox{SW: LatLng{37.8100, -122.4800}, NE: LatLng{37.8300, -122.4740}}
+
+	if _, err := r.RidesThrough(context.Background(), rides, area, 4); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.RidesThrough(ctx, rides, area, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
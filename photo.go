@@ -0,0 +1,128 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// Photo is a photo attached to a ride.
+type Photo struct {
+	ID      int    `json:"id"`
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
+}
+
+var (
+	ErrPhotoTooLarge = errors.New("photo is too large")
+	ErrPhotoFormat   = errors.New("unsupported photo format")
+)
+
+// PhotoUploadError is a photo the server rejected. Err is ErrPhotoTooLarge or
+// ErrPhotoFormat if the reason is known, and Message is the server's
+// explanation.
+type PhotoUploadError struct {
+	Err     error
+	Message string
+}
+
+func (e *PhotoUploadError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("photo rejected: %s", e.Message)
+	}
+	return fmt.Sprintf("photo rejected: %v: %s", e.Err, e.Message)
+}
+
+func (e *PhotoUploadError) Unwrap() error {
+	return e.Err
+}
+
+var photoTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+}
+
+// UploadRidePhoto attaches a photo to a ride. The type is taken from the
+// filename's extension, and must be JPEG, PNG or GIF.
+func (r *RWGPS) UploadRidePhoto(rideID int, photo io.Reader, filename, caption string) (*Photo, error) {
+	contentType, ok := photoTypes[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return nil, fmt.Errorf("can't upload %q: %w", filename, ErrPhotoFormat)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="photo[file]"; filename=%q`, filepath.Base(filename)))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return nil, fmt.Errorf("can't create upload for %q: %w", filename, err)
+	}
+	if _, err := io.Copy(part, photo); err != nil {
+		return nil, fmt.Errorf("can't read %q: %w", filename, err)
+	}
+	if err := mw.WriteField("photo[caption]", caption); err != nil {
+		return nil, fmt.Errorf("can't create upload for %q: %w", filename, err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("can't create upload for %q: %w", filename, err)
+	}
+
+	res, err := r.doWithType(http.MethodPost, fmt.Sprintf("/trips/%d/photos.json", rideID), nil, body.Bytes(), mw.FormDataContentType())
+	if err != nil {
+		return nil, fmt.Errorf("error uploading %q to ride %d: %w", filename, rideID, photoError(err))
+	}
+
+	var resStruct struct {
+		Photo Photo
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return &resStruct.Photo, nil
+}
+
+// photoError turns the server's validation errors into PhotoUploadErrors.
+func photoError(err error) error {
+	var se *statusError
+	if !errors.As(err, &se) {
+		return err
+	}
+
+	msg := se.body
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal([]byte(se.body), &body) == nil && len(body.Errors) > 0 {
+		msg = strings.Join(body.Errors, "; ")
+	}
+
+	switch se.code {
+	case http.StatusRequestEntityTooLarge:
+		return &PhotoUploadError{Err: ErrPhotoTooLarge, Message: msg}
+	case http.StatusUnsupportedMediaType:
+		return &PhotoUploadError{Err: ErrPhotoFormat, Message: msg}
+	case http.StatusUnprocessableEntity:
+		lower := strings.ToLower(msg)
+		switch {
+		case strings.Contains(lower, "large") || strings.Contains(lower, "size"):
+			return &PhotoUploadError{Err: ErrPhotoTooLarge, Message: msg}
+		case strings.Contains(lower, "format") || strings.Contains(lower, "type"):
+			return &PhotoUploadError{Err: ErrPhotoFormat, Message: msg}
+		}
+		return &PhotoUploadError{Message: msg}
+	}
+
+	return err
+}
@@ -0,0 +1,155 @@
+package goride
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// A 1x1 PNG.
+var testPNG = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+	0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0xf8, 0xcf, 0xc0, 0xf0,
+	0x1f, 0x00, 0x05, 0x00, 0x01, 0xff, 0x89, 0x99, 0x3d, 0x1d, 0x00, 0x00,
+	0x00, 0x00, 0x49, 0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+}
+
+type photoUpload struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Caption     string
+}
+
+func TestUploadRidePhoto(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	var got photoUpload
+	f.handle("/trips/1/photos.json", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "bad method", http.StatusMethodNotAllowed)
+			return
+		}
+		file, h, err := req.FormFile("photo[file]")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := ioutil.ReadAll(file)
+		got = photoUpload{
+			Filename:    h.Filename,
+			ContentType: h.Header.Get("Content-Type"),
+			Data:        data,
+			Caption:     req.FormValue("photo[caption]"),
+		}
+		fmt.Fprintf(w, `{"photo": {"id": 555, "url": "https://example.com/photos/555.png", "caption": %q}}`, got.Caption)
+	})
+	r := testObj(f.URL)
+
+	photo, err := r.UploadRidePhoto(1, bytes.NewReader(testPNG), "/tmp/summit.PNG", "At the top")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := photoUpload{
+		Filename:    "summit.PNG",
+		ContentType: "image/png",
+		Data:        testPNG,
+		Caption:     "At the top",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad upload: -want +got\n%s", diff)
+	}
+	wantPhoto := &Photo{ID: 555, URL: "https://example.com/photos/555.png", Caption: "At the top"}
+	if diff := cmp.Diff(wantPhoto, photo); diff != "" {
+		t.Errorf("bad photo: -want +got\n%s", diff)
+	}
+}
+
+func TestUploadRidePhotoErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		filename string
+		code     int
+		body     string
+		want     error
+		wantMsg  string
+	}{
+		{
+			desc:     "unsupported extension",
+			filename: "ride.tiff",
+			want:     ErrPhotoFormat,
+		},
+		{
+			desc:     "too large",
+			filename: "big.jpg",
+			code:     http.StatusRequestEntityTooLarge,
+			body:     "request too large",
+			want:     ErrPhotoTooLarge,
+			wantMsg:  "request too large",
+		},
+		{
+			desc:     "validation, size",
+			filename: "big.jpg",
+			code:     http.StatusUnprocessableEntity,
+			body:     `{"errors": ["File size must be under 10MB"]}`,
+			want:     ErrPhotoTooLarge,
+			wantMsg:  "File size must be under 10MB",
+		},
+		{
+			desc:     "validation, format",
+			filename: "fake.gif",
+			code:     http.StatusUnprocessableEntity,
+			body:     `{"errors": ["Unrecognized image format"]}`,
+			want:     ErrPhotoFormat,
+			wantMsg:  "Unrecognized image format",
+		},
+		{
+			desc:     "validation, other",
+			filename: "ok.jpg",
+			code:     http.StatusUnprocessableEntity,
+			body:     `{"errors": ["Caption is too rude", "Try again"]}`,
+			wantMsg:  "Caption is too rude; Try again",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t, testRides()...)
+			f.handle("/trips/1/photos.json", func(w http.ResponseWriter, req *http.Request) {
+				http.Error(w, tc.body, tc.code)
+			})
+			r := testObj(f.URL)
+
+			_, err := r.UploadRidePhoto(1, bytes.NewReader(testPNG), tc.filename, "")
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("error %v isn't %v", err, tc.want)
+			}
+
+			var pe *PhotoUploadError
+			if tc.wantMsg == "" {
+				if len(f.writes()) != 0 {
+					t.Errorf("unsupported photo was sent to the server")
+				}
+				return
+			}
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected a PhotoUploadError, got %v", err)
+			}
+			if !strings.Contains(pe.Message, tc.wantMsg) {
+				t.Errorf("bad message: want %q, got %q", tc.wantMsg, pe.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type OpKind string
+
+const (
+	OpRename        OpKind = "rename"
+	OpSetGear       OpKind = "set_gear"
+	OpSetVisibility OpKind = "set_visibility"
+	OpDelete        OpKind = "delete"
+)
+
+// Operation is a single change to make to a ride. Only the field matching the
+// Kind is used.
+type Operation struct {
+	Kind       OpKind     `json:"kind"`
+	RideID     int        `json:"ride_id"`
+	Name       string     `json:"name,omitempty"`
+	GearID     int        `json:"gear_id,omitempty"`
+	Visibility Visibility `json:"visibility,omitempty"`
+}
+
+// PlannedChange is an operation, along with the state of the ride when it was
+// planned.
+type PlannedChange struct {
+	Op        Operation `json:"op"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	// NoOp is set when the ride is already in the wanted state.
+	NoOp bool `json:"noop,omitempty"`
+}
+
+// PlanResult is what Plan found would change. It can be saved as JSON, and
+// later passed to Apply.
+type PlanResult struct {
+	Changes []PlannedChange `json:"changes"`
+}
+
+type OpError struct {
+	Op  Operation
+	Err error
+}
+
+type ApplyResult struct {
+	Applied []Operation
+	Skipped []Operation
+	// Drifted lists the rides that changed since the plan was made.
+	Drifted []int
+	Failed  []OpError
+}
+
+var ErrPlanDrifted = errors.New("rides changed since the plan was made")
+
+// Plan works out what the operations would change, using only read calls.
+func (r *RWGPS) Plan(ops []Operation) (PlanResult, error) {
+	var plan PlanResult
+	rides := make(map[int]*Ride)
+	deleted := make(map[int]bool)
+	for _, op := range ops {
+		if deleted[op.RideID] {
+			return PlanResult{}, fmt.Errorf("can't %s ride %d, it's deleted earlier in the plan", op.Kind, op.RideID)
+		}
+		ride, ok := rides[op.RideID]
+		if !ok {
+			var err error
+			ride, err = r.GetRideSummary(op.RideID)
+			if err != nil {
+				return PlanResult{}, fmt.Errorf("error planning %s of ride %d: %w", op.Kind, op.RideID, err)
+			}
+			rides[op.RideID] = ride
+		}
+
+		c := PlannedChange{Op: op, UpdatedAt: ride.UpdatedAt}
+		switch op.Kind {
+		case OpRename:
+			c.Before, c.After = ride.Name, op.Name
+		case OpSetGear:
+			c.Before, c.After = "none", strconv.Itoa(op.GearID)
+			if ride.Gear != nil {
+				c.Before = strconv.Itoa(ride.Gear.ID)
+			}
+		case OpSetVisibility:
+			c.Before, c.After = ride.Visibility.String(), op.Visibility.String()
+		case OpDelete:
+			c.Before, c.After = ride.Name, "deleted"
+			deleted[op.RideID] = true
+		default:
+			return PlanResult{}, fmt.Errorf("unknown operation %q for ride %d", op.Kind, op.RideID)
+		}
+		c.NoOp = c.Before == c.After
+		plan.Changes = append(plan.Changes, c)
+	}
+
+	return plan, nil
+}
+
+// Apply makes the changes in the plan. If any of the rides changed since the
+// plan was made, nothing is changed and ErrPlanDrifted is returned. Failed
+// operations don't stop the rest from being applied.
+func (r *RWGPS) Apply(plan PlanResult) (ApplyResult, error) {
+	var res ApplyResult
+
+	planned := make(map[int]time.Time)
+	for _, c := range plan.Changes {
+		if !c.NoOp {
+			planned[c.Op.RideID] = c.UpdatedAt
+		}
+	}
+	for id, updated := range planned {
+		// Only the update time is needed, not the whole ride.
+		ride, err := r.GetRideWithOpts(id, RequestOptions{Fields: []string{"id", "updated_at"}, NoTrackPoints: true})
+		if err != nil {
+			return res, fmt.Errorf("error checking ride %d: %w", id, err)
+		}
+		if !ride.UpdatedAt.Equal(updated) {
+			res.Drifted = append(res.Drifted, id)
+		}
+	}
+	if len(res.Drifted) > 0 {
+		sort.Ints(res.Drifted)
+		return res, fmt.Errorf("%w: %v", ErrPlanDrifted, res.Drifted)
+	}
+
+	for _, c := range plan.Changes {
+		if c.NoOp {
+			res.Skipped = append(res.Skipped, c.Op)
+			continue
+		}
+
+		if err := r.applyOp(c.Op); err != nil {
+			res.Failed = append(res.Failed, OpError{Op: c.Op, Err: err})
+			continue
+		}
+		res.Applied = append(res.Applied, c.Op)
+	}
+
+	if len(res.Failed) > 0 {
+		return res, fmt.Errorf("%d of %d operations failed, first error: %w", len(res.Failed), len(plan.Changes), res.Failed[0].Err)
+	}
+
+	return res, nil
+}
+
+func (r *RWGPS) applyOp(op Operation) error {
+	var u RideUpdate
+	switch op.Kind {
+	case OpRename:
+		u.Name = &op.Name
+	case OpSetGear:
+		u.GearID = &op.GearID
+	case OpSetVisibility:
+		u.Visibility = &op.Visibility
+	case OpDelete:
+		return r.DeleteRide(op.RideID)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+
+	return r.UpdateRide(op.RideID, u)
+}
@@ -0,0 +1,124 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testOps = []Operation{
+	{Kind: OpRename, RideID: 1, Name: "Commute"},
+	{Kind: OpSetGear, RideID: 2, GearID: 35},
+	{Kind: OpSetVisibility, RideID: 3, Visibility: Public},
+	{Kind: OpDelete, RideID: 3},
+}
+
+func TestPlanApply(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+
+	plan, err := r.Plan(testOps)
+	if err != nil {
+		t.Fatalf("error planning: %v", err)
+	}
+	if w := f.writes(); len(w) != 0 {
+		t.Fatalf("planning made writes: %v", w)
+	}
+
+	var summary [][3]interface{}
+	for _, c := range plan.Changes {
+		summary = append(summary, [3]interface{}{c.Before, c.After, c.NoOp})
+	}
+	wantSummary := [][3]interface{}{
+		{"Morning Ride", "Commute", false},
+		{"17", "35", false},
+		{"public", "public", true},
+		{"Evening Ride", "deleted", false},
+	}
+	if diff := cmp.Diff(wantSummary, summary); diff != "" {
+		t.Errorf("bad plan: -want +got\n%s", diff)
+	}
+
+	// The plan should survive being saved and loaded.
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("can't encode plan: %v", err)
+	}
+	var loaded PlanResult
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("can't decode plan: %v", err)
+	}
+	if diff := cmp.Diff(plan, loaded); diff != "" {
+		t.Errorf("plan didn't round trip: -want +got\n%s", diff)
+	}
+
+	res, err := r.Apply(loaded)
+	if err != nil {
+		t.Fatalf("error applying: %v", err)
+	}
+	if len(res.Applied) != 3 || len(res.Skipped) != 1 || len(res.Failed) != 0 {
+		t.Errorf("bad apply result: %+v", res)
+	}
+
+	wantWrites := []string{"PUT /trips/1.json", "PUT /trips/2.json", "DELETE /trips/3.json"}
+	if diff := cmp.Diff(wantWrites, f.writes()); diff != "" {
+		t.Errorf("bad writes: -want +got\n%s", diff)
+	}
+	if f.ride(1).Name != "Commute" || f.ride(2).Gear.ID != 35 || f.ride(3) != nil {
+		t.Errorf("changes weren't applied")
+	}
+	if n := f.trackPointGets(); n != 0 {
+		t.Errorf("planning and applying fetched the track points %d times", n)
+	}
+}
+
+func TestApplyDrift(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+
+	plan, err := r.Plan(testOps)
+	if err != nil {
+		t.Fatalf("error planning: %v", err)
+	}
+
+	// Someone edits ride 2 on the website.
+	f.mu.Lock()
+	f.rides[2].Name = "Renamed elsewhere"
+	f.touch(f.rides[2])
+	f.mu.Unlock()
+
+	res, err := r.Apply(plan)
+	if !errors.Is(err, ErrPlanDrifted) {
+		t.Fatalf("expected ErrPlanDrifted, got %v", err)
+	}
+	if diff := cmp.Diff([]int{2}, res.Drifted); diff != "" {
+		t.Errorf("bad drifted rides: -want +got\n%s", diff)
+	}
+	if w := f.writes(); len(w) != 0 {
+		t.Errorf("drifted plan made writes: %v", w)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+
+	tests := []struct {
+		desc string
+		ops  []Operation
+	}{
+		{"missing ride", []Operation{{Kind: OpRename, RideID: 42, Name: "x"}}},
+		{"unknown op", []Operation{{Kind: "paint", RideID: 1}}},
+		{"op after delete", []Operation{{Kind: OpDelete, RideID: 1}, {Kind: OpRename, RideID: 1, Name: "x"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := r.Plan(tc.ops); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
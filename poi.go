@@ -0,0 +1,268 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// POIType is the kind of a point of interest on a route.
+type POIType int
+
+const (
+	POIGeneric   POIType = 0
+	POIWater     POIType = 1
+	POICamping   POIType = 2
+	POIFood      POIType = 3
+	POILodging   POIType = 4
+	POIBikeShop  POIType = 5
+	POIRestroom  POIType = 6
+	POIViewpoint POIType = 7
+)
+
+func (t POIType) String() string {
+	switch t {
+	case POIGeneric:
+		return "generic"
+	case POIWater:
+		return "water"
+	case POICamping:
+		return "camping"
+	case POIFood:
+		return "food"
+	case POILodging:
+		return "lodging"
+	case POIBikeShop:
+		return "bike shop"
+	case POIRestroom:
+		return "restroom"
+	case POIViewpoint:
+		return "viewpoint"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+func (t POIType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+// UnmarshalJSON keeps unknown values as is, so they round trip.
+func (t *POIType) UnmarshalJSON(data []byte) error {
+	var n *int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("bad POI type %s: %w", data, err)
+	}
+	if n != nil {
+		*t = POIType(*n)
+	}
+
+	return nil
+}
+
+// POI is a point of interest on a route, like water, a campsite or a place
+// to resupply. ID is 0 for POIs that aren't on the server yet.
+type POI struct {
+	ID          int
+	Type        POIType
+	Name        string
+	Description string
+	Location    LatLng
+	URL         string
+}
+
+type poiJSON struct {
+	ID          int     `json:"id,omitempty"`
+	Type        POIType `json:"poi_type"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Lat         float32 `json:"lat"`
+	Lng         float32 `json:"lng"`
+	URL         string  `json:"url"`
+}
+
+func (p *POI) UnmarshalJSON(data []byte) error {
+	var raw poiJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bad point of interest: %w", err)
+	}
+	*p = POI{
+		ID:          raw.ID,
+		Type:        raw.Type,
+		Name:        raw.Name,
+		Description: raw.Description,
+		Location:    LatLng{Lat: raw.Lat, Lng: raw.Lng},
+		URL:         raw.URL,
+	}
+
+	return nil
+}
+
+func (p POI) MarshalJSON() ([]byte, error) {
+	return json.Marshal(poiJSON{
+		ID:          p.ID,
+		Type:        p.Type,
+		Name:        p.Name,
+		Description: p.Description,
+		Lat:         p.Location.Lat,
+		Lng:         p.Location.Lng,
+		URL:         p.URL,
+	})
+}
+
+func poiBody(p POI) ([]byte, error) {
+	p.ID = 0
+	body, err := json.Marshal(struct {
+		POI POI `json:"point_of_interest"`
+	}{p})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode point of interest %q: %w", p.Name, err)
+	}
+	return body, nil
+}
+
+// CreateRoutePOI adds a point of interest to a route, and returns it with its
+// new ID.
+func (r *RWGPS) CreateRoutePOI(routeID int, poi POI) (*POI, error) {
+	body, err := poiBody(poi)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.do(http.MethodPost, fmt.Sprintf("/routes/%d/points_of_interest.json", routeID), nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("error adding point of interest %q to route %d: %w", poi.Name, routeID, err)
+	}
+
+	var resStruct struct {
+		POI *POI `json:"point_of_interest"`
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+	if resStruct.POI == nil {
+		return nil, fmt.Errorf("adding point of interest %q to route %d didn't return it: %w", poi.Name, routeID, ErrDecode)
+	}
+
+	return resStruct.POI, nil
+}
+
+// UpdateRoutePOI replaces a point of interest on a route with poi, which must
+// have its ID. POIs that don't exist return ErrNotFound.
+func (r *RWGPS) UpdateRoutePOI(routeID int, poi POI) error {
+	if poi.ID == 0 {
+		return fmt.Errorf("can't update point of interest %q on route %d without its ID", poi.Name, routeID)
+	}
+	body, err := poiBody(poi)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/routes/%d/points_of_interest/%d.json", routeID, poi.ID)
+	if err := r.send(http.MethodPut, path, nil, body); err != nil {
+		return fmt.Errorf("error updating point of interest %d on route %d: %w", poi.ID, routeID, err)
+	}
+
+	return nil
+}
+
+// DeleteRoutePOI deletes a point of interest from a route. POIs that don't
+// exist return ErrNotFound.
+func (r *RWGPS) DeleteRoutePOI(routeID, poiID int) error {
+	path := fmt.Sprintf("/routes/%d/points_of_interest/%d.json", routeID, poiID)
+	if err := r.send(http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("error deleting point of interest %d from route %d: %w", poiID, routeID, err)
+	}
+
+	return nil
+}
+
+// POIChanges are the writes that turn a route's POIs into the wanted ones.
+// Updates and deletes have the IDs of the route's POIs.
+type POIChanges struct {
+	Create []POI
+	Update []POI
+	Delete []POI
+}
+
+// Empty is true if there's nothing to change.
+func (c POIChanges) Empty() bool {
+	return len(c.Create) == 0 && len(c.Update) == 0 && len(c.Delete) == 0
+}
+
+// DiffPOIs compares a route's current POIs with the wanted ones. Wanted POIs
+// with an ID are matched to the current one with that ID, and the others to
+// a current one with the same type and name, so a list kept elsewhere
+// doesn't need to track IDs. Matched POIs that differ are updated, wanted ones
+// without a match are created, and current ones that weren't wanted are
+// deleted. Changes are in the order of the lists they came from.
+func DiffPOIs(current, want []POI) POIChanges {
+	var res POIChanges
+	matched := make([]bool, len(current))
+	match := func(w POI) int {
+		for i, c := range current {
+			if matched[i] {
+				continue
+			}
+			if w.ID != 0 && c.ID == w.ID {
+				return i
+			}
+			if w.ID == 0 && c.Type == w.Type && c.Name == w.Name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, w := range want {
+		i := match(w)
+		if i < 0 {
+			w.ID = 0
+			res.Create = append(res.Create, w)
+			continue
+		}
+		matched[i] = true
+		w.ID = current[i].ID
+		if w != current[i] {
+			res.Update = append(res.Update, w)
+		}
+	}
+	for i, c := range current {
+		if !matched[i] {
+			res.Delete = append(res.Delete, c)
+		}
+	}
+
+	return res
+}
+
+// SyncRoutePOIs makes a route's POIs match want, see DiffPOIs, and returns the
+// changes. Deletes are done first, then updates, then creates, stopping at the
+// first error. In a dry run, the changes are returned without being made.
+func (r *RWGPS) SyncRoutePOIs(routeID int, want []POI) (POIChanges, error) {
+	route, err := r.GetRoute(routeID)
+	if err != nil {
+		return POIChanges{}, fmt.Errorf("can't sync points of interest: %w", err)
+	}
+
+	changes := DiffPOIs(route.POIs, want)
+	for _, p := range changes.Delete {
+		if err := r.DeleteRoutePOI(routeID, p.ID); err != nil {
+			return changes, err
+		}
+	}
+	for _, p := range changes.Update {
+		if err := r.UpdateRoutePOI(routeID, p); err != nil {
+			return changes, err
+		}
+	}
+	for _, p := range changes.Create {
+		if _, err := r.CreateRoutePOI(routeID, p); err != nil && !errors.Is(err, ErrDryRun) {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
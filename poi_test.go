@@ -0,0 +1,226 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPOIType(t *testing.T) {
+	if got := POIBikeShop.String(); got != "bike shop" {
+		t.Errorf("bad name: %q", got)
+	}
+	if got := POIType(42).String(); got != "unknown(42)" {
+		t.Errorf("bad unknown name: %q", got)
+	}
+
+	var p POI
+	data := `{"id":74,"poi_type":42,"name":"Ferry crossing","description":"","lat":40.4,"lng":-124.3,"url":""}`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Type != 42 {
+		t.Errorf("bad type: %v", p.Type)
+	}
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(data, string(got)); diff != "" {
+		t.Errorf("POI didn't round trip: -want +got\n%s", diff)
+	}
+
+	if err := json.Unmarshal([]byte(`{"poi_type":"water"}`), &p); err == nil {
+		t.Errorf("expected an error for a POI type that isn't a number")
+	}
+}
+
+func TestGetRoutePOIs(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/routes/31330500.json": getTestData("route_pois.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	route, err := r.GetRoute(31330500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []POI{
+		{ID: 71, Type: POIWater, Name: "Honeydew store", Description: "Tap behind the store", Location: LatLng{40.2437, -124.1229}},
+		{ID: 72, Type: POICamping, Name: "A.W. Way", Description: "County park, $5", Location: LatLng{40.2481, -124.1025}, URL: "https://example.com/aw-way"},
+		{ID: 73, Type: POIFood, Name: "Petrolia general store", Description: "Closes at 6", Location: LatLng{40.3251, -124.2874}},
+		{ID: 74, Type: POIType(42), Name: "Ferry crossing", Location: LatLng{40.4, -124.3}},
+	}
+	if diff := cmp.Diff(want, route.POIs); diff != "" {
+		t.Errorf("bad POIs: -want +got\n%s", diff)
+	}
+}
+
+func TestDiffPOIs(t *testing.T) {
+	water := POI{ID: 1, Type: POIWater, Name: "Spring", Location: LatLng{40, -124}}
+	camp := POI{ID: 2, Type: POICamping, Name: "Camp", Location: LatLng{40.1, -124}}
+	food := POI{ID: 3, Type: POIFood, Name: "Store", Location: LatLng{40.2, -124}}
+	noID := func(p POI) POI {
+		p.ID = 0
+		return p
+	}
+	moved := func(p POI) POI {
+		p.Location.Lat += 0.01
+		return p
+	}
+
+	tests := []struct {
+		desc    string
+		current []POI
+		want    []POI
+		changes POIChanges
+	}{
+		{
+			desc:    "same",
+			current: []POI{water, camp},
+			want:    []POI{water, camp},
+		},
+		{
+			desc:    "same without IDs",
+			current: []POI{water, camp},
+			want:    []POI{noID(camp), noID(water)},
+		},
+		{
+			desc:    "moved",
+			current: []POI{water, camp},
+			want:    []POI{noID(moved(camp)), water},
+			changes: POIChanges{Update: []POI{moved(camp)}},
+		},
+		{
+			desc:    "renamed by ID",
+			current: []POI{water},
+			want:    []POI{{ID: 1, Type: POIWater, Name: "Piped spring", Location: water.Location}},
+			changes: POIChanges{Update: []POI{{ID: 1, Type: POIWater, Name: "Piped spring", Location: water.Location}}},
+		},
+		{
+			desc:    "renamed without ID",
+			current: []POI{water},
+			want:    []POI{{Type: POIWater, Name: "Piped spring", Location: water.Location}},
+			changes: POIChanges{
+				Create: []POI{{Type: POIWater, Name: "Piped spring", Location: water.Location}},
+				Delete: []POI{water},
+			},
+		},
+		{
+			desc:    "added and removed",
+			current: []POI{water, camp},
+			want:    []POI{noID(food), camp},
+			changes: POIChanges{Create: []POI{noID(food)}, Delete: []POI{water}},
+		},
+		{
+			desc:    "unknown ID",
+			current: []POI{water},
+			want:    []POI{water, food},
+			changes: POIChanges{Create: []POI{noID(food)}},
+		},
+		{
+			desc:    "same names",
+			current: []POI{water, {ID: 4, Type: POIWater, Name: "Spring", Location: LatLng{41, -124}}},
+			want:    []POI{noID(water), noID(water)},
+			changes: POIChanges{Update: []POI{{ID: 4, Type: POIWater, Name: "Spring", Location: water.Location}}},
+		},
+		{
+			desc:    "empty",
+			current: []POI{water, camp},
+			changes: POIChanges{Delete: []POI{water, camp}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := DiffPOIs(tc.current, tc.want)
+			if diff := cmp.Diff(tc.changes, got); diff != "" {
+				t.Errorf("bad changes: -want +got\n%s", diff)
+			}
+			if got.Empty() != (len(tc.changes.Create)+len(tc.changes.Update)+len(tc.changes.Delete) == 0) {
+				t.Errorf("bad Empty() %v for %+v", got.Empty(), got)
+			}
+		})
+	}
+}
+
+func TestSyncRoutePOIs(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var bodies []string
+	f.handle("/routes/31330500.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("route_pois.json"))
+	})
+	f.handle("/routes/31330500/points_of_interest.json", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		fmt.Fprint(w, `{"point_of_interest":{"id":75,"poi_type":1,"name":"Mattole River","lat":40.29,"lng":-124.35}}`)
+	})
+	for _, id := range []int{71, 72, 73, 74} {
+		f.handle(fmt.Sprintf("/routes/31330500/points_of_interest/%d.json", id), func(w http.ResponseWriter, req *http.Request) {
+			b, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(b))
+			fmt.Fprint(w, "{}")
+		})
+	}
+	r := testObj(f.URL)
+
+	want := []POI{
+		{Type: POIWater, Name: "Honeydew store", Description: "Tap behind the store", Location: LatLng{40.2437, -124.1229}},
+		{Type: POICamping, Name: "A.W. Way", Description: "County park, $10", Location: LatLng{40.2481, -124.1025}, URL: "https://example.com/aw-way"},
+		{Type: POIType(42), Name: "Ferry crossing", Location: LatLng{40.4, -124.3}},
+		{Type: POIWater, Name: "Mattole River", Location: LatLng{40.29, -124.35}},
+	}
+	changes, err := r.SyncRoutePOIs(31330500, want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes.Create) != 1 || len(changes.Update) != 1 || len(changes.Delete) != 1 {
+		t.Errorf("bad changes: %+v", changes)
+	}
+
+	wantWrites := []string{
+		"DELETE /routes/31330500/points_of_interest/73.json",
+		"PUT /routes/31330500/points_of_interest/72.json",
+		"POST /routes/31330500/points_of_interest.json",
+	}
+	if diff := cmp.Diff(wantWrites, f.writes()); diff != "" {
+		t.Errorf("bad writes: -want +got\n%s", diff)
+	}
+	wantBodies := []string{
+		"",
+		`{"point_of_interest":{"poi_type":2,"name":"A.W. Way","description":"County park, $10","lat":40.2481,"lng":-124.1025,"url":"https://example.com/aw-way"}}`,
+		`{"point_of_interest":{"poi_type":1,"name":"Mattole River","description":"","lat":40.29,"lng":-124.35,"url":""}}`,
+	}
+	if diff := cmp.Diff(wantBodies, bodies); diff != "" {
+		t.Errorf("bad bodies: -want +got\n%s", diff)
+	}
+
+	// In a dry run, nothing is written but the changes are still returned.
+	dry := testObj(f.URL)
+	WithDryRun(true)(dry)
+	changes, err = dry.SyncRoutePOIs(31330500, want)
+	if err != nil {
+		t.Fatalf("unexpected error in a dry run: %v", err)
+	}
+	if len(changes.Create) != 1 || len(changes.Update) != 1 || len(changes.Delete) != 1 {
+		t.Errorf("bad dry run changes: %+v", changes)
+	}
+	if got := len(dry.DryRunJournal()); got != 3 {
+		t.Errorf("want 3 writes in the journal, got %d", got)
+	}
+	if got := len(f.writes()); got != 3 {
+		t.Errorf("dry run sent writes: %v", f.writes())
+	}
+}
+
+func TestUpdateRoutePOIWithoutID(t *testing.T) {
+	r := testObj("http://localhost:0")
+	if err := r.UpdateRoutePOI(1, POI{Name: "No ID"}); err == nil {
+		t.Errorf("expected an error updating a POI without an ID")
+	}
+}
@@ -0,0 +1,123 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// EncodePolyline encodes a track in Google's polyline format, with precision
+// decimal digits (5 or 6). Points without a position are skipped.
+func EncodePolyline(points []TrackPoint, precision int) string {
+	factor := math.Pow10(precision)
+	var sb strings.Builder
+	var lastLat, lastLng int64
+	for _, p := range points {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		lat, lng := int64(math.Round(p.Lat*factor)), int64(math.Round(p.Lng*factor))
+		encodePolylineValue(&sb, lat-lastLat)
+		encodePolylineValue(&sb, lng-lastLng)
+		lastLat, lastLng = lat, lng
+	}
+
+	return sb.String()
+}
+
+func encodePolylineValue(sb *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte(0x20|u&0x1f) + 63)
+		u >>= 5
+	}
+	sb.WriteByte(byte(u) + 63)
+}
+
+// DecodePolyline decodes a Google polyline encoded with precision decimal
+// digits.
+func DecodePolyline(s string, precision int) ([]LatLng, error) {
+	factor := math.Pow10(precision)
+	var res []LatLng
+	var lat, lng int64
+	for i := 0; i < len(s); {
+		var vals [2]int64
+		for k := range vals {
+			var u uint64
+			var shift uint
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("truncated polyline at point %d", len(res))
+				}
+				b := uint64(s[i]) - 63
+				i++
+				if b > 0x3f || shift > 60 {
+					return nil, fmt.Errorf("bad polyline character %q at %d", s[i-1], i-1)
+				}
+				u |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			vals[k] = int64(u >> 1)
+			if u&1 != 0 {
+				vals[k] = ^vals[k]
+			}
+		}
+		lat += vals[0]
+		lng += vals[1]
+		res = append(res, LatLng{Lat: float32(float64(lat) / factor), Lng: float32(float64(lng) / factor)})
+	}
+
+	return res, nil
+}
+
+// RideThumbnailPolyline encodes the ride's track, simplified as little as
+// needed for the URL escaped polyline to fit in maxChars.
+func RideThumbnailPolyline(ride *Ride, maxChars int) (string, error) {
+	enc, err := thumbnailPolyline(ride.TrackPoints, maxChars)
+	if err != nil {
+		return "", fmt.Errorf("can't fit ride %d: %w", ride.ID, err)
+	}
+
+	return enc, nil
+}
+
+// thumbnailPolyline encodes the track, simplified as little as needed for the
+// URL escaped polyline to fit in maxChars.
+func thumbnailPolyline(points []TrackPoint, maxChars int) (string, error) {
+	fits := func(s string) bool { return len(url.QueryEscape(s)) <= maxChars }
+
+	enc := EncodePolyline(points, 5)
+	if fits(enc) {
+		return enc, nil
+	}
+
+	// Find the smallest tolerance that fits, to about a meter.
+	lo, hi := 0.0, 1.0
+	for {
+		enc = EncodePolyline(Simplify(points, hi), 5)
+		if fits(enc) {
+			break
+		}
+		if hi > 2*earthRadius {
+			return "", fmt.Errorf("track doesn't fit in %d characters", maxChars)
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if s := EncodePolyline(Simplify(points, mid), 5); fits(s) {
+			hi, enc = mid, s
+		} else {
+			lo = mid
+		}
+	}
+
+	return enc, nil
+}
@@ -0,0 +1,111 @@
+package goride
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	// Google's example from the polyline format docs.
+	points := []TrackPoint{
+		{Lat: 38.5, Lng: -120.2},
+		{Elevation: 10},
+		{Lat: 40.7, Lng: -120.95},
+		{Lat: 43.252, Lng: -126.453},
+	}
+	want := "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+	if got := EncodePolyline(points, 5); got != want {
+		t.Errorf("bad encoding: want %q, got %q", want, got)
+	}
+
+	got, err := DecodePolyline(want, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantLL := []LatLng{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if diff := cmp.Diff(wantLL, got); diff != "" {
+		t.Errorf("bad decoding: -want +got\n%s", diff)
+	}
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	track := getTestRide(t).TrackPoints
+
+	for _, precision := range []int{5, 6} {
+		got, err := DecodePolyline(EncodePolyline(track, precision), precision)
+		if err != nil {
+			t.Fatalf("precision %d: unexpected error: %v", precision, err)
+		}
+		var want []LatLng
+		for _, p := range track {
+			if p.Lat != 0 || p.Lng != 0 {
+				want = append(want, LatLng{Lat: float32(p.Lat), Lng: float32(p.Lng)})
+			}
+		}
+		if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-5)); diff != "" {
+			t.Errorf("precision %d: bad round trip: -want +got\n%s", precision, diff)
+		}
+	}
+}
+
+func TestDecodePolylineErrors(t *testing.T) {
+	for _, s := range []string{"_p~iF~ps|U_ulL", "_p~iF~ps|U_ulLnnqC_mq", "_p~iF ps|U"} {
+		if got, err := DecodePolyline(s, 5); err == nil {
+			t.Errorf("%q: expected an error, got %v", s, got)
+		}
+	}
+}
+
+func TestRideThumbnailPolyline(t *testing.T) {
+	ride := getTestRide(t)
+	full := EncodePolyline(ride.TrackPoints, 5)
+	fullLen := len(url.QueryEscape(full))
+
+	tests := []struct {
+		desc     string
+		maxChars int
+		wantErr  bool
+	}{
+		{desc: "fits", maxChars: 10000},
+		{desc: "simplified", maxChars: 1000},
+		{desc: "tiny", maxChars: 60},
+		{desc: "too small", maxChars: 10, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := RideThumbnailPolyline(ride, tc.maxChars)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n := len(url.QueryEscape(got)); n > tc.maxChars {
+				t.Errorf("polyline too long: %d > %d", n, tc.maxChars)
+			}
+			if fullLen <= tc.maxChars && got != full {
+				t.Errorf("expected the full track when it fits")
+			}
+			// Don't simplify more than needed.
+			if n := len(url.QueryEscape(got)); fullLen > tc.maxChars && n < tc.maxChars*3/4 {
+				t.Errorf("polyline simplified too much: %d chars out of %d", n, tc.maxChars)
+			}
+			ll, err := DecodePolyline(got, 5)
+			if err != nil {
+				t.Fatalf("can't decode thumbnail: %v", err)
+			}
+			first := Simplify(ride.TrackPoints, 0)[0]
+			if diff := cmp.Diff(LatLng{float32(first.Lat), float32(first.Lng)}, ll[0], cmpopts.EquateApprox(0, 1e-5)); diff != "" {
+				t.Errorf("bad start: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package goride
+
+import (
+	"math"
+	"time"
+)
+
+// Gaps in power data up to this long are interpolated by PowerSummary. Longer
+// ones are left out.
+const powerMaxGap = 10 * time.Second
+
+// npWindow is the rolling average used for normalized power.
+const npWindow = 30
+
+// PowerStats summarize a ride's power. Watts are averaged over Duration, the
+// time with power data. IntensityFactor and TSS are 0 without an FTP.
+type PowerStats struct {
+	NormalizedPower float64
+	IntensityFactor float64
+	TSS             float64
+	AvgPower        float64
+	MaxPower        float64
+	Kilojoules      float64
+	Duration        time.Duration
+}
+
+// PowerSummary computes a ride's power stats, with intensity relative to ftp
+// in watts. The power is resampled to one reading a second: readings are
+// interpolated across gaps between points of up to 10 seconds, and longer
+// gaps, like auto-pause or a power meter dropout, are left out completely.
+// Points with zero power are coasting, and count. Normalized power is the
+// fourth root of the mean of the 30 second rolling average to the fourth
+// power, or the average power for rides shorter than 30 seconds.
+func PowerSummary(points []TrackPoint, ftp int) PowerStats {
+	samples := powerSamples(points)
+	var res PowerStats
+	if len(samples) == 0 {
+		return res
+	}
+
+	var sum float64
+	for _, w := range samples {
+		sum += w
+		res.MaxPower = math.Max(res.MaxPower, w)
+	}
+	res.Duration = time.Duration(len(samples)) * time.Second
+	res.AvgPower = sum / float64(len(samples))
+	res.Kilojoules = sum / 1000
+
+	res.NormalizedPower = res.AvgPower
+	if len(samples) >= npWindow {
+		var window, fourth float64
+		for i, w := range samples {
+			window += w
+			if i < npWindow-1 {
+				continue
+			}
+			if i >= npWindow {
+				window -= samples[i-npWindow]
+			}
+			fourth += math.Pow(window/npWindow, 4)
+		}
+		res.NormalizedPower = math.Pow(fourth/float64(len(samples)-npWindow+1), 0.25)
+	}
+
+	if ftp > 0 {
+		res.IntensityFactor = res.NormalizedPower / float64(ftp)
+		res.TSS = res.Duration.Hours() * res.IntensityFactor * res.IntensityFactor * 100
+	}
+
+	return res
+}
+
+// powerSamples resamples the points' power to a reading a second, going
+// linearly from each point to the next. Gaps longer than powerMaxGap are
+// skipped. It's empty if no point has power.
+func powerSamples(points []TrackPoint) []float64 {
+	hasPower := false
+	for _, p := range points {
+		if p.Power > 0 {
+			hasPower = true
+			break
+		}
+	}
+	if !hasPower {
+		return nil
+	}
+
+	var res []float64
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		dt := q.Time.Sub(p.Time)
+		if dt <= 0 || dt > powerMaxGap {
+			continue
+		}
+		n := int(dt.Round(time.Second) / time.Second)
+		for k := 0; k < n; k++ {
+			res = append(res, p.Power+(q.Power-p.Power)*float64(k)/float64(n))
+		}
+	}
+
+	return res
+}
+
+// FTP returns the functional threshold power from the config, in watts, or 0
+// if it's not set.
+func (r *RWGPS) FTP() int {
+	return r.config.FTP
+}
@@ -0,0 +1,184 @@
+package goride
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// powerTrace is a point a second for d, with power from watts, which is given
+// the seconds since the start.
+func powerTrace(start time.Time, d time.Duration, watts func(s int) float64) []TrackPoint {
+	var res []TrackPoint
+	for s := 0; s <= int(d/time.Second); s++ {
+		res = append(res, TrackPoint{Time: start.Add(time.Duration(s) * time.Second), Power: watts(s)})
+	}
+	return res
+}
+
+func TestPowerSummary(t *testing.T) {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	steady := powerTrace(start, time.Hour, func(int) float64 { return 200 })
+	steadyStats := PowerStats{
+		NormalizedPower: 200,
+		IntensityFactor: 0.8,
+		TSS:             64,
+		AvgPower:        200,
+		MaxPower:        200,
+		Kilojoules:      720,
+		Duration:        time.Hour,
+	}
+
+	// Every 7th point missing, leaving 2 second gaps.
+	var dropouts []TrackPoint
+	for i, p := range steady {
+		if i%7 != 3 {
+			dropouts = append(dropouts, p)
+		}
+	}
+
+	// Half an hour at 200W and half an hour at 300W, with a 5 minute pause
+	// between them. After the pause, the 30s rolling average climbs from 200
+	// to 300 over 29 windows.
+	paused := append(
+		powerTrace(start, 30*time.Minute, func(int) float64 { return 200 }),
+		powerTrace(start.Add(35*time.Minute), 30*time.Minute, func(int) float64 { return 300 })...)
+	fourth := 1771 * (math.Pow(200, 4) + math.Pow(300, 4))
+	for k := 1; k < 30; k++ {
+		fourth += math.Pow(200+100*float64(k)/30, 4)
+	}
+	pausedNP := math.Pow(fourth/3571, 0.25)
+
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		ftp    int
+		want   PowerStats
+	}{
+		{
+			desc:   "steady",
+			points: steady,
+			ftp:    250,
+			want:   steadyStats,
+		},
+		{
+			desc:   "short dropouts",
+			points: dropouts,
+			ftp:    250,
+			want:   steadyStats,
+		},
+		{
+			desc:   "pause",
+			points: paused,
+			ftp:    250,
+			want: PowerStats{
+				NormalizedPower: pausedNP,
+				IntensityFactor: pausedNP / 250,
+				TSS:             pausedNP * pausedNP / 250 / 250 * 100,
+				AvgPower:        250,
+				MaxPower:        300,
+				Kilojoules:      900,
+				Duration:        time.Hour,
+			},
+		},
+		{
+			desc: "interpolated ramp",
+			points: []TrackPoint{
+				{Time: start, Power: 100},
+				{Time: start.Add(10 * time.Second), Power: 200},
+				{Time: start.Add(20 * time.Second), Power: 100},
+			},
+			// 100, 110, ... 190, then 200, 190, ... 110.
+			want: PowerStats{
+				NormalizedPower: 150,
+				AvgPower:        150,
+				MaxPower:        200,
+				Kilojoules:      3,
+				Duration:        20 * time.Second,
+			},
+		},
+		{
+			desc: "long gap",
+			points: []TrackPoint{
+				{Time: start, Power: 300},
+				{Time: start.Add(11 * time.Second), Power: 200},
+				{Time: start.Add(12 * time.Second), Power: 100},
+			},
+			want: PowerStats{
+				NormalizedPower: 200,
+				AvgPower:        200,
+				MaxPower:        200,
+				Kilojoules:      0.2,
+				Duration:        time.Second,
+			},
+		},
+		{
+			desc:   "coasting",
+			points: powerTrace(start, 10*time.Second, func(s int) float64 { return float64(s%2) * 200 }),
+			want: PowerStats{
+				NormalizedPower: 100,
+				AvgPower:        100,
+				MaxPower:        200,
+				Kilojoules:      1,
+				Duration:        10 * time.Second,
+			},
+		},
+		{
+			desc:   "no power",
+			points: powerTrace(start, time.Minute, func(int) float64 { return 0 }),
+			ftp:    250,
+		},
+		{
+			desc: "empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := PowerSummary(tc.points, tc.ftp)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad power stats: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFTPConfig(t *testing.T) {
+	tests := []struct {
+		desc    string
+		athlete string
+		want    int
+		wantErr bool
+	}{
+		{desc: "none"},
+		{desc: "set", athlete: "ftp = 250", want: 250},
+		{desc: "bad", athlete: "ftp = lots", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			lines := []string{"[Auth]", "email = test@example.com", "[Athlete]", tc.athlete}
+			path := filepath.Join(t.TempDir(), "cfg.ini")
+			writeTestFile(t, path, strings.Join(lines, "\n"))
+
+			r, err := New(path)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got FTP %d", r.FTP())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := r.FTP(); got != tc.want {
+				t.Errorf("want FTP %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
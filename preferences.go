@@ -0,0 +1,61 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zigdon/goride/units"
+)
+
+// Preferences are the user's RWGPS settings. Settings the server leaves out
+// default to metric units, public rides and routes, and no default gear.
+type Preferences struct {
+	Units           units.System
+	TripVisibility  Visibility
+	RouteVisibility Visibility
+	DefaultGearID   int
+}
+
+func (p *Preferences) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		MetricUnits     *bool           `json:"metric_units"`
+		TripVisibility  Visibility      `json:"default_privacy_trip"`
+		RouteVisibility Visibility      `json:"default_privacy_route"`
+		DefaultGearID   json.RawMessage `json:"default_gear_id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bad preferences: %w", err)
+	}
+
+	*p = Preferences{
+		TripVisibility:  raw.TripVisibility,
+		RouteVisibility: raw.RouteVisibility,
+	}
+	if raw.MetricUnits != nil && !*raw.MetricUnits {
+		p.Units = units.Imperial
+	}
+	// The gear ID is sent as a string, and is empty when there's no default.
+	// Anything that isn't a number is treated as no default, rather than
+	// failing the login.
+	if gear, err := strconv.Atoi(strings.Trim(string(raw.DefaultGearID), `"`)); err == nil {
+		p.DefaultGearID = gear
+	}
+
+	return nil
+}
+
+// WithDefaults fills in the visibility and gear the update leaves out with the
+// user's defaults, e.g. for a ride that was just uploaded.
+func (u RideUpdate) WithDefaults(p Preferences) RideUpdate {
+	if u.Visibility == nil {
+		v := p.TripVisibility
+		u.Visibility = &v
+	}
+	if u.GearID == nil && p.DefaultGearID != 0 {
+		g := p.DefaultGearID
+		u.GearID = &g
+	}
+	return u
+}
@@ -0,0 +1,72 @@
+package goride
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride/units"
+)
+
+func TestPreferences(t *testing.T) {
+	tests := []struct {
+		desc string
+		data string
+		want Preferences
+	}{
+		{
+			desc: "missing",
+			data: `{"id":1}`,
+		},
+		{
+			desc: "empty",
+			data: `{"id":1,"preferences":{}}`,
+		},
+		{
+			desc: "metric",
+			data: `{"id":1,"preferences":{"metric_units":true,"default_privacy_trip":2,"default_gear_id":"17"}}`,
+			want: Preferences{TripVisibility: FriendsOnly, DefaultGearID: 17},
+		},
+		{
+			desc: "imperial, numeric gear",
+			data: `{"id":1,"preferences":{"metric_units":false,"default_gear_id":17}}`,
+			want: Preferences{Units: units.Imperial, DefaultGearID: 17},
+		},
+		{
+			desc: "no gear",
+			data: `{"id":1,"preferences":{"default_gear_id":"","default_privacy_route":null}}`,
+		},
+		{
+			desc: "bad gear",
+			data: `{"id":1,"preferences":{"default_gear_id":"none"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var u User
+			if err := json.Unmarshal([]byte(tc.data), &u); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, u.Preferences); diff != "" {
+				t.Errorf("bad preferences: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRideUpdateWithDefaults(t *testing.T) {
+	prefs := Preferences{TripVisibility: Private, DefaultGearID: 17}
+	name := "Morning Ride"
+	public := Public
+
+	got := RideUpdate{Name: &name}.WithDefaults(prefs)
+	if got.Visibility == nil || *got.Visibility != Private || got.GearID == nil || *got.GearID != 17 {
+		t.Errorf("defaults weren't filled in: %+v", got)
+	}
+
+	got = RideUpdate{Visibility: &public}.WithDefaults(Preferences{})
+	if *got.Visibility != Public || got.GearID != nil {
+		t.Errorf("explicit fields were changed: %+v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package goride
+
+import "fmt"
+
+// previewCacheSize is how many previews GetRidePreviewTrack keeps.
+const previewCacheSize = 1000
+
+type previewKey struct {
+	id        int
+	maxPoints int
+}
+
+type ridePreview struct {
+	track    []LatLng
+	distance float32
+}
+
+// GetRidePreviewTrack returns a ride's track with at most maxPoints points, for
+// drawing small previews, and the ride's distance in meters, for scaling it.
+// The track is simplified as little as needed to fit, always keeping its
+// first and last points. The API can't send a reduced track, so the whole ride
+// is fetched, but only once: previews are cached for the life of the client,
+// by ride and maxPoints. maxPoints must be at least 2.
+func (r *RWGPS) GetRidePreviewTrack(id, maxPoints int) ([]LatLng, float32, error) {
+	if maxPoints < 2 {
+		return nil, 0, fmt.Errorf("can't preview ride %d in %d points, need at least 2", id, maxPoints)
+	}
+	key := previewKey{id: id, maxPoints: maxPoints}
+	r.mu.Lock()
+	p, ok := r.previews[key]
+	r.mu.Unlock()
+	if ok {
+		return append([]LatLng(nil), p.track...), p.distance, nil
+	}
+
+	ride, err := r.GetRideWithOpts(id, RequestOptions{Fields: []string{"id", "distance", "track_points"}})
+	if err != nil {
+		return nil, 0, fmt.Errorf("can't preview ride %d: %w", id, err)
+	}
+	p = ridePreview{distance: ride.Distance}
+	for _, tp := range simplifyToCount(ride.TrackPoints, maxPoints) {
+		p.track = append(p.track, LatLng{Lat: float32(tp.Lat), Lng: float32(tp.Lng)})
+	}
+	r.log().Debug("made ride preview", "id", id, "points", len(ride.TrackPoints), "preview", len(p.track))
+
+	r.mu.Lock()
+	if r.previews == nil {
+		r.previews = make(map[previewKey]ridePreview)
+	}
+	if len(r.previews) >= previewCacheSize {
+		// Previews are cheap to make again, so any one can go.
+		for k := range r.previews {
+			delete(r.previews, k)
+			break
+		}
+	}
+	r.previews[key] = p
+	r.mu.Unlock()
+
+	return append([]LatLng(nil), p.track...), p.distance, nil
+}
+
+// simplifyToCount simplifies the track as little as needed to have at most
+// maxPoints points, which must be at least 2. Points without a position are
+// dropped.
+func simplifyToCount(points []TrackPoint, maxPoints int) []TrackPoint {
+	var res []TrackPoint
+	for _, p := range points {
+		if p.Lat != 0 || p.Lng != 0 {
+			res = append(res, p)
+		}
+	}
+	if len(res) <= maxPoints {
+		return res
+	}
+
+	// Find the smallest tolerance that fits, to about a centimeter, since
+	// dense tracks have many points less than a meter off the line. A
+	// tolerance past the size of the earth always leaves only the first and
+	// last points.
+	lo, hi := 0.0, 1.0
+	for {
+		res = Simplify(points, hi)
+		if len(res) <= maxPoints || hi > 2*earthRadius {
+			break
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 0.01 {
+		mid := (lo + hi) / 2
+		if s := Simplify(points, mid); len(s) <= maxPoints {
+			hi, res = mid, s
+		} else {
+			lo = mid
+		}
+	}
+
+	return res
+}
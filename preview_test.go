@@ -0,0 +1,112 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSimplifyToCount(t *testing.T) {
+	points := fixtureTrackPoints(t, "trip.json")
+	var track []TrackPoint
+	for _, p := range points {
+		if p.Lat != 0 || p.Lng != 0 {
+			track = append(track, p)
+		}
+	}
+
+	for _, n := range []int{2, 3, 10, 200, len(track) - 1, len(track), 5000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			got := simplifyToCount(points, n)
+			if len(got) > n {
+				t.Errorf("simplifyToCount(%d): got %d points", n, len(got))
+			}
+			if n >= len(track) && len(got) != len(track) {
+				t.Errorf("simplifyToCount(%d): want all %d points, got %d", n, len(track), len(got))
+			}
+			// Simplifying as little as needed gets close to the limit.
+			if n >= 10 && len(got) < n*9/10 && len(got) < len(track) {
+				t.Errorf("simplifyToCount(%d): only %d points", n, len(got))
+			}
+			if got[0] != track[0] || got[len(got)-1] != track[len(track)-1] {
+				t.Errorf("simplifyToCount(%d): didn't keep the first and last points", n)
+			}
+		})
+	}
+}
+
+func TestGetRidePreviewTrack(t *testing.T) {
+	var trip struct {
+		Trip *Ride `json:"trip"`
+	}
+	if err := decodeJSON(getTestData("trip.json"), &trip); err != nil {
+		t.Fatalf("can't decode trip.json: %v", err)
+	}
+	f := newFakeRWGPS(t, trip.Trip)
+	r := testObj(f.URL)
+	id := trip.Trip.ID
+
+	gets := func() int {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		n := 0
+		for _, req := range f.requests {
+			if req == fmt.Sprintf("GET /trips/%d.json", id) {
+				n++
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		desc      string
+		maxPoints int
+		wantGets  int
+	}{
+		{desc: "first", maxPoints: 50, wantGets: 1},
+		{desc: "cached", maxPoints: 50, wantGets: 1},
+		{desc: "other size", maxPoints: 20, wantGets: 2},
+		{desc: "first size still cached", maxPoints: 50, wantGets: 2},
+	}
+
+	var first []LatLng
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			track, distance, err := r.GetRidePreviewTrack(id, tc.maxPoints)
+			if err != nil {
+				t.Fatalf("GetRidePreviewTrack(%d, %d): %v", id, tc.maxPoints, err)
+			}
+			if len(track) > tc.maxPoints || len(track) < 2 {
+				t.Errorf("GetRidePreviewTrack(%d, %d): got %d points", id, tc.maxPoints, len(track))
+			}
+			if distance != trip.Trip.Distance {
+				t.Errorf("GetRidePreviewTrack(%d, %d): want distance %v, got %v", id, tc.maxPoints, trip.Trip.Distance, distance)
+			}
+			if got := gets(); got != tc.wantGets {
+				t.Errorf("want %d fetches, got %d", tc.wantGets, got)
+			}
+
+			if tc.maxPoints != 50 {
+				return
+			}
+			if first == nil {
+				first = append([]LatLng(nil), track...)
+				// Changing the result doesn't change the cache.
+				track[0] = LatLng{}
+				return
+			}
+			if diff := cmp.Diff(first, track); diff != "" {
+				t.Errorf("cached preview: -want +got:\n%s", diff)
+			}
+		})
+	}
+
+	if _, _, err := r.GetRidePreviewTrack(id, 1); err == nil {
+		t.Errorf("GetRidePreviewTrack(%d, 1): want an error", id)
+	}
+	if _, _, err := r.GetRidePreviewTrack(id+1, 50); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRidePreviewTrack(%d, 50): want ErrNotFound, got %v", id+1, err)
+	}
+}
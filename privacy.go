@@ -0,0 +1,119 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// PrivacyZone is a circle, like around home or work, where the start and end
+// of tracks are hidden from other users.
+type PrivacyZone struct {
+	ID     int
+	Center LatLng
+	// RadiusMeters is the zone's radius, in meters.
+	RadiusMeters float64
+}
+
+type privacyZoneJSON struct {
+	ID     int     `json:"id,omitempty"`
+	Lat    float32 `json:"lat"`
+	Lng    float32 `json:"lng"`
+	Radius float64 `json:"radius"`
+}
+
+func (z *PrivacyZone) UnmarshalJSON(data []byte) error {
+	var raw privacyZoneJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bad privacy zone: %w", err)
+	}
+	*z = PrivacyZone{ID: raw.ID, Center: LatLng{Lat: raw.Lat, Lng: raw.Lng}, RadiusMeters: raw.Radius}
+
+	return nil
+}
+
+func (z PrivacyZone) MarshalJSON() ([]byte, error) {
+	return json.Marshal(privacyZoneJSON{ID: z.ID, Lat: z.Center.Lat, Lng: z.Center.Lng, Radius: z.RadiusMeters})
+}
+
+// GetPrivacyZones lists the user's privacy zones.
+func (r *RWGPS) GetPrivacyZones() ([]PrivacyZone, error) {
+	res, err := r.Get("/privacy_zones.json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting privacy zones: %w", err)
+	}
+
+	var resStruct struct {
+		PrivacyZones []PrivacyZone `json:"privacy_zones"`
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return resStruct.PrivacyZones, nil
+}
+
+// CreatePrivacyZone adds a privacy zone of radiusMeters around center, and
+// returns it with its new ID.
+func (r *RWGPS) CreatePrivacyZone(center LatLng, radiusMeters float64) (*PrivacyZone, error) {
+	if radiusMeters <= 0 {
+		return nil, fmt.Errorf("bad privacy zone radius %vm", radiusMeters)
+	}
+
+	body, err := json.Marshal(struct {
+		Zone PrivacyZone `json:"privacy_zone"`
+	}{PrivacyZone{Center: center, RadiusMeters: radiusMeters}})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode privacy zone: %w", err)
+	}
+
+	res, err := r.do(http.MethodPost, "/privacy_zones.json", nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating privacy zone: %w", err)
+	}
+
+	var resStruct struct {
+		Zone PrivacyZone `json:"privacy_zone"`
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return &resStruct.Zone, nil
+}
+
+// DeletePrivacyZone deletes a privacy zone. Zones that don't exist return
+// ErrNotFound.
+func (r *RWGPS) DeletePrivacyZone(id int) error {
+	if err := r.send(http.MethodDelete, fmt.Sprintf("/privacy_zones/%d.json", id), nil, nil); err != nil {
+		return fmt.Errorf("error deleting privacy zone %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// ApplyPrivacyZones drops the points inside any of the zones, the way RWGPS
+// hides them from other users. Points without a position are kept.
+func ApplyPrivacyZones(points []TrackPoint, zones []PrivacyZone) []TrackPoint {
+	var res []TrackPoint
+	for _, p := range points {
+		if !inPrivacyZone(p, zones) {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+func inPrivacyZone(p TrackPoint, zones []PrivacyZone) bool {
+	if p.Lat == 0 && p.Lng == 0 {
+		return false
+	}
+	for _, z := range zones {
+		if haversine(float64(z.Center.Lat), float64(z.Center.Lng), p.Lat, p.Lng) <= z.RadiusMeters {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyPrivacyZones(t *testing.T) {
+	home := PrivacyZone{Center: LatLng{Lat: 37.5, Lng: -122.25}, RadiusMeters: 200}
+	// About 1112m north of home, so it covers 1012m to 1212m.
+	work := PrivacyZone{Center: LatLng{Lat: 37.51, Lng: -122.25}, RadiusMeters: 100}
+	// north is a point m meters due north of home.
+	north := func(m float64) TrackPoint {
+		return TrackPoint{Lat: 37.5 + m/(earthRadius*math.Pi/180), Lng: -122.25, Distance: m}
+	}
+
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		zones  []PrivacyZone
+		want   []float64
+	}{
+		{
+			desc:   "leaving home",
+			points: []TrackPoint{north(0), north(150), north(199), north(201), north(500)},
+			zones:  []PrivacyZone{home},
+			want:   []float64{201, 500},
+		},
+		{
+			desc:   "on the edge is hidden",
+			points: []TrackPoint{north(199.99), north(200.01)},
+			zones:  []PrivacyZone{home},
+			want:   []float64{200.01},
+		},
+		{
+			desc:   "home to work",
+			points: []TrackPoint{north(100), north(500), north(1011), north(1013), north(1111.9), north(1211), north(1213)},
+			zones:  []PrivacyZone{home, work},
+			want:   []float64{500, 1011, 1213},
+		},
+		{
+			desc:   "no zones",
+			points: []TrackPoint{north(0), north(100)},
+			want:   []float64{0, 100},
+		},
+		{
+			desc:   "no position",
+			points: []TrackPoint{{Distance: 5}, north(10)},
+			zones:  []PrivacyZone{home},
+			want:   []float64{5},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got []float64
+			for _, p := range ApplyPrivacyZones(tc.points, tc.zones) {
+				got = append(got, p.Distance)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad points: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrivacyZones(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	var body string
+	f.handle("/privacy_zones.json", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			b, _ := io.ReadAll(req.Body)
+			body = string(b)
+			fmt.Fprint(w, `{"privacy_zone":{"id":8,"lat":37.5,"lng":-122.25,"radius":250}}`)
+			return
+		}
+		fmt.Fprint(w, `{"privacy_zones":[{"id":7,"lat":45.5,"lng":-122.5,"radius":500}]}`)
+	})
+	f.handle("/privacy_zones/7.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "{}")
+	})
+
+	got, err := r.GetPrivacyZones()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []PrivacyZone{{ID: 7, Center: LatLng{Lat: 45.5, Lng: -122.5}, RadiusMeters: 500}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad zones: -want +got\n%s", diff)
+	}
+
+	z, err := r.CreatePrivacyZone(LatLng{Lat: 37.5, Lng: -122.25}, 250)
+	if err != nil {
+		t.Fatalf("can't create zone: %v", err)
+	}
+	if z.ID != 8 || z.RadiusMeters != 250 {
+		t.Errorf("bad new zone: %+v", z)
+	}
+	if want := `{"privacy_zone":{"lat":37.5,"lng":-122.25,"radius":250}}`; body != want {
+		t.Errorf("bad body: want %s, got %s", want, body)
+	}
+	if _, err := r.CreatePrivacyZone(LatLng{}, 0); err == nil {
+		t.Errorf("expected an error for an empty zone")
+	}
+
+	if err := r.DeletePrivacyZone(7); err != nil {
+		t.Errorf("can't delete zone: %v", err)
+	}
+	if err := r.DeletePrivacyZone(9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound deleting a missing zone, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package goride
+
+import (
+	"math"
+	"sort"
+)
+
+// Smoothing is how ElevationProfile smooths elevations.
+type Smoothing int
+
+const (
+	SmoothNone Smoothing = iota
+	SmoothMovingAverage
+	SmoothMedian
+)
+
+// ProfileOptions tunes ElevationProfile. Zero fields use the defaults.
+type ProfileOptions struct {
+	// Distance between profile points, in meters. Defaults to 100.
+	Interval  float64
+	Smoothing Smoothing
+	// Number of profile points smoothed over, centered on each point.
+	// Defaults to 5.
+	Window int
+}
+
+// ProfilePoint is an elevation at a distance along a track, both in meters.
+type ProfilePoint struct {
+	Distance  float64
+	Elevation float64
+}
+
+func (o ProfileOptions) withDefaults() ProfileOptions {
+	if o.Interval <= 0 {
+		o.Interval = 100
+	}
+	if o.Window <= 0 {
+		o.Window = 5
+	}
+
+	return o
+}
+
+// ElevationProfile resamples the track's elevation every opts.Interval meters,
+// starting at the first point, and ending with the last. Points that only
+// carry sensor data are skipped.
+func ElevationProfile(points []TrackPoint, opts ProfileOptions) []ProfilePoint {
+	opts = opts.withDefaults()
+	dist := trackDistances(points)
+
+	var raw []ProfilePoint
+	skip := sensorOnly(points)
+	for i, p := range points {
+		if skip(p) {
+			continue
+		}
+		raw = append(raw, ProfilePoint{Distance: dist[i], Elevation: p.Elevation})
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var res []ProfilePoint
+	start, end := raw[0].Distance, raw[len(raw)-1].Distance
+	j := 0
+	for n := 0; ; n++ {
+		d := start + float64(n)*opts.Interval
+		if d > end {
+			break
+		}
+		for j < len(raw)-1 && raw[j+1].Distance <= d {
+			j++
+		}
+		e := raw[j].Elevation
+		if j < len(raw)-1 {
+			a, b := raw[j], raw[j+1]
+			e += (b.Elevation - a.Elevation) * (d - a.Distance) / (b.Distance - a.Distance)
+		}
+		res = append(res, ProfilePoint{Distance: d, Elevation: e})
+	}
+	if last := res[len(res)-1]; last.Distance < end {
+		res = append(res, raw[len(raw)-1])
+	}
+
+	return smooth(res, opts.Smoothing, opts.Window)
+}
+
+// sensorOnly returns a check for points that only carry sensor data. In a
+// track with positions, that's any point without one.
+func sensorOnly(points []TrackPoint) func(TrackPoint) bool {
+	for _, p := range points {
+		if p.Lat != 0 || p.Lng != 0 {
+			return func(p TrackPoint) bool { return p.Lat == 0 && p.Lng == 0 }
+		}
+	}
+
+	return func(TrackPoint) bool { return false }
+}
+
+// smooth replaces each elevation with the average or median of the window
+// centered on it. The window shrinks near the ends.
+func smooth(profile []ProfilePoint, by Smoothing, window int) []ProfilePoint {
+	if by == SmoothNone || window < 2 {
+		return profile
+	}
+
+	res := make([]ProfilePoint, len(profile))
+	vals := make([]float64, 0, window)
+	for i, p := range profile {
+		from, to := i-window/2, i+(window-1)/2
+		if from < 0 {
+			from = 0
+		}
+		if to > len(profile)-1 {
+			to = len(profile) - 1
+		}
+
+		vals = vals[:0]
+		for _, q := range profile[from : to+1] {
+			vals = append(vals, q.Elevation)
+		}
+		switch by {
+		case SmoothMovingAverage:
+			var sum float64
+			for _, v := range vals {
+				sum += v
+			}
+			p.Elevation = sum / float64(len(vals))
+		case SmoothMedian:
+			sort.Float64s(vals)
+			if n := len(vals); n%2 == 1 {
+				p.Elevation = vals[n/2]
+			} else {
+				p.Elevation = (vals[n/2-1] + vals[n/2]) / 2
+			}
+		}
+		res[i] = p
+	}
+
+	return res
+}
+
+// TotalAscent sums the climbing along the track, ignoring changes in
+// direction smaller than threshold meters, so noise doesn't add up.
+func TotalAscent(points []TrackPoint, threshold float64) float64 {
+	up, _ := ascentDescent(points, threshold)
+	return up
+}
+
+// TotalDescent is TotalAscent for descending.
+func TotalDescent(points []TrackPoint, threshold float64) float64 {
+	_, down := ascentDescent(points, threshold)
+	return down
+}
+
+// ascentDescent follows the elevation between turning points, only switching
+// direction once it's moved back by at least threshold from the last extreme.
+func ascentDescent(points []TrackPoint, threshold float64) (float64, float64) {
+	var up, down float64
+	if len(points) == 0 {
+		return 0, 0
+	}
+
+	// Until a direction is set, ref and ext are the lowest and highest points.
+	dir := 0
+	ref, ext := points[0].Elevation, points[0].Elevation
+	for _, p := range points[1:] {
+		e := p.Elevation
+		switch dir {
+		case 0:
+			lo, hi := math.Min(ref, ext), math.Max(ref, ext)
+			switch {
+			case e-lo >= threshold && e > lo:
+				dir, ref, ext = 1, lo, e
+			case hi-e >= threshold && e < hi:
+				dir, ref, ext = -1, hi, e
+			default:
+				ref, ext = math.Min(lo, e), math.Max(hi, e)
+			}
+		case 1:
+			if e > ext {
+				ext = e
+			} else if ext-e >= threshold && e < ext {
+				up += ext - ref
+				dir, ref, ext = -1, ext, e
+			}
+		case -1:
+			if e < ext {
+				ext = e
+			} else if e-ext >= threshold && e > ext {
+				down += ref - ext
+				dir, ref, ext = 1, ext, e
+			}
+		}
+	}
+
+	switch dir {
+	case 1:
+		up += ext - ref
+	case -1:
+		down += ref - ext
+	}
+
+	return up, down
+}
@@ -0,0 +1,113 @@
+package goride
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// sawtooth is 10 teeth, each climbing 50m over 500m and descending back over
+// another 500m, with points every 10m.
+func sawtooth(i int) float64 {
+	d := i % 100
+	if d > 50 {
+		d = 100 - d
+	}
+	return 100 + float64(d)
+}
+
+// synthSawtooth returns the sawtooth track, with +-noise meters alternating
+// between points.
+func synthSawtooth(noise float64) []TrackPoint {
+	var res []TrackPoint
+	for i := 0; i <= 1000; i++ {
+		n := noise
+		if i%2 == 1 {
+			n = -noise
+		}
+		res = append(res, TrackPoint{Distance: float64(i * 10), Elevation: sawtooth(i) + n})
+	}
+
+	return res
+}
+
+func TestElevationProfileResampling(t *testing.T) {
+	track := synthSawtooth(0)
+
+	got := ElevationProfile(track[:13], ProfileOptions{Interval: 25})
+	want := []ProfilePoint{
+		{0, 100},
+		{25, 102.5},
+		{50, 105},
+		{75, 107.5},
+		{100, 110},
+		{120, 112},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad profile: -want +got\n%s", diff)
+	}
+
+	got = ElevationProfile(track, ProfileOptions{})
+	if len(got) != 101 || got[100].Distance != 10000 {
+		t.Errorf("bad default profile: %d points, ending at %v", len(got), got[len(got)-1])
+	}
+
+	if got := ElevationProfile(nil, ProfileOptions{}); got != nil {
+		t.Errorf("expected no profile for an empty track, got %v", got)
+	}
+}
+
+func TestElevationProfileSmoothing(t *testing.T) {
+	track := synthSawtooth(1.5)
+	rmsError := func(profile []ProfilePoint) float64 {
+		var sum float64
+		for _, p := range profile {
+			d := p.Elevation - sawtooth(int(p.Distance/10))
+			sum += d * d
+		}
+		return math.Sqrt(sum / float64(len(profile)))
+	}
+
+	raw := rmsError(ElevationProfile(track, ProfileOptions{Interval: 10}))
+	if raw < 1.4 {
+		t.Fatalf("expected a noisy profile, got rms error %f", raw)
+	}
+
+	for _, s := range []Smoothing{SmoothMovingAverage, SmoothMedian} {
+		got := rmsError(ElevationProfile(track, ProfileOptions{Interval: 10, Smoothing: s, Window: 3}))
+		if got >= raw/2 {
+			t.Errorf("smoothing %d didn't help: rms error %f, raw %f", s, got, raw)
+		}
+	}
+}
+
+func TestTotalAscent(t *testing.T) {
+	tests := []struct {
+		desc      string
+		noise     float64
+		threshold float64
+	}{
+		{desc: "clean", threshold: 0},
+		{desc: "clean with threshold", threshold: 5},
+		{desc: "noisy", noise: 1.5, threshold: 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			track := synthSawtooth(tc.noise)
+			up, down := TotalAscent(track, tc.threshold), TotalDescent(track, tc.threshold)
+			// The noise can move each tooth's peak and trough.
+			if math.Abs(up-500) > 20*tc.noise+0.01 {
+				t.Errorf("bad ascent: want 500, got %f", up)
+			}
+			if math.Abs(down-500) > 20*tc.noise+0.01 {
+				t.Errorf("bad descent: want 500, got %f", down)
+			}
+		})
+	}
+
+	if got := TotalAscent(synthSawtooth(1.5), 0); got < 1000 {
+		t.Errorf("expected noise to inflate the ascent without a threshold, got %f", got)
+	}
+}
@@ -0,0 +1,312 @@
+// Package report renders a ride as a summary post, in Markdown or HTML, with
+// its stats and, when the track is available, splits, climbs and stops.
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zigdon/goride"
+	"github.com/zigdon/goride/units"
+)
+
+// Format is the markup a report is written in.
+type Format int
+
+const (
+	Markdown Format = iota
+	HTML
+)
+
+func (f Format) String() string {
+	switch f {
+	case Markdown:
+		return "markdown"
+	case HTML:
+		return "html"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(f))
+}
+
+// ReportOptions control what's in a report and how it looks. Zero fields use
+// the defaults.
+type ReportOptions struct {
+	Format Format
+	// Units for distances, speeds and elevations. Defaults to metric.
+	Units units.System
+	// SplitDistance in meters. Defaults to a km or a mile, depending on
+	// Units.
+	SplitDistance float64
+	Climbs        goride.ClimbOptions
+	Stops         goride.StopOptions
+	// HRZones, if set, adds the time in each heart rate zone, see
+	// goride.TimeInZones and RWGPS.HeartRateZones.
+	HRZones []int
+	// Template replaces the built-in template for the format. It's parsed
+	// with text/template for Markdown and html/template for HTML, and
+	// executed with a *Data.
+	Template string
+}
+
+func (o ReportOptions) withDefaults() ReportOptions {
+	if o.SplitDistance == 0 {
+		o.SplitDistance = goride.SplitKm
+		if o.Units == units.Imperial {
+			o.SplitDistance = goride.SplitMile
+		}
+	}
+
+	return o
+}
+
+// Data is what report templates are executed with. All values are formatted
+// already, in the report's units. Splits, Climbs and Stops are empty when the
+// ride has no track points, and Zones also when it has no heart rate or no
+// zones were asked for.
+type Data struct {
+	ID          int
+	Name        string
+	Description string
+	Date        string
+	Stats       []Stat
+	Splits      []Split
+	Climbs      []Climb
+	Stops       []Stop
+	Zones       []Zone
+	// StoppedTime is the total of Stops.
+	StoppedTime string
+}
+
+// Stat is one row of the stats table.
+type Stat struct {
+	Label string
+	Value string
+}
+
+type Split struct {
+	Number   int
+	Distance string
+	Time     string
+	Speed    string
+	Climbing string
+}
+
+type Climb struct {
+	Number   int
+	Category string
+	Length   string
+	Gain     string
+	AvgGrade string
+	MaxGrade string
+	// VAM is empty if the track has no times.
+	VAM string
+}
+
+type Stop struct {
+	Start    string
+	Duration string
+}
+
+type Zone struct {
+	Number  int
+	Range   string
+	Time    string
+	Percent string
+}
+
+// RideReport renders the ride as a report.
+func RideReport(ride *goride.Ride, opts ReportOptions) (string, error) {
+	opts = opts.withDefaults()
+	data, err := newData(ride, opts)
+	if err != nil {
+		return "", fmt.Errorf("can't build report for ride %d: %w", ride.ID, err)
+	}
+
+	src := opts.Template
+	var b strings.Builder
+	switch opts.Format {
+	case Markdown:
+		if src == "" {
+			src = markdownTemplate
+		}
+		tmpl, err := template.New("report").Parse(src)
+		if err != nil {
+			return "", fmt.Errorf("bad markdown template: %w", err)
+		}
+		err = tmpl.Execute(&b, data)
+		if err != nil {
+			return "", fmt.Errorf("error writing report for ride %d: %w", ride.ID, err)
+		}
+	case HTML:
+		if src == "" {
+			src = htmlTemplate
+		}
+		tmpl, err := htmltemplate.New("report").Parse(src)
+		if err != nil {
+			return "", fmt.Errorf("bad html template: %w", err)
+		}
+		err = tmpl.Execute(&b, data)
+		if err != nil {
+			return "", fmt.Errorf("error writing report for ride %d: %w", ride.ID, err)
+		}
+	default:
+		return "", fmt.Errorf("unknown report format %s", opts.Format)
+	}
+
+	return b.String(), nil
+}
+
+func newData(ride *goride.Ride, opts ReportOptions) (*Data, error) {
+	u := opts.Units
+	m := ride.Metrics
+	dist := float64(m.Distance)
+	if dist == 0 {
+		dist = float64(ride.Distance)
+	}
+
+	data := &Data{
+		ID:          ride.ID,
+		Name:        ride.Name,
+		Description: ride.Description,
+		Date:        ride.LocalStarted().Format("Monday, January 2, 2006 15:04"),
+	}
+	add := func(label, value string) {
+		data.Stats = append(data.Stats, Stat{Label: label, Value: value})
+	}
+	add("Distance", u.FormatDistance(units.Distance(dist)))
+	if m.MovingTime > 0 {
+		add("Moving time", formatDuration(time.Duration(m.MovingTime)*time.Second))
+	}
+	// Metrics.Duration is decoded as a number of seconds.
+	if m.Duration > 0 {
+		add("Elapsed time", formatDuration(m.Duration*time.Second))
+	}
+	add("Climbing", u.FormatElevation(units.Elevation(m.ElevationGain)))
+	if m.Speed.Avg > 0 {
+		add("Average speed", u.FormatSpeed(units.Speed(m.Speed.Avg)))
+	}
+	if m.Speed.Max > 0 {
+		add("Max speed", u.FormatSpeed(units.Speed(m.Speed.Max)))
+	}
+	if m.HR.Avg > 0 {
+		add("Average heart rate", fmt.Sprintf("%.0f bpm", m.HR.Avg))
+	}
+	if m.Watts.Avg > 0 {
+		add("Average power", fmt.Sprintf("%.0f W", m.Watts.Avg))
+	}
+	if m.Calories > 0 {
+		add("Calories", fmt.Sprintf("%d", m.Calories))
+	}
+
+	points := ride.TrackPoints
+	if len(points) == 0 {
+		return data, nil
+	}
+
+	if gas := goride.GradeAdjustedSpeed(points); gas > 0 {
+		add("Grade adjusted speed", u.FormatSpeed(units.Speed(gas)))
+	}
+
+	splits, err := goride.ComputeSplits(points, opts.SplitDistance)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range splits {
+		data.Splits = append(data.Splits, Split{
+			Number:   s.Number,
+			Distance: u.FormatDistance(units.Distance(s.Distance)),
+			Time:     formatDuration(s.MovingTime),
+			Speed:    u.FormatSpeed(units.Speed(s.AvgSpeed)),
+			Climbing: u.FormatElevation(units.Elevation(s.ElevationGain)),
+		})
+	}
+
+	climbs, err := goride.DetectClimbs(points, opts.Climbs)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range climbs {
+		var vam string
+		if v := goride.VAM(c, points); v > 0 {
+			vam = fmt.Sprintf("%.0f m/h", v)
+		}
+		data.Climbs = append(data.Climbs, Climb{
+			Number:   i + 1,
+			Category: c.Category.String(),
+			Length:   u.FormatDistance(units.Distance(c.Length)),
+			Gain:     u.FormatElevation(units.Elevation(c.Gain)),
+			AvgGrade: fmt.Sprintf("%.1f%%", c.AvgGrade),
+			MaxGrade: fmt.Sprintf("%.1f%%", c.MaxGrade),
+			VAM:      vam,
+		})
+	}
+
+	stops := goride.DetectStops(points, opts.Stops)
+	loc := ride.LocalStarted().Location()
+	for _, s := range stops {
+		data.Stops = append(data.Stops, Stop{
+			Start:    s.Start.In(loc).Format("15:04"),
+			Duration: formatDuration(s.Duration),
+		})
+	}
+	if len(stops) > 0 {
+		data.StoppedTime = formatDuration(goride.TotalStopTime(stops))
+	}
+
+	if len(opts.HRZones) > 0 {
+		data.Zones, err = zones(points, opts.HRZones)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// zones returns the time in each heart rate zone, or nothing if the track has
+// no heart rate.
+func zones(points []goride.TrackPoint, bounds []int) ([]Zone, error) {
+	times, err := goride.TimeInZones(points, bounds)
+	if err != nil {
+		return nil, err
+	}
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	pct := goride.ZonePercentages(times)
+	var res []Zone
+	for i, t := range times {
+		var r string
+		switch {
+		case i == 0:
+			r = fmt.Sprintf("< %d bpm", bounds[0])
+		case i == len(bounds):
+			r = fmt.Sprintf("%d+ bpm", bounds[i-1])
+		default:
+			r = fmt.Sprintf("%d-%d bpm", bounds[i-1], bounds[i]-1)
+		}
+		res = append(res, Zone{
+			Number:  i + 1,
+			Range:   r,
+			Time:    formatDuration(t),
+			Percent: fmt.Sprintf("%.0f%%", pct[i]),
+		})
+	}
+
+	return res, nil
+}
+
+// formatDuration shows d as h:mm:ss, rounded to the second.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride"
+	"github.com/zigdon/goride/units"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func testRide(t *testing.T) *goride.Ride {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "trip.json"))
+	if err != nil {
+		t.Fatalf("can't read trip.json: %v", err)
+	}
+	var res struct {
+		Trip goride.Ride
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("can't decode trip.json: %v", err)
+	}
+
+	return &res.Trip
+}
+
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("can't read golden file: %v", err)
+	}
+	if diff := cmp.Diff(string(want), got); diff != "" {
+		t.Errorf("output doesn't match %s: -want +got\n%s", path, diff)
+	}
+}
+
+func TestRideReport(t *testing.T) {
+	full := testRide(t)
+	noTrack := testRide(t)
+	noTrack.TrackPoints = nil
+
+	tests := []struct {
+		desc   string
+		ride   *goride.Ride
+		opts   ReportOptions
+		golden string
+	}{
+		{desc: "markdown", ride: full, golden: "report.md"},
+		{desc: "markdown without track", ride: noTrack, golden: "report_notrack.md"},
+		{desc: "html", ride: full, opts: ReportOptions{Format: HTML}, golden: "report.html"},
+		{desc: "html without track", ride: noTrack, opts: ReportOptions{Format: HTML}, golden: "report_notrack.html"},
+		{desc: "imperial", ride: full, opts: ReportOptions{Units: units.Imperial}, golden: "report_imperial.md"},
+		{desc: "hr zones", ride: full, opts: ReportOptions{HRZones: goride.ZonesFromMaxHR(190)}, golden: "report_zones.md"},
+		{desc: "hr zones html", ride: full, opts: ReportOptions{Format: HTML, HRZones: []int{140, 160}}, golden: "report_zones.html"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := RideReport(tc.ride, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkGolden(t, tc.golden, got)
+		})
+	}
+}
+
+func TestRideReportTemplate(t *testing.T) {
+	ride := testRide(t)
+	ride.Name = "Fish & <chips>"
+
+	tests := []struct {
+		desc    string
+		opts    ReportOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "markdown",
+			opts: ReportOptions{Template: "{{.Name}}: {{range .Stats}}{{if eq .Label \"Distance\"}}{{.Value}}{{end}}{{end}}, {{len .Climbs}} climbs"},
+			want: "Fish & <chips>: 43.0 km, 5 climbs",
+		},
+		{
+			desc: "html is escaped",
+			opts: ReportOptions{Format: HTML, Template: "<b>{{.Name}}</b>"},
+			want: "<b>Fish &amp; &lt;chips&gt;</b>",
+		},
+		{
+			desc:    "bad template",
+			opts:    ReportOptions{Template: "{{.Name"},
+			wantErr: true,
+		},
+		{
+			desc:    "missing field",
+			opts:    ReportOptions{Template: "{{.Nope}}"},
+			wantErr: true,
+		},
+		{
+			desc:    "bad format",
+			opts:    ReportOptions{Format: Format(7)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := RideReport(ride, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, strings.TrimSpace(got)); diff != "" {
+				t.Errorf("bad report: -want +got\n%s", diff)
+			}
+		})
+	}
+}
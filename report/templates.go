@@ -0,0 +1,112 @@
+package report
+
+const markdownTemplate = `# {{.Name}}
+
+{{.Date}}
+{{- with .Description}}
+
+{{.}}
+{{- end}}
+
+## Stats
+
+| | |
+|---|--:|
+{{- range .Stats}}
+| {{.Label}} | {{.Value}} |
+{{- end}}
+{{- if .Splits}}
+
+## Splits
+
+| # | Distance | Time | Speed | Climbing |
+|--:|--:|--:|--:|--:|
+{{- range .Splits}}
+| {{.Number}} | {{.Distance}} | {{.Time}} | {{.Speed}} | {{.Climbing}} |
+{{- end}}
+{{- end}}
+{{- if .Climbs}}
+
+## Climbs
+
+| # | Category | Length | Gain | Average | Max | VAM |
+|--:|---|--:|--:|--:|--:|--:|
+{{- range .Climbs}}
+| {{.Number}} | {{.Category}} | {{.Length}} | {{.Gain}} | {{.AvgGrade}} | {{.MaxGrade}} | {{.VAM}} |
+{{- end}}
+{{- end}}
+{{- if .Zones}}
+
+## Heart rate zones
+
+| Zone | Heart rate | Time | % |
+|--:|---|--:|--:|
+{{- range .Zones}}
+| {{.Number}} | {{.Range}} | {{.Time}} | {{.Percent}} |
+{{- end}}
+{{- end}}
+{{- if .Stops}}
+
+## Stops
+
+Stopped for {{.StoppedTime}} in total.
+
+| Time | Duration |
+|---|--:|
+{{- range .Stops}}
+| {{.Start}} | {{.Duration}} |
+{{- end}}
+{{- end}}
+`
+
+const htmlTemplate = `<article class="ride-report">
+<h1>{{.Name}}</h1>
+<p class="date">{{.Date}}</p>
+{{- with .Description}}
+<p class="description">{{.}}</p>
+{{- end}}
+<h2>Stats</h2>
+<table class="stats">
+{{- range .Stats}}
+<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- if .Splits}}
+<h2>Splits</h2>
+<table class="splits">
+<tr><th>#</th><th>Distance</th><th>Time</th><th>Speed</th><th>Climbing</th></tr>
+{{- range .Splits}}
+<tr><td>{{.Number}}</td><td>{{.Distance}}</td><td>{{.Time}}</td><td>{{.Speed}}</td><td>{{.Climbing}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Climbs}}
+<h2>Climbs</h2>
+<table class="climbs">
+<tr><th>#</th><th>Category</th><th>Length</th><th>Gain</th><th>Average</th><th>Max</th><th>VAM</th></tr>
+{{- range .Climbs}}
+<tr><td>{{.Number}}</td><td>{{.Category}}</td><td>{{.Length}}</td><td>{{.Gain}}</td><td>{{.AvgGrade}}</td><td>{{.MaxGrade}}</td><td>{{.VAM}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Zones}}
+<h2>Heart rate zones</h2>
+<table class="zones">
+<tr><th>Zone</th><th>Heart rate</th><th>Time</th><th>%</th></tr>
+{{- range .Zones}}
+<tr><td>{{.Number}}</td><td>{{.Range}}</td><td>{{.Time}}</td><td>{{.Percent}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Stops}}
+<h2>Stops</h2>
+<p>Stopped for {{.StoppedTime}} in total.</p>
+<table class="stops">
+<tr><th>Time</th><th>Duration</th></tr>
+{{- range .Stops}}
+<tr><td>{{.Start}}</td><td>{{.Duration}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</article>
+`
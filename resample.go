@@ -0,0 +1,155 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ResampleOptions tune ResampleByTime and ResampleByDistance.
+type ResampleOptions struct {
+	// MaxGap is the longest time between two points that's interpolated
+	// across. Longer gaps, e.g. from auto-pause, are left as a break with no
+	// points in them. Defaults to a minute.
+	MaxGap time.Duration
+}
+
+func (o ResampleOptions) withDefaults() ResampleOptions {
+	if o.MaxGap == 0 {
+		o.MaxGap = time.Minute
+	}
+	return o
+}
+
+// ResampleByTime returns points every interval from the first point's time to
+// the last's. Points that fall between two of the input's are linearly
+// interpolated, including the position, elevation and sensor values, and
+// marked Interpolated. Distance is set on every point, computed from the
+// coordinates if the input doesn't have it. The points must be in time order.
+func ResampleByTime(points []TrackPoint, interval time.Duration, opts ResampleOptions) ([]TrackPoint, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid resample interval %s", interval)
+	}
+	opts = opts.withDefaults()
+	if len(points) < 2 {
+		return append([]TrackPoint{}, points...), nil
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Time.Before(points[i-1].Time) {
+			return nil, fmt.Errorf("point %d is out of order: %s before %s", i, points[i].Time, points[i-1].Time)
+		}
+	}
+
+	dist := trackDistances(points)
+	start, end := points[0].Time, points[len(points)-1].Time
+	res := make([]TrackPoint, 0, int(end.Sub(start)/interval)+1)
+	seg := 1
+	for k := 0; ; k++ {
+		t := start.Add(time.Duration(k) * interval)
+		if t.After(end) {
+			break
+		}
+		for points[seg].Time.Before(t) {
+			seg++
+		}
+		a, b := seg-1, seg
+		span := points[b].Time.Sub(points[a].Time)
+		switch {
+		case t.Equal(points[b].Time):
+			res = append(res, withDistance(points[b], dist[b]))
+		case t.Equal(points[a].Time):
+			res = append(res, withDistance(points[a], dist[a]))
+		case span > opts.MaxGap:
+			// Skip to the first time after the gap.
+			k = int((points[b].Time.Sub(start)+interval-1)/interval) - 1
+		default:
+			f := float64(t.Sub(points[a].Time)) / float64(span)
+			res = append(res, interpolatePoint(points[a], points[b], dist[a], dist[b], f))
+		}
+	}
+
+	return res, nil
+}
+
+// ResampleByDistance is ResampleByTime, with points every interval meters
+// along the track instead. Stretches longer than MaxGap in time are still
+// left as breaks. The points must be in order along the track.
+func ResampleByDistance(points []TrackPoint, interval float64, opts ResampleOptions) ([]TrackPoint, error) {
+	if interval <= 0 || math.IsNaN(interval) {
+		return nil, fmt.Errorf("invalid resample interval %f", interval)
+	}
+	opts = opts.withDefaults()
+	if len(points) < 2 {
+		return append([]TrackPoint{}, points...), nil
+	}
+	dist := trackDistances(points)
+	for i := 1; i < len(points); i++ {
+		if dist[i] < dist[i-1] {
+			return nil, fmt.Errorf("point %d is out of order: distance %f before %f", i, dist[i], dist[i-1])
+		}
+	}
+
+	start, end := dist[0], dist[len(dist)-1]
+	res := make([]TrackPoint, 0, int((end-start)/interval)+1)
+	seg := 1
+	for k := 0; ; k++ {
+		d := start + float64(k)*interval
+		if d > end {
+			break
+		}
+		for dist[seg] < d {
+			seg++
+		}
+		a, b := seg-1, seg
+		switch {
+		case d == dist[b]:
+			res = append(res, withDistance(points[b], dist[b]))
+		case d == dist[a]:
+			res = append(res, withDistance(points[a], dist[a]))
+		case points[b].Time.Sub(points[a].Time) > opts.MaxGap:
+			k = int(math.Ceil((dist[b]-start)/interval)) - 1
+		default:
+			f := (d - dist[a]) / (dist[b] - dist[a])
+			res = append(res, interpolatePoint(points[a], points[b], dist[a], dist[b], f))
+		}
+	}
+
+	return res, nil
+}
+
+func withDistance(p TrackPoint, d float64) TrackPoint {
+	p.Distance = d
+	return p
+}
+
+// interpolatePoint returns the point f of the way from a to b, which are da
+// and db along the track. If only one of them has a position, the point gets
+// it if that's the one it's nearer to.
+func interpolatePoint(a, b TrackPoint, da, db, f float64) TrackPoint {
+	p := TrackPoint{
+		Distance:     lerp(da, db, f),
+		Elevation:    lerp(a.Elevation, b.Elevation, f),
+		Speed:        lerp(a.Speed, b.Speed, f),
+		Grade:        lerp(a.Grade, b.Grade, f),
+		HeartRate:    lerp(a.HeartRate, b.HeartRate, f),
+		Cadence:      lerp(a.Cadence, b.Cadence, f),
+		Power:        lerp(a.Power, b.Power, f),
+		Temperature:  lerp(a.Temperature, b.Temperature, f),
+		Surface:      a.Surface,
+		Interpolated: true,
+	}
+	if !a.Time.IsZero() && !b.Time.IsZero() {
+		p.Time = a.Time.Add(time.Duration(f * float64(b.Time.Sub(a.Time))))
+	}
+	aPos, bPos := a.Lat != 0 || a.Lng != 0, b.Lat != 0 || b.Lng != 0
+	switch {
+	case aPos && bPos:
+		p.Lat, p.Lng = lerp(a.Lat, b.Lat, f), lerp(a.Lng, b.Lng, f)
+	case aPos && f < 0.5:
+		p.Lat, p.Lng = a.Lat, a.Lng
+	case bPos && f >= 0.5:
+		p.Lat, p.Lng = b.Lat, b.Lng
+	}
+
+	return p
+}
@@ -0,0 +1,284 @@
+package goride
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var resampleStart = time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+
+// at is the time s seconds into the test tracks.
+func at(s float64) time.Time {
+	return resampleStart.Add(time.Duration(s * float64(time.Second)))
+}
+
+func TestResampleByTime(t *testing.T) {
+	a := TrackPoint{Time: at(0), Lat: 45, Lng: -122, Elevation: 100, Distance: 0, HeartRate: 100, Power: 200}
+	b := TrackPoint{Time: at(4), Lat: 45.0004, Lng: -122, Elevation: 104, Distance: 40, HeartRate: 120, Power: 100}
+	c := TrackPoint{Time: at(5), Lat: 45.0005, Lng: -122, Elevation: 104, Distance: 50, HeartRate: 120, Power: 100}
+
+	tests := []struct {
+		desc     string
+		points   []TrackPoint
+		interval time.Duration
+		opts     ResampleOptions
+		want     []TrackPoint
+		wantErr  bool
+	}{
+		{
+			desc:     "every second",
+			points:   []TrackPoint{a, b, c},
+			interval: time.Second,
+			want: []TrackPoint{
+				a,
+				{Time: at(1), Lat: 45.0001, Lng: -122, Elevation: 101, Distance: 10, HeartRate: 105, Power: 175, Interpolated: true},
+				{Time: at(2), Lat: 45.0002, Lng: -122, Elevation: 102, Distance: 20, HeartRate: 110, Power: 150, Interpolated: true},
+				{Time: at(3), Lat: 45.0003, Lng: -122, Elevation: 103, Distance: 30, HeartRate: 115, Power: 125, Interpolated: true},
+				b,
+				c,
+			},
+		},
+		{
+			desc:     "uneven interval",
+			points:   []TrackPoint{a, b, c},
+			interval: 1500 * time.Millisecond,
+			want: []TrackPoint{
+				a,
+				{Time: at(1.5), Lat: 45.00015, Lng: -122, Elevation: 101.5, Distance: 15, HeartRate: 107.5, Power: 162.5, Interpolated: true},
+				{Time: at(3), Lat: 45.0003, Lng: -122, Elevation: 103, Distance: 30, HeartRate: 115, Power: 125, Interpolated: true},
+				{Time: at(4.5), Lat: 45.00045, Lng: -122, Elevation: 104, Distance: 45, HeartRate: 120, Power: 100, Interpolated: true},
+			},
+		},
+		{
+			desc: "gap",
+			points: []TrackPoint{
+				{Time: at(0), Power: 100},
+				{Time: at(2), Power: 200},
+				{Time: at(100), Power: 300},
+				{Time: at(104), Power: 100},
+			},
+			interval: 3 * time.Second,
+			// 3 is in the gap, so the next point is at 102.
+			want: []TrackPoint{
+				{Time: at(0), Power: 100},
+				{Time: at(102), Power: 200, Interpolated: true},
+			},
+		},
+		{
+			desc: "longer gap allowed",
+			points: []TrackPoint{
+				{Time: at(0), Power: 100},
+				{Time: at(100), Power: 300},
+				{Time: at(104), Power: 100},
+			},
+			interval: 25 * time.Second,
+			opts:     ResampleOptions{MaxGap: 2 * time.Minute},
+			want: []TrackPoint{
+				{Time: at(0), Power: 100},
+				{Time: at(25), Power: 150, Interpolated: true},
+				{Time: at(50), Power: 200, Interpolated: true},
+				{Time: at(75), Power: 250, Interpolated: true},
+				{Time: at(100), Power: 300},
+			},
+		},
+		{
+			desc: "one position",
+			points: []TrackPoint{
+				{Time: at(0), Lat: 45, Lng: -122},
+				{Time: at(4), HeartRate: 100},
+			},
+			interval: time.Second,
+			want: []TrackPoint{
+				{Time: at(0), Lat: 45, Lng: -122},
+				{Time: at(1), Lat: 45, Lng: -122, HeartRate: 25, Interpolated: true},
+				{Time: at(2), HeartRate: 50, Interpolated: true},
+				{Time: at(3), HeartRate: 75, Interpolated: true},
+				{Time: at(4), HeartRate: 100},
+			},
+		},
+		{
+			desc:     "one point",
+			points:   []TrackPoint{a},
+			interval: time.Second,
+			want:     []TrackPoint{a},
+		},
+		{
+			desc:     "out of order",
+			points:   []TrackPoint{b, a},
+			interval: time.Second,
+			wantErr:  true,
+		},
+		{
+			desc:    "bad interval",
+			points:  []TrackPoint{a, b},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ResampleByTime(tc.points, tc.interval, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad points: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResampleByDistance(t *testing.T) {
+	tests := []struct {
+		desc     string
+		points   []TrackPoint
+		interval float64
+		want     []TrackPoint
+		wantErr  bool
+	}{
+		{
+			desc: "every 10m",
+			points: []TrackPoint{
+				{Time: at(0), Distance: 0, Elevation: 100},
+				{Time: at(5), Distance: 25, Elevation: 105},
+				{Time: at(20), Distance: 50, Elevation: 100},
+			},
+			interval: 10,
+			want: []TrackPoint{
+				{Time: at(0), Distance: 0, Elevation: 100},
+				{Time: at(2), Distance: 10, Elevation: 102, Interpolated: true},
+				{Time: at(4), Distance: 20, Elevation: 104, Interpolated: true},
+				{Time: at(8), Distance: 30, Elevation: 104, Interpolated: true},
+				{Time: at(14), Distance: 40, Elevation: 102, Interpolated: true},
+				{Time: at(20), Distance: 50, Elevation: 100},
+			},
+		},
+		{
+			desc: "stopped",
+			points: []TrackPoint{
+				{Time: at(0), Distance: 0, HeartRate: 100},
+				{Time: at(10), Distance: 20, HeartRate: 120},
+				{Time: at(40), Distance: 20, HeartRate: 90},
+				{Time: at(50), Distance: 40, HeartRate: 110},
+			},
+			interval: 10,
+			want: []TrackPoint{
+				{Time: at(0), Distance: 0, HeartRate: 100},
+				{Time: at(5), Distance: 10, HeartRate: 110, Interpolated: true},
+				{Time: at(10), Distance: 20, HeartRate: 120},
+				{Time: at(45), Distance: 30, HeartRate: 100, Interpolated: true},
+				{Time: at(50), Distance: 40, HeartRate: 110},
+			},
+		},
+		{
+			desc: "gap",
+			points: []TrackPoint{
+				{Time: at(0), Distance: 0},
+				{Time: at(10), Distance: 50},
+				{Time: at(600), Distance: 150},
+				{Time: at(610), Distance: 200},
+			},
+			interval: 25,
+			want: []TrackPoint{
+				{Time: at(0), Distance: 0},
+				{Time: at(5), Distance: 25, Interpolated: true},
+				{Time: at(10), Distance: 50},
+				{Time: at(600), Distance: 150},
+				{Time: at(605), Distance: 175, Interpolated: true},
+				{Time: at(610), Distance: 200},
+			},
+		},
+		{
+			desc: "route without times",
+			points: []TrackPoint{
+				{Distance: 0, Elevation: 10},
+				{Distance: 1000, Elevation: 20},
+			},
+			interval: 400,
+			want: []TrackPoint{
+				{Distance: 0, Elevation: 10},
+				{Distance: 400, Elevation: 14, Interpolated: true},
+				{Distance: 800, Elevation: 18, Interpolated: true},
+			},
+		},
+		{
+			desc: "out of order",
+			points: []TrackPoint{
+				{Distance: 0},
+				{Distance: 100},
+				{Distance: 50},
+			},
+			interval: 10,
+			wantErr:  true,
+		},
+		{
+			desc:     "bad interval",
+			points:   []TrackPoint{{Distance: 0}, {Distance: 100}},
+			interval: -1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ResampleByDistance(tc.points, tc.interval, ResampleOptions{})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad points: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+// BenchmarkResample resamples an hour of smart recording, with points 1 to 7
+// seconds apart.
+func BenchmarkResample(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	var points []TrackPoint
+	p := TrackPoint{Time: resampleStart, Lat: 45, Lng: -122}
+	for p.Time.Before(resampleStart.Add(time.Hour)) {
+		points = append(points, p)
+		secs := 1 + rnd.Intn(7)
+		p.Time = p.Time.Add(time.Duration(secs) * time.Second)
+		p.Lat += float64(secs) * 8 / metersPerDegree
+		p.Distance += float64(secs) * 8
+		p.Elevation += rnd.Float64() - 0.5
+		p.HeartRate = 120 + rnd.Float64()*40
+		p.Power = rnd.Float64() * 300
+	}
+
+	b.Run("time", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ResampleByTime(points, time.Second, ResampleOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("distance", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ResampleByDistance(points, 10, ResampleOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
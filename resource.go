@@ -0,0 +1,86 @@
+package goride
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ResourceKind is the type of thing an RWGPS URL points to.
+type ResourceKind string
+
+const (
+	KindTrip  ResourceKind = "trip"
+	KindRoute ResourceKind = "route"
+	KindUser  ResourceKind = "user"
+	KindEvent ResourceKind = "event"
+)
+
+// Resource is the ride, route, user or event an RWGPS URL points to.
+type Resource struct {
+	Kind ResourceKind
+	ID   int
+}
+
+// URLError is returned by ParseURL for URLs that don't point to anything it
+// knows about.
+type URLError struct {
+	URL    string
+	Reason string
+}
+
+func (e *URLError) Error() string {
+	return fmt.Sprintf("bad RWGPS url %q: %s", e.URL, e.Reason)
+}
+
+// urlKinds maps the first part of a URL's path to what it points to.
+var urlKinds = map[string]ResourceKind{
+	"trips":  KindTrip,
+	"rides":  KindTrip,
+	"routes": KindRoute,
+	"users":  KindUser,
+	"events": KindEvent,
+}
+
+// ParseURL finds what a ridewithgps.com URL, like a link pasted into a chat,
+// points to. The scheme and "www." are optional, and query strings,
+// fragments, trailing slashes and ".json" are ignored. IDs can be followed by
+// a slug, like events' "/events/123-spring-century".
+func ParseURL(s string) (Resource, error) {
+	raw := strings.TrimSpace(s)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Resource{}, &URLError{URL: s, Reason: err.Error()}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Resource{}, &URLError{URL: s, Reason: "not a web link"}
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "ridewithgps.com" {
+		return Resource{}, &URLError{URL: s, Reason: "not a ridewithgps.com link"}
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return Resource{}, &URLError{URL: s, Reason: "no ID in the path"}
+	}
+	kind, ok := urlKinds[strings.ToLower(parts[0])]
+	if !ok {
+		return Resource{}, &URLError{URL: s, Reason: fmt.Sprintf("unknown link type %q", parts[0])}
+	}
+
+	id := strings.TrimSuffix(parts[1], ".json")
+	if i := strings.IndexByte(id, '-'); i > 0 {
+		id = id[:i]
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return Resource{}, &URLError{URL: s, Reason: fmt.Sprintf("bad ID %q", parts[1])}
+	}
+
+	return Resource{Kind: kind, ID: n}, nil
+}
@@ -0,0 +1,62 @@
+package goride
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		desc    string
+		url     string
+		want    Resource
+		wantErr bool
+	}{
+		{desc: "trip", url: "https://ridewithgps.com/trips/94", want: Resource{KindTrip, 94}},
+		{desc: "http", url: "http://ridewithgps.com/trips/94", want: Resource{KindTrip, 94}},
+		{desc: "www", url: "https://www.ridewithgps.com/trips/94", want: Resource{KindTrip, 94}},
+		{desc: "no scheme", url: "ridewithgps.com/trips/94", want: Resource{KindTrip, 94}},
+		{desc: "no scheme with www", url: "www.ridewithgps.com/routes/31330404", want: Resource{KindRoute, 31330404}},
+		{desc: "upper case host", url: "https://RideWithGPS.com/trips/94", want: Resource{KindTrip, 94}},
+		{desc: "trailing slash", url: "https://ridewithgps.com/trips/94/", want: Resource{KindTrip, 94}},
+		{desc: "query", url: "https://ridewithgps.com/routes/31330404?privacy_code=abc123", want: Resource{KindRoute, 31330404}},
+		{desc: "fragment", url: "https://ridewithgps.com/trips/94#photos", want: Resource{KindTrip, 94}},
+		{desc: "json", url: "https://ridewithgps.com/trips/94.json", want: Resource{KindTrip, 94}},
+		{desc: "rides", url: "https://ridewithgps.com/rides/94", want: Resource{KindTrip, 94}},
+		{desc: "sub page", url: "https://ridewithgps.com/trips/94/edit", want: Resource{KindTrip, 94}},
+		{desc: "user", url: "https://ridewithgps.com/users/1268590", want: Resource{KindUser, 1268590}},
+		{desc: "event slug", url: "https://ridewithgps.com/events/12-grizzly-peak-century", want: Resource{KindEvent, 12}},
+		{desc: "surrounding space", url: "  https://ridewithgps.com/trips/94\n", want: Resource{KindTrip, 94}},
+		{desc: "other site", url: "https://www.strava.com/activities/94", wantErr: true},
+		{desc: "look-alike host", url: "https://ridewithgps.com.example.com/trips/94", wantErr: true},
+		{desc: "subdomain", url: "https://evil.ridewithgps.com.evil/trips/94", wantErr: true},
+		{desc: "ftp", url: "ftp://ridewithgps.com/trips/94", wantErr: true},
+		{desc: "home page", url: "https://ridewithgps.com/", wantErr: true},
+		{desc: "no id", url: "https://ridewithgps.com/trips", wantErr: true},
+		{desc: "unknown kind", url: "https://ridewithgps.com/clubs/501", wantErr: true},
+		{desc: "not a number", url: "https://ridewithgps.com/trips/abc", wantErr: true},
+		{desc: "zero", url: "https://ridewithgps.com/trips/0", wantErr: true},
+		{desc: "negative", url: "https://ridewithgps.com/trips/-5", wantErr: true},
+		{desc: "empty", url: "", wantErr: true},
+		{desc: "garbage", url: "::::", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseURL(tc.url)
+			if tc.wantErr {
+				var ue *URLError
+				if !errors.As(err, &ue) {
+					t.Errorf("want a URLError, got %+v, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryOptions control how rate limited requests are retried.
+type RetryOptions struct {
+	// Rate limited requests are retried up to Retries times, waiting RetryWait
+	// before the first retry and twice as long before each of the next ones.
+	// Default to 3 and 10 seconds. Negative Retries disables retrying.
+	Retries   int
+	RetryWait time.Duration
+}
+
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.Retries == 0 {
+		o.Retries = 3
+	}
+	if o.RetryWait == 0 {
+		o.RetryWait = 10 * time.Second
+	}
+	return o
+}
+
+// retryRateLimited calls f until it isn't rate limited, or runs out of
+// retries, doubling the wait each time. It stops waiting, and returns ctx's
+// error, once ctx is done.
+func (r *RWGPS) retryRateLimited(ctx context.Context, opts RetryOptions, f func() error) error {
+	wait := opts.RetryWait
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || !errors.Is(err, ErrRateLimited) || i >= opts.Retries {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		r.countRetry(wait)
+		wait *= 2
+	}
+}
@@ -0,0 +1,73 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryRateLimited(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      RetryOptions
+		fails     int
+		wantCalls int
+		wantErr   error
+	}{
+		{desc: "first try", opts: RetryOptions{Retries: 2, RetryWait: time.Millisecond}, wantCalls: 1},
+		{desc: "retried", opts: RetryOptions{Retries: 2, RetryWait: time.Millisecond}, fails: 2, wantCalls: 3},
+		{desc: "out of retries", opts: RetryOptions{Retries: 2, RetryWait: time.Millisecond}, fails: 5, wantCalls: 3, wantErr: ErrRateLimited},
+		{desc: "no retries", opts: RetryOptions{Retries: -1, RetryWait: time.Millisecond}, fails: 5, wantCalls: 1, wantErr: ErrRateLimited},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls := 0
+			err := testObj("").retryRateLimited(context.Background(), tc.opts, func() error {
+				if calls++; calls <= tc.fails {
+					return ErrRateLimited
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+				t.Errorf("want error %v, got %v", tc.wantErr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("want %d calls, got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRetryRateLimitedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewMemoryMetrics()
+	r := testObj("")
+	WithMetrics(m)(r)
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- r.retryRateLimited(ctx, RetryOptions{Retries: 3, RetryWait: time.Hour}, func() error {
+			calls++
+			return ErrRateLimited
+		})
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry didn't stop when the context was canceled")
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+	// The wait was cut short, so nothing was retried.
+	if got := m.Counters()[MetricRetries]; got != 0 {
+		t.Errorf("want no retries counted, got %v", got)
+	}
+}
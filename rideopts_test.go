@@ -0,0 +1,195 @@
+package goride
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetRidesWithOpts(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    GetRidesOpts
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			desc: "zero value",
+			want: url.Values{"offset": {"0"}, "limit": {"2"}},
+		},
+		{
+			desc: "sorted",
+			opts: GetRidesOpts{SortBy: "distance", Order: OrderDesc},
+			want: url.Values{
+				"offset": {"0"}, "limit": {"2"},
+				"sort_by": {"distance"}, "order": {"desc"},
+			},
+		},
+		{
+			desc: "filtered",
+			opts: GetRidesOpts{
+				DepartedAfter:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.FixedZone("PST", -8*3600)),
+				DepartedBefore: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+				GearID:         7,
+			},
+			want: url.Values{
+				"offset": {"0"}, "limit": {"2"},
+				"departed_at_min": {"2021-01-01T08:00:00Z"},
+				"departed_at_max": {"2021-02-01T00:00:00Z"},
+				"gear_id":         {"7"},
+			},
+		},
+		{
+			desc: "fields",
+			opts: GetRidesOpts{Fields: []string{"id", "name"}},
+			want: url.Values{
+				"offset": {"0"}, "limit": {"2"},
+				"fields": {"id,name"},
+			},
+		},
+		{
+			desc:    "bad sort field",
+			opts:    GetRidesOpts{SortBy: "speed"},
+			wantErr: true,
+		},
+		{
+			desc:    "bad order",
+			opts:    GetRidesOpts{SortBy: "name", Order: "up"},
+			wantErr: true,
+		},
+		{
+			desc:    "order without sort field",
+			opts:    GetRidesOpts{Order: OrderAsc},
+			wantErr: true,
+		},
+		{
+			desc: "empty range",
+			opts: GetRidesOpts{
+				DepartedAfter:  time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+				DepartedBefore: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: true,
+		},
+	}
+
+	var got url.Values
+	f := func(_ string, args url.Values) string {
+		got = args
+		return getTestData("trips0-2.json")
+	}
+	server := startServer(t,
+		nil,
+		map[string]func(string, url.Values) string{
+			"/users/1/trips.json": f,
+		})
+	defer server.Close()
+	r := testObj(server.URL)
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got = nil
+			rides, _, err := r.GetRidesWithOpts(1, 0, 2, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				if got != nil {
+					t.Errorf("invalid opts were sent to the server: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rides) != 2 {
+				t.Errorf("wrong number of rides: %d", len(rides))
+			}
+
+			for _, k := range []string{"apikey", "version", "auth_token"} {
+				got.Del(k)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad query: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetRideWithOpts(t *testing.T) {
+	tests := []struct {
+		desc       string
+		get        func(r *RWGPS) (*Ride, error)
+		body       string
+		want       url.Values
+		wantPoints bool
+	}{
+		{
+			desc:       "no options",
+			get:        func(r *RWGPS) (*Ride, error) { return r.GetRide(94) },
+			want:       url.Values{},
+			wantPoints: true,
+		},
+		{
+			desc: "summary",
+			get:  func(r *RWGPS) (*Ride, error) { return r.GetRideSummary(94) },
+			want: url.Values{"exclude": {"track_points"}},
+		},
+		{
+			desc: "fields",
+			get: func(r *RWGPS) (*Ride, error) {
+				return r.GetRideWithOpts(94, RequestOptions{Fields: []string{"id", "name", "distance"}})
+			},
+			want:       url.Values{"fields": {"id,name,distance"}},
+			wantPoints: true,
+		},
+		{
+			desc: "summary with quoted numbers",
+			get:  func(r *RWGPS) (*Ride, error) { return r.GetRideSummary(94) },
+			body: strings.Replace(getTestData("trip.json"), `"distance":42990.7,`, `"distance":"42990.7",`, 1),
+			want: url.Values{"exclude": {"track_points"}},
+		},
+	}
+
+	var got url.Values
+	var body string
+	server := startServer(t,
+		nil,
+		map[string]func(string, url.Values) string{
+			// The server ignores the options, and always sends everything.
+			"/trips/94.json": func(_ string, args url.Values) string {
+				got = args
+				return body
+			},
+		})
+	defer server.Close()
+	r := testObj(server.URL)
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			body = tc.body
+			if body == "" {
+				body = getTestData("trip.json")
+			}
+			ride, err := tc.get(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ride.ID != 94 || ride.Name != "Peak To Peak" || ride.Distance != 42990.7 {
+				t.Errorf("bad ride: %d %q %v", ride.ID, ride.Name, ride.Distance)
+			}
+			if gotPoints := len(ride.TrackPoints) > 0; gotPoints != tc.wantPoints {
+				t.Errorf("got %d track points, want points: %v", len(ride.TrackPoints), tc.wantPoints)
+			}
+
+			for _, k := range []string{"apikey", "version", "auth_token"} {
+				got.Del(k)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad query: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+// Package ridesync keeps a local copy of a user's rides, so they can be
+// queried without going back to the API. Each sync only fetches the rides that
+// changed since the last one.
+package ridesync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zigdon/goride"
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+const (
+	snapshotFile = "rides.json"
+	tripsDir     = "trips"
+	version      = 1
+	pageSize     = 100
+)
+
+// Options control what's kept in the DB.
+type Options struct {
+	// FullRides also keeps the full ride, with its track points, for every
+	// synced ride.
+	FullRides bool
+}
+
+// DB is a local ride database, stored in a directory. It's safe for
+// concurrent use, and can be read while a sync is running.
+type DB struct {
+	dir  string
+	svc  goride.Service
+	opts Options
+
+	// syncMu makes sure only one sync runs at a time.
+	syncMu sync.Mutex
+
+	mu    sync.RWMutex
+	rides map[int]*goride.RideSlim
+	// marks are the newest UpdatedAt synced for each user.
+	marks map[int]time.Time
+}
+
+// snapshot is the on-disk format of the DB.
+type snapshot struct {
+	Version int                `json:"version"`
+	Marks   map[int]time.Time  `json:"marks"`
+	Rides   []*goride.RideSlim `json:"rides"`
+}
+
+// SyncStats counts the changes made by a sync.
+type SyncStats struct {
+	Added   int
+	Updated int
+	Deleted int
+}
+
+// Open loads the DB stored in dir, creating it if needed. If the stored rides
+// can't be read, the file is renamed with a ".corrupt" suffix and the DB starts
+// out empty, so the next sync fetches everything again.
+func Open(dir string, svc goride.Service, opts Options) (*DB, error) {
+	if err := os.MkdirAll(filepath.Join(dir, tripsDir), 0700); err != nil {
+		return nil, fmt.Errorf("can't create db dir %q: %w", dir, err)
+	}
+
+	db := &DB{
+		dir:   dir,
+		svc:   svc,
+		opts:  opts,
+		rides: make(map[int]*goride.RideSlim),
+		marks: make(map[int]time.Time),
+	}
+
+	// Leftovers from a write that didn't finish.
+	tmps, _ := filepath.Glob(filepath.Join(dir, atomicfile.TempPattern))
+	more, _ := filepath.Glob(filepath.Join(dir, tripsDir, atomicfile.TempPattern))
+	for _, tmp := range append(tmps, more...) {
+		os.Remove(tmp)
+	}
+
+	path := filepath.Join(dir, snapshotFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read %q: %w", path, err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil || snap.Version != version {
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, fmt.Errorf("can't move corrupt db aside: %w", err)
+		}
+		return db, nil
+	}
+
+	for _, r := range snap.Rides {
+		db.rides[r.ID] = r
+	}
+	for user, mark := range snap.Marks {
+		db.marks[user] = mark
+	}
+
+	return db, nil
+}
+
+// SyncUser fetches the user's rides that changed since the last sync, newest
+// first, and stores them. Deleted rides are removed. Nothing is stored unless
+// the sync completes, so a failed sync is simply retried next time.
+func (db *DB) SyncUser(user int) (SyncStats, error) {
+	db.syncMu.Lock()
+	defer db.syncMu.Unlock()
+
+	db.mu.RLock()
+	mark := db.marks[user]
+	db.mu.RUnlock()
+
+	// Rides updated at the mark itself are fetched again, in case some of them
+	// were missed. The unchanged ones are skipped below.
+	var changed []*goride.RideSlim
+	newMark := mark
+	opts := goride.GetRidesOpts{SortBy: "updated_at", Order: goride.OrderDesc}
+	offset := 0
+pages:
+	for {
+		rides, count, err := db.svc.GetRidesWithOpts(user, offset, pageSize, opts)
+		if err != nil {
+			return SyncStats{}, fmt.Errorf("error syncing rides for user %d: %w", user, err)
+		}
+		for _, r := range rides {
+			if r.UpdatedAt.Before(mark) {
+				break pages
+			}
+			if r.UpdatedAt.After(newMark) {
+				newMark = r.UpdatedAt
+			}
+			c := *r
+			changed = append(changed, &c)
+		}
+		offset += len(rides)
+		if len(rides) == 0 || offset >= count {
+			break
+		}
+	}
+
+	db.mu.RLock()
+	rides := make(map[int]*goride.RideSlim, len(db.rides)+len(changed))
+	for id, r := range db.rides {
+		rides[id] = r
+	}
+	db.mu.RUnlock()
+
+	var stats SyncStats
+	// stale are the rides whose stored full ride is out of date.
+	var stale []int
+	for _, r := range changed {
+		old, ok := rides[r.ID]
+		if r.IsDeleted() {
+			if ok {
+				delete(rides, r.ID)
+				stale = append(stale, r.ID)
+				stats.Deleted++
+			}
+			continue
+		}
+		if ok && old.UpdatedAt.Equal(r.UpdatedAt) {
+			continue
+		}
+		if db.opts.FullRides {
+			if _, err := db.fetchRide(r.ID); err != nil {
+				return SyncStats{}, fmt.Errorf("error syncing rides for user %d: %w", user, err)
+			}
+		} else if ok {
+			// FullRide stored the old version, and would keep returning it.
+			stale = append(stale, r.ID)
+		}
+		rides[r.ID] = r
+		if ok {
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+	}
+
+	db.mu.RLock()
+	marks := make(map[int]time.Time, len(db.marks)+1)
+	for u, m := range db.marks {
+		marks[u] = m
+	}
+	db.mu.RUnlock()
+	marks[user] = newMark
+
+	if err := db.save(rides, marks); err != nil {
+		return SyncStats{}, fmt.Errorf("error saving rides for user %d: %w", user, err)
+	}
+
+	db.mu.Lock()
+	db.rides = rides
+	db.marks = marks
+	db.mu.Unlock()
+
+	for _, id := range stale {
+		os.Remove(db.ridePath(id))
+	}
+
+	return stats, nil
+}
+
+// LastSync returns the UpdatedAt of the newest ride synced for the user, or
+// the zero time if the user was never synced.
+func (db *DB) LastSync(user int) time.Time {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.marks[user]
+}
+
+// Len returns the number of rides in the DB.
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.rides)
+}
+
+// Ride returns a stored ride.
+func (db *DB) Ride(id int) (*goride.RideSlim, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	r, ok := db.rides[id]
+	if !ok {
+		return nil, false
+	}
+	c := *r
+	return &c, true
+}
+
+// FullRide returns the full ride for a stored ride. If it wasn't stored, or
+// can't be read, it's fetched again. Rides not in the DB return
+// goride.ErrNotFound.
+func (db *DB) FullRide(id int) (*goride.Ride, error) {
+	if _, ok := db.Ride(id); !ok {
+		return nil, fmt.Errorf("ride %d isn't synced: %w", id, goride.ErrNotFound)
+	}
+
+	data, err := os.ReadFile(db.ridePath(id))
+	if err == nil {
+		var ride goride.Ride
+		if err := json.Unmarshal(data, &ride); err == nil {
+			return &ride, nil
+		}
+	}
+
+	return db.fetchRide(id)
+}
+
+// RidesBetween returns the rides that departed in [from, to), newest first.
+func (db *DB) RidesBetween(from, to time.Time) []*goride.RideSlim {
+	return db.filter(func(r *goride.RideSlim) bool {
+		return !r.DepartedAt.Before(from) && r.DepartedAt.Before(to)
+	})
+}
+
+// RidesWithGear returns the rides using the gear, newest first.
+func (db *DB) RidesWithGear(gearID int) []*goride.RideSlim {
+	return db.filter(func(r *goride.RideSlim) bool {
+		return r.GearID == gearID
+	})
+}
+
+// RidesIn returns the rides whose bounding box overlaps box, newest first.
+// Rides without a bounding box are skipped.
+func (db *DB) RidesIn(box goride.BoundingBox) []*goride.RideSlim {
+	return db.filter(func(r *goride.RideSlim) bool {
+		return box.Intersects(r.Bounds())
+	})
+}
+
+func (db *DB) filter(keep func(*goride.RideSlim) bool) []*goride.RideSlim {
+	db.mu.RLock()
+	var res []*goride.RideSlim
+	for _, r := range db.rides {
+		if keep(r) {
+			c := *r
+			res = append(res, &c)
+		}
+	}
+	db.mu.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].DepartedAt.Equal(res[j].DepartedAt) {
+			return res[i].DepartedAt.After(res[j].DepartedAt)
+		}
+		return res[i].ID > res[j].ID
+	})
+
+	return res
+}
+
+// fetchRide gets the full ride from the service, and stores it.
+func (db *DB) fetchRide(id int) (*goride.Ride, error) {
+	ride, err := db.svc.GetRide(id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ride %d: %w", id, err)
+	}
+
+	data, err := json.Marshal(ride)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode ride %d: %w", id, err)
+	}
+	if err := atomicfile.WriteFile(db.ridePath(id), data); err != nil {
+		return nil, fmt.Errorf("can't store ride %d: %w", id, err)
+	}
+
+	return ride, nil
+}
+
+func (db *DB) ridePath(id int) string {
+	return filepath.Join(db.dir, tripsDir, strconv.Itoa(id)+".json")
+}
+
+func (db *DB) save(rides map[int]*goride.RideSlim, marks map[int]time.Time) error {
+	snap := snapshot{Version: version, Marks: marks}
+	for _, r := range rides {
+		snap.Rides = append(snap.Rides, r)
+	}
+	sort.Slice(snap.Rides, func(i, j int) bool { return snap.Rides[i].ID < snap.Rides[j].ID })
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(filepath.Join(db.dir, snapshotFile), data)
+}
@@ -0,0 +1,335 @@
+package ridesync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride"
+	"github.com/zigdon/goride/goridetest"
+)
+
+var day0 = time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+
+func testRide(id, days int) *goride.RideSlim {
+	t := day0.AddDate(0, 0, days)
+	return &goride.RideSlim{ID: id, UserID: 7, DepartedAt: t, UpdatedAt: t.Add(time.Hour)}
+}
+
+func ids(rides []*goride.RideSlim) []int {
+	var res []int
+	for _, r := range rides {
+		res = append(res, r.ID)
+	}
+	return res
+}
+
+func TestSyncUser(t *testing.T) {
+	dir := t.TempDir()
+	f := goridetest.New()
+	rides := []*goride.RideSlim{testRide(1, 0), testRide(2, 1), testRide(3, 2)}
+	f.AddRides(7, rides...)
+
+	db, err := Open(dir, f, Options{})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+
+	stats, err := db.SyncUser(7)
+	if err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+	if diff := cmp.Diff(SyncStats{Added: 3}, stats); diff != "" {
+		t.Errorf("bad first sync: -want +got\n%s", diff)
+	}
+	if want := rides[2].UpdatedAt; !db.LastSync(7).Equal(want) {
+		t.Errorf("bad mark: want %s, got %s", want, db.LastSync(7))
+	}
+
+	// Nothing changed, only the first page is needed.
+	before := len(f.CallsTo("GetRidesWithOpts"))
+	stats, err = db.SyncUser(7)
+	if err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	if diff := cmp.Diff(SyncStats{}, stats); diff != "" {
+		t.Errorf("bad second sync: -want +got\n%s", diff)
+	}
+	if n := len(f.CallsTo("GetRidesWithOpts")) - before; n != 1 {
+		t.Errorf("expected 1 page fetch, got %d", n)
+	}
+
+	rides[0].Name = "renamed"
+	rides[0].UpdatedAt = day0.AddDate(0, 0, 5)
+	deleted := day0.AddDate(0, 0, 6)
+	rides[1].DeletedAt = &deleted
+	rides[1].UpdatedAt = deleted
+	f.SetRides(7, append(rides, testRide(4, 3))...)
+
+	stats, err = db.SyncUser(7)
+	if err != nil {
+		t.Fatalf("third sync failed: %v", err)
+	}
+	if diff := cmp.Diff(SyncStats{Added: 1, Updated: 1, Deleted: 1}, stats); diff != "" {
+		t.Errorf("bad third sync: -want +got\n%s", diff)
+	}
+
+	reopened, err := Open(dir, f, Options{})
+	if err != nil {
+		t.Fatalf("can't reopen db: %v", err)
+	}
+	if diff := cmp.Diff([]int{4, 3, 1}, ids(reopened.RidesBetween(day0, day0.AddDate(1, 0, 0)))); diff != "" {
+		t.Errorf("bad rides after reopening: -want +got\n%s", diff)
+	}
+	if r, _ := reopened.Ride(1); r == nil || r.Name != "renamed" {
+		t.Errorf("ride 1 wasn't updated: %+v", r)
+	}
+	if !reopened.LastSync(7).Equal(deleted) {
+		t.Errorf("bad mark after reopening: want %s, got %s", deleted, reopened.LastSync(7))
+	}
+}
+
+func TestSyncUserError(t *testing.T) {
+	dir := t.TempDir()
+	f := goridetest.New()
+	for i := 0; i < 150; i++ {
+		f.AddRides(7, testRide(i+1, i))
+	}
+	db, err := Open(dir, f, Options{FullRides: true})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+
+	// The list pages are fine, but the full rides are missing.
+	if _, err := db.SyncUser(7); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if db.Len() != 0 || !db.LastSync(7).IsZero() {
+		t.Errorf("partial sync was kept: %d rides, mark %s", db.Len(), db.LastSync(7))
+	}
+
+	db, err = Open(dir, f, Options{})
+	if err != nil {
+		t.Fatalf("can't reopen db: %v", err)
+	}
+	f.SetError("GetRidesWithOpts", errors.New("boom"))
+	if _, err := db.SyncUser(7); err == nil {
+		t.Errorf("expected an error")
+	}
+
+	f.SetError("GetRidesWithOpts", nil)
+	stats, err := db.SyncUser(7)
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if stats.Added != 150 {
+		t.Errorf("expected 150 rides added, got %+v", stats)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, snapshotFile)
+	if err := os.WriteFile(path, []byte(`{"version":1,"rides":[{"id":`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tmp-123"), []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := goridetest.New()
+	f.AddRides(7, testRide(1, 0))
+	db, err := Open(dir, f, Options{})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	if db.Len() != 0 {
+		t.Errorf("expected an empty db, got %d rides", db.Len())
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Errorf("corrupt file wasn't kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tmp-123")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temp file wasn't removed: %v", err)
+	}
+
+	stats, err := db.SyncUser(7)
+	if err != nil || stats.Added != 1 {
+		t.Errorf("bad resync: %+v, %v", stats, err)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	f := goridetest.New()
+	a := testRide(1, 0)
+	a.GearID = 10
+	a.SwLat, a.SwLng, a.NeLat, a.NeLng = 37.0, -122.5, 37.5, -122.0
+	b := testRide(2, 10)
+	b.GearID = 11
+	b.SwLat, b.SwLng, b.NeLat, b.NeLng = 40.0, -74.5, 40.5, -74.0
+	c := testRide(3, 20)
+	c.GearID = 10
+	f.AddRides(7, a, b, c)
+
+	db, err := Open(t.TempDir(), f, Options{})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	if _, err := db.SyncUser(7); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	tests := []struct {
+		desc string
+		got  []*goride.RideSlim
+		want []int
+	}{
+		{
+			desc: "between",
+			got:  db.RidesBetween(day0.AddDate(0, 0, 5), day0.AddDate(0, 0, 20)),
+			want: []int{2},
+		},
+		{
+			desc: "gear",
+			got:  db.RidesWithGear(10),
+			want: []int{3, 1},
+		},
+		{
+			desc: "box",
+			got: db.RidesIn(goride.BoundingBox{
+				SW: goride.LatLng{Lat: 37.4, Lng: -123},
+				NE: goride.LatLng{Lat: 38, Lng: -122.2},
+			}),
+			want: []int{1},
+		},
+		{
+			desc: "no box",
+			got: db.RidesIn(goride.BoundingBox{
+				SW: goride.LatLng{Lat: -1, Lng: -1},
+				NE: goride.LatLng{Lat: 1, Lng: 1},
+			}),
+			want: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ids(tc.got)); diff != "" {
+				t.Errorf("bad rides: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFullRide(t *testing.T) {
+	dir := t.TempDir()
+	f := goridetest.New()
+	f.AddRides(7, testRide(1, 0))
+	f.AddRide(&goride.Ride{ID: 1, Name: "full", TrackPoints: []goride.TrackPoint{{Distance: 1}, {Distance: 2}}})
+
+	db, err := Open(dir, f, Options{FullRides: true})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	if _, err := db.SyncUser(7); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	got, err := db.FullRide(1)
+	if err != nil {
+		t.Fatalf("can't get full ride: %v", err)
+	}
+	if got.Name != "full" || len(got.TrackPoints) != 2 {
+		t.Errorf("bad full ride: %+v", got)
+	}
+	if n := len(f.CallsTo("GetRide")); n != 1 {
+		t.Errorf("expected the stored ride to be used, got %d fetches", n)
+	}
+
+	if err := os.WriteFile(db.ridePath(1), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.FullRide(1); err != nil || got.Name != "full" {
+		t.Errorf("corrupt ride wasn't refetched: %+v, %v", got, err)
+	}
+
+	if _, err := db.FullRide(2); !errors.Is(err, goride.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFullRideUpdated(t *testing.T) {
+	dir := t.TempDir()
+	f := goridetest.New()
+	summary := testRide(1, 0)
+	f.AddRides(7, summary)
+	f.AddRide(&goride.Ride{ID: 1, Name: "before"})
+
+	db, err := Open(dir, f, Options{})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+	if _, err := db.SyncUser(7); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if got, err := db.FullRide(1); err != nil || got.Name != "before" {
+		t.Fatalf("bad full ride: %+v, %v", got, err)
+	}
+
+	name := "after"
+	if err := f.UpdateRide(1, goride.RideUpdate{Name: &name}); err != nil {
+		t.Fatalf("can't update ride: %v", err)
+	}
+	summary.UpdatedAt = summary.UpdatedAt.Add(time.Hour)
+	f.SetRides(7, summary)
+	if stats, err := db.SyncUser(7); err != nil || stats.Updated != 1 {
+		t.Fatalf("sync failed: %+v, %v", stats, err)
+	}
+	if got, err := db.FullRide(1); err != nil || got.Name != "after" {
+		t.Errorf("updated ride wasn't refetched: %+v, %v", got, err)
+	}
+}
+
+func TestReadWhileSyncing(t *testing.T) {
+	f := goridetest.New()
+	for i := 0; i < 500; i++ {
+		f.AddRides(7, testRide(i+1, i))
+	}
+	db, err := Open(t.TempDir(), f, Options{})
+	if err != nil {
+		t.Fatalf("can't open db: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// Syncs are all or nothing, readers never see a partial one.
+				if n := len(db.RidesWithGear(0)); n != 0 && n != 500 {
+					t.Errorf("saw a partial sync: %d rides", n)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.SyncUser(7); err != nil {
+				t.Errorf("sync failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if db.Len() != 500 {
+		t.Errorf("expected 500 rides, got %d", db.Len())
+	}
+}
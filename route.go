@@ -0,0 +1,204 @@
+package goride
+
+import (
+	"fmt"
+	"time"
+)
+
+// Route is a planned route. UnpavedPct is nil if the server doesn't know the
+// route's surface, see UnpavedFraction.
+type Route struct {
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Distance      float32       `json:"distance"`
+	ElevationGain float32       `json:"elevation_gain"`
+	ElevationLoss float32       `json:"elevation_loss"`
+	UnpavedPct    *float32      `json:"unpaved_pct"`
+	Visibility    Visibility    `json:"visibility"`
+	Tags          []string      `json:"tag_names"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	TrackPoints   []TrackPoint  `json:"track_points"`
+	CoursePoints  []CoursePoint `json:"course_points"`
+	POIs          []POI         `json:"points_of_interest"`
+}
+
+// CoursePoint is a cue on a route, like a turn or a water stop. Index is the
+// track point it's attached to.
+type CoursePoint struct {
+	Lat      float64 `json:"y"`
+	Lng      float64 `json:"x"`
+	Distance float64 `json:"d"`
+	Index    int     `json:"i"`
+	Type     string  `json:"t"`
+	Notes    string  `json:"n"`
+}
+
+// GetRoute gets a route, with its track points. Routes that don't exist
+// return ErrNotFound, and ones the user can't see return ErrPrivate.
+func (r *RWGPS) GetRoute(id int) (*Route, error) {
+	var resStruct struct {
+		Type  string
+		Route Route
+	}
+
+	err := r.getJSON(fmt.Sprintf("/routes/%d.json", id), nil, &resStruct)
+	if err != nil {
+		return nil, fmt.Errorf("error getting route id %d: %w", id, err)
+	}
+
+	if resStruct.Type != "route" {
+		return nil, fmt.Errorf("unexpected result type %q", resStruct.Type)
+	}
+
+	return &resStruct.Route, nil
+}
+
+// SpeedModel describes how fast a rider goes. FlatSpeed and MaxDescentSpeed
+// are in km/h, ClimbVAM in vertical meters per hour. Models with Bands, see
+// BuildSpeedModel, use them instead of ClimbVAM and MaxDescentSpeed.
+type SpeedModel struct {
+	FlatSpeed       float64     `json:"flat_speed"`
+	ClimbVAM        float64     `json:"climb_vam,omitempty"`
+	MaxDescentSpeed float64     `json:"max_descent_speed,omitempty"`
+	Bands           []SpeedBand `json:"bands,omitempty"`
+}
+
+// Grades steeper than this (downhill) are ridden at MaxDescentSpeed.
+const descentGrade = -0.02
+
+// SegmentTime estimates how long it takes to ride dist meters while climbing
+// rise meters. Climbs take the longer of the flat time and the VAM time, or
+// the speed of the grade's band if the model has bands.
+func (m SpeedModel) SegmentTime(dist, rise float64) time.Duration {
+	if dist <= 0 || m.FlatSpeed <= 0 {
+		return 0
+	}
+	if len(m.Bands) > 0 {
+		return hoursToDuration(dist / 1000 / m.bandSpeed(rise/dist))
+	}
+
+	speed := m.FlatSpeed
+	if rise/dist < descentGrade && m.MaxDescentSpeed > 0 {
+		speed = m.MaxDescentSpeed
+	}
+	hours := dist / 1000 / speed
+	if rise > 0 && m.ClimbVAM > 0 && rise/m.ClimbVAM > hours {
+		hours = rise / m.ClimbVAM
+	}
+
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// MovingTime estimates the moving time over a track, using the cumulative
+// Distance of each point.
+func (m SpeedModel) MovingTime(points []TrackPoint) time.Duration {
+	var total time.Duration
+	for i := 1; i < len(points); i++ {
+		total += m.SegmentTime(points[i].Distance-points[i-1].Distance, points[i].Elevation-points[i-1].Elevation)
+	}
+
+	return total
+}
+
+// RouteStats are the numbers used to compare routes. Distance and Climbing
+// are in meters, SteepestKm is the average grade (percent) of the steepest
+// kilometer, and Unpaved is a fraction between 0 and 1.
+type RouteStats struct {
+	Distance   float64
+	Climbing   float64
+	SteepestKm float64
+	Unpaved    float64
+	MovingTime time.Duration
+}
+
+// RouteComparison holds the stats for two routes, and their deltas (B - A).
+type RouteComparison struct {
+	A, B            RouteStats
+	DistanceDelta   float64
+	ClimbingDelta   float64
+	SteepestKmDelta float64
+	UnpavedDelta    float64
+	MovingTimeDelta time.Duration
+}
+
+func CompareRoutes(a, b *Route, model SpeedModel) RouteComparison {
+	res := RouteComparison{
+		A: routeStats(a, model),
+		B: routeStats(b, model),
+	}
+	res.DistanceDelta = res.B.Distance - res.A.Distance
+	res.ClimbingDelta = res.B.Climbing - res.A.Climbing
+	res.SteepestKmDelta = res.B.SteepestKm - res.A.SteepestKm
+	res.UnpavedDelta = res.B.Unpaved - res.A.Unpaved
+	res.MovingTimeDelta = res.B.MovingTime - res.A.MovingTime
+
+	return res
+}
+
+func routeStats(r *Route, model SpeedModel) RouteStats {
+	s := RouteStats{
+		Distance:   float64(r.Distance),
+		Climbing:   float64(r.ElevationGain),
+		SteepestKm: steepest(r.TrackPoints, 1000),
+		MovingTime: model.MovingTime(r.TrackPoints),
+	}
+	// Routes with an unknown surface compare as paved.
+	s.Unpaved, _ = r.UnpavedFraction()
+
+	if n := len(r.TrackPoints); n > 0 {
+		if s.Distance == 0 {
+			s.Distance = r.TrackPoints[n-1].Distance - r.TrackPoints[0].Distance
+		}
+		if s.Climbing == 0 {
+			for i := 1; i < n; i++ {
+				if rise := r.TrackPoints[i].Elevation - r.TrackPoints[i-1].Elevation; rise > 0 {
+					s.Climbing += rise
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// steepest returns the highest average grade, in percent, over any stretch of
+// at least window meters. Tracks shorter than the window use their full length.
+func steepest(points []TrackPoint, window float64) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	grade := func(i, j int) float64 {
+		d := points[j].Distance - points[i].Distance
+		if d <= 0 {
+			return 0
+		}
+		return (points[j].Elevation - points[i].Elevation) / d * 100
+	}
+
+	last := len(points) - 1
+	if points[last].Distance-points[0].Distance < window {
+		return grade(0, last)
+	}
+
+	var max float64
+	first := true
+	j := 0
+	for i := range points {
+		for j < last && points[j].Distance-points[i].Distance < window {
+			j++
+		}
+		if points[j].Distance-points[i].Distance < window {
+			break
+		}
+		if g := grade(i, j); first || g > max {
+			max = g
+			first = false
+		}
+	}
+
+	return max
+}
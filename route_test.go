@@ -0,0 +1,143 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetRoute(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/routes/31330404.json": getTestData("route.json")},
+		nil)
+	defer server.Close()
+
+	r := testObj(server.URL)
+	if _, err := r.GetRoute(1); err == nil {
+		t.Errorf("didn't get an error when fetching bad route")
+	}
+
+	got, err := r.GetRoute(31330404)
+	if err != nil {
+		t.Fatalf("unexpected error when fetching route: %v", err)
+	}
+
+	if got.Name != "Grizzly Peak" || got.UnpavedPct == nil || *got.UnpavedPct != 20 || len(got.Tags) != 1 || got.Tags[0] != "gravel" {
+		t.Errorf("bad route: %+v", got)
+	}
+
+	want := []TrackPoint{
+		{Lng: -122.2431, Lat: 37.8598, Elevation: 120, Distance: 0},
+		{Lng: -122.2422, Lat: 37.8603, Elevation: 130, Distance: 500},
+		{Lng: -122.2410, Lat: 37.8611, Elevation: 155, Distance: 1000},
+		{Lng: -122.2401, Lat: 37.8617, Elevation: 145, Distance: 1500},
+	}
+	if diff := cmp.Diff(want, got.TrackPoints); diff != "" {
+		t.Errorf("bad track points: -want +got\n%s", diff)
+	}
+}
+
+// synthRoute builds a route out of (length, grade) segments, with a point
+// every 100m.
+func synthRoute(unpaved float32, segments ...[2]float64) *Route {
+	r := &Route{UnpavedPct: &unpaved}
+	p := TrackPoint{}
+	r.TrackPoints = append(r.TrackPoints, p)
+	for _, s := range segments {
+		for d := 0.0; d < s[0]; d += 100 {
+			p.Distance += 100
+			p.Elevation += 100 * s[1]
+			r.TrackPoints = append(r.TrackPoints, p)
+		}
+	}
+
+	return r
+}
+
+func TestCompareRoutes(t *testing.T) {
+	model := SpeedModel{FlatSpeed: 20, ClimbVAM: 600, MaxDescentSpeed: 40}
+
+	// a is a flat 10km, mostly gravel.
+	a := synthRoute(50, [2]float64{10000, 0})
+	// b is shorter, paved, but has a 2km 10% climb.
+	b := synthRoute(10, [2]float64{3000, 0}, [2]float64{2000, 0.1}, [2]float64{3000, 0})
+
+	got := CompareRoutes(a, b, model)
+
+	if got.DistanceDelta >= 0 {
+		t.Errorf("expected b to be shorter: %v", got.DistanceDelta)
+	}
+	if got.ClimbingDelta <= 0 {
+		t.Errorf("expected b to climb more: %v", got.ClimbingDelta)
+	}
+	if got.SteepestKmDelta <= 0 {
+		t.Errorf("expected b to be steeper: %v", got.SteepestKmDelta)
+	}
+	if got.UnpavedDelta >= 0 {
+		t.Errorf("expected b to be more paved: %v", got.UnpavedDelta)
+	}
+
+	want := RouteComparison{
+		A: RouteStats{
+			Distance:   10000,
+			Unpaved:    0.5,
+			MovingTime: 30 * time.Minute,
+		},
+		B: RouteStats{
+			Distance:   8000,
+			Climbing:   200,
+			SteepestKm: 10,
+			Unpaved:    0.1,
+			// 6km flat at 20km/h, plus 200m at 600m/h.
+			MovingTime: 38 * time.Minute,
+		},
+		DistanceDelta:   -2000,
+		ClimbingDelta:   200,
+		SteepestKmDelta: 10,
+		UnpavedDelta:    -0.4,
+		MovingTimeDelta: 8 * time.Minute,
+	}
+
+	approx := cmp.Comparer(func(x, y float64) bool { return x-y < 1e-6 && y-x < 1e-6 })
+	round := cmp.Transformer("round", func(d time.Duration) time.Duration { return d.Round(time.Second) })
+	if diff := cmp.Diff(want, got, approx, round); diff != "" {
+		t.Errorf("bad comparison: -want +got\n%s", diff)
+	}
+}
+
+func TestSpeedModel(t *testing.T) {
+	model := SpeedModel{FlatSpeed: 20, ClimbVAM: 600, MaxDescentSpeed: 40}
+	banded := SpeedModel{FlatSpeed: 20, ClimbVAM: 600, Bands: []SpeedBand{
+		{Grade: -0.06, Speed: 45},
+		{Grade: 0, Speed: 36},
+		{Grade: 0.04, Speed: 12},
+	}}
+
+	tests := []struct {
+		desc  string
+		model SpeedModel
+		dist  float64
+		rise  float64
+		want  time.Duration
+	}{
+		{desc: "flat", model: model, dist: 10000, want: 30 * time.Minute},
+		{desc: "gentle climb", model: model, dist: 10000, rise: 50, want: 30 * time.Minute},
+		{desc: "steep climb", model: model, dist: 1000, rise: 100, want: 10 * time.Minute},
+		{desc: "descent", model: model, dist: 10000, rise: -500, want: 15 * time.Minute},
+		{desc: "no descent cap", model: SpeedModel{FlatSpeed: 20}, dist: 10000, rise: -500, want: 30 * time.Minute},
+		{desc: "no distance", model: model, rise: 10},
+		{desc: "band", model: banded, dist: 1000, rise: 40, want: 5 * time.Minute},
+		{desc: "between bands", model: banded, dist: 1000, rise: 20, want: 150 * time.Second},
+		{desc: "past the bands", model: banded, dist: 1000, rise: -150, want: 80 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := tc.model.SegmentTime(tc.dist, tc.rise).Round(time.Second)
+			if got != tc.want {
+				t.Errorf("bad time: want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package goride
+
+import "time"
+
+// SanitizeOptions choose what SanitizeTrack removes from a track.
+type SanitizeOptions struct {
+	StripHeartRate   bool
+	StripPower       bool
+	StripCadence     bool
+	StripTemperature bool
+	// StripTime clears the timestamps. Otherwise, if ShiftTo is set, they're
+	// moved so the track starts at ShiftTo, keeping the gaps between points.
+	StripTime bool
+	ShiftTo   time.Time
+	// TrimStart and TrimEnd drop the first and last meters of the track.
+	TrimStart float64
+	TrimEnd   float64
+	// Points inside any of the zones are dropped.
+	Zones []PrivacyZone
+}
+
+// SanitizeTrack returns a copy of the track that's safe to share, e.g. with
+// ExportGPX or WriteGeoJSON. Trimmed distances are measured along the track,
+// and the remaining points' Distance is rebased to start at zero. The input
+// isn't changed.
+func SanitizeTrack(points []TrackPoint, opts SanitizeOptions) []TrackPoint {
+	// Points without a position stay with the distance of the last one that
+	// had one, so they're trimmed with it.
+	along := make([]float64, len(points))
+	var total float64
+	var last *TrackPoint
+	for i := range points {
+		p := &points[i]
+		if p.Lat != 0 || p.Lng != 0 {
+			if last != nil {
+				total += haversine(last.Lat, last.Lng, p.Lat, p.Lng)
+			}
+			last = p
+		}
+		along[i] = total
+	}
+
+	var res []TrackPoint
+	for i, p := range points {
+		if along[i] < opts.TrimStart || total-along[i] < opts.TrimEnd {
+			continue
+		}
+		if inPrivacyZone(p, opts.Zones) {
+			continue
+		}
+		res = append(res, p)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+
+	var shift time.Duration
+	if !opts.StripTime && !opts.ShiftTo.IsZero() {
+		for _, p := range res {
+			if !p.Time.IsZero() {
+				shift = opts.ShiftTo.Sub(p.Time)
+				break
+			}
+		}
+	}
+	start := res[0].Distance
+	for i := range res {
+		p := &res[i]
+		p.Distance -= start
+		switch {
+		case opts.StripTime:
+			p.Time = time.Time{}
+		case !p.Time.IsZero():
+			p.Time = p.Time.Add(shift)
+		}
+		if opts.StripHeartRate {
+			p.HeartRate = 0
+		}
+		if opts.StripPower {
+			p.Power = 0
+		}
+		if opts.StripCadence {
+			p.Cadence = 0
+		}
+		if opts.StripTemperature {
+			p.Temperature = 0
+		}
+	}
+
+	return res
+}
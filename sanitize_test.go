@@ -0,0 +1,150 @@
+package goride
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSanitizeTrack(t *testing.T) {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	epoch := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A point every 100m going north, with everything recorded.
+	track := func() []TrackPoint {
+		var res []TrackPoint
+		for i := 0; i < 5; i++ {
+			res = append(res, TrackPoint{
+				Time:        start.Add(time.Duration(i) * 20 * time.Second),
+				Lat:         37.5 + float64(i)*100/(earthRadius*math.Pi/180),
+				Lng:         -122.25,
+				Elevation:   10,
+				Distance:    float64(i) * 100,
+				HeartRate:   140,
+				Power:       200,
+				Cadence:     90,
+				Temperature: 20,
+			})
+		}
+		return res
+	}
+	full := track()
+	with := func(i int, f func(*TrackPoint)) TrackPoint {
+		p := full[i]
+		f(&p)
+		return p
+	}
+	noSensors := func(p *TrackPoint) {
+		p.HeartRate, p.Power, p.Cadence, p.Temperature = 0, 0, 0, 0
+	}
+
+	tests := []struct {
+		desc string
+		opts SanitizeOptions
+		want []TrackPoint
+	}{
+		{
+			desc: "nothing",
+			want: full,
+		},
+		{
+			desc: "heart rate and power",
+			opts: SanitizeOptions{StripHeartRate: true, StripPower: true},
+			want: []TrackPoint{
+				with(0, func(p *TrackPoint) { p.HeartRate, p.Power = 0, 0 }),
+				with(1, func(p *TrackPoint) { p.HeartRate, p.Power = 0, 0 }),
+				with(2, func(p *TrackPoint) { p.HeartRate, p.Power = 0, 0 }),
+				with(3, func(p *TrackPoint) { p.HeartRate, p.Power = 0, 0 }),
+				with(4, func(p *TrackPoint) { p.HeartRate, p.Power = 0, 0 }),
+			},
+		},
+		{
+			desc: "all sensors and time",
+			opts: SanitizeOptions{StripHeartRate: true, StripPower: true, StripCadence: true, StripTemperature: true, StripTime: true, ShiftTo: epoch},
+			want: []TrackPoint{
+				with(0, func(p *TrackPoint) { noSensors(p); p.Time = time.Time{} }),
+				with(1, func(p *TrackPoint) { noSensors(p); p.Time = time.Time{} }),
+				with(2, func(p *TrackPoint) { noSensors(p); p.Time = time.Time{} }),
+				with(3, func(p *TrackPoint) { noSensors(p); p.Time = time.Time{} }),
+				with(4, func(p *TrackPoint) { noSensors(p); p.Time = time.Time{} }),
+			},
+		},
+		{
+			desc: "shift time",
+			opts: SanitizeOptions{ShiftTo: epoch},
+			want: []TrackPoint{
+				with(0, func(p *TrackPoint) { p.Time = epoch }),
+				with(1, func(p *TrackPoint) { p.Time = epoch.Add(20 * time.Second) }),
+				with(2, func(p *TrackPoint) { p.Time = epoch.Add(40 * time.Second) }),
+				with(3, func(p *TrackPoint) { p.Time = epoch.Add(60 * time.Second) }),
+				with(4, func(p *TrackPoint) { p.Time = epoch.Add(80 * time.Second) }),
+			},
+		},
+		{
+			desc: "trim start and end",
+			opts: SanitizeOptions{TrimStart: 150, TrimEnd: 50},
+			want: []TrackPoint{
+				with(2, func(p *TrackPoint) { p.Distance = 0 }),
+				with(3, func(p *TrackPoint) { p.Distance = 100 }),
+			},
+		},
+		{
+			desc: "trim and shift",
+			opts: SanitizeOptions{TrimStart: 150, ShiftTo: epoch},
+			want: []TrackPoint{
+				with(2, func(p *TrackPoint) { p.Distance, p.Time = 0, epoch }),
+				with(3, func(p *TrackPoint) { p.Distance, p.Time = 100, epoch.Add(20*time.Second) }),
+				with(4, func(p *TrackPoint) { p.Distance, p.Time = 200, epoch.Add(40*time.Second) }),
+			},
+		},
+		{
+			desc: "privacy zone",
+			opts: SanitizeOptions{Zones: []PrivacyZone{{Center: LatLng{Lat: 37.5, Lng: -122.25}, RadiusMeters: 120}}},
+			want: []TrackPoint{
+				with(2, func(p *TrackPoint) { p.Distance = 0 }),
+				with(3, func(p *TrackPoint) { p.Distance = 100 }),
+				with(4, func(p *TrackPoint) { p.Distance = 200 }),
+			},
+		},
+		{
+			desc: "trimmed away",
+			opts: SanitizeOptions{TrimStart: 300, TrimEnd: 300},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			in := track()
+			got := SanitizeTrack(in, tc.opts)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-6)); diff != "" {
+				t.Errorf("bad track: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(track(), in); diff != "" {
+				t.Errorf("input was changed: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSanitizeTrackExport(t *testing.T) {
+	points := SanitizeTrack(getTestRide(t).TrackPoints, SanitizeOptions{StripHeartRate: true, StripTime: true, TrimStart: 500, TrimEnd: 500})
+
+	var gpx strings.Builder
+	if err := ExportGPX(&gpx, "shared", points); err != nil {
+		t.Fatalf("can't export GPX: %v", err)
+	}
+	if strings.Contains(gpx.String(), "<time>") || strings.Contains(gpx.String(), "<hr>") {
+		t.Errorf("GPX has stripped fields")
+	}
+
+	var geo strings.Builder
+	if err := WriteGeoJSON(&geo, points, GeoJSONOptions{Name: "shared"}); err != nil {
+		t.Fatalf("can't export GeoJSON: %v", err)
+	}
+	if got := len(validateGeoJSON(t, []byte(geo.String()))); got != 1 {
+		t.Errorf("want 1 feature, got %d", got)
+	}
+}
@@ -0,0 +1,150 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// SearchType limits a search to routes or rides.
+type SearchType string
+
+const (
+	SearchAll    SearchType = ""
+	SearchRoutes SearchType = "routes"
+	SearchRides  SearchType = "trips"
+)
+
+// SearchOptions are the criteria for Search. Zero fields aren't searched on.
+// Distances and elevations are in meters.
+type SearchOptions struct {
+	Keywords string
+	// Search around Center, within Radius meters, or within Bounds. Not both.
+	Center LatLng
+	Radius float64
+	Bounds BoundingBox
+	Type   SearchType
+	// Length of the route or ride.
+	MinLength float64
+	MaxLength float64
+	// Elevation gain of the route or ride.
+	MinElevation float64
+	MaxElevation float64
+}
+
+func (o SearchOptions) values() (url.Values, error) {
+	args := url.Values{}
+	if o.Keywords != "" {
+		args.Set("keywords", o.Keywords)
+	}
+
+	hasBounds := o.Bounds != (BoundingBox{})
+	switch {
+	case o.Radius < 0:
+		return nil, fmt.Errorf("invalid search radius %vm", o.Radius)
+	case o.Radius > 0 && hasBounds:
+		return nil, fmt.Errorf("can't search both around a point and in a bounding box")
+	case o.Radius > 0:
+		args.Set("lat", formatCoord(o.Center.Lat))
+		args.Set("lng", formatCoord(o.Center.Lng))
+		args.Set("radius", strconv.FormatFloat(o.Radius, 'f', -1, 64))
+	case hasBounds:
+		if o.Bounds.Empty() {
+			return nil, fmt.Errorf("invalid search bounds %+v", o.Bounds)
+		}
+		args.Set("sw_lat", formatCoord(o.Bounds.SW.Lat))
+		args.Set("sw_lng", formatCoord(o.Bounds.SW.Lng))
+		args.Set("ne_lat", formatCoord(o.Bounds.NE.Lat))
+		args.Set("ne_lng", formatCoord(o.Bounds.NE.Lng))
+	}
+
+	switch o.Type {
+	case SearchAll:
+	case SearchRoutes, SearchRides:
+		args.Set("assets", string(o.Type))
+	default:
+		return nil, fmt.Errorf("invalid search type %q, must be %q or %q", o.Type, SearchRoutes, SearchRides)
+	}
+
+	for _, rng := range []struct {
+		name     string
+		min, max float64
+	}{{"length", o.MinLength, o.MaxLength}, {"elevation_gain", o.MinElevation, o.MaxElevation}} {
+		if rng.max > 0 && rng.min > rng.max {
+			return nil, fmt.Errorf("empty %s range: %v is more than %v", rng.name, rng.min, rng.max)
+		}
+		if rng.min > 0 {
+			args.Set(rng.name+"_min", strconv.FormatFloat(rng.min, 'f', -1, 64))
+		}
+		if rng.max > 0 {
+			args.Set(rng.name+"_max", strconv.FormatFloat(rng.max, 'f', -1, 64))
+		}
+	}
+
+	return args, nil
+}
+
+func formatCoord(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// SearchResult is a single route or ride found by Search. Exactly one of Route
+// and Ride is set.
+type SearchResult struct {
+	Route *Route
+	Ride  *RideSlim
+}
+
+// Search finds routes and rides, including other users' public ones. It
+// returns a page of results, and the total number found. Results of types
+// the client doesn't know about are skipped.
+func (r *RWGPS) Search(opts SearchOptions, offset, limit int) ([]*SearchResult, int, error) {
+	args, err := opts.values()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Each result is decoded on its own, so unknown types don't fail the
+	// whole page, even in strict mode.
+	var page []json.RawMessage
+	count, err := r.getPage("/find/search.json", offset, limit, args, &page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching %d+%d: %w", offset, limit, err)
+	}
+
+	res := []*SearchResult{}
+	for _, raw := range page {
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return nil, 0, fmt.Errorf("bad search result: %w: %w", ErrDecode, err)
+		}
+
+		switch head.Type {
+		case "route":
+			var item struct {
+				Type  string
+				Route *Route
+			}
+			if err := r.decode(string(raw), &item); err != nil {
+				return nil, 0, err
+			}
+			res = append(res, &SearchResult{Route: item.Route})
+		case "trip":
+			var item struct {
+				Type string
+				Trip *RideSlim
+			}
+			if err := r.decode(string(raw), &item); err != nil {
+				return nil, 0, err
+			}
+			res = append(res, &SearchResult{Ride: item.Trip})
+		default:
+			r.log().Debug("skipping search result", "type", head.Type)
+		}
+	}
+
+	return res, count, nil
+}
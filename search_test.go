@@ -0,0 +1,116 @@
+package goride
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearch(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var args url.Values
+	f.handle("/find/search.json", func(w http.ResponseWriter, req *http.Request) {
+		args = req.URL.Query()
+		fmt.Fprint(w, getTestData("search.json"))
+	})
+	r := testObj(f.URL)
+
+	got, count, err := r.Search(SearchOptions{
+		Keywords:  "grizzly",
+		Center:    LatLng{Lat: 37.875, Lng: -122.25},
+		Radius:    5000,
+		MinLength: 1000,
+		MaxLength: 50000,
+	}, 20, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 57 {
+		t.Errorf("wrong count: %d", count)
+	}
+
+	var gotIDs []string
+	for _, res := range got {
+		switch {
+		case res.Route != nil && res.Ride == nil:
+			gotIDs = append(gotIDs, fmt.Sprintf("route %d", res.Route.ID))
+		case res.Ride != nil && res.Route == nil:
+			gotIDs = append(gotIDs, fmt.Sprintf("ride %d", res.Ride.ID))
+		default:
+			t.Errorf("result should be a route or a ride: %+v", res)
+		}
+	}
+	wantIDs := []string{"route 31330404", "ride 38045212", "route 40001234"}
+	if diff := cmp.Diff(wantIDs, gotIDs); diff != "" {
+		t.Errorf("bad results: -want +got\n%s", diff)
+	}
+	if len(got) == 3 && (got[1].Ride.Name != "Trailhead loop" || got[2].Route.ElevationGain != 540) {
+		t.Errorf("results weren't decoded: %+v, %+v", got[1].Ride, got[2].Route)
+	}
+
+	for k, want := range map[string]string{
+		"keywords":   "grizzly",
+		"lat":        "37.875",
+		"lng":        "-122.25",
+		"radius":     "5000",
+		"length_min": "1000",
+		"length_max": "50000",
+		"offset":     "20",
+		"limit":      "10",
+	} {
+		if args.Get(k) != want {
+			t.Errorf("bad %s arg: want %q, got %q", k, want, args.Get(k))
+		}
+	}
+	for _, k := range []string{"assets", "sw_lat", "elevation_gain_min"} {
+		if args.Has(k) {
+			t.Errorf("unexpected %s arg: %q", k, args.Get(k))
+		}
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	box := BoundingBox{SW: LatLng{Lat: 37.8, Lng: -122.3}, NE: LatLng{Lat: 37.9, Lng: -122.2}}
+	tests := []struct {
+		desc    string
+		opts    SearchOptions
+		want    url.Values
+		wantErr bool
+	}{
+		{desc: "empty", want: url.Values{}},
+		{
+			desc: "bounds and type",
+			opts: SearchOptions{Bounds: box, Type: SearchRoutes, MinElevation: 500},
+			want: url.Values{
+				"sw_lat": {"37.8"}, "sw_lng": {"-122.3"}, "ne_lat": {"37.9"}, "ne_lng": {"-122.2"},
+				"assets": {"routes"}, "elevation_gain_min": {"500"},
+			},
+		},
+		{desc: "radius and bounds", opts: SearchOptions{Radius: 1000, Bounds: box}, wantErr: true},
+		{desc: "negative radius", opts: SearchOptions{Radius: -1}, wantErr: true},
+		{desc: "inverted bounds", opts: SearchOptions{Bounds: BoundingBox{SW: box.NE, NE: box.SW}}, wantErr: true},
+		{desc: "bad type", opts: SearchOptions{Type: "clubs"}, wantErr: true},
+		{desc: "empty length range", opts: SearchOptions{MinLength: 2000, MaxLength: 1000}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := tc.opts.values()
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad args: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package goride
+
+import (
+	"sort"
+	"time"
+)
+
+// SensorSample is a reading from a sensor that recorded separately from the
+// GPS, such as a power meter. Zero values mean the sensor didn't report it.
+type SensorSample struct {
+	Time      time.Time
+	HeartRate float64
+	Cadence   float64
+	Power     float64
+}
+
+// MergeReport describes what happened to the samples given to
+// MergeSensorStream.
+type MergeReport struct {
+	Matched int
+	// Samples that had no track point within maxSkew.
+	Unmatched int
+	// Samples from before the track started or after it ended.
+	Dropped []SensorSample
+}
+
+// MergeSensorStream copies the sensor readings onto the track point closest in
+// time to each sample, as long as it's within maxSkew. The input track isn't
+// modified. Use EstimateSensorOffset and ShiftSensorStream first if the two
+// clocks disagree.
+func MergeSensorStream(track []TrackPoint, sensor []SensorSample, maxSkew time.Duration) ([]TrackPoint, MergeReport) {
+	var report MergeReport
+	res := make([]TrackPoint, len(track))
+	copy(res, track)
+	if len(res) == 0 {
+		report.Dropped = append(report.Dropped, sensor...)
+		return res, report
+	}
+
+	start := res[0].Time.Add(-maxSkew)
+	end := res[len(res)-1].Time.Add(maxSkew)
+	type match struct {
+		sample SensorSample
+		skew   time.Duration
+	}
+	best := make(map[int]match)
+	for _, s := range sensor {
+		if s.Time.Before(start) || s.Time.After(end) {
+			report.Dropped = append(report.Dropped, s)
+			continue
+		}
+
+		i, skew := nearestPoint(res, s.Time)
+		if skew > maxSkew {
+			report.Unmatched++
+			continue
+		}
+		if prev, ok := best[i]; ok {
+			// Only one sample per point, the closest one wins.
+			report.Unmatched++
+			if prev.skew <= skew {
+				continue
+			}
+		} else {
+			report.Matched++
+		}
+		best[i] = match{sample: s, skew: skew}
+	}
+
+	// The readings are only copied once every point has its sample, so a
+	// point never mixes readings from two of them.
+	for i, m := range best {
+		if m.sample.HeartRate != 0 {
+			res[i].HeartRate = m.sample.HeartRate
+		}
+		if m.sample.Cadence != 0 {
+			res[i].Cadence = m.sample.Cadence
+		}
+		if m.sample.Power != 0 {
+			res[i].Power = m.sample.Power
+		}
+	}
+
+	return res, report
+}
+
+// nearestPoint returns the index of the point closest to t, and how far from
+// t it is. The points must be sorted by time.
+func nearestPoint(points []TrackPoint, t time.Time) (int, time.Duration) {
+	i := sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(t) })
+	if i == len(points) {
+		i--
+	} else if i > 0 && t.Sub(points[i-1].Time) < points[i].Time.Sub(t) {
+		i--
+	}
+
+	d := points[i].Time.Sub(t)
+	if d < 0 {
+		d = -d
+	}
+
+	return i, d
+}
+
+// ShiftSensorStream returns a copy of the samples with offset added to their
+// times.
+func ShiftSensorStream(sensor []SensorSample, offset time.Duration) []SensorSample {
+	res := make([]SensorSample, len(sensor))
+	for i, s := range sensor {
+		s.Time = s.Time.Add(offset)
+		res[i] = s
+	}
+
+	return res
+}
+
+// EstimateSensorOffset guesses the constant clock offset between the track
+// and the sensor, by finding the shift (in whole seconds, up to maxOffset
+// either way) where the sensor's pedaling best lines up with the track's
+// moving and stopped periods. Add the result to the sensor times to align them.
+func EstimateSensorOffset(track []TrackPoint, sensor []SensorSample, maxOffset time.Duration) time.Duration {
+	if len(track) == 0 || len(sensor) == 0 {
+		return 0
+	}
+
+	var best time.Duration
+	bestScore := -1
+	for off := -maxOffset.Truncate(time.Second); off <= maxOffset; off += time.Second {
+		score := 0
+		for _, s := range sensor {
+			i, skew := nearestPoint(track, s.Time.Add(off))
+			if skew > time.Second {
+				continue
+			}
+			active := s.Power > 0 || s.Cadence > 0
+			moving := track[i].Speed > 0
+			if active == moving {
+				score++
+			}
+		}
+		if score > bestScore || (score == bestScore && absDuration(off) < absDuration(best)) {
+			best = off
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
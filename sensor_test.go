@@ -0,0 +1,98 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var sensorStart = time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+
+// synthSensorRide returns a 10 minute track with two stops, and the matching
+// power meter stream recorded with a clock that's off by skew.
+func synthSensorRide(skew time.Duration) ([]TrackPoint, []SensorSample) {
+	var track []TrackPoint
+	var sensor []SensorSample
+	for i := 0; i < 600; i++ {
+		moving := !(i >= 100 && i < 160) && !(i >= 300 && i < 330)
+		p := TrackPoint{Time: sensorStart.Add(time.Duration(i) * time.Second)}
+		s := SensorSample{Time: p.Time.Add(skew)}
+		if moving {
+			p.Speed = 25
+			s.Power = float64(150 + i%50)
+			s.Cadence = 90
+		}
+		track = append(track, p)
+		sensor = append(sensor, s)
+	}
+
+	return track, sensor
+}
+
+func TestEstimateSensorOffset(t *testing.T) {
+	for _, skew := range []time.Duration{0, 7 * time.Second, -12 * time.Second} {
+		t.Run(skew.String(), func(t *testing.T) {
+			track, sensor := synthSensorRide(skew)
+			got := EstimateSensorOffset(track, sensor, 30*time.Second)
+			if got != -skew {
+				t.Errorf("bad offset: want %s, got %s", -skew, got)
+			}
+		})
+	}
+}
+
+func TestMergeSensorStream(t *testing.T) {
+	track, sensor := synthSensorRide(7 * time.Second)
+
+	// Unaligned, the last 7 samples are past the end of the track.
+	_, report := MergeSensorStream(track, sensor, 0)
+	if len(report.Dropped) != 7 || report.Matched != 593 {
+		t.Errorf("bad unaligned report: %d dropped, %d matched", len(report.Dropped), report.Matched)
+	}
+
+	off := EstimateSensorOffset(track, sensor, 30*time.Second)
+	got, report := MergeSensorStream(track, ShiftSensorStream(sensor, off), time.Second)
+	if len(report.Dropped) != 0 || report.Unmatched != 0 || report.Matched != 600 {
+		t.Errorf("bad aligned report: %+v", report)
+	}
+
+	want := TrackPoint{Time: sensorStart.Add(42 * time.Second), Speed: 25, Power: 192, Cadence: 90}
+	if diff := cmp.Diff(want, got[42]); diff != "" {
+		t.Errorf("bad merged point: -want +got\n%s", diff)
+	}
+	if got[120].Power != 0 {
+		t.Errorf("expected no power while stopped, got %f", got[120].Power)
+	}
+	if track[42].Power != 0 {
+		t.Errorf("input track was modified")
+	}
+}
+
+func TestMergeSensorStreamGaps(t *testing.T) {
+	track := []TrackPoint{
+		{Time: sensorStart},
+		{Time: sensorStart.Add(10 * time.Second)},
+	}
+	sensor := []SensorSample{
+		{Time: sensorStart.Add(-time.Minute), Power: 1},
+		{Time: sensorStart.Add(time.Second), Power: 2, HeartRate: 140},
+		{Time: sensorStart, Power: 3},
+		{Time: sensorStart.Add(5 * time.Second), Power: 4},
+		{Time: sensorStart.Add(time.Minute), Power: 5},
+	}
+
+	got, report := MergeSensorStream(track, sensor, 2*time.Second)
+
+	want := MergeReport{
+		Matched:   1,
+		Unmatched: 2,
+		Dropped:   []SensorSample{sensor[0], sensor[4]},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("bad report: -want +got\n%s", diff)
+	}
+	if got[0].Power != 3 || got[0].HeartRate != 0 {
+		t.Errorf("expected only the closest sample to be used, got %+v", got[0])
+	}
+}
@@ -0,0 +1,22 @@
+package goride
+
+// Service is the core of the RWGPS API: reading users, rides and routes, and
+// changing and deleting rides. It's implemented by *RWGPS, and by the fake in
+// goridetest for use in tests. The rest of the API, like clubs, collections
+// or uploads, is only on *RWGPS.
+type Service interface {
+	GetCurrentUser() (*User, error)
+	GetUser(id int) (*User, error)
+	GetRide(id int) (*Ride, error)
+	GetRideSummary(id int) (*Ride, error)
+	GetRideWithOpts(id int, opts RequestOptions) (*Ride, error)
+	GetRides(user, offset, limit int) ([]*RideSlim, int, error)
+	GetRidesWithOpts(user, offset, limit int, opts GetRidesOpts) ([]*RideSlim, int, error)
+	AllRides(user int) ([]*RideSlim, error)
+	GetRidesForRoute(routeID, offset, limit int) ([]*RideSlim, int, error)
+	GetRoute(id int) (*Route, error)
+	UpdateRide(id int, u RideUpdate) error
+	DeleteRide(id int) error
+}
+
+var _ Service = (*RWGPS)(nil)
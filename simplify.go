@@ -0,0 +1,45 @@
+package goride
+
+// Simplify reduces a track to the points needed to stay within tolerance
+// meters of the original line, using Douglas-Peucker. The first and last
+// points are always kept, points without a position are dropped.
+func Simplify(points []TrackPoint, tolerance float64) []TrackPoint {
+	var track []TrackPoint
+	for _, p := range points {
+		if p.Lat != 0 || p.Lng != 0 {
+			track = append(track, p)
+		}
+	}
+	if len(track) < 3 {
+		return track
+	}
+
+	xys := projectTrack(track, track[0].Lat)
+	keep := make([]bool, len(track))
+	keep[0], keep[len(track)-1] = true, true
+	stack := [][2]int{{0, len(track) - 1}}
+	for len(stack) > 0 {
+		from, to := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		worst, furthest := 0, -1.0
+		for i := from + 1; i < to; i++ {
+			if d, _ := closestOnSegment(xys[i], xys[from], xys[to]); d > furthest {
+				worst, furthest = i, d
+			}
+		}
+		if furthest > tolerance {
+			keep[worst] = true
+			stack = append(stack, [2]int{from, worst}, [2]int{worst, to})
+		}
+	}
+
+	var res []TrackPoint
+	for i, p := range track {
+		if keep[i] {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
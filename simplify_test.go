@@ -0,0 +1,54 @@
+package goride
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSimplify(t *testing.T) {
+	// An L with a 3m kink half way along the first leg. The diagonals take 51
+	// points each.
+	track := synthPath([2]float64{}, 10, [2]float64{500, 3}, [2]float64{500, -3}, [2]float64{0, 1000})
+
+	tests := []struct {
+		desc      string
+		points    []TrackPoint
+		tolerance float64
+		want      []TrackPoint
+	}{
+		{
+			desc:      "corners only",
+			points:    track,
+			tolerance: 5,
+			want:      []TrackPoint{track[0], track[102], track[202]},
+		},
+		{
+			desc:      "keep the kink",
+			points:    track,
+			tolerance: 1,
+			want:      []TrackPoint{track[0], track[51], track[102], track[202]},
+		},
+		{
+			desc:      "no positions",
+			points:    append([]TrackPoint{{Elevation: 1}}, track[:3]...),
+			tolerance: 5,
+			want:      []TrackPoint{track[0], track[2]},
+		},
+		{
+			desc:      "short",
+			points:    track[:2],
+			tolerance: 5,
+			want:      track[:2],
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := Simplify(tc.points, tc.tolerance)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad simplification: -want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// UserSlim is the short user record in follower lists and the activity feed.
+type UserSlim struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+// GetFollowers gets a page of the users following a user, and the total
+// number of followers.
+func (r *RWGPS) GetFollowers(userID, offset, limit int) ([]*UserSlim, int, error) {
+	var users []*UserSlim
+	count, err := r.getPage(fmt.Sprintf("/users/%d/followers.json", userID), offset, limit, nil, &users)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting followers %d+%d for user %d: %w", offset, limit, userID, err)
+	}
+
+	return users, count, nil
+}
+
+// GetFollowing gets a page of the users a user follows, and the total number
+// they follow.
+func (r *RWGPS) GetFollowing(userID, offset, limit int) ([]*UserSlim, int, error) {
+	var users []*UserSlim
+	count, err := r.getPage(fmt.Sprintf("/users/%d/following.json", userID), offset, limit, nil, &users)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting users followed %d+%d by user %d: %w", offset, limit, userID, err)
+	}
+
+	return users, count, nil
+}
+
+// Follow starts following a user.
+func (r *RWGPS) Follow(userID int) error {
+	if err := r.send(http.MethodPost, fmt.Sprintf("/users/%d/follow.json", userID), nil, nil); err != nil {
+		return fmt.Errorf("error following user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Unfollow stops following a user.
+func (r *RWGPS) Unfollow(userID int) error {
+	if err := r.send(http.MethodDelete, fmt.Sprintf("/users/%d/follow.json", userID), nil, nil); err != nil {
+		return fmt.Errorf("error unfollowing user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Feed entry types the client knows about. Others are kept as is in
+// FeedEntry.Type, with the original entry in Raw.
+const (
+	FeedRide  = "trip"
+	FeedRoute = "route"
+	FeedEvent = "event"
+)
+
+// FeedEntry is an entry in the friends activity feed. Only the one of Ride,
+// Route and Event matching Type is set.
+type FeedEntry struct {
+	ID        int
+	Type      string
+	CreatedAt time.Time
+	User      UserSlim
+	Ride      *RideSlim
+	Route     *Route
+	Event     *Event
+	// Raw is the entry as the server sent it.
+	Raw json.RawMessage
+}
+
+// UnmarshalJSON decodes a feed entry. Entries of unknown types, or whose item
+// doesn't look like we expect, only get their ID, type, time and user, so one
+// odd entry doesn't fail the whole page.
+func (e *FeedEntry) UnmarshalJSON(data []byte) error {
+	var base struct {
+		ID        int       `json:"id"`
+		Type      string    `json:"type"`
+		CreatedAt time.Time `json:"created_at"`
+		User      UserSlim  `json:"user"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return fmt.Errorf("bad feed entry: %w", err)
+	}
+	*e = FeedEntry{
+		ID:        base.ID,
+		Type:      base.Type,
+		CreatedAt: base.CreatedAt,
+		User:      base.User,
+		Raw:       append(json.RawMessage(nil), data...),
+	}
+
+	switch base.Type {
+	case FeedRide:
+		var item struct{ Trip *RideSlim }
+		if json.Unmarshal(data, &item) == nil {
+			e.Ride = item.Trip
+		}
+	case FeedRoute:
+		var item struct{ Route *Route }
+		if json.Unmarshal(data, &item) == nil {
+			e.Route = item.Route
+		}
+	case FeedEvent:
+		var item struct{ Event *Event }
+		if json.Unmarshal(data, &item) == nil {
+			e.Event = item.Event
+		}
+	}
+
+	return nil
+}
+
+// GetFriendsFeed gets a page of the activity of the users the current user
+// follows, newest first, and the total number of entries.
+func (r *RWGPS) GetFriendsFeed(offset, limit int) ([]*FeedEntry, int, error) {
+	var res []*FeedEntry
+	count, err := r.getPage("/activity_feed.json", offset, limit, nil, &res)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting activity feed %d+%d: %w", offset, limit, err)
+	}
+
+	return res, count, nil
+}
@@ -0,0 +1,132 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetFollowers(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/1268590/followers.json", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		fmt.Fprint(w, getTestData(fmt.Sprintf("followers%s-%s.json", q.Get("offset"), q.Get("limit"))))
+	})
+	f.handle("/users/1268590/following.json", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		fmt.Fprint(w, getTestData(fmt.Sprintf("following%s-%s.json", q.Get("offset"), q.Get("limit"))))
+	})
+	r := testObj(f.URL)
+
+	followers, count, err := r.GetFollowers(1268590, 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []*UserSlim{{ID: 2, Name: "Alex R", Location: "Oakland, CA"}, {ID: 3, Name: "Sam T"}}
+	if diff := cmp.Diff(want, followers); diff != "" {
+		t.Errorf("bad followers: -want +got\n%s", diff)
+	}
+	if count != 5 {
+		t.Errorf("want 5 followers, got %d", count)
+	}
+
+	following, count, err := r.GetFollowing(1268590, 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]*UserSlim{{ID: 3, Name: "Sam T"}}, following); diff != "" {
+		t.Errorf("bad following: -want +got\n%s", diff)
+	}
+	if count != 1 {
+		t.Errorf("want 1 followed, got %d", count)
+	}
+
+	if _, _, err := r.GetFollowers(42, 0, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing user: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	f := newFakeRWGPS(t)
+	ok := func(w http.ResponseWriter, req *http.Request) { fmt.Fprint(w, "{}") }
+	f.handle("/users/2/follow.json", ok)
+	f.handle("/users/3/follow.json", ok)
+	r := testObj(f.URL)
+
+	if err := r.Follow(2); err != nil {
+		t.Errorf("unexpected error following: %v", err)
+	}
+	if err := r.Unfollow(3); err != nil {
+		t.Errorf("unexpected error unfollowing: %v", err)
+	}
+	if err := r.Follow(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing user: want ErrNotFound, got %v", err)
+	}
+
+	want := []string{"POST /users/2/follow.json", "DELETE /users/3/follow.json", "POST /users/42/follow.json"}
+	if diff := cmp.Diff(want, f.writes()); diff != "" {
+		t.Errorf("bad writes: -want +got\n%s", diff)
+	}
+}
+
+func TestGetFriendsFeed(t *testing.T) {
+	server := startServer(t,
+		map[string]string{"/activity_feed.json": getTestData("activity_feed.json")},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	got, count, err := r.GetFriendsFeed(0, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 42 || len(got) != 5 {
+		t.Fatalf("bad feed: %d of %d entries", len(got), count)
+	}
+
+	type entry struct {
+		ID                 int
+		Type, User         string
+		Ride, Route, Event string
+		CreatedAt          time.Time
+	}
+	var gotEntries []entry
+	for _, e := range got {
+		ge := entry{ID: e.ID, Type: e.Type, User: e.User.Name, CreatedAt: e.CreatedAt}
+		if e.Ride != nil {
+			ge.Ride = e.Ride.Name
+		}
+		if e.Route != nil {
+			ge.Route = e.Route.Name
+		}
+		if e.Event != nil {
+			ge.Event = e.Event.Name
+		}
+		gotEntries = append(gotEntries, ge)
+	}
+	want := []entry{
+		{ID: 5004, Type: FeedRide, User: "Alex R", Ride: "Grizzly Peak loop", CreatedAt: time.Date(2022, 6, 12, 17, 5, 0, 0, time.UTC)},
+		{ID: 5003, Type: FeedRoute, User: "Sam T", Route: "Mt Tam via Fairfax", CreatedAt: time.Date(2022, 6, 11, 20, 0, 0, 0, time.UTC)},
+		{ID: 5002, Type: FeedEvent, User: "Alex R", Event: "Saturday Brevet", CreatedAt: time.Date(2022, 6, 10, 9, 0, 0, 0, time.UTC)},
+		{ID: 5001, Type: "kudos", User: "Sam T", CreatedAt: time.Date(2022, 6, 9, 12, 0, 0, 0, time.UTC)},
+		// A trip entry we can't decode only gets the basics.
+		{ID: 5000, Type: FeedRide, User: "Sam T", CreatedAt: time.Date(2022, 6, 8, 12, 0, 0, 0, time.UTC)},
+	}
+	if diff := cmp.Diff(want, gotEntries); diff != "" {
+		t.Errorf("bad feed: -want +got\n%s", diff)
+	}
+
+	if got[0].Ride.Duration != 5400 || got[0].Ride.Distance != 41250.5 {
+		t.Errorf("bad ride: %+v", got[0].Ride)
+	}
+	if want := time.Date(2022, 6, 18, 14, 0, 0, 0, time.UTC); !got[2].Event.StartsAt.Equal(want) {
+		t.Errorf("bad event start: want %s, got %s", want, got[2].Event.StartsAt)
+	}
+	if diff := cmp.Diff(`{"id":5001,"type":"kudos","created_at":"2022-06-09T12:00:00Z","user":{"id":3,"name":"Sam T","location":""},"kudos":{"trip_id":38045212}}`, string(got[3].Raw)); diff != "" {
+		t.Errorf("bad raw entry: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,144 @@
+package goride
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// speedBandWidth is the width of the grade bands of a SpeedModel.
+	speedBandWidth = 0.02
+	// speedModelMaxGrade is the steepest grade a SpeedModel tells apart. Steeper
+	// stretches count towards the band at this grade.
+	speedModelMaxGrade = 0.2
+	// speedBandMinDistance is how many meters of riding a grade band needs to
+	// be part of a SpeedModel, so a few noisy points don't make one up.
+	speedBandMinDistance = 500
+)
+
+// ErrNoSpeedData is returned when rides don't have the moving track points
+// needed to build a SpeedModel.
+var ErrNoSpeedData = errors.New("no moving track points")
+
+// SpeedBand is the average moving speed on a range of grades.
+type SpeedBand struct {
+	// Grade is the middle of the band, as a fraction.
+	Grade float64 `json:"grade"`
+	// Speed is in km/h.
+	Speed float64 `json:"speed"`
+	// Distance is how many meters of riding the speed is from.
+	Distance float64 `json:"distance"`
+}
+
+// BuildSpeedModel bins the moving speeds of the rides' track points by grade,
+// in bands 2% wide, and sets FlatSpeed to their overall average. Stretches
+// that are stopped or gaps in the recording are left out, like in Splits, and
+// rides without elevations only count towards FlatSpeed. The model can be
+// saved as JSON and loaded later, rather than built again.
+func BuildSpeedModel(rides []*Ride) (SpeedModel, error) {
+	bands := int(math.Round(speedModelMaxGrade / speedBandWidth))
+	dist := make([]float64, 2*bands+1)
+	hours := make([]float64, 2*bands+1)
+	var totalDist, totalHours float64
+
+	for _, ride := range rides {
+		points := ride.TrackPoints
+		d := trackDistances(points)
+		elev := trackElevations(points)
+		hasElev := hasElevations(points)
+		for i := 1; i < len(points); i++ {
+			seg := d[i] - d[i-1]
+			dt := points[i].Time.Sub(points[i-1].Time)
+			if seg <= 0 || dt <= 0 || dt > splitMaxGap {
+				continue
+			}
+			if speed := seg / 1000 / dt.Hours(); speed < splitStopSpeed || speed > splitMaxSpeed {
+				continue
+			}
+			totalDist += seg
+			totalHours += dt.Hours()
+			if hasElev {
+				b := bands + speedBand((elev[i]-elev[i-1])/seg)
+				dist[b] += seg
+				hours[b] += dt.Hours()
+			}
+		}
+	}
+	if totalHours == 0 {
+		return SpeedModel{}, ErrNoSpeedData
+	}
+
+	res := SpeedModel{FlatSpeed: totalDist / 1000 / totalHours}
+	for b := range dist {
+		if dist[b] < speedBandMinDistance {
+			continue
+		}
+		res.Bands = append(res.Bands, SpeedBand{
+			Grade:    float64(b-bands) * speedBandWidth,
+			Speed:    dist[b] / 1000 / hours[b],
+			Distance: dist[b],
+		})
+	}
+
+	return res, nil
+}
+
+// speedBand is the band of a grade, counting from the flat one.
+func speedBand(grade float64) int {
+	grade = max(-speedModelMaxGrade, min(grade, speedModelMaxGrade))
+	return int(math.Round(grade / speedBandWidth))
+}
+
+// bandSpeed is the speed at a grade from the model's bands, in km/h. Grades
+// between bands get a speed interpolated from the bands around them, and ones
+// past the last band that band's speed.
+func (m SpeedModel) bandSpeed(grade float64) float64 {
+	if grade <= m.Bands[0].Grade {
+		return m.Bands[0].Speed
+	}
+	for i := 1; i < len(m.Bands); i++ {
+		a, b := m.Bands[i-1], m.Bands[i]
+		if grade <= b.Grade {
+			return lerp(a.Speed, b.Speed, (grade-a.Grade)/(b.Grade-a.Grade))
+		}
+	}
+
+	return m.Bands[len(m.Bands)-1].Speed
+}
+
+// EstimateTime estimates how long riding a route's track points would take,
+// see SegmentTime. Unlike MovingTime, points without an elevation get one
+// interpolated from their neighbors, and routes without any elevations are
+// estimated at the model's FlatSpeed.
+func EstimateTime(points []TrackPoint, model SpeedModel) time.Duration {
+	if len(points) == 0 || model.FlatSpeed <= 0 {
+		return 0
+	}
+	dist := trackDistances(points)
+	if !hasElevations(points) {
+		return hoursToDuration(dist[len(dist)-1] / 1000 / model.FlatSpeed)
+	}
+
+	elev := trackElevations(points)
+	var total time.Duration
+	for i := 1; i < len(points); i++ {
+		total += model.SegmentTime(dist[i]-dist[i-1], elev[i]-elev[i-1])
+	}
+
+	return total
+}
+
+// hasElevations is true if any of the points has an elevation.
+func hasElevations(points []TrackPoint) bool {
+	for _, p := range points {
+		if p.Elevation != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hoursToDuration(h float64) time.Duration {
+	return time.Duration(math.Round(h * float64(time.Hour)))
+}
@@ -0,0 +1,132 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// speedModelRides are a hilly ride at 36km/h on the flat, 12km/h up 4% and
+// 45km/h down 6%, with too little of 10% to make a band, and a short ride
+// without elevations at 36km/h.
+func speedModelRides() []*Ride {
+	hilly := timedRoute(
+		[3]float64{2000, 0, 10},
+		[3]float64{2000, 0.04, 30},
+		[3]float64{200, 0.1, 60},
+		[3]float64{2000, -0.06, 8},
+	)
+	return []*Ride{{TrackPoints: hilly}, {TrackPoints: workout(workoutStep{dur: 100 * time.Second})}}
+}
+
+func TestBuildSpeedModel(t *testing.T) {
+	got, err := BuildSpeedModel(speedModelRides())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SpeedModel{
+		FlatSpeed: 7.2 / (1180.0 / 3600),
+		Bands: []SpeedBand{
+			{Grade: -0.06, Speed: 45, Distance: 2000},
+			{Grade: 0, Speed: 36, Distance: 2000},
+			{Grade: 0.04, Speed: 12, Distance: 2000},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("bad model: -want +got\n%s", diff)
+	}
+
+	// Models survive being saved.
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var loaded SpeedModel
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(got, loaded); diff != "" {
+		t.Errorf("model didn't round trip: -want +got\n%s", diff)
+	}
+
+	stopped := []*Ride{{}, {TrackPoints: withoutTimes(timedRoute([3]float64{1000, 0, 10}))}}
+	if _, err := BuildSpeedModel(stopped); !errors.Is(err, ErrNoSpeedData) {
+		t.Errorf("want ErrNoSpeedData, got %v", err)
+	}
+}
+
+func TestEstimateTime(t *testing.T) {
+	model, err := BuildSpeedModel(speedModelRides())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	route := timedRoute(
+		[3]float64{1000, 0, 0},
+		[3]float64{1000, 0.04, 0},
+		[3]float64{1000, 0.02, 0},
+		[3]float64{500, 0.15, 0},
+		[3]float64{900, -0.1, 0},
+	)
+
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		model  SpeedModel
+		want   time.Duration
+	}{
+		{
+			desc:   "by grade",
+			points: route,
+			model:  model,
+			// 100s flat, 300s up 4%, 150s up 2% at 24km/h between the bands,
+			// 150s up 15% at the steepest band's speed, and 72s down 10% at the
+			// steepest descent's.
+			want: 772 * time.Second,
+		},
+		{
+			desc:   "missing elevations",
+			points: withoutElevation(route, 0, 3, 4),
+			model:  model,
+			want:   772 * time.Second,
+		},
+		{
+			desc: "no elevations",
+			points: withoutElevation(route, func() []int {
+				var res []int
+				for i := range route {
+					res = append(res, i)
+				}
+				return res
+			}()...),
+			model: model,
+			want:  time.Duration(4.4 / model.FlatSpeed * float64(time.Hour)),
+		},
+		{
+			desc:   "flat model",
+			points: route,
+			model:  SpeedModel{FlatSpeed: 22},
+			want:   720 * time.Second,
+		},
+		{
+			desc:   "empty model",
+			points: route,
+		},
+		{
+			desc:  "no points",
+			model: model,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := EstimateTime(tc.points, tc.model)
+			if !cmp.Equal(tc.want.Seconds(), got.Seconds(), cmpopts.EquateApprox(0, 1e-3)) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package goride
+
+import (
+	"fmt"
+	"time"
+)
+
+// Common split distances, in meters.
+const (
+	SplitKm   = 1000
+	SplitMile = 1609.344
+)
+
+const (
+	// Segments slower than this (km/h) don't count towards moving time.
+	splitStopSpeed = 1
+	// Segments with a longer gap between points, or faster than
+	// splitMaxSpeed (km/h), are GPS gaps. Their distance is counted, but not
+	// as moving time.
+	splitMaxGap   = time.Minute
+	splitMaxSpeed = 150
+)
+
+// Split is the stats for one stretch of a ride. Distance and elevations are in
+// meters, AvgSpeed in km/h over the moving time.
+type Split struct {
+	Number        int
+	Distance      float64
+	ElapsedTime   time.Duration
+	MovingTime    time.Duration
+	AvgSpeed      float64
+	ElevationGain float64
+	ElevationLoss float64
+	// Zero if no heart rate was recorded.
+	AvgHeartRate float64
+	// The final split, shorter than the split distance.
+	Partial bool
+}
+
+// ComputeSplits divides a track into splits of splitDistance meters, e.g.
+// SplitKm or SplitMile. Values at split boundaries are interpolated between
+// points. Points must be in time order. If the points don't have a cumulative
+// Distance, it's computed from their coordinates.
+func ComputeSplits(points []TrackPoint, splitDistance float64) ([]Split, error) {
+	if splitDistance <= 0 {
+		return nil, fmt.Errorf("invalid split distance %f", splitDistance)
+	}
+	res := []Split{}
+	if len(points) < 2 {
+		return res, nil
+	}
+
+	dist := trackDistances(points)
+	for i := 1; i < len(points); i++ {
+		if points[i].Time.Before(points[i-1].Time) {
+			return nil, fmt.Errorf("point %d is out of order: %s before %s", i, points[i].Time, points[i-1].Time)
+		}
+		if dist[i] < dist[i-1] {
+			return nil, fmt.Errorf("point %d is out of order: distance %f before %f", i, dist[i], dist[i-1])
+		}
+	}
+
+	cur := Split{Number: 1}
+	var hrSum float64
+	var hrCount int
+	addHR := func(hr float64) {
+		if hr > 0 {
+			hrSum += hr
+			hrCount++
+		}
+	}
+	closeSplit := func() {
+		if cur.MovingTime > 0 {
+			cur.AvgSpeed = cur.Distance / 1000 / cur.MovingTime.Hours()
+		}
+		if hrCount > 0 {
+			cur.AvgHeartRate = hrSum / float64(hrCount)
+		}
+		res = append(res, cur)
+		cur = Split{Number: cur.Number + 1}
+		hrSum, hrCount = 0, 0
+	}
+
+	addHR(points[0].HeartRate)
+	end := dist[0] + splitDistance
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		d := dist[i] - dist[i-1]
+		dt := q.Time.Sub(p.Time)
+		rise := q.Elevation - p.Elevation
+		moving := dt > 0 && dt <= splitMaxGap
+		if moving {
+			speed := d / 1000 / dt.Hours()
+			moving = speed >= splitStopSpeed && speed <= splitMaxSpeed
+		}
+
+		// Take the segment a piece at a time, splitting it at each boundary
+		// it crosses.
+		pos := dist[i-1]
+		for {
+			to := dist[i]
+			last := to < end
+			if !last {
+				to = end
+			}
+			frac := 1.0
+			if d > 0 {
+				frac = (to - pos) / d
+			}
+
+			cur.Distance += to - pos
+			cur.ElapsedTime += time.Duration(frac * float64(dt))
+			if moving {
+				cur.MovingTime += time.Duration(frac * float64(dt))
+			}
+			if r := frac * rise; r > 0 {
+				cur.ElevationGain += r
+			} else {
+				cur.ElevationLoss -= r
+			}
+			pos = to
+
+			if last {
+				addHR(q.HeartRate)
+				break
+			}
+			if pos == dist[i] {
+				// The point is exactly on the boundary, it ends this split.
+				addHR(q.HeartRate)
+				closeSplit()
+				end += splitDistance
+				break
+			}
+			closeSplit()
+			end += splitDistance
+		}
+	}
+
+	if cur.Distance > 0 {
+		cur.Partial = true
+		closeSplit()
+	}
+
+	return res, nil
+}
+
+// trackDistances returns the cumulative distance at each point. The
+// points' own Distance is used if they have one, otherwise it's computed from
+// their coordinates. Points without coordinates don't add any distance.
+func trackDistances(points []TrackPoint) []float64 {
+	res := make([]float64, len(points))
+	hasDistance := false
+	for i, p := range points {
+		if i > 0 && p.Distance != 0 {
+			hasDistance = true
+			break
+		}
+	}
+
+	var prev *TrackPoint
+	for i := range points {
+		p := &points[i]
+		if hasDistance {
+			res[i] = p.Distance
+			continue
+		}
+		if i > 0 {
+			res[i] = res[i-1]
+		}
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		if prev != nil {
+			res[i] += haversine(prev.Lat, prev.Lng, p.Lat, p.Lng)
+		}
+		prev = p
+	}
+
+	return res
+}
@@ -0,0 +1,192 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// splitStep is a stretch of identical steps between track points.
+type splitStep struct {
+	n    int
+	dist float64
+	dt   time.Duration
+	rise float64
+	hr   float64
+}
+
+func synthTrack(steps ...splitStep) []TrackPoint {
+	p := TrackPoint{Time: sensorStart, HeartRate: 100}
+	res := []TrackPoint{p}
+	for _, s := range steps {
+		for i := 0; i < s.n; i++ {
+			p.Distance += s.dist
+			p.Time = p.Time.Add(s.dt)
+			p.Elevation += s.rise
+			p.HeartRate = s.hr
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// getTestRide decodes the ride in testdata/trip.json.
+func getTestRide(t *testing.T) *Ride {
+	var res struct {
+		Trip Ride
+	}
+	if err := decodeJSON(getTestData("trip.json"), &res); err != nil {
+		t.Fatalf("can't decode trip.json: %v", err)
+	}
+
+	return &res.Trip
+}
+
+func TestComputeSplits(t *testing.T) {
+	track := synthTrack(
+		splitStep{n: 12, dist: 100, dt: 10 * time.Second, rise: 5, hr: 120},
+		// A coffee stop.
+		splitStep{n: 1, dt: 10 * time.Minute, hr: 90},
+		// A GPS jump.
+		splitStep{n: 1, dist: 300, dt: time.Second},
+		splitStep{n: 10, dist: 100, dt: 20 * time.Second, rise: -3, hr: 130},
+		splitStep{n: 1, dist: 50, dt: 10 * time.Second, hr: 140},
+	)
+
+	got, err := ComputeSplits(track, SplitKm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Split{
+		{
+			Number:        1,
+			Distance:      1000,
+			ElapsedTime:   100 * time.Second,
+			MovingTime:    100 * time.Second,
+			AvgSpeed:      36,
+			ElevationGain: 50,
+			AvgHeartRate:  (100 + 10*120) / 11.0,
+		},
+		{
+			Number:        2,
+			Distance:      1000,
+			ElapsedTime:   721 * time.Second,
+			MovingTime:    120 * time.Second,
+			AvgSpeed:      30,
+			ElevationGain: 10,
+			ElevationLoss: 15,
+			AvgHeartRate:  (2*120 + 90 + 5*130) / 8.0,
+		},
+		{
+			Number:        3,
+			Distance:      550,
+			ElapsedTime:   110 * time.Second,
+			MovingTime:    110 * time.Second,
+			AvgSpeed:      18,
+			ElevationLoss: 15,
+			AvgHeartRate:  (5*130 + 140) / 6.0,
+			Partial:       true,
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("bad splits: -want +got\n%s", diff)
+	}
+}
+
+func TestComputeSplitsBoundaries(t *testing.T) {
+	tests := []struct {
+		desc  string
+		track []TrackPoint
+		split float64
+		want  []float64
+	}{
+		{
+			desc: "empty",
+			want: []float64{},
+		},
+		{
+			desc:  "single point",
+			track: synthTrack(),
+			want:  []float64{},
+		},
+		{
+			desc:  "ends on a boundary",
+			track: synthTrack(splitStep{n: 20, dist: 100, dt: 10 * time.Second}),
+			split: SplitKm,
+			want:  []float64{1000, 1000},
+		},
+		{
+			desc:  "miles, across points",
+			track: synthTrack(splitStep{n: 4, dist: 1000, dt: 100 * time.Second}),
+			split: SplitMile,
+			want:  []float64{SplitMile, SplitMile, 4000 - 2*SplitMile},
+		},
+		{
+			desc:  "several splits in one segment",
+			track: synthTrack(splitStep{n: 1, dist: 3500, dt: 5 * time.Minute}),
+			split: SplitKm,
+			want:  []float64{1000, 1000, 1000, 500},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			split := tc.split
+			if split == 0 {
+				split = SplitKm
+			}
+			splits, err := ComputeSplits(tc.track, split)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := []float64{}
+			var elapsed time.Duration
+			for _, s := range splits {
+				got = append(got, s.Distance)
+				elapsed += s.ElapsedTime
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("bad split distances: -want +got\n%s", diff)
+			}
+			if n := len(tc.track); n > 0 {
+				if total := tc.track[n-1].Time.Sub(tc.track[0].Time); absDuration(total-elapsed) > time.Microsecond {
+					t.Errorf("split times don't add up: want %s, got %s", total, elapsed)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeSplitsErrors(t *testing.T) {
+	track := synthTrack(splitStep{n: 3, dist: 100, dt: time.Second})
+	if _, err := ComputeSplits(track, 0); err == nil {
+		t.Errorf("expected an error for a zero split distance")
+	}
+
+	track[2].Time = track[0].Time
+	if _, err := ComputeSplits(track, SplitKm); err == nil {
+		t.Errorf("expected an error for out of order points")
+	}
+}
+
+func TestComputeSplitsFromCoordinates(t *testing.T) {
+	got, err := ComputeSplits(getTestRide(t).TrackPoints, SplitKm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The ride is 42.99km.
+	if len(got) != 43 || !got[42].Partial {
+		t.Fatalf("bad splits: %d, last %+v", len(got), got[len(got)-1])
+	}
+	for _, s := range got {
+		if s.AvgSpeed <= 0 || s.AvgSpeed > 70 || s.AvgHeartRate == 0 {
+			t.Errorf("unlikely split: %+v", s)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package goride
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zigdon/goride/units"
+)
+
+type GroupBy int
+
+const (
+	GroupByMonth GroupBy = iota
+	GroupByYear
+	GroupByGear
+)
+
+// Totals are the summed stats for a group of rides.
+type Totals struct {
+	Rides         int
+	Distance      float64
+	ElevationGain float64
+	MovingTime    time.Duration
+	Longest       *RideSlim
+}
+
+// Aggregate sums rides into Totals, keyed by "2006-01" for months, "2006" for
+// years, or the gear ID. Periods use the ride's local departure time.
+func Aggregate(rides []*RideSlim, by GroupBy) map[string]Totals {
+	res := make(map[string]Totals)
+	for _, r := range rides {
+		if r == nil {
+			continue
+		}
+
+		var key string
+		switch by {
+		case GroupByMonth:
+			key = r.LocalDepartedAt().Format("2006-01")
+		case GroupByYear:
+			key = r.LocalDepartedAt().Format("2006")
+		case GroupByGear:
+			key = strconv.Itoa(r.GearID)
+		}
+
+		t := res[key]
+		t.Rides++
+		t.Distance += float64(r.Distance)
+		t.ElevationGain += float64(r.ElevationGain)
+		t.MovingTime += time.Duration(r.MovingTime) * time.Second
+		if t.Longest == nil || r.Distance > t.Longest.Distance {
+			t.Longest = r
+		}
+		res[key] = t
+	}
+
+	return res
+}
+
+// Eddington returns the largest E such that E rides were at least E km long,
+// or E miles in the imperial system. Each ride counts on its own, even if
+// there were several on one day.
+func Eddington(rides []*RideSlim, system units.System) int {
+	var dists []float64
+	for _, r := range rides {
+		if r == nil || r.IsDeleted() {
+			continue
+		}
+		d := units.Distance(r.Distance)
+		if system == units.Imperial {
+			dists = append(dists, d.Miles())
+		} else {
+			dists = append(dists, d.Km())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(dists)))
+
+	e := 0
+	for e < len(dists) && dists[e] >= float64(e+1) {
+		e++
+	}
+
+	return e
+}
+
+// StreakStats are runs of consecutive days, and weeks, with at least one ride.
+// Current streaks are still going: they include today or yesterday (this week
+// or last week). Weeks start on Monday.
+type StreakStats struct {
+	CurrentDays  int
+	LongestDays  int
+	CurrentWeeks int
+	LongestWeeks int
+	// LongestStart is the first day of the longest daily streak.
+	LongestStart time.Time
+}
+
+// Streaks finds the riding streaks, with days starting at midnight in loc. If
+// loc is nil, each ride's own time zone is used.
+func Streaks(rides []*RideSlim, loc *time.Location) StreakStats {
+	return streaks(rides, loc, time.Now())
+}
+
+func streaks(rides []*RideSlim, loc *time.Location, now time.Time) StreakStats {
+	var res StreakStats
+	dayLoc := loc
+	if loc == nil {
+		dayLoc = time.UTC
+	} else {
+		now = now.In(loc)
+	}
+
+	// Days are counted from the epoch, so consecutive days differ by one.
+	dayNum := func(t time.Time) int {
+		y, m, d := t.Date()
+		return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	}
+	// The epoch was a Thursday, so weeks start on Monday.
+	weekNum := func(day int) int {
+		w := day + 3
+		if w < 0 {
+			w -= 6
+		}
+		return w / 7
+	}
+
+	seen := make(map[int]bool)
+	var days []int
+	for _, r := range rides {
+		if r == nil || r.IsDeleted() {
+			continue
+		}
+		t := r.LocalDepartedAt()
+		if loc != nil {
+			t = r.DepartedAt.In(loc)
+		}
+		if d := dayNum(t); !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	if len(days) == 0 {
+		return res
+	}
+	sort.Ints(days)
+
+	var weeks []int
+	for _, d := range days {
+		if w := weekNum(d); len(weeks) == 0 || weeks[len(weeks)-1] != w {
+			weeks = append(weeks, w)
+		}
+	}
+
+	longest := func(nums []int) (int, int, int) {
+		best, bestStart, run, start := 0, 0, 0, 0
+		for i, n := range nums {
+			if i > 0 && n == nums[i-1]+1 {
+				run++
+			} else {
+				run, start = 1, n
+			}
+			if run > best {
+				best, bestStart = run, start
+			}
+		}
+		return best, bestStart, run
+	}
+
+	var first, lastRun int
+	res.LongestDays, first, lastRun = longest(days)
+	y, m, d := time.Unix(int64(first)*86400, 0).UTC().Date()
+	res.LongestStart = time.Date(y, m, d, 0, 0, 0, 0, dayLoc)
+	today := dayNum(now)
+	if today-days[len(days)-1] <= 1 {
+		res.CurrentDays = lastRun
+	}
+
+	res.LongestWeeks, _, lastRun = longest(weeks)
+	if weekNum(today)-weeks[len(weeks)-1] <= 1 {
+		res.CurrentWeeks = lastRun
+	}
+
+	return res
+}
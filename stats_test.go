@@ -0,0 +1,184 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zigdon/goride/units"
+)
+
+func TestAggregate(t *testing.T) {
+	nye := &RideSlim{
+		ID:            1,
+		DepartedAt:    time.Date(2020, 1, 1, 5, 30, 0, 0, time.UTC),
+		UtcOffset:     -8 * 3600,
+		GearID:        17,
+		Distance:      10000,
+		ElevationGain: 100,
+		MovingTime:    1800,
+	}
+	sydney := &RideSlim{
+		ID:            2,
+		DepartedAt:    time.Date(2019, 12, 31, 20, 0, 0, 0, time.UTC),
+		UtcOffset:     10 * 3600,
+		GearID:        17,
+		Distance:      20000,
+		ElevationGain: 50,
+		MovingTime:    3600,
+	}
+	june := &RideSlim{
+		ID:            3,
+		DepartedAt:    time.Date(2019, 6, 15, 12, 0, 0, 0, time.UTC),
+		GearID:        35,
+		Distance:      5000,
+		ElevationGain: 10,
+		MovingTime:    600,
+	}
+	rides := []*RideSlim{nye, sydney, june, nil}
+
+	tests := []struct {
+		desc  string
+		rides []*RideSlim
+		by    GroupBy
+		want  map[string]Totals
+	}{
+		{
+			desc: "empty",
+			by:   GroupByYear,
+			want: map[string]Totals{},
+		},
+		{
+			desc:  "by year",
+			rides: rides,
+			by:    GroupByYear,
+			want: map[string]Totals{
+				"2019": {Rides: 2, Distance: 15000, ElevationGain: 110, MovingTime: 40 * time.Minute, Longest: nye},
+				"2020": {Rides: 1, Distance: 20000, ElevationGain: 50, MovingTime: time.Hour, Longest: sydney},
+			},
+		},
+		{
+			desc:  "by month",
+			rides: rides,
+			by:    GroupByMonth,
+			want: map[string]Totals{
+				"2019-06": {Rides: 1, Distance: 5000, ElevationGain: 10, MovingTime: 10 * time.Minute, Longest: june},
+				"2019-12": {Rides: 1, Distance: 10000, ElevationGain: 100, MovingTime: 30 * time.Minute, Longest: nye},
+				"2020-01": {Rides: 1, Distance: 20000, ElevationGain: 50, MovingTime: time.Hour, Longest: sydney},
+			},
+		},
+		{
+			desc:  "by gear",
+			rides: rides,
+			by:    GroupByGear,
+			want: map[string]Totals{
+				"17": {Rides: 2, Distance: 30000, ElevationGain: 150, MovingTime: 90 * time.Minute, Longest: sydney},
+				"35": {Rides: 1, Distance: 5000, ElevationGain: 10, MovingTime: 10 * time.Minute, Longest: june},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := Aggregate(tc.rides, tc.by)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad totals: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEddington(t *testing.T) {
+	var rides []*RideSlim
+	for _, km := range []float32{50, 40, 30, 10, 5, 3, 3} {
+		rides = append(rides, &RideSlim{Distance: km * 1000})
+	}
+	deleted := time.Now()
+	rides = append(rides, &RideSlim{Distance: 100000, DeletedAt: &deleted}, nil)
+
+	tests := []struct {
+		desc   string
+		system units.System
+		want   int
+	}{
+		{desc: "km", system: units.Metric, want: 5},
+		{desc: "miles", system: units.Imperial, want: 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := Eddington(rides, tc.system); got != tc.want {
+				t.Errorf("bad Eddington number: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	at := func(day, hour, min int) *RideSlim {
+		return &RideSlim{DepartedAt: time.Date(2021, 8, day, hour, min, 0, 0, la)}
+	}
+	deleted := time.Now()
+	gone := at(5, 9, 0)
+	gone.DeletedAt = &deleted
+	rides := []*RideSlim{
+		// Monday to Wednesday, with two rides on Tuesday. Wednesday's ride is
+		// on Thursday in UTC.
+		at(2, 7, 0), at(3, 7, 0), at(3, 16, 0), at(4, 23, 30),
+		gone,
+		at(6, 8, 0),
+		// Nothing the week of the 9th.
+		at(16, 8, 0),
+		at(22, 8, 0), at(23, 8, 0),
+		nil,
+	}
+
+	tests := []struct {
+		desc string
+		loc  *time.Location
+		now  time.Time
+		want StreakStats
+	}{
+		{
+			desc: "local",
+			loc:  la,
+			now:  time.Date(2021, 8, 24, 10, 0, 0, 0, la),
+			want: StreakStats{CurrentDays: 2, LongestDays: 3, CurrentWeeks: 2, LongestWeeks: 2, LongestStart: time.Date(2021, 8, 2, 0, 0, 0, 0, la)},
+		},
+		{
+			desc: "utc",
+			loc:  time.UTC,
+			now:  time.Date(2021, 8, 24, 10, 0, 0, 0, la),
+			want: StreakStats{CurrentDays: 2, LongestDays: 2, CurrentWeeks: 2, LongestWeeks: 2, LongestStart: time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			desc: "broken",
+			loc:  la,
+			now:  time.Date(2021, 8, 26, 10, 0, 0, 0, la),
+			want: StreakStats{LongestDays: 3, CurrentWeeks: 2, LongestWeeks: 2, LongestStart: time.Date(2021, 8, 2, 0, 0, 0, 0, la)},
+		},
+		{
+			desc: "weeks broken",
+			loc:  la,
+			now:  time.Date(2021, 9, 7, 10, 0, 0, 0, la),
+			want: StreakStats{LongestDays: 3, LongestWeeks: 2, LongestStart: time.Date(2021, 8, 2, 0, 0, 0, 0, la)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := streaks(rides, tc.loc, tc.now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad streaks: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff(StreakStats{}, Streaks(nil, la)); diff != "" {
+		t.Errorf("expected no streaks: -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,102 @@
+package goride
+
+import "time"
+
+// StopOptions tunes DetectStops. Zero fields use the defaults.
+type StopOptions struct {
+	// Slower than this, in km/h, is stopped. Defaults to 2.
+	SpeedThreshold float64
+	// Shorter stops are ignored. Defaults to 30s.
+	MinDuration time.Duration
+	// Stops separated by less than this are merged, so GPS jitter while
+	// standing still doesn't split a stop. Defaults to 20s.
+	MergeGap time.Duration
+}
+
+// Stop is a period where the rider wasn't moving. Location is the average
+// position during the stop.
+type Stop struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Location LatLng
+}
+
+func (o StopOptions) withDefaults() StopOptions {
+	if o.SpeedThreshold == 0 {
+		o.SpeedThreshold = 2
+	}
+	if o.MinDuration == 0 {
+		o.MinDuration = 30 * time.Second
+	}
+	if o.MergeGap == 0 {
+		o.MergeGap = 20 * time.Second
+	}
+
+	return o
+}
+
+// DetectStops finds where a ride stopped. Speeds are computed from the
+// distance between points, so gaps in recording where the rider didn't move
+// are stops too. The points must be in time order.
+func DetectStops(points []TrackPoint, opts StopOptions) []Stop {
+	opts = opts.withDefaults()
+	dist := trackDistances(points)
+
+	// Find runs of stopped segments, as [first, last] point indexes.
+	var runs [][2]int
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Time.Sub(points[i-1].Time)
+		if dt <= 0 {
+			continue
+		}
+		speed := (dist[i] - dist[i-1]) / 1000 / dt.Hours()
+		if speed >= opts.SpeedThreshold {
+			continue
+		}
+		if n := len(runs); n > 0 && points[i-1].Time.Sub(points[runs[n-1][1]].Time) < opts.MergeGap {
+			runs[n-1][1] = i
+			continue
+		}
+		runs = append(runs, [2]int{i - 1, i})
+	}
+
+	var res []Stop
+	for _, run := range runs {
+		s := Stop{
+			Start: points[run[0]].Time,
+			End:   points[run[1]].Time,
+		}
+		s.Duration = s.End.Sub(s.Start)
+		if s.Duration < opts.MinDuration {
+			continue
+		}
+
+		var lat, lng float64
+		var n int
+		for _, p := range points[run[0] : run[1]+1] {
+			if p.Lat == 0 && p.Lng == 0 {
+				continue
+			}
+			lat += p.Lat
+			lng += p.Lng
+			n++
+		}
+		if n > 0 {
+			s.Location = LatLng{Lat: float32(lat / float64(n)), Lng: float32(lng / float64(n))}
+		}
+		res = append(res, s)
+	}
+
+	return res
+}
+
+// TotalStopTime sums the duration of the stops.
+func TotalStopTime(stops []Stop) time.Duration {
+	var total time.Duration
+	for _, s := range stops {
+		total += s.Duration
+	}
+
+	return total
+}
@@ -0,0 +1,105 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// synthStopsTrack is 1s points: a minute riding, a 2 minute stop with the GPS
+// jumping around every 15s, another minute riding, a 10s pause, and a final
+// minute riding.
+func synthStopsTrack() []TrackPoint {
+	var res []TrackPoint
+	p := TrackPoint{Time: sensorStart, Lat: 45.5, Lng: -122.6}
+	add := func(n int, dist float64, jitter bool) {
+		for i := 0; i < n; i++ {
+			p.Time = p.Time.Add(time.Second)
+			p.Distance += dist
+			if jitter && i%15 == 14 {
+				p.Distance += 4
+			}
+			res = append(res, p)
+		}
+	}
+
+	res = append(res, p)
+	add(59, 10, false)
+	p.Lat, p.Lng = 45.6, -122.7
+	add(1, 10, false)
+	add(120, 0, true)
+	add(60, 10, false)
+	add(10, 0, false)
+	add(60, 10, false)
+
+	return res
+}
+
+func TestDetectStops(t *testing.T) {
+	stop := func(from, to int) Stop {
+		return Stop{
+			Start:    sensorStart.Add(time.Duration(from) * time.Second),
+			End:      sensorStart.Add(time.Duration(to) * time.Second),
+			Duration: time.Duration(to-from) * time.Second,
+			Location: LatLng{Lat: 45.6, Lng: -122.7},
+		}
+	}
+
+	tests := []struct {
+		desc string
+		opts StopOptions
+		want []Stop
+	}{
+		{
+			desc: "defaults",
+			want: []Stop{stop(60, 179)},
+		},
+		{
+			desc: "short stops",
+			opts: StopOptions{MinDuration: 5 * time.Second},
+			want: []Stop{stop(60, 179), {
+				Start:    sensorStart.Add(240 * time.Second),
+				End:      sensorStart.Add(250 * time.Second),
+				Duration: 10 * time.Second,
+				Location: LatLng{Lat: 45.6, Lng: -122.7},
+			}},
+		},
+		{
+			desc: "no merging",
+			opts: StopOptions{MinDuration: 14 * time.Second, MergeGap: time.Nanosecond},
+			want: []Stop{stop(60, 74), stop(75, 89), stop(90, 104), stop(105, 119), stop(120, 134), stop(135, 149), stop(150, 164), stop(165, 179)},
+		},
+		{
+			desc: "high threshold",
+			opts: StopOptions{SpeedThreshold: 40},
+			want: []Stop{{
+				Start:    sensorStart,
+				End:      sensorStart.Add(310 * time.Second),
+				Duration: 310 * time.Second,
+				Location: LatLng{Lat: 45.58071, Lng: -122.68071},
+			}},
+		},
+	}
+
+	track := synthStopsTrack()
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := DetectStops(track, tc.opts)
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b float32) bool {
+				return a-b < 1e-4 && b-a < 1e-4
+			})); diff != "" {
+				t.Errorf("bad stops: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDetectStopsMatchesMovingTime(t *testing.T) {
+	r := getTestRide(t)
+	got := TotalStopTime(DetectStops(r.TrackPoints, StopOptions{}))
+	want := time.Duration(r.Metrics.Duration-time.Duration(r.Metrics.MovingTime)) * time.Second
+	if absDuration(got-want) > 30*time.Second {
+		t.Errorf("stop time doesn't match the ride's moving time: want %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,266 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	ridesPageSize = 100
+	// Rate limited pages are retried up to pageRetries times, waiting
+	// pageRetryWait before the first retry and twice as long before each of
+	// the next ones.
+	pageRetries   = 3
+	pageRetryWait = time.Second
+)
+
+// RideStore is a local copy of rides, used for lookups the API can't do.
+type RideStore interface {
+	RidesForRoute(routeID int) ([]*RideSlim, error)
+}
+
+// MemoryRideStore is a RideStore indexing rides held in memory.
+type MemoryRideStore struct {
+	byRoute map[int][]*RideSlim
+}
+
+func NewMemoryRideStore(rides []*RideSlim) *MemoryRideStore {
+	s := &MemoryRideStore{byRoute: make(map[int][]*RideSlim)}
+	for _, r := range rides {
+		if r.RouteID != 0 {
+			s.byRoute[r.RouteID] = append(s.byRoute[r.RouteID], r)
+		}
+	}
+
+	return s
+}
+
+func (s *MemoryRideStore) RidesForRoute(routeID int) ([]*RideSlim, error) {
+	return s.byRoute[routeID], nil
+}
+
+// SetRideStore sets the store used when the server can't answer a query. If
+// none is set, one is built from all of the current user's rides when needed.
+func (r *RWGPS) SetRideStore(s RideStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = s
+}
+
+// AllRides pages through all of a user's rides.
+func (r *RWGPS) AllRides(user int) ([]*RideSlim, error) {
+	var res []*RideSlim
+	var err error
+	r.RidesIter(user)(func(ride *RideSlim, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		res = append(res, ride)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetAllRidesFast is AllRides, fetching the pages after the first one
+// concurrently, at most concurrency at a time. The rides are in the same order
+// as AllRides. Rides added while the pages are fetched push the others to
+// later pages, so rides that show up twice are only returned once, and pages
+// past the original end are fetched if needed. Rate limited pages are
+// retried; any other error, or ctx being done, stops the fetch once the pages
+// in flight return.
+func (r *RWGPS) GetAllRidesFast(ctx context.Context, user, concurrency int) ([]*RideSlim, error) {
+	concurrency = max(concurrency, 1)
+	getPage := func(ctx context.Context, offset int) ([]*RideSlim, int, error) {
+		wait := pageRetryWait
+		for i := 0; ; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+			rides, count, err := r.GetRides(user, offset, ridesPageSize)
+			if err == nil || !errors.Is(err, ErrRateLimited) || i >= pageRetries {
+				return rides, count, err
+			}
+			r.log().Debug("rides page rate limited", "user", user, "offset", offset, "wait", wait)
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return nil, 0, ctx.Err()
+			case <-t.C:
+			}
+			r.countRetry(wait)
+			wait *= 2
+		}
+	}
+
+	// The first page says how many pages there are.
+	first, count, err := getPage(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	pages := make([][]*RideSlim, max(1, (count+ridesPageSize-1)/ridesPageSize))
+	pages[0] = first
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var fetchErr error
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(pages)-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range work {
+				rides, n, err := getPage(fetchCtx, p*ridesPageSize)
+				mu.Lock()
+				if err != nil && fetchErr == nil {
+					fetchErr = err
+					cancel()
+				}
+				pages[p] = rides
+				count = max(count, n)
+				mu.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for p := 1; p < len(pages); p++ {
+		select {
+		case work <- p:
+		case <-fetchCtx.Done():
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	// Rides added since the first page can push the last ones further.
+	for offset := len(pages) * ridesPageSize; fetchErr == nil && offset < count; offset += ridesPageSize {
+		var rides []*RideSlim
+		rides, _, fetchErr = getPage(ctx, offset)
+		pages = append(pages, rides)
+	}
+	if fetchErr == nil {
+		fetchErr = ctx.Err()
+	}
+	if fetchErr != nil {
+		return nil, fmt.Errorf("error getting rides for %d: %w", user, fetchErr)
+	}
+
+	var res []*RideSlim
+	seen := make(map[int]bool, count)
+	for _, page := range pages {
+		for _, ride := range page {
+			if seen[ride.ID] {
+				continue
+			}
+			seen[ride.ID] = true
+			res = append(res, ride)
+		}
+	}
+	r.log().Debug("got all rides", "user", user, "count", count, "fetched", len(res), "pages", len(pages))
+
+	return res, nil
+}
+
+// RidesIter returns an iterator over all of a user's rides, fetching pages as
+// they're needed. It can be used as an iter.Seq2, and stops fetching as soon as
+// the loop ends. A failed fetch is yielded as an error, and ends the
+// iteration.
+func (r *RWGPS) RidesIter(user int) func(yield func(*RideSlim, error) bool) {
+	return func(yield func(*RideSlim, error) bool) {
+		offset := 0
+		for {
+			rides, count, err := r.GetRides(user, offset, ridesPageSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			offset += len(rides)
+			r.log().Debug("got rides page", "user", user, "fetched", offset, "count", count)
+			for _, ride := range rides {
+				if !yield(ride, nil) {
+					return
+				}
+			}
+			if len(rides) == 0 || offset >= count {
+				return
+			}
+		}
+	}
+}
+
+// GetRidesForRoute lists the rides that followed a route. If the server can't
+// filter by route, the rides are looked up in the ride store instead.
+func (r *RWGPS) GetRidesForRoute(routeID, offset, limit int) ([]*RideSlim, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("invalid page %d+%d: offset and limit can't be negative", offset, limit)
+	}
+	rides, count, err := r.getRidesPage(fmt.Sprintf("/routes/%d/trips.json", routeID), offset, limit, nil)
+	if err == nil {
+		r.log().Debug("got rides for route from the server", "route", routeID)
+		return rides, count, nil
+	}
+	if !isStatus(err, http.StatusNotFound) {
+		return nil, 0, fmt.Errorf("error getting rides %d+%d for route %d: %w", offset, limit, routeID, err)
+	}
+
+	r.log().Debug("server can't list rides for route, using the local ride store", "route", routeID)
+	store, err := r.rideStore()
+	if err != nil {
+		return nil, 0, err
+	}
+	rides, err = store.RidesForRoute(routeID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting rides for route %d from the store: %w", routeID, err)
+	}
+
+	count = len(rides)
+	if offset > count {
+		offset = count
+	}
+	end := offset + limit
+	if end > count {
+		end = count
+	}
+
+	return rides[offset:end], count, nil
+}
+
+// rideStore returns the ride store, building one from all of the current
+// user's rides if none was set.
+func (r *RWGPS) rideStore() (RideStore, error) {
+	r.mu.Lock()
+	store := r.store
+	r.mu.Unlock()
+	if store != nil {
+		return store, nil
+	}
+
+	u, err := r.loggedInUser()
+	if err != nil {
+		return nil, fmt.Errorf("can't build ride store: %w", err)
+	}
+	all, err := r.AllRides(u.ID)
+	if err != nil {
+		return nil, fmt.Errorf("can't build ride store: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Another goroutine may have built one meanwhile.
+	if r.store == nil {
+		r.store = NewMemoryRideStore(all)
+	}
+	return r.store, nil
+}
@@ -0,0 +1,399 @@
+package goride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func ridesPage(t *testing.T, count int, rides []*RideSlim) string {
+	data, err := json.Marshal(struct {
+		Count int         `json:"results_count"`
+		Rides []*RideSlim `json:"results"`
+	}{count, rides})
+	if err != nil {
+		t.Fatalf("can't encode rides: %v", err)
+	}
+
+	return string(data)
+}
+
+func TestGetRidesForRouteServer(t *testing.T) {
+	var gotArgs url.Values
+	f := func(_ string, args url.Values) string {
+		gotArgs = args
+		return getTestData("trips0-2.json")
+	}
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/routes/5/trips.json": f,
+	})
+	defer server.Close()
+	r := testObj(server.URL)
+	logs := &logRecorder{}
+	WithLogger(slog.New(logs))(r)
+	rides, count, err := r.GetRidesForRoute(5, 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if count != 1273 || len(rides) != 2 {
+		t.Errorf("bad result: %d rides out of %d", len(rides), count)
+	}
+	if gotArgs.Get("offset") != "0" || gotArgs.Get("limit") != "2" || gotArgs.Get("auth_token") != "ffffff" {
+		t.Errorf("bad request args: %v", gotArgs)
+	}
+	if !logs.has(slog.LevelDebug, "from the server") {
+		t.Errorf("expected the server path to be logged, got %v", logs.messages())
+	}
+}
+
+func TestGetRidesForRouteFallback(t *testing.T) {
+	var all []*RideSlim
+	for i := 1; i <= 5; i++ {
+		all = append(all, &RideSlim{ID: i, RouteID: i % 2})
+	}
+	var requests []string
+	f := func(_ string, args url.Values) string {
+		requests = append(requests, args.Get("offset"))
+		switch args.Get("offset") {
+		case "0":
+			return ridesPage(t, len(all), all[:3])
+		case "3":
+			return ridesPage(t, len(all), all[3:])
+		}
+		return ridesPage(t, len(all), nil)
+	}
+	server := startServer(t, nil, map[string]func(string, url.Values) string{
+		"/users/1268590/trips.json": f,
+	})
+	defer server.Close()
+	r := testObj(server.URL)
+	logs := &logRecorder{}
+	WithLogger(slog.New(logs))(r)
+	tests := []struct {
+		desc    string
+		offset  int
+		limit   int
+		wantIDs []int
+	}{
+		{desc: "all", offset: 0, limit: 10, wantIDs: []int{1, 3, 5}},
+		{desc: "page", offset: 1, limit: 1, wantIDs: []int{3}},
+		{desc: "past the end", offset: 5, limit: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rides, count, err := r.GetRidesForRoute(1, tc.offset, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != 3 {
+				t.Errorf("wrong count: %d", count)
+			}
+
+			var gotIDs []int
+			for _, ride := range rides {
+				gotIDs = append(gotIDs, ride.ID)
+			}
+			if diff := cmp.Diff(tc.wantIDs, gotIDs); diff != "" {
+				t.Errorf("bad ride IDs: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff([]string{"0", "3"}, requests); diff != "" {
+		t.Errorf("expected the store to be built once: -want +got\n%s", diff)
+	}
+	if !logs.has(slog.LevelDebug, "local ride store") {
+		t.Errorf("expected the fallback to be logged, got %v", logs.messages())
+	}
+
+	for _, p := range [][2]int{{-1, 10}, {0, -1}} {
+		if _, _, err := r.GetRidesForRoute(1, p[0], p[1]); err == nil {
+			t.Errorf("GetRidesForRoute(1, %d, %d): want an error", p[0], p[1])
+		}
+	}
+}
+
+func TestMemoryRideStore(t *testing.T) {
+	s := NewMemoryRideStore([]*RideSlim{{ID: 1, RouteID: 7}, {ID: 2}, {ID: 3, RouteID: 7}})
+	for route, want := range map[int]int{7: 2, 0: 0, 8: 0} {
+		t.Run(fmt.Sprint(route), func(t *testing.T) {
+			got, err := s.RidesForRoute(route)
+			if err != nil || len(got) != want {
+				t.Errorf("RidesForRoute(%d) = %d rides, %v; want %d", route, len(got), err, want)
+			}
+		})
+	}
+}
+
+func TestRidesIter(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var calls []string
+	f.handle("/users/1/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		offset := req.URL.Query().Get("offset")
+		calls = append(calls, offset)
+		switch offset {
+		case "0":
+			fmt.Fprint(w, getTestData("trips0-2.json"))
+		case "2":
+			http.Error(w, "oops", http.StatusInternalServerError)
+		}
+	})
+	r := testObj(f.URL)
+
+	tests := []struct {
+		desc      string
+		stopAfter int
+		wantIDs   []int
+		wantErr   bool
+		wantCalls []string
+	}{
+		{
+			desc:      "stop early",
+			stopAfter: 1,
+			wantIDs:   []int{38045212},
+			wantCalls: []string{"0"},
+		},
+		{
+			desc:      "stop at the end of the page",
+			stopAfter: 2,
+			wantIDs:   []int{38045212, 37648524},
+			wantCalls: []string{"0"},
+		},
+		{
+			desc:      "error on the second page",
+			wantIDs:   []int{38045212, 37648524},
+			wantErr:   true,
+			wantCalls: []string{"0", "2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls = nil
+			var ids []int
+			var gotErr error
+			r.RidesIter(1)(func(ride *RideSlim, err error) bool {
+				if err != nil {
+					gotErr = err
+					return false
+				}
+				ids = append(ids, ride.ID)
+				return len(ids) != tc.stopAfter
+			})
+
+			if diff := cmp.Diff(tc.wantIDs, ids); diff != "" {
+				t.Errorf("bad rides: -want +got\n%s", diff)
+			}
+			if tc.wantErr != (gotErr != nil) {
+				t.Errorf("unexpected error: %v", gotErr)
+			}
+			if diff := cmp.Diff(tc.wantCalls, calls); diff != "" {
+				t.Errorf("bad calls: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+// slowRides serves count rides, newest first, taking latency for each page.
+// Pages from shiftAt on are served as if added rides were uploaded after the
+// first pages were fetched.
+type slowRides struct {
+	t         *testing.T
+	count     int
+	latency   time.Duration
+	shiftAt   int
+	added     int
+	limitedAt int
+	cancelAt  int
+	cancel    func()
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	offsets     []int
+	limited     bool
+}
+
+func (s *slowRides) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/users/current.json" {
+		fmt.Fprint(w, defaultAuth(req.URL.Path, req.URL.Query()))
+		return
+	}
+	offset, _ := strconv.Atoi(req.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+
+	s.mu.Lock()
+	s.offsets = append(s.offsets, offset)
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	limited := offset != 0 && offset == s.limitedAt && !s.limited
+	if limited {
+		s.limited = true
+	}
+	if offset != 0 && offset == s.cancelAt {
+		s.cancel()
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(s.latency)
+	if limited {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+		return
+	}
+	count := s.count
+	if s.shiftAt != 0 && offset >= s.shiftAt {
+		count += s.added
+	}
+	var rides []*RideSlim
+	for i := offset; i < min(offset+limit, count); i++ {
+		rides = append(rides, &RideSlim{ID: count - i})
+	}
+	fmt.Fprint(w, ridesPage(s.t, count, rides))
+}
+
+func (s *slowRides) requested() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := append([]int{}, s.offsets...)
+	sort.Ints(res)
+	return res
+}
+
+func TestGetAllRidesFast(t *testing.T) {
+	const count = 1273
+	var wantIDs []int
+	for i := count; i > 0; i-- {
+		wantIDs = append(wantIDs, i)
+	}
+	var allOffsets []int
+	for o := 0; o < count; o += ridesPageSize {
+		allOffsets = append(allOffsets, o)
+	}
+
+	tests := []struct {
+		desc        string
+		concurrency int
+		server      *slowRides
+		wantOffsets []int
+		wantErr     error
+	}{
+		{
+			desc:        "parallel",
+			concurrency: 4,
+			server:      &slowRides{latency: 20 * time.Millisecond},
+			wantOffsets: allOffsets,
+		},
+		{
+			desc:        "serial",
+			concurrency: 0,
+			server:      &slowRides{},
+			wantOffsets: allOffsets,
+		},
+		{
+			desc:        "list shifted",
+			concurrency: 4,
+			server:      &slowRides{shiftAt: 500, added: 30},
+			wantOffsets: append(allOffsets, 1300),
+		},
+		{
+			desc:        "rate limited",
+			concurrency: 4,
+			server:      &slowRides{limitedAt: 300},
+			wantOffsets: append([]int{0, 100, 200, 300, 300}, allOffsets[4:]...),
+		},
+		{
+			desc:        "cancelled",
+			concurrency: 2,
+			server:      &slowRides{cancelAt: 100, latency: 10 * time.Millisecond},
+			wantOffsets: []int{0, 100, 200},
+			wantErr:     context.Canceled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := tc.server
+			s.t, s.count, s.cancel = t, count, cancel
+			server := httptest.NewServer(s)
+			defer server.Close()
+			r := testObj(server.URL)
+
+			got, err := r.GetAllRidesFast(ctx, 1, tc.concurrency)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("want error %v, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil {
+				var ids []int
+				for _, r := range got {
+					ids = append(ids, r.ID)
+				}
+				if diff := cmp.Diff(wantIDs, ids); diff != "" {
+					t.Errorf("bad rides: -want +got\n%s", diff)
+				}
+			}
+			if diff := cmp.Diff(tc.wantOffsets, s.requested()); diff != "" {
+				t.Errorf("bad pages fetched: -want +got\n%s", diff)
+			}
+			if want := max(tc.concurrency, 1); s.maxInFlight > want {
+				t.Errorf("%d pages fetched at once, want at most %d", s.maxInFlight, want)
+			}
+		})
+	}
+}
+
+func TestGetAllRidesFastSpeedup(t *testing.T) {
+	const latency = 100 * time.Millisecond
+	s := &slowRides{t: t, count: 1273, latency: latency}
+	server := httptest.NewServer(s)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	start := time.Now()
+	serial, err := r.AllRides(1)
+	if err != nil {
+		t.Fatalf("AllRides: %v", err)
+	}
+	serialTime := time.Since(start)
+
+	start = time.Now()
+	fast, err := r.GetAllRidesFast(context.Background(), 1, 8)
+	if err != nil {
+		t.Fatalf("GetAllRidesFast: %v", err)
+	}
+	fastTime := time.Since(start)
+
+	if diff := cmp.Diff(serial, fast); diff != "" {
+		t.Errorf("different rides: -serial +fast\n%s", diff)
+	}
+	// Fetching the pages one at a time can't be faster than their latency
+	// combined.
+	if pages := (s.count + ridesPageSize - 1) / ridesPageSize; fastTime >= time.Duration(pages)*latency {
+		t.Errorf("no speedup: %s in parallel, %s serially", fastTime, serialTime)
+	}
+	t.Logf("%d rides in %s in parallel, %s serially", len(fast), fastTime, serialTime)
+}
@@ -0,0 +1,145 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Surface is the kind of surface under a stretch of a route.
+type Surface int
+
+const (
+	SurfaceUnknown Surface = 0
+	SurfacePaved   Surface = 1
+	// SurfaceUnpaved is unpaved, but not known to be gravel or dirt.
+	SurfaceUnpaved Surface = 2
+	SurfaceGravel  Surface = 3
+	SurfaceDirt    Surface = 4
+)
+
+func (s Surface) String() string {
+	switch s {
+	case SurfaceUnknown:
+		return "unknown"
+	case SurfacePaved:
+		return "paved"
+	case SurfaceUnpaved:
+		return "unpaved"
+	case SurfaceGravel:
+		return "gravel"
+	case SurfaceDirt:
+		return "dirt"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Unpaved is true for the surfaces that aren't paved. Unknown ones aren't
+// either.
+func (s Surface) Unpaved() bool {
+	return s == SurfaceUnpaved || s == SurfaceGravel || s == SurfaceDirt
+}
+
+// Known is true for the surfaces this package knows about.
+func (s Surface) Known() bool {
+	return s == SurfacePaved || s.Unpaved()
+}
+
+func (s Surface) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// UnmarshalJSON keeps unknown values as is, so they round trip.
+func (s *Surface) UnmarshalJSON(data []byte) error {
+	var n *int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("bad surface %s: %w", data, err)
+	}
+	if n != nil {
+		*s = Surface(*n)
+	}
+
+	return nil
+}
+
+// UnpavedFraction is how much of the route is unpaved, between 0 and 1. It's
+// measured along the track if its points have surfaces, and taken from the
+// server's UnpavedPct otherwise. If neither is there, known is false, rather
+// than calling the route paved.
+func (r *Route) UnpavedFraction() (frac float64, known bool) {
+	dist := trackDistances(r.TrackPoints)
+	var unpaved, total float64
+	for i := 1; i < len(r.TrackPoints); i++ {
+		s := r.TrackPoints[i-1].Surface
+		if !s.Known() {
+			continue
+		}
+		d := dist[i] - dist[i-1]
+		total += d
+		if s.Unpaved() {
+			unpaved += d
+		}
+	}
+	if total > 0 {
+		return unpaved / total, true
+	}
+	if r.UnpavedPct != nil {
+		return float64(*r.UnpavedPct) / 100, true
+	}
+
+	return 0, false
+}
+
+// SurfaceSector is a stretch of a track on one kind of surface, from the
+// Start track point to the End one. Distances are in meters.
+type SurfaceSector struct {
+	Start, End    int
+	StartDistance float64
+	Distance      float64
+	// Surface is SurfaceUnpaved for sectors that mix unpaved surfaces.
+	Surface Surface
+}
+
+// UnpavedSectors returns the unpaved stretches of a track, like the gravel
+// sectors of a race, in order. Each point's Surface is the surface up to the
+// next point. Back to back stretches of different unpaved surfaces are one
+// sector, and a paved or unknown stretch ends it. Sectors shorter than
+// minLength meters are left out.
+func UnpavedSectors(points []TrackPoint, minLength float64) []SurfaceSector {
+	res := []SurfaceSector{}
+	dist := trackDistances(points)
+	start := -1
+	var surface Surface
+	closeSector := func(end int) {
+		if d := dist[end] - dist[start]; d >= minLength && d > 0 {
+			res = append(res, SurfaceSector{
+				Start:         start,
+				End:           end,
+				StartDistance: dist[start],
+				Distance:      d,
+				Surface:       surface,
+			})
+		}
+		start = -1
+	}
+
+	for i := 0; i < len(points)-1; i++ {
+		s := points[i].Surface
+		switch {
+		case !s.Unpaved():
+			if start >= 0 {
+				closeSector(i)
+			}
+		case start < 0:
+			start, surface = i, s
+		case s != surface:
+			surface = SurfaceUnpaved
+		}
+	}
+	if start >= 0 {
+		closeSector(len(points) - 1)
+	}
+
+	return res
+}
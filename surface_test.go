@@ -0,0 +1,171 @@
+package goride
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSurface(t *testing.T) {
+	if got := SurfaceGravel.String(); got != "gravel" {
+		t.Errorf("bad name: %q", got)
+	}
+	if got := Surface(42).String(); got != "unknown(42)" {
+		t.Errorf("bad unknown name: %q", got)
+	}
+	if Surface(42).Unpaved() || Surface(42).Known() {
+		t.Errorf("unknown surfaces should be neither unpaved nor known")
+	}
+
+	var p TrackPoint
+	if err := json.Unmarshal([]byte(`{"x":-96.18,"y":38.404,"S":42}`), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(`{"y":38.404,"x":-96.18,"S":42}`, string(got)); diff != "" {
+		t.Errorf("surface didn't round trip: -want +got\n%s", diff)
+	}
+}
+
+func TestUnpavedSectors(t *testing.T) {
+	surfaced := func(surfaces ...Surface) []TrackPoint {
+		var res []TrackPoint
+		for i, s := range surfaces {
+			res = append(res, TrackPoint{Lat: 38.4, Lng: -96.18 + float64(i)*0.001, Distance: float64(i * 100), Surface: s})
+		}
+		return res
+	}
+	u, p, g, d := SurfaceUnknown, SurfacePaved, SurfaceGravel, SurfaceDirt
+
+	tests := []struct {
+		desc      string
+		points    []TrackPoint
+		minLength float64
+		want      []SurfaceSector
+	}{
+		{
+			desc:   "paved",
+			points: surfaced(p, p, p),
+			want:   []SurfaceSector{},
+		},
+		{
+			desc:   "no surfaces",
+			points: surfaced(u, u, u),
+			want:   []SurfaceSector{},
+		},
+		{
+			desc:   "in the middle",
+			points: surfaced(p, g, g, p, p),
+			want:   []SurfaceSector{{Start: 1, End: 3, StartDistance: 100, Distance: 200, Surface: g}},
+		},
+		{
+			desc:   "at both ends",
+			points: surfaced(d, p, g, g),
+			want: []SurfaceSector{
+				{Start: 0, End: 1, Distance: 100, Surface: d},
+				{Start: 2, End: 3, StartDistance: 200, Distance: 100, Surface: g},
+			},
+		},
+		{
+			desc:   "last point's surface doesn't count",
+			points: surfaced(p, p, g),
+			want:   []SurfaceSector{},
+		},
+		{
+			desc:   "mixed",
+			points: surfaced(g, d, g, p),
+			want:   []SurfaceSector{{Start: 0, End: 3, Distance: 300, Surface: SurfaceUnpaved}},
+		},
+		{
+			desc:   "unknown ends a sector",
+			points: surfaced(g, u, g, g),
+			want: []SurfaceSector{
+				{Start: 0, End: 1, Distance: 100, Surface: g},
+				{Start: 2, End: 3, StartDistance: 200, Distance: 100, Surface: g},
+			},
+		},
+		{
+			desc:      "short sectors",
+			points:    surfaced(g, p, d, d, d, p),
+			minLength: 150,
+			want:      []SurfaceSector{{Start: 2, End: 5, StartDistance: 200, Distance: 300, Surface: d}},
+		},
+		{
+			desc: "empty",
+			want: []SurfaceSector{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := UnpavedSectors(tc.points, tc.minLength)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad sectors: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRouteSurface(t *testing.T) {
+	server := startServer(t,
+		map[string]string{
+			"/routes/31330600.json": getTestData("route_surface.json"),
+			"/routes/31330404.json": getTestData("route.json"),
+			"/routes/31330500.json": getTestData("route_pois.json"),
+		},
+		nil)
+	defer server.Close()
+	r := testObj(server.URL)
+
+	tests := []struct {
+		desc    string
+		id      int
+		frac    float64
+		known   bool
+		sectors []SurfaceSector
+	}{
+		{
+			desc:  "from track points",
+			id:    31330600,
+			frac:  0.44,
+			known: true,
+			sectors: []SurfaceSector{
+				{Start: 1, End: 4, StartDistance: 1000, Distance: 2000, Surface: SurfaceUnpaved},
+				{Start: 6, End: 7, StartDistance: 5000, Distance: 200, Surface: SurfaceGravel},
+			},
+		},
+		{
+			desc:    "from the route",
+			id:      31330404,
+			frac:    0.2,
+			known:   true,
+			sectors: []SurfaceSector{},
+		},
+		{
+			desc:    "unknown",
+			id:      31330500,
+			sectors: []SurfaceSector{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			route, err := r.GetRoute(tc.id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			frac, known := route.UnpavedFraction()
+			if known != tc.known || !cmp.Equal(tc.frac, frac, cmpopts.EquateApprox(0, 1e-6)) {
+				t.Errorf("want unpaved fraction %v (known %v), got %v (known %v)", tc.frac, tc.known, frac, known)
+			}
+			if diff := cmp.Diff(tc.sectors, UnpavedSectors(route.TrackPoints, 0)); diff != "" {
+				t.Errorf("bad sectors: -want +got\n%s", diff)
+			}
+		})
+	}
+}
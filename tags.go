@@ -0,0 +1,70 @@
+package goride
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddRideTags adds tags to a ride. Tags it already has, in any case, are
+// skipped, and nothing is written if there's nothing new.
+func (r *RWGPS) AddRideTags(id int, tags ...string) error {
+	ride, err := r.GetRideSummary(id)
+	if err != nil {
+		return fmt.Errorf("can't tag ride %d: %w", id, err)
+	}
+
+	res := append([]string(nil), ride.Tags...)
+	for _, t := range tags {
+		if t != "" && !hasTag(res, t) {
+			res = append(res, t)
+		}
+	}
+	if len(res) == len(ride.Tags) {
+		return nil
+	}
+
+	return r.UpdateRide(id, RideUpdate{Tags: &res})
+}
+
+// RemoveRideTags removes tags from a ride, ignoring case. Nothing is written
+// if the ride has none of them.
+func (r *RWGPS) RemoveRideTags(id int, tags ...string) error {
+	ride, err := r.GetRideSummary(id)
+	if err != nil {
+		return fmt.Errorf("can't untag ride %d: %w", id, err)
+	}
+
+	res := []string{}
+	for _, t := range ride.Tags {
+		if !hasTag(tags, t) {
+			res = append(res, t)
+		}
+	}
+	if len(res) == len(ride.Tags) {
+		return nil
+	}
+
+	return r.UpdateRide(id, RideUpdate{Tags: &res})
+}
+
+// FilterByTag returns the rides tagged with tag, ignoring case.
+func FilterByTag(rides []*RideSlim, tag string) []*RideSlim {
+	var res []*RideSlim
+	for _, r := range rides {
+		if hasTag(r.Tags, tag) {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package goride
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRideTags(t *testing.T) {
+	f := newFakeRWGPS(t,
+		&Ride{ID: 1, Tags: []string{"commute"}},
+		&Ride{ID: 2, Tags: []string{"Gravel", "brevet", "hills"}},
+	)
+	r := testObj(f.URL)
+
+	steps := []struct {
+		desc string
+		call func() error
+		id   int
+		want []string
+	}{
+		{
+			desc: "add",
+			call: func() error { return r.AddRideTags(1, "gravel", "hills") },
+			id:   1,
+			want: []string{"commute", "gravel", "hills"},
+		},
+		{
+			desc: "add existing",
+			call: func() error { return r.AddRideTags(1, "Commute", "gravel") },
+			id:   1,
+			want: []string{"commute", "gravel", "hills"},
+		},
+		{
+			desc: "remove ignores case",
+			call: func() error { return r.RemoveRideTags(2, "gravel", "HILLS") },
+			id:   2,
+			want: []string{"brevet"},
+		},
+		{
+			desc: "remove missing",
+			call: func() error { return r.RemoveRideTags(2, "commute") },
+			id:   2,
+			want: []string{"brevet"},
+		},
+		{
+			desc: "remove last",
+			call: func() error { return r.RemoveRideTags(2, "brevet") },
+			id:   2,
+			want: []string{},
+		},
+	}
+
+	for _, s := range steps {
+		if err := s.call(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", s.desc, err)
+		}
+		if diff := cmp.Diff(s.want, f.ride(s.id).Tags); diff != "" {
+			t.Errorf("%s: bad tags: -want +got\n%s", s.desc, diff)
+		}
+	}
+
+	want := []string{
+		`{"trip":{"tag_names":["commute","gravel","hills"]}}`,
+		`{"trip":{"tag_names":["brevet"]}}`,
+		`{"trip":{"tag_names":[]}}`,
+	}
+	if diff := cmp.Diff(want, f.putBodies()); diff != "" {
+		t.Errorf("bad request bodies: -want +got\n%s", diff)
+	}
+	if n := f.trackPointGets(); n != 0 {
+		t.Errorf("tagging fetched the track points %d times", n)
+	}
+
+	if err := r.AddRideTags(42, "commute"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound tagging a missing ride, got %v", err)
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	rides := []*RideSlim{
+		{ID: 1, Tags: []string{"commute"}},
+		{ID: 2, Tags: []string{"Gravel", "brevet"}},
+		{ID: 3},
+		{ID: 4, Tags: []string{"gravel"}},
+	}
+
+	tests := []struct {
+		desc string
+		tag  string
+		want []int
+	}{
+		{desc: "one", tag: "commute", want: []int{1}},
+		{desc: "ignores case", tag: "gravel", want: []int{2, 4}},
+		{desc: "none", tag: "tandem"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got []int
+			for _, r := range FilterByTag(rides, tc.tag) {
+				got = append(got, r.ID)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad rides: -want +got\n%s", diff)
+			}
+		})
+	}
+}
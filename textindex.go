@@ -0,0 +1,197 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+const (
+	// indexVersion is the version of the saved index format.
+	indexVersion = 1
+	// indexNameWeight is how much more a word in a ride's name counts than one
+	// in its description.
+	indexNameWeight = 10
+)
+
+// indexWords splits text into words, folded as foldText does.
+func indexWords(text string) []string {
+	var res []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			res = append(res, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteString(foldRune(r))
+		case r == '\'' || r == '’':
+			// "Niño's" is searched as "ninos".
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return res
+}
+
+// posting counts how many times a word is in a ride's name and description.
+type posting struct {
+	ID   int `json:"id"`
+	Name int `json:"name,omitempty"`
+	Desc int `json:"desc,omitempty"`
+}
+
+// Index is a full text index of rides' names and descriptions, for searching
+// rides without going back to the server, see BuildIndex.
+type Index struct {
+	// words are the indexed words, sorted, for prefix searches.
+	words    []string
+	postings map[string][]posting
+}
+
+// BuildIndex indexes the names and descriptions of the rides.
+func BuildIndex(rides []*RideSlim) *Index {
+	ix := &Index{postings: make(map[string][]posting)}
+	for _, ride := range rides {
+		counts := make(map[string]*posting)
+		add := func(text string, name bool) {
+			for _, w := range indexWords(text) {
+				p := counts[w]
+				if p == nil {
+					p = &posting{ID: ride.ID}
+					counts[w] = p
+				}
+				if name {
+					p.Name++
+				} else {
+					p.Desc++
+				}
+			}
+		}
+		add(ride.Name, true)
+		add(ride.Description, false)
+		for w, p := range counts {
+			ix.postings[w] = append(ix.postings[w], *p)
+		}
+	}
+	ix.sortWords()
+
+	return ix
+}
+
+func (ix *Index) sortWords() {
+	ix.words = make([]string, 0, len(ix.postings))
+	for w := range ix.postings {
+		ix.words = append(ix.words, w)
+	}
+	sort.Strings(ix.words)
+}
+
+// Search returns the IDs of the rides matching all the words of the query,
+// best first. Case and diacritics are ignored, and each word also matches the
+// words it starts, so "tir" finds "tire" and "tired". Words in a ride's name
+// count more than ones in its description, and whole words more than
+// prefixes. Rides that rank the same are newest (highest ID) first.
+func (ix *Index) Search(query string) []int {
+	var scores map[int]int
+	for _, term := range indexWords(query) {
+		termScores := make(map[int]int)
+		for i := sort.SearchStrings(ix.words, term); i < len(ix.words) && strings.HasPrefix(ix.words[i], term); i++ {
+			w := ix.words[i]
+			weight := 1
+			if w == term {
+				weight = 2
+			}
+			for _, p := range ix.postings[w] {
+				termScores[p.ID] = max(termScores[p.ID], weight*(indexNameWeight*p.Name+p.Desc))
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	res := []int{}
+	for id := range scores {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if scores[res[i]] != scores[res[j]] {
+			return scores[res[i]] > scores[res[j]]
+		}
+		return res[i] > res[j]
+	})
+
+	return res
+}
+
+type indexJSON struct {
+	Version  int                  `json:"version"`
+	Postings map[string][]posting `json:"postings"`
+}
+
+func (ix *Index) MarshalJSON() ([]byte, error) {
+	return json.Marshal(indexJSON{Version: indexVersion, Postings: ix.postings})
+}
+
+func (ix *Index) UnmarshalJSON(data []byte) error {
+	var raw indexJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bad index: %w", err)
+	}
+	if raw.Version != indexVersion {
+		return fmt.Errorf("unsupported index version %d, want %d", raw.Version, indexVersion)
+	}
+	ix.postings = raw.Postings
+	if ix.postings == nil {
+		ix.postings = make(map[string][]posting)
+	}
+	ix.sortWords()
+
+	return nil
+}
+
+// Save writes the index to a file, for LoadIndex.
+func (ix *Index) Save(path string) error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return fmt.Errorf("can't encode index: %w", err)
+	}
+	if err := atomicfile.WriteFile(path, data); err != nil {
+		return fmt.Errorf("can't save index: %w", err)
+	}
+	return nil
+}
+
+// LoadIndex reads an index written by Save. A missing file returns an error
+// matching os.ErrNotExist, so the index can be built instead.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read index: %w", err)
+	}
+	ix := &Index{}
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, fmt.Errorf("can't load index from %s: %w", path, err)
+	}
+	return ix, nil
+}
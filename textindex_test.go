@@ -0,0 +1,82 @@
+package goride
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func indexedRides() []*RideSlim {
+	return []*RideSlim{
+		{ID: 1, Name: "Flat tire on Skyline"},
+		{ID: 2, Name: "Morning Ride", Description: "Got a flat tire near Woodside, fixed it quickly."},
+		{ID: 3, Name: "Café ride", Description: "Coffee at the café in Sausalito"},
+		{ID: 4, Name: "Tired legs", Description: "Flat route along the bay."},
+		{ID: 5, Name: "Crème brûlée run", Description: "Dessert at Niño's bakery in Łódź"},
+	}
+}
+
+var indexQueries = []struct {
+	desc  string
+	query string
+	want  []int
+}{
+	{desc: "name beats description", query: "flat tire", want: []int{1, 4, 2}},
+	{desc: "case", query: "FLAT", want: []int{1, 4, 2}},
+	{desc: "prefix", query: "tir", want: []int{4, 1, 2}},
+	{desc: "all words", query: "flat woodside", want: []int{2}},
+	{desc: "diacritics", query: "creme brulee", want: []int{5}},
+	{desc: "diacritics in the query", query: "Café", want: []int{3}},
+	{desc: "apostrophe", query: "nino", want: []int{5}},
+	{desc: "letters with strokes", query: "lodz", want: []int{5}},
+	{desc: "punctuation", query: "bay.", want: []int{4}},
+	{desc: "no match", query: "pizza", want: []int{}},
+	{desc: "one word missing", query: "flat pizza", want: []int{}},
+	{desc: "empty", query: " ", want: []int{}},
+}
+
+func TestIndexSearch(t *testing.T) {
+	ix := BuildIndex(indexedRides())
+	for _, tc := range indexQueries {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ix.Search(tc.query)); diff != "" {
+				t.Errorf("bad results for %q: -want +got\n%s", tc.query, diff)
+			}
+		})
+	}
+}
+
+func TestIndexSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if _, err := LoadIndex(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want os.ErrNotExist for a missing index, got %v", err)
+	}
+
+	if err := BuildIndex(indexedRides()).Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ix, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(BuildIndex(indexedRides()), ix, cmp.AllowUnexported(Index{}, posting{})); diff != "" {
+		t.Errorf("index didn't round trip: -want +got\n%s", diff)
+	}
+	for _, tc := range indexQueries {
+		if diff := cmp.Diff(tc.want, ix.Search(tc.query)); diff != "" {
+			t.Errorf("bad results for %q after loading: -want +got\n%s", tc.query, diff)
+		}
+	}
+
+	writeTestFile(t, path, `{"version":2,"postings":{}}`)
+	if _, err := LoadIndex(path); err == nil {
+		t.Errorf("expected an error for an index in another version")
+	}
+	writeTestFile(t, path, `{"version":1,`)
+	if _, err := LoadIndex(path); err == nil {
+		t.Errorf("expected an error for a bad index")
+	}
+}
@@ -0,0 +1,124 @@
+package goride
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxZoom is the zoom used for tracks that are a single point.
+const maxZoom = 18
+
+// ThumbOptions describe the static map service ThumbnailURL builds links for.
+type ThumbOptions struct {
+	// Template is the service's URL, with placeholders for the values:
+	// {key}, {width}, {height}, {lat}, {lng}, {zoom} and {polyline}, e.g.
+	// "https://maps.example.com/static?key={key}&size={width}x{height}&path=enc:{polyline}".
+	// The polyline is URL escaped, the other values are used as is.
+	Template string
+	APIKey   string
+	// Width and Height of the image, in pixels. Default to 600x400.
+	Width  int
+	Height int
+	// MaxURLLength is the longest URL the service accepts. The track is
+	// simplified to fit. Defaults to 8192.
+	MaxURLLength int
+}
+
+func (o ThumbOptions) withDefaults() ThumbOptions {
+	if o.Width == 0 {
+		o.Width = 600
+	}
+	if o.Height == 0 {
+		o.Height = 400
+	}
+	if o.MaxURLLength == 0 {
+		o.MaxURLLength = 8192
+	}
+	return o
+}
+
+// ThumbnailURL builds a link to a static map image of the track. Points
+// without a position are left out.
+func ThumbnailURL(points []TrackPoint, opts ThumbOptions) (string, error) {
+	opts = opts.withDefaults()
+	if !strings.Contains(opts.Template, "{polyline}") {
+		return "", fmt.Errorf("thumbnail template %q has no {polyline}", opts.Template)
+	}
+
+	box := TrackBounds(points)
+	if box.Empty() {
+		return "", fmt.Errorf("can't make a thumbnail of a track without positions")
+	}
+	center := box.Center()
+	fill := strings.NewReplacer(
+		"{key}", opts.APIKey,
+		"{width}", strconv.Itoa(opts.Width),
+		"{height}", strconv.Itoa(opts.Height),
+		"{lat}", formatCoord(center.Lat),
+		"{lng}", formatCoord(center.Lng),
+		"{zoom}", strconv.Itoa(FitZoom(box, opts.Width, opts.Height)),
+	)
+	base := fill.Replace(opts.Template)
+
+	// Each copy of the polyline gets an equal share of what's left.
+	n := strings.Count(base, "{polyline}")
+	budget := (opts.MaxURLLength - len(base) + n*len("{polyline}")) / n
+	enc, err := thumbnailPolyline(points, budget)
+	if err != nil {
+		return "", fmt.Errorf("can't fit thumbnail in %d characters: %w", opts.MaxURLLength, err)
+	}
+
+	return strings.ReplaceAll(base, "{polyline}", url.QueryEscape(enc)), nil
+}
+
+// TrackBounds returns the smallest box around the track's points. It's Empty
+// if none of them have a position.
+func TrackBounds(points []TrackPoint) BoundingBox {
+	minLat, minLng := math.Inf(1), math.Inf(1)
+	maxLat, maxLng := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		if p.Lat == 0 && p.Lng == 0 {
+			continue
+		}
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+		minLng, maxLng = math.Min(minLng, p.Lng), math.Max(maxLng, p.Lng)
+	}
+	if math.IsInf(minLat, 1) {
+		return BoundingBox{SW: LatLng{Lat: 1, Lng: 1}}
+	}
+
+	return BoundingBox{
+		SW: LatLng{Lat: float32(minLat), Lng: float32(minLng)},
+		NE: LatLng{Lat: float32(maxLat), Lng: float32(maxLng)},
+	}
+}
+
+// Center returns the middle of the box.
+func (b BoundingBox) Center() LatLng {
+	return LatLng{Lat: (b.SW.Lat + b.NE.Lat) / 2, Lng: (b.SW.Lng + b.NE.Lng) / 2}
+}
+
+// FitZoom returns the highest web map zoom level (256 pixel tiles) at which
+// the box fits in a width x height image.
+func FitZoom(b BoundingBox, width, height int) int {
+	// Web Mercator, where the whole world is 1x1 at zoom 0.
+	mercY := func(lat float32) float64 {
+		s := math.Sin(float64(lat) * math.Pi / 180)
+		return 0.5 - math.Log((1+s)/(1-s))/(4*math.Pi)
+	}
+	dx := float64(b.NE.Lng-b.SW.Lng) / 360
+	dy := mercY(b.SW.Lat) - mercY(b.NE.Lat)
+
+	zoom := maxZoom
+	if dx > 0 {
+		zoom = min(zoom, int(math.Floor(math.Log2(float64(width)/256/dx))))
+	}
+	if dy > 0 {
+		zoom = min(zoom, int(math.Floor(math.Log2(float64(height)/256/dy))))
+	}
+
+	return max(zoom, 0)
+}
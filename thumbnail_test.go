@@ -0,0 +1,178 @@
+package goride
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestThumbnailURL(t *testing.T) {
+	ride := getTestRide(t)
+	tmpl := "https://maps.example.com/static?key={key}&size={width}x{height}&center={lat},{lng}&zoom={zoom}&path=enc:{polyline}"
+
+	tests := []struct {
+		desc    string
+		points  []TrackPoint
+		opts    ThumbOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			desc:   "defaults",
+			points: ride.TrackPoints,
+			opts:   ThumbOptions{Template: tmpl, APIKey: "sekrit"},
+			want:   []string{"key=sekrit&", "size=600x400&", "path=enc:"},
+		},
+		{
+			desc:   "short",
+			points: ride.TrackPoints,
+			opts:   ThumbOptions{Template: tmpl, APIKey: "sekrit", Width: 200, Height: 100, MaxURLLength: 400},
+			want:   []string{"size=200x100&"},
+		},
+		{
+			desc:    "no room",
+			points:  ride.TrackPoints,
+			opts:    ThumbOptions{Template: tmpl, MaxURLLength: 100},
+			wantErr: true,
+		},
+		{
+			desc:    "no polyline",
+			points:  ride.TrackPoints,
+			opts:    ThumbOptions{Template: "https://maps.example.com/static"},
+			wantErr: true,
+		},
+		{
+			desc:    "no positions",
+			points:  []TrackPoint{{}, {}},
+			opts:    ThumbOptions{Template: tmpl},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ThumbnailURL(tc.points, tc.opts)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			max := tc.opts.withDefaults().MaxURLLength
+			if len(got) > max {
+				t.Errorf("url too long: %d > %d", len(got), max)
+			}
+			if strings.Contains(got, "{") {
+				t.Errorf("unfilled placeholder in %q", got)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("%q not in %q", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTrackBounds(t *testing.T) {
+	tests := []struct {
+		desc       string
+		points     []TrackPoint
+		want       BoundingBox
+		wantCenter LatLng
+		wantEmpty  bool
+	}{
+		{
+			desc: "track",
+			points: []TrackPoint{
+				{Lat: 45.5, Lng: -122.6},
+				{},
+				{Lat: 45.25, Lng: -122.7},
+				{Lat: 45.75, Lng: -122.5},
+			},
+			want:       BoundingBox{SW: LatLng{Lat: 45.25, Lng: -122.7}, NE: LatLng{Lat: 45.75, Lng: -122.5}},
+			wantCenter: LatLng{Lat: 45.5, Lng: -122.6},
+		},
+		{
+			desc:       "single point",
+			points:     []TrackPoint{{Lat: 10, Lng: 20}},
+			want:       BoundingBox{SW: LatLng{Lat: 10, Lng: 20}, NE: LatLng{Lat: 10, Lng: 20}},
+			wantCenter: LatLng{Lat: 10, Lng: 20},
+		},
+		{
+			desc:      "no positions",
+			points:    []TrackPoint{{}},
+			wantEmpty: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := TrackBounds(tc.points)
+			if got.Empty() != tc.wantEmpty {
+				t.Fatalf("Empty(): want %v, got %v", tc.wantEmpty, got.Empty())
+			}
+			if tc.wantEmpty {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("bad bounds: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantCenter, got.Center()); diff != "" {
+				t.Errorf("bad center: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFitZoom(t *testing.T) {
+	tests := []struct {
+		desc          string
+		box           BoundingBox
+		width, height int
+		want          int
+	}{
+		{
+			desc:  "whole world",
+			box:   BoundingBox{SW: LatLng{Lat: -85, Lng: -180}, NE: LatLng{Lat: 85, Lng: 180}},
+			width: 256, height: 256,
+			want: 0,
+		},
+		{
+			desc:  "one tile at zoom 8",
+			box:   BoundingBox{SW: LatLng{Lat: 0, Lng: 0}, NE: LatLng{Lat: 0.001, Lng: 1.40625}},
+			width: 256, height: 256,
+			want: 8,
+		},
+		{
+			desc:  "wider image",
+			box:   BoundingBox{SW: LatLng{Lat: 0, Lng: 0}, NE: LatLng{Lat: 0.001, Lng: 1.40625}},
+			width: 1024, height: 256,
+			want: 10,
+		},
+		{
+			desc:  "limited by height",
+			box:   BoundingBox{SW: LatLng{Lat: 45, Lng: -122.6}, NE: LatLng{Lat: 46, Lng: -122.5}},
+			width: 600, height: 400,
+			want: 8,
+		},
+		{
+			desc:  "single point",
+			box:   BoundingBox{SW: LatLng{Lat: 10, Lng: 20}, NE: LatLng{Lat: 10, Lng: 20}},
+			width: 600, height: 400,
+			want: maxZoom,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := FitZoom(tc.box, tc.width, tc.height); got != tc.want {
+				t.Errorf("want zoom %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
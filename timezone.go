@@ -0,0 +1,52 @@
+package goride
+
+import (
+	"sync"
+	"time"
+)
+
+// LocalDepartedAt returns DepartedAt in the ride's own timezone.
+func (r *RideSlim) LocalDepartedAt() time.Time {
+	return localTime(r.DepartedAt, r.TimeZone, r.UtcOffset)
+}
+
+// LocalStarted returns Started in the ride's own timezone.
+func (r *Ride) LocalStarted() time.Time {
+	return localTime(r.Started, r.TimeZone, r.UtcOffset)
+}
+
+// localTime moves t into the named timezone, falling back to a fixed offset
+// (in seconds) if the name is missing or unknown. With neither, t is returned
+// as is.
+func localTime(t time.Time, tz string, offset int) time.Time {
+	if tz != "" {
+		if loc, err := loadLocation(tz); err == nil {
+			return t.In(loc)
+		}
+	}
+	if offset != 0 {
+		return t.In(time.FixedZone("", offset))
+	}
+
+	return t
+}
+
+// locations caches loadLocation's results by name.
+var locations sync.Map
+
+type locationResult struct {
+	loc *time.Location
+	err error
+}
+
+// loadLocation is time.LoadLocation, which reads the zoneinfo database every
+// time, remembering what it returned. Unknown names are remembered too.
+func loadLocation(name string) (*time.Location, error) {
+	if res, ok := locations.Load(name); ok {
+		return res.(locationResult).loc, res.(locationResult).err
+	}
+	loc, err := time.LoadLocation(name)
+	locations.Store(name, locationResult{loc, err})
+
+	return loc, err
+}
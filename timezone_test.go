@@ -0,0 +1,97 @@
+package goride
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalDepartedAt(t *testing.T) {
+	tests := []struct {
+		desc     string
+		departed time.Time
+		tz       string
+		offset   int
+		want     string
+	}{
+		{
+			desc:     "named zone, summer time",
+			departed: time.Date(2019, 8, 2, 4, 48, 25, 0, time.UTC),
+			tz:       "America/Los_Angeles",
+			offset:   -28800,
+			want:     "2019-08-01T21:48:25-07:00",
+		},
+		{
+			desc:     "named zone, winter time",
+			departed: time.Date(2019, 1, 2, 4, 48, 25, 0, time.UTC),
+			tz:       "America/Los_Angeles",
+			want:     "2019-01-01T20:48:25-08:00",
+		},
+		{
+			desc:     "spring forward",
+			departed: time.Date(2021, 3, 14, 10, 30, 0, 0, time.UTC),
+			tz:       "America/Los_Angeles",
+			want:     "2021-03-14T03:30:00-07:00",
+		},
+		{
+			desc:     "southern hemisphere",
+			departed: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			tz:       "Australia/Sydney",
+			want:     "2021-01-01T11:00:00+11:00",
+		},
+		{
+			desc:     "unknown zone falls back to the offset",
+			departed: time.Date(2019, 8, 2, 4, 48, 25, 0, time.UTC),
+			tz:       "Mars/Olympus_Mons",
+			offset:   -28800,
+			want:     "2019-08-01T20:48:25-08:00",
+		},
+		{
+			desc:     "offset only",
+			departed: time.Date(2019, 8, 2, 4, 48, 25, 0, time.UTC),
+			offset:   19800,
+			want:     "2019-08-02T10:18:25+05:30",
+		},
+		{
+			desc:     "no zone",
+			departed: time.Date(2019, 8, 2, 4, 48, 25, 0, time.UTC),
+			want:     "2019-08-02T04:48:25Z",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			slim := &RideSlim{DepartedAt: tc.departed, TimeZone: tc.tz, UtcOffset: tc.offset}
+			ride := &Ride{Started: tc.departed, TimeZone: tc.tz, UtcOffset: tc.offset}
+
+			for _, got := range []time.Time{slim.LocalDepartedAt(), ride.LocalStarted()} {
+				if s := got.Format(time.RFC3339); s != tc.want {
+					t.Errorf("bad local time: want %s, got %s", tc.want, s)
+				}
+				if !got.Equal(tc.departed) {
+					t.Errorf("local time %s isn't the same instant as %s", got, tc.departed)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	for _, name := range []string{"America/Los_Angeles", "Mars/Olympus_Mons"} {
+		want, wantErr := time.LoadLocation(name)
+		for i := 0; i < 2; i++ {
+			got, err := loadLocation(name)
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("loadLocation(%q): want error %v, got %v", name, wantErr, err)
+			}
+			if err == nil && got.String() != want.String() {
+				t.Errorf("loadLocation(%q): got %v", name, got)
+			}
+		}
+	}
+
+	a, _ := loadLocation("Australia/Sydney")
+	b, _ := loadLocation("Australia/Sydney")
+	if a != b {
+		t.Errorf("loadLocation didn't cache the location")
+	}
+}
@@ -0,0 +1,82 @@
+package goride
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TotalsSource is where UserTotals.Years came from.
+type TotalsSource int
+
+const (
+	// TotalsFromServer is the server's own breakdown.
+	TotalsFromServer TotalsSource = iota
+	// TotalsFromRides is computed from all the user's rides, for when the
+	// server doesn't send one.
+	TotalsFromRides
+)
+
+// UserTotals are a user's lifetime totals, as shown on their profile, and a
+// breakdown by year. Years are keyed by "2006", like Aggregate with
+// GroupByYear. Only totals computed from rides have Longest set.
+type UserTotals struct {
+	Lifetime    Totals
+	Years       map[string]Totals
+	YearsSource TotalsSource
+}
+
+// totalsJSON is a block of totals as the server sends it. Distance and
+// elevation are in meters, and the moving time in seconds.
+type totalsJSON struct {
+	Year          int     `json:"year"`
+	Count         int     `json:"count"`
+	Distance      float64 `json:"distance"`
+	ElevationGain float64 `json:"elevation_gain"`
+	MovingTime    int     `json:"moving_time"`
+}
+
+func (t totalsJSON) totals() Totals {
+	return Totals{
+		Rides:         t.Count,
+		Distance:      t.Distance,
+		ElevationGain: t.ElevationGain,
+		MovingTime:    time.Duration(t.MovingTime) * time.Second,
+	}
+}
+
+// GetUserTotals gets a user's totals. If the server doesn't break them down by
+// year, the years are computed from all the user's rides, see AllRides, which
+// can take a while for users with a lot of them.
+func (r *RWGPS) GetUserTotals(userID int) (*UserTotals, error) {
+	var resStruct struct {
+		Totals *struct {
+			Lifetime totalsJSON   `json:"lifetime"`
+			Years    []totalsJSON `json:"years"`
+		} `json:"user_totals"`
+	}
+	if err := r.getJSON(fmt.Sprintf("/users/%d/totals.json", userID), nil, &resStruct); err != nil {
+		return nil, fmt.Errorf("error getting totals for user %d: %w", userID, err)
+	}
+	if resStruct.Totals == nil {
+		return nil, fmt.Errorf("error getting totals for user %d: %w", userID, ErrNotFound)
+	}
+
+	res := &UserTotals{Lifetime: resStruct.Totals.Lifetime.totals(), Years: make(map[string]Totals)}
+	if years := resStruct.Totals.Years; years != nil {
+		for _, y := range years {
+			res.Years[strconv.Itoa(y.Year)] = y.totals()
+		}
+		return res, nil
+	}
+
+	r.log().Debug("no yearly totals from the server, computing them from rides", "user", userID)
+	rides, err := r.AllRides(userID)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute yearly totals for user %d: %w", userID, err)
+	}
+	res.Years = Aggregate(rides, GroupByYear)
+	res.YearsSource = TotalsFromRides
+
+	return res, nil
+}
@@ -0,0 +1,94 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGetUserTotals(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/1268590/totals.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("user_totals.json"))
+	})
+	r := testObj(f.URL)
+
+	got, err := r.GetUserTotals(1268590)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &UserTotals{
+		Lifetime: Totals{Rides: 3073, Distance: 61234567.8, ElevationGain: 612345.6, MovingTime: 9876543 * time.Second},
+		Years: map[string]Totals{
+			"2019": {Rides: 1000, Distance: 20000000, ElevationGain: 200000, MovingTime: 3200000 * time.Second},
+			"2020": {Rides: 1100, Distance: 22000000.5, ElevationGain: 220000.1, MovingTime: 3500000 * time.Second},
+			"2021": {Rides: 973, Distance: 19234567.3, ElevationGain: 192345.5, MovingTime: 3176543 * time.Second},
+		},
+		YearsSource: TotalsFromServer,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad totals: -want +got\n%s", diff)
+	}
+
+	// The totals match the user's own count, and the years add up.
+	u, err := r.loggedInUser()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Lifetime.Rides != u.TotalTrips {
+		t.Errorf("lifetime rides %d don't match the user's %d", got.Lifetime.Rides, u.TotalTrips)
+	}
+	sum := 0
+	for _, y := range got.Years {
+		sum += y.Rides
+	}
+	if sum != u.TotalTrips {
+		t.Errorf("yearly rides add up to %d, want %d", sum, u.TotalTrips)
+	}
+
+	if _, err := r.GetUserTotals(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing user: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetUserTotalsFromRides(t *testing.T) {
+	f := newFakeRWGPS(t)
+	f.handle("/users/2/totals.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, getTestData("user_totals_lifetime.json"))
+	})
+	f.handle("/users/2/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"results":[
+			{"id":1,"departed_at":"2020-12-31T20:00:00Z","distance":30000,"elevation_gain":400,"moving_time":4200},
+			{"id":2,"departed_at":"2021-01-02T08:00:00Z","distance":45000,"elevation_gain":600,"moving_time":6300},
+			{"id":3,"departed_at":"2021-03-04T08:00:00Z","distance":15000,"elevation_gain":200,"moving_time":2100}
+		],"results_count":3}`)
+	})
+	r := testObj(f.URL)
+
+	got, err := r.GetUserTotals(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.YearsSource != TotalsFromRides {
+		t.Errorf("want yearly totals from rides, got %v", got.YearsSource)
+	}
+	want := map[string]Totals{
+		"2020": {Rides: 1, Distance: 30000, ElevationGain: 400, MovingTime: 4200 * time.Second},
+		"2021": {Rides: 2, Distance: 60000, ElevationGain: 800, MovingTime: 8400 * time.Second},
+	}
+	if diff := cmp.Diff(want, got.Years, cmpopts.IgnoreFields(Totals{}, "Longest")); diff != "" {
+		t.Errorf("bad yearly totals: -want +got\n%s", diff)
+	}
+	if got.Years["2021"].Longest.ID != 2 {
+		t.Errorf("bad longest ride in 2021: %+v", got.Years["2021"].Longest)
+	}
+	wantLifetime := Totals{Rides: 3, Distance: 90000, ElevationGain: 1200, MovingTime: 12600 * time.Second}
+	if diff := cmp.Diff(wantLifetime, got.Lifetime); diff != "" {
+		t.Errorf("bad lifetime totals: -want +got\n%s", diff)
+	}
+}
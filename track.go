@@ -0,0 +1,55 @@
+package goride
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TrackPoint is a single sample along a ride or route. Speed is in km/h,
+// distances and elevations in meters.
+type TrackPoint struct {
+	Time        time.Time `json:"-"`
+	Lat         float64   `json:"y,omitempty"`
+	Lng         float64   `json:"x,omitempty"`
+	Elevation   float64   `json:"e,omitempty"`
+	Distance    float64   `json:"d,omitempty"`
+	Speed       float64   `json:"s,omitempty"`
+	Grade       float64   `json:"g,omitempty"`
+	HeartRate   float64   `json:"h,omitempty"`
+	Cadence     float64   `json:"c,omitempty"`
+	Power       float64   `json:"p,omitempty"`
+	Temperature float64   `json:"T,omitempty"`
+	// Surface is the surface from this point to the next, on routes.
+	Surface Surface `json:"S,omitempty"`
+	// Interpolated is set on points made up by resampling or merging, see
+	// ResampleByTime and MergeTracksWithOpts.
+	Interpolated bool `json:"-"`
+}
+
+type trackPoint TrackPoint
+
+type trackPointJSON struct {
+	*trackPoint
+	Epoch int64 `json:"t,omitempty"`
+}
+
+func (p *TrackPoint) UnmarshalJSON(data []byte) error {
+	w := trackPointJSON{trackPoint: (*trackPoint)(p)}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	if w.Epoch != 0 {
+		p.Time = time.Unix(w.Epoch, 0).UTC()
+	}
+
+	return nil
+}
+
+func (p TrackPoint) MarshalJSON() ([]byte, error) {
+	w := trackPointJSON{trackPoint: (*trackPoint)(&p)}
+	if !p.Time.IsZero() {
+		w.Epoch = p.Time.Unix()
+	}
+
+	return json.Marshal(w)
+}
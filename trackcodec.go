@@ -0,0 +1,328 @@
+package goride
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// The binary track format, see MarshalTrackPoints, is a header, the number of
+// points, and then one column per field, each starting with how it's encoded.
+const (
+	trackMagic   = "GRTP"
+	trackVersion = 1
+)
+
+// How a column is encoded.
+const (
+	// columnAbsent is a column that's zero for every point, and isn't written.
+	columnAbsent = iota
+	// columnDelta is a column of integers, written as the varint difference
+	// from the previous point's. Float columns are in fixed point, with the
+	// power of ten they're scaled by written first.
+	columnDelta
+	// columnRaw is a column of values that aren't exact in fixed point,
+	// written as they are.
+	columnRaw
+)
+
+// errBadTrackData is wrapped by the errors for data UnmarshalTrackPoints can't
+// read.
+var errBadTrackData = errors.New("bad track data")
+
+// maxColumnDigits is the most decimal digits a float column can have and
+// still be written in fixed point.
+const maxColumnDigits = 9
+
+// trackColumns are the float fields of TrackPoint, in the order they're
+// written. New ones can only be added at the end.
+var trackColumns = []func(p *TrackPoint) *float64{
+	func(p *TrackPoint) *float64 { return &p.Lat },
+	func(p *TrackPoint) *float64 { return &p.Lng },
+	func(p *TrackPoint) *float64 { return &p.Elevation },
+	func(p *TrackPoint) *float64 { return &p.Distance },
+	func(p *TrackPoint) *float64 { return &p.Speed },
+	func(p *TrackPoint) *float64 { return &p.Grade },
+	func(p *TrackPoint) *float64 { return &p.HeartRate },
+	func(p *TrackPoint) *float64 { return &p.Cadence },
+	func(p *TrackPoint) *float64 { return &p.Power },
+	func(p *TrackPoint) *float64 { return &p.Temperature },
+}
+
+// MarshalTrackPoints encodes track points in a compact binary format, for
+// caching. It's lossless, except that, as in the JSON, a time at the Unix
+// epoch reads back as the zero time. Tracks from the server, whose values are
+// rounded, take a small fraction of the size of their JSON. Read it back with
+// UnmarshalTrackPoints.
+func MarshalTrackPoints(points []TrackPoint) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(trackMagic)
+	buf.WriteByte(trackVersion)
+	writeUvarint(&buf, uint64(len(points)))
+
+	// Times are whole seconds unless any of them isn't. A zero time is 0, as
+	// in the JSON. They're always written, so every point takes at least a
+	// byte.
+	nanos := false
+	for _, p := range points {
+		if !p.Time.IsZero() && p.Time.Nanosecond() != 0 {
+			nanos = true
+		}
+	}
+	if nanos {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.WriteByte(columnDelta)
+	writeDeltas(&buf, points, func(p *TrackPoint) int64 {
+		switch {
+		case p.Time.IsZero():
+			return 0
+		case nanos:
+			return p.Time.UnixNano()
+		}
+		return p.Time.Unix()
+	})
+
+	for _, c := range trackColumns {
+		writeFloatColumn(&buf, points, c)
+	}
+	writeIntColumn(&buf, points, func(p *TrackPoint) int64 { return int64(p.Surface) })
+	writeIntColumn(&buf, points, func(p *TrackPoint) int64 {
+		if p.Interpolated {
+			return 1
+		}
+		return 0
+	})
+
+	return buf.Bytes()
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+}
+
+func writeVarint(buf *bytes.Buffer, v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutVarint(tmp[:], v)])
+}
+
+func writeIntColumn(buf *bytes.Buffer, points []TrackPoint, get func(p *TrackPoint) int64) {
+	absent := true
+	for i := range points {
+		if get(&points[i]) != 0 {
+			absent = false
+			break
+		}
+	}
+	if absent {
+		buf.WriteByte(columnAbsent)
+		return
+	}
+	buf.WriteByte(columnDelta)
+	writeDeltas(buf, points, get)
+}
+
+func writeDeltas(buf *bytes.Buffer, points []TrackPoint, get func(p *TrackPoint) int64) {
+	var prev int64
+	for i := range points {
+		v := get(&points[i])
+		writeVarint(buf, v-prev)
+		prev = v
+	}
+}
+
+// fixedPoint returns v in fixed point, and false if that isn't exactly v.
+func fixedPoint(v, scale float64) (int64, bool) {
+	f := math.Round(v * scale)
+	if math.IsNaN(f) || math.Abs(f) > 1<<53 || f/scale != v {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// columnDigits returns the fewest decimal digits that all the column's values
+// are exact in, or false if that's more than maxColumnDigits.
+func columnDigits(points []TrackPoint, get func(p *TrackPoint) *float64) (int, bool) {
+digits:
+	for digits := 0; digits <= maxColumnDigits; digits++ {
+		scale := math.Pow10(digits)
+		for i := range points {
+			if _, ok := fixedPoint(*get(&points[i]), scale); !ok {
+				continue digits
+			}
+		}
+		return digits, true
+	}
+	return 0, false
+}
+
+func writeFloatColumn(buf *bytes.Buffer, points []TrackPoint, get func(p *TrackPoint) *float64) {
+	absent := true
+	for i := range points {
+		if *get(&points[i]) != 0 {
+			absent = false
+			break
+		}
+	}
+	if absent {
+		buf.WriteByte(columnAbsent)
+		return
+	}
+
+	digits, ok := columnDigits(points, get)
+	if !ok {
+		buf.WriteByte(columnRaw)
+		var tmp [8]byte
+		for i := range points {
+			binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(*get(&points[i])))
+			buf.Write(tmp[:])
+		}
+		return
+	}
+	buf.WriteByte(columnDelta)
+	buf.WriteByte(byte(digits))
+	scale := math.Pow10(digits)
+	writeDeltas(buf, points, func(p *TrackPoint) int64 {
+		v, _ := fixedPoint(*get(p), scale)
+		return v
+	})
+}
+
+// UnmarshalTrackPoints decodes track points written by MarshalTrackPoints.
+func UnmarshalTrackPoints(data []byte) ([]TrackPoint, error) {
+	rd := bytes.NewReader(data)
+	header := make([]byte, len(trackMagic)+1)
+	if _, err := io.ReadFull(rd, header); err != nil || string(header[:len(trackMagic)]) != trackMagic {
+		return nil, fmt.Errorf("%w: not a track", errBadTrackData)
+	}
+	if v := header[len(trackMagic)]; v != trackVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d, want %d", errBadTrackData, v, trackVersion)
+	}
+	n, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errBadTrackData, err)
+	}
+	// Every point takes at least a byte, so a count past that is corrupt,
+	// and mustn't be allocated.
+	if n > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: %d points in %d bytes", errBadTrackData, n, len(data))
+	}
+	points := make([]TrackPoint, n)
+
+	nanos, err := rd.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%w: times: %w", errBadTrackData, err)
+	}
+	times, err := readIntColumn(rd, len(points))
+	if err != nil {
+		return nil, fmt.Errorf("%w: times: %w", errBadTrackData, err)
+	}
+	for i, t := range times {
+		switch {
+		case t == 0:
+		case nanos == 1:
+			points[i].Time = time.Unix(0, t).UTC()
+		default:
+			points[i].Time = time.Unix(t, 0).UTC()
+		}
+	}
+
+	for ci, c := range trackColumns {
+		if err := readFloatColumn(rd, points, c); err != nil {
+			return nil, fmt.Errorf("%w: column %d: %w", errBadTrackData, ci, err)
+		}
+	}
+	surfaces, err := readIntColumn(rd, len(points))
+	if err != nil {
+		return nil, fmt.Errorf("%w: surfaces: %w", errBadTrackData, err)
+	}
+	interpolated, err := readIntColumn(rd, len(points))
+	if err != nil {
+		return nil, fmt.Errorf("%w: interpolated: %w", errBadTrackData, err)
+	}
+	for i := range points {
+		if surfaces != nil {
+			points[i].Surface = Surface(surfaces[i])
+		}
+		if interpolated != nil {
+			points[i].Interpolated = interpolated[i] != 0
+		}
+	}
+	if rd.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d bytes left over", errBadTrackData, rd.Len())
+	}
+
+	return points, nil
+}
+
+// readIntColumn returns nil for an absent column.
+func readIntColumn(rd *bytes.Reader, n int) ([]int64, error) {
+	mode, err := rd.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case columnAbsent:
+		return nil, nil
+	case columnDelta:
+	default:
+		return nil, fmt.Errorf("bad encoding %d", mode)
+	}
+	res := make([]int64, n)
+	var prev int64
+	for i := range res {
+		d, err := binary.ReadVarint(rd)
+		if err != nil {
+			return nil, err
+		}
+		prev += d
+		res[i] = prev
+	}
+	return res, nil
+}
+
+func readFloatColumn(rd *bytes.Reader, points []TrackPoint, get func(p *TrackPoint) *float64) error {
+	mode, err := rd.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case columnAbsent:
+	case columnDelta:
+		digits, err := rd.ReadByte()
+		if err != nil {
+			return err
+		}
+		if digits > maxColumnDigits {
+			return fmt.Errorf("bad scale 1e%d", digits)
+		}
+		scale := math.Pow10(int(digits))
+		var prev int64
+		for i := range points {
+			d, err := binary.ReadVarint(rd)
+			if err != nil {
+				return err
+			}
+			prev += d
+			*get(&points[i]) = float64(prev) / scale
+		}
+	case columnRaw:
+		var tmp [8]byte
+		for i := range points {
+			if _, err := io.ReadFull(rd, tmp[:]); err != nil {
+				return err
+			}
+			*get(&points[i]) = math.Float64frombits(binary.LittleEndian.Uint64(tmp[:]))
+		}
+	default:
+		return fmt.Errorf("bad encoding %d", mode)
+	}
+	return nil
+}
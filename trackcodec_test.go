@@ -0,0 +1,119 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func fixtureTrackPoints(t *testing.T, name string) []TrackPoint {
+	t.Helper()
+	var res struct {
+		Trip struct {
+			TrackPoints []TrackPoint `json:"track_points"`
+		} `json:"trip"`
+		Route struct {
+			TrackPoints []TrackPoint `json:"track_points"`
+		} `json:"route"`
+	}
+	if err := decodeJSON(getTestData(name), &res); err != nil {
+		t.Fatalf("can't decode %s: %v", name, err)
+	}
+	return append(res.Trip.TrackPoints, res.Route.TrackPoints...)
+}
+
+func TestTrackPointsBinary(t *testing.T) {
+	start := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		// maxRatio is the largest the binary can be, as a fraction of the
+		// JSON.
+		maxRatio float64
+	}{
+		{
+			desc:     "ride",
+			points:   fixtureTrackPoints(t, "trip.json"),
+			maxRatio: 0.2,
+		},
+		{
+			desc:   "route with surfaces",
+			points: fixtureTrackPoints(t, "route_surface.json"),
+		},
+		{
+			desc: "resampled",
+			points: []TrackPoint{
+				{Time: start, Lat: 45.1, Lng: -122.1, Distance: 0},
+				{Time: start.Add(1500 * time.Millisecond), Lat: 45.10000004, Lng: -122.10000003, Distance: 1.0 / 3, Interpolated: true},
+				{Time: start.Add(3 * time.Second), Lat: 45.2, Lng: -122.2, Distance: 2},
+			},
+		},
+		{
+			desc: "missing values",
+			points: []TrackPoint{
+				{Lat: 45.1, Lng: -122.1},
+				{Time: start, HeartRate: 140},
+				{},
+			},
+		},
+		{
+			desc:   "zero points",
+			points: make([]TrackPoint, 100),
+		},
+		{
+			desc:   "empty",
+			points: []TrackPoint{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			data := MarshalTrackPoints(tc.points)
+			got, err := UnmarshalTrackPoints(data)
+			if err != nil {
+				t.Fatalf("UnmarshalTrackPoints(): %v", err)
+			}
+			if diff := cmp.Diff(tc.points, got); diff != "" {
+				t.Errorf("round trip: -want +got:\n%s", diff)
+			}
+
+			if tc.maxRatio == 0 {
+				return
+			}
+			js, err := json.Marshal(tc.points)
+			if err != nil {
+				t.Fatalf("can't marshal: %v", err)
+			}
+			if ratio := float64(len(data)) / float64(len(js)); ratio > tc.maxRatio {
+				t.Errorf("binary is %d bytes, %.2f of the %d bytes of JSON, want at most %.2f", len(data), ratio, len(js), tc.maxRatio)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTrackPointsErrors(t *testing.T) {
+	good := MarshalTrackPoints(fixtureTrackPoints(t, "trip.json"))
+	tests := []struct {
+		desc string
+		data []byte
+	}{
+		{desc: "empty"},
+		{desc: "not a track", data: []byte("{\"track_points\":[]}")},
+		{desc: "other version", data: append([]byte("GRTP\x02"), good[5:]...)},
+		{desc: "truncated", data: good[:len(good)/2]},
+		{desc: "left over", data: append(append([]byte(nil), good...), 0)},
+		{desc: "too many points", data: []byte("GRTP\x01\xff\xff\xff\xff\x0f")},
+		{desc: "bad encoding", data: []byte("GRTP\x01\x01\x00\x07")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := UnmarshalTrackPoints(tc.data); !errors.Is(err, errBadTrackData) {
+				t.Errorf("UnmarshalTrackPoints(): want errBadTrackData, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package goride
+
+import (
+	"fmt"
+	"time"
+)
+
+// SplitTrack splits a track in two at a time, e.g. to cut off the drive home
+// from a ride that wasn't stopped. Points before at go in the first half, and
+// the rest, including any point exactly at at, in the second. If at is before
+// the track, the first half is empty, and if it's after, the second is. The
+// second half's Distance starts from 0, and TrackMetrics gives the summary of
+// each half. Points must have times, in order. The input isn't changed.
+func SplitTrack(points []TrackPoint, at time.Time) ([]TrackPoint, []TrackPoint, error) {
+	for i, p := range points {
+		if p.Time.IsZero() {
+			return nil, nil, fmt.Errorf("point %d has no time", i)
+		}
+		if i > 0 && p.Time.Before(points[i-1].Time) {
+			return nil, nil, fmt.Errorf("point %d is out of order: %s before %s", i, p.Time, points[i-1].Time)
+		}
+	}
+
+	k := 0
+	for k < len(points) && points[k].Time.Before(at) {
+		k++
+	}
+	first, second := splitTrackAt(points, trackDistances(points), k)
+	return first, second, nil
+}
+
+// SplitAtDistance is SplitTrack, splitting meters along the track instead.
+// Points must be in order along the track.
+func SplitAtDistance(points []TrackPoint, meters float64) ([]TrackPoint, []TrackPoint, error) {
+	dist := trackDistances(points)
+	for i := 1; i < len(points); i++ {
+		if dist[i] < dist[i-1] {
+			return nil, nil, fmt.Errorf("point %d is out of order: distance %f before %f", i, dist[i], dist[i-1])
+		}
+	}
+
+	k := 0
+	for k < len(points) && dist[k] < meters {
+		k++
+	}
+	first, second := splitTrackAt(points, dist, k)
+	return first, second, nil
+}
+
+// TrimAfter returns the part of the track before at, see SplitTrack.
+func TrimAfter(points []TrackPoint, at time.Time) ([]TrackPoint, error) {
+	first, _, err := SplitTrack(points, at)
+	return first, err
+}
+
+// TrimBefore returns the part of the track from at on, see SplitTrack.
+func TrimBefore(points []TrackPoint, at time.Time) ([]TrackPoint, error) {
+	_, second, err := SplitTrack(points, at)
+	return second, err
+}
+
+// splitTrackAt copies the points before k and from k on, setting their
+// Distance from dist, starting from 0 in each half.
+func splitTrackAt(points []TrackPoint, dist []float64, k int) ([]TrackPoint, []TrackPoint) {
+	first := make([]TrackPoint, k)
+	for i := range first {
+		first[i] = withDistance(points[i], dist[i]-dist[0])
+	}
+	second := make([]TrackPoint, len(points)-k)
+	for i := range second {
+		second[i] = withDistance(points[k+i], dist[k+i]-dist[k])
+	}
+	return first, second
+}
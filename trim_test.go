@@ -0,0 +1,152 @@
+package goride
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSplitTrack(t *testing.T) {
+	// 21 points a second and 10m apart, at 200W for 10s then 100W.
+	points := workout(workoutStep{10 * time.Second, 200, 140}, workoutStep{10 * time.Second, 100, 120})
+	start := points[0].Time
+	// part is points[lo:hi], with the distances from 0.
+	part := func(lo, hi int) []TrackPoint {
+		res := []TrackPoint{}
+		for _, p := range points[lo:hi] {
+			p.Distance -= float64(lo * 10)
+			res = append(res, p)
+		}
+		return res
+	}
+
+	tests := []struct {
+		desc       string
+		at         time.Time
+		meters     float64
+		wantFirst  []TrackPoint
+		wantSecond []TrackPoint
+	}{
+		{
+			desc:       "on a point",
+			at:         start.Add(10 * time.Second),
+			meters:     100,
+			wantFirst:  part(0, 10),
+			wantSecond: part(10, 21),
+		},
+		{
+			desc:       "between points",
+			at:         start.Add(9500 * time.Millisecond),
+			meters:     95,
+			wantFirst:  part(0, 10),
+			wantSecond: part(10, 21),
+		},
+		{
+			desc:       "first point",
+			at:         start,
+			meters:     0,
+			wantFirst:  part(0, 0),
+			wantSecond: part(0, 21),
+		},
+		{
+			desc:       "last point",
+			at:         start.Add(20 * time.Second),
+			meters:     200,
+			wantFirst:  part(0, 20),
+			wantSecond: part(20, 21),
+		},
+		{
+			desc:       "before the track",
+			at:         start.Add(-time.Hour),
+			meters:     -10,
+			wantFirst:  part(0, 0),
+			wantSecond: part(0, 21),
+		},
+		{
+			desc:       "after the track",
+			at:         start.Add(time.Hour),
+			meters:     1000,
+			wantFirst:  part(0, 21),
+			wantSecond: part(21, 21),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			first, second, err := SplitTrack(points, tc.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantFirst, first); diff != "" {
+				t.Errorf("bad first half: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSecond, second); diff != "" {
+				t.Errorf("bad second half: -want +got\n%s", diff)
+			}
+
+			first, second, err = SplitAtDistance(points, tc.meters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantFirst, first); diff != "" {
+				t.Errorf("bad first half by distance: -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSecond, second); diff != "" {
+				t.Errorf("bad second half by distance: -want +got\n%s", diff)
+			}
+		})
+	}
+
+	if _, _, err := SplitTrack([]TrackPoint{points[1], points[0]}, start); err == nil {
+		t.Errorf("expected an error splitting points out of order")
+	}
+	if _, _, err := SplitTrack([]TrackPoint{{Lat: 45, Lng: -122}}, start); err == nil {
+		t.Errorf("expected an error splitting points without times")
+	}
+	if _, _, err := SplitAtDistance([]TrackPoint{points[2], points[1]}, 5); err == nil {
+		t.Errorf("expected an error splitting points out of order")
+	}
+}
+
+func TestSplitTrackMetrics(t *testing.T) {
+	points := workout(workoutStep{10 * time.Second, 200, 140}, workoutStep{10 * time.Second, 100, 120})
+	first, second, err := SplitTrack(points, points[10].Time)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantFirst := Metrics{Distance: 90, Duration: 9, MovingTime: 9, FirstTime: points[0].Time.Unix()}
+	wantFirst.Speed.Avg, wantFirst.Speed.Max = 36, 36
+	wantFirst.HR.Avg, wantFirst.HR.Min, wantFirst.HR.Max = 140, 140, 140
+	wantFirst.Watts.Avg, wantFirst.Watts.Min, wantFirst.Watts.Max = 200, 200, 200
+	// The workout's last point has no sensor values.
+	wantSecond := Metrics{Distance: 100, Duration: 10, MovingTime: 10, FirstTime: points[10].Time.Unix()}
+	wantSecond.Speed.Avg, wantSecond.Speed.Max = 36, 36
+	wantSecond.HR.Avg, wantSecond.HR.Min, wantSecond.HR.Max = 120, 120, 120
+	wantSecond.Watts.Avg, wantSecond.Watts.Min, wantSecond.Watts.Max = 100, 100, 100
+
+	opt := cmpopts.EquateApprox(0, 1e-3)
+	if diff := cmp.Diff(wantFirst, TrackMetrics(first), opt); diff != "" {
+		t.Errorf("bad first half metrics: -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(wantSecond, TrackMetrics(second), opt); diff != "" {
+		t.Errorf("bad second half metrics: -want +got\n%s", diff)
+	}
+
+	trimmed, err := TrimAfter(points, points[10].Time)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(first, trimmed); diff != "" {
+		t.Errorf("TrimAfter doesn't match the first half: -want +got\n%s", diff)
+	}
+	trimmed, err = TrimBefore(points, points[10].Time)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(second, trimmed); diff != "" {
+		t.Errorf("TrimBefore doesn't match the second half: -want +got\n%s", diff)
+	}
+}
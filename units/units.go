@@ -0,0 +1,98 @@
+// Package units converts and formats the distances, speeds and elevations
+// returned by the RWGPS API.
+package units
+
+import (
+	"math"
+	"strconv"
+)
+
+const (
+	metersPerMile = 1609.344
+	metersPerFoot = 0.3048
+)
+
+// Distance is a distance in meters, as returned by the API.
+type Distance float64
+
+func (d Distance) Meters() float64 { return float64(d) }
+func (d Distance) Km() float64     { return float64(d) / 1000 }
+func (d Distance) Miles() float64  { return float64(d) / metersPerMile }
+
+// Speed is a speed in km/h, as returned by the API.
+type Speed float64
+
+// MetersPerSecond makes a Speed from meters per second.
+func MetersPerSecond(v float64) Speed {
+	return Speed(v * 3.6)
+}
+
+func (s Speed) Kph() float64 { return float64(s) }
+func (s Speed) Mph() float64 { return float64(s) * 1000 / metersPerMile }
+
+// Elevation is a height, or a change in height, in meters.
+type Elevation float64
+
+func (e Elevation) Meters() float64 { return float64(e) }
+func (e Elevation) Feet() float64   { return float64(e) / metersPerFoot }
+
+// System is the set of units values are shown in.
+type System int
+
+const (
+	Metric System = iota
+	Imperial
+)
+
+func (s System) String() string {
+	if s == Imperial {
+		return "imperial"
+	}
+	return "metric"
+}
+
+// FormatDistance shows the distance in km or miles, rounded to the nearest
+// 0.1, e.g. "42.9 km".
+func (s System) FormatDistance(d Distance) string {
+	if s == Imperial {
+		return format(d.Miles(), 1) + " mi"
+	}
+	return format(d.Km(), 1) + " km"
+}
+
+// FormatShortDistance shows the distance in whole km or miles, e.g. "43 km".
+func (s System) FormatShortDistance(d Distance) string {
+	if s == Imperial {
+		return format(d.Miles(), 0) + " mi"
+	}
+	return format(d.Km(), 0) + " km"
+}
+
+// FormatSpeed shows the speed in km/h or mph, rounded to the nearest 0.1, e.g.
+// "23.9 km/h".
+func (s System) FormatSpeed(v Speed) string {
+	if s == Imperial {
+		return format(v.Mph(), 1) + " mph"
+	}
+	return format(v.Kph(), 1) + " km/h"
+}
+
+// FormatElevation shows the elevation in whole meters or feet, e.g. "754 m".
+func (s System) FormatElevation(e Elevation) string {
+	if s == Imperial {
+		return format(e.Feet(), 0) + " ft"
+	}
+	return format(e.Meters(), 0) + " m"
+}
+
+// format rounds half away from zero, so 0.05 shows as 0.1 even though it's
+// stored as slightly less than that.
+func format(v float64, prec int) string {
+	scale := math.Pow(10, float64(prec))
+	r := math.Round(v*scale+math.Copysign(1e-9, v)) / scale
+	if r == 0 {
+		// No "-0.0".
+		r = 0
+	}
+	return strconv.FormatFloat(r, 'f', prec, 64)
+}
@@ -0,0 +1,60 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		desc string
+		got  float64
+		want float64
+	}{
+		{desc: "km", got: Distance(42990.7).Km(), want: 42.9907},
+		{desc: "miles", got: Distance(1609.344).Miles(), want: 1},
+		{desc: "kph", got: Speed(23.9).Kph(), want: 23.9},
+		{desc: "mph", got: Speed(1.609344).Mph(), want: 1},
+		{desc: "m/s", got: MetersPerSecond(10).Kph(), want: 36},
+		{desc: "feet", got: Elevation(0.3048).Feet(), want: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if math.Abs(tc.got-tc.want) > 1e-9 {
+				t.Errorf("want %f, got %f", tc.want, tc.got)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		desc string
+		got  string
+		want string
+	}{
+		{desc: "km", got: Metric.FormatDistance(42990.7), want: "43.0 km"},
+		{desc: "km rounds down", got: Metric.FormatDistance(12340), want: "12.3 km"},
+		{desc: "km half rounds up", got: Metric.FormatDistance(50), want: "0.1 km"},
+		{desc: "miles", got: Imperial.FormatDistance(42990.7), want: "26.7 mi"},
+		{desc: "zero", got: Metric.FormatDistance(0), want: "0.0 km"},
+		{desc: "tiny negative", got: Metric.FormatDistance(-1), want: "0.0 km"},
+		{desc: "short km", got: Metric.FormatShortDistance(42490.7), want: "42 km"},
+		{desc: "short miles", got: Imperial.FormatShortDistance(42990.7), want: "27 mi"},
+		{desc: "kph", got: Metric.FormatSpeed(23.902175), want: "23.9 km/h"},
+		{desc: "mph", got: Imperial.FormatSpeed(23.902175), want: "14.9 mph"},
+		{desc: "meters", got: Metric.FormatElevation(754.3168), want: "754 m"},
+		{desc: "meters half", got: Metric.FormatElevation(2.5), want: "3 m"},
+		{desc: "feet", got: Imperial.FormatElevation(754.3168), want: "2475 ft"},
+		{desc: "descent", got: Metric.FormatElevation(-12.5), want: "-13 m"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, tc.got)
+			}
+		})
+	}
+}
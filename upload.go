@@ -0,0 +1,79 @@
+package goride
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// rideFormats are the ride file types the server accepts.
+var rideFormats = map[string]bool{".fit": true, ".gpx": true, ".tcx": true}
+
+// UploadOptions are the details to set on an uploaded ride. Zero fields are
+// left to the server, which uses the user's defaults.
+type UploadOptions struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Visibility  *Visibility `json:"visibility,omitempty"`
+	GearID      int         `json:"gear_id,omitempty"`
+}
+
+// UploadRide uploads a FIT, GPX or TCX file as a new ride, and returns its
+// ID. The type is taken from the filename's extension. The ride may still be
+// processing when this returns, see WaitForRide.
+func (r *RWGPS) UploadRide(file io.Reader, filename string, opts UploadOptions) (int, error) {
+	if !rideFormats[strings.ToLower(filepath.Ext(filename))] {
+		return 0, fmt.Errorf("can't upload %q: unsupported ride format", filename)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filepath.Base(filename))
+	if err != nil {
+		return 0, fmt.Errorf("can't create upload for %q: %w", filename, err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return 0, fmt.Errorf("can't read %q: %w", filename, err)
+	}
+	fields := map[string]string{"trip[name]": opts.Name, "trip[description]": opts.Description}
+	if opts.Visibility != nil {
+		fields["trip[visibility]"] = strconv.Itoa(int(*opts.Visibility))
+	}
+	if opts.GearID != 0 {
+		fields["trip[gear_id]"] = strconv.Itoa(opts.GearID)
+	}
+	for _, k := range []string{"trip[name]", "trip[description]", "trip[visibility]", "trip[gear_id]"} {
+		if v := fields[k]; v != "" {
+			if err := mw.WriteField(k, v); err != nil {
+				return 0, fmt.Errorf("can't create upload for %q: %w", filename, err)
+			}
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return 0, fmt.Errorf("can't create upload for %q: %w", filename, err)
+	}
+
+	res, err := r.doWithType(http.MethodPost, "/trips.json", nil, body.Bytes(), mw.FormDataContentType())
+	if err != nil {
+		return 0, fmt.Errorf("error uploading %q: %w", filename, err)
+	}
+
+	var resStruct struct {
+		Trip struct {
+			ID int
+		}
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return 0, err
+	}
+	if resStruct.Trip.ID == 0 {
+		return 0, fmt.Errorf("upload of %q didn't return a ride: %w", filename, ErrDecode)
+	}
+
+	return resStruct.Trip.ID, nil
+}
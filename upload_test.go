@@ -0,0 +1,58 @@
+package goride
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUploadRide(t *testing.T) {
+	f := newFakeRWGPS(t)
+	var got map[string]string
+	f.handle("/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = map[string]string{}
+		for k, v := range req.MultipartForm.Value {
+			got[k] = v[0]
+		}
+		file, hdr, err := req.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		got["file"] = hdr.Filename + ": " + string(data)
+		fmt.Fprint(w, `{"success":1,"trip":{"id":777}}`)
+	})
+	r := testObj(f.URL)
+
+	vis := FriendsOnly
+	id, err := r.UploadRide(strings.NewReader("<gpx/>"), "/tmp/Morning.GPX", UploadOptions{Name: "Morning", Visibility: &vis, GearID: 17})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 777 {
+		t.Errorf("want ride 777, got %d", id)
+	}
+	want := map[string]string{
+		"file":             "Morning.GPX: <gpx/>",
+		"trip[name]":       "Morning",
+		"trip[visibility]": "2",
+		"trip[gear_id]":    "17",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad upload: -want +got\n%s", diff)
+	}
+
+	if _, err := r.UploadRide(strings.NewReader(""), "ride.csv", UploadOptions{}); err == nil {
+		t.Errorf("expected an error uploading a CSV")
+	}
+}
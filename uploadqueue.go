@@ -0,0 +1,209 @@
+package goride
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+const (
+	queuePending = "pending"
+	queueDone    = "done"
+)
+
+// UploadJob is a ride file waiting in an UploadQueue.
+type UploadJob struct {
+	ID       string        `json:"id"`
+	Path     string        `json:"path"`
+	Options  UploadOptions `json:"options"`
+	Queued   time.Time     `json:"queued"`
+	Attempts int           `json:"attempts"`
+	// Error is why the last attempt failed.
+	Error string `json:"error,omitempty"`
+	// RideID is set as soon as the upload succeeds.
+	RideID int `json:"ride_id,omitempty"`
+}
+
+// QueueOptions control how an UploadQueue retries rate limited uploads.
+type QueueOptions struct {
+	RetryOptions
+}
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	o.RetryOptions = o.RetryOptions.withDefaults()
+	return o
+}
+
+// UploadQueue holds ride uploads in a directory until they can be sent, e.g.
+// once there's a connection again. Each job is a JSON file in dir/pending,
+// moved to dir/done once uploaded.
+type UploadQueue struct {
+	dir  string
+	r    *RWGPS
+	opts QueueOptions
+}
+
+// FlushStats count what a Flush did.
+type FlushStats struct {
+	Uploaded int
+	Failed   int
+}
+
+// NewUploadQueue opens the queue in dir, creating it if needed.
+func (r *RWGPS) NewUploadQueue(dir string, opts QueueOptions) (*UploadQueue, error) {
+	for _, sub := range []string{queuePending, queueDone} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, fmt.Errorf("can't create queue dir %q: %w", dir, err)
+		}
+	}
+	// Leftovers from a write that didn't finish.
+	tmps, _ := filepath.Glob(filepath.Join(dir, queuePending, atomicfile.TempPattern))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+
+	return &UploadQueue{dir: dir, r: r, opts: opts.withDefaults()}, nil
+}
+
+// Enqueue adds a ride file to the queue, and returns the job's ID. The file
+// is read when the queue is flushed, so it has to stay where it is until then.
+func (q *UploadQueue) Enqueue(path string, opts UploadOptions) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("can't queue %q: %w", path, err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return "", fmt.Errorf("can't queue %q: %w", path, err)
+	}
+	if !rideFormats[strings.ToLower(filepath.Ext(abs))] {
+		return "", fmt.Errorf("can't queue %q: unsupported ride format", path)
+	}
+
+	now := time.Now().UTC()
+	rnd := make([]byte, 4)
+	if _, err := rand.Read(rnd); err != nil {
+		return "", fmt.Errorf("can't queue %q: %w", path, err)
+	}
+	// IDs sort in the order the jobs were queued.
+	job := &UploadJob{
+		ID:      now.Format("20060102T150405.000000000") + "-" + hex.EncodeToString(rnd),
+		Path:    abs,
+		Options: opts,
+		Queued:  now,
+	}
+	if err := q.save(job); err != nil {
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+// Pending lists the jobs that haven't been uploaded yet, oldest first, with
+// the error from their last attempt.
+func (q *UploadQueue) Pending() ([]*UploadJob, error) {
+	return q.list(queuePending)
+}
+
+// Done lists the jobs that were uploaded, oldest first.
+func (q *UploadQueue) Done() ([]*UploadJob, error) {
+	return q.list(queueDone)
+}
+
+// Flush tries to upload each pending job. Jobs that fail stay queued, with
+// their error, for the next Flush. A job's ride ID is saved before it's moved
+// to done, so a job that was uploaded but not moved, e.g. because the process
+// was killed, isn't uploaded again. Flush stops early if ctx is done.
+func (q *UploadQueue) Flush(ctx context.Context) (FlushStats, error) {
+	var stats FlushStats
+	jobs, err := q.Pending()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		if job.RideID == 0 {
+			job.Attempts++
+			err := q.r.retryRateLimited(ctx, q.opts.RetryOptions, func() error {
+				f, err := os.Open(job.Path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				job.RideID, err = q.r.UploadRide(f, job.Path, job.Options)
+				return err
+			})
+			if err != nil {
+				job.Error = err.Error()
+				stats.Failed++
+				if err := q.save(job); err != nil {
+					return stats, err
+				}
+				continue
+			}
+			job.Error = ""
+			if err := q.save(job); err != nil {
+				return stats, fmt.Errorf("uploaded %q as ride %d, but can't record it: %w", job.Path, job.RideID, err)
+			}
+			stats.Uploaded++
+		}
+
+		from := filepath.Join(q.dir, queuePending, job.ID+".json")
+		if err := os.Rename(from, filepath.Join(q.dir, queueDone, job.ID+".json")); err != nil {
+			return stats, fmt.Errorf("can't move job %s to done: %w", job.ID, err)
+		}
+	}
+
+	if stats.Failed > 0 {
+		return stats, fmt.Errorf("%d of %d uploads failed", stats.Failed, len(jobs))
+	}
+
+	return stats, nil
+}
+
+func (q *UploadQueue) save(job *UploadJob) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode job %s: %w", job.ID, err)
+	}
+	if err := atomicfile.WriteFile(filepath.Join(q.dir, queuePending, job.ID+".json"), data); err != nil {
+		return fmt.Errorf("can't save job %s: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+func (q *UploadQueue) list(sub string) ([]*UploadJob, error) {
+	paths, err := filepath.Glob(filepath.Join(q.dir, sub, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("can't list jobs: %w", err)
+	}
+	sort.Strings(paths)
+
+	var res []*UploadJob
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("can't read job %q: %w", p, err)
+		}
+		job := &UploadJob{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("bad job %q: %w: %w", p, ErrDecode, err)
+		}
+		res = append(res, job)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package goride
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// flakyUploads serves uploads, failing the first try of each file in fail.
+func flakyUploads(t *testing.T, f *fakeRWGPS, fail ...string) map[string]int {
+	uploads := make(map[string]int)
+	failed := make(map[string]bool)
+	for _, name := range fail {
+		failed[name] = false
+	}
+	f.handle("/trips.json", func(w http.ResponseWriter, req *http.Request) {
+		_, hdr, err := req.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if done, ok := failed[hdr.Filename]; ok && !done {
+			failed[hdr.Filename] = true
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		uploads[hdr.Filename]++
+		fmt.Fprintf(w, `{"trip":{"id":%d}}`, 1000+len(uploads))
+	})
+	return uploads
+}
+
+func TestUploadQueue(t *testing.T) {
+	f := newFakeRWGPS(t)
+	uploads := flakyUploads(t, f, "b.fit")
+	r := testObj(f.URL)
+
+	src := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.gpx", "b.fit", "c.tcx"} {
+		p := filepath.Join(src, name)
+		writeTestFile(t, p, "ride")
+		paths = append(paths, p)
+	}
+
+	dir := t.TempDir()
+	q, err := r.NewUploadQueue(dir, QueueOptions{RetryOptions{RetryWait: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("can't open queue: %v", err)
+	}
+	for _, p := range paths {
+		if _, err := q.Enqueue(p, UploadOptions{}); err != nil {
+			t.Fatalf("can't queue %q: %v", p, err)
+		}
+	}
+	if _, err := q.Enqueue(filepath.Join(src, "missing.gpx"), UploadOptions{}); err == nil {
+		t.Errorf("expected an error queueing a missing file")
+	}
+
+	// Offline for b.fit.
+	stats, err := q.Flush(context.Background())
+	if err == nil {
+		t.Errorf("expected an error for the failed upload")
+	}
+	if diff := cmp.Diff(FlushStats{Uploaded: 2, Failed: 1}, stats); diff != "" {
+		t.Errorf("bad first flush: -want +got\n%s", diff)
+	}
+	pending, _ := q.Pending()
+	if len(pending) != 1 || pending[0].Path != paths[1] || pending[0].Error == "" || pending[0].Attempts != 1 {
+		t.Fatalf("want b.fit pending with its error, got %+v", pending)
+	}
+
+	// A new process picks up where the last one left off.
+	q, err = r.NewUploadQueue(dir, QueueOptions{RetryOptions{RetryWait: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("can't reopen queue: %v", err)
+	}
+	stats, err = q.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(FlushStats{Uploaded: 1}, stats); diff != "" {
+		t.Errorf("bad second flush: -want +got\n%s", diff)
+	}
+
+	// Killed after uploading, but before the job was moved to done.
+	done, _ := q.Done()
+	if len(done) != 3 {
+		t.Fatalf("want 3 done jobs, got %d", len(done))
+	}
+	last := done[len(done)-1].ID + ".json"
+	if err := os.Rename(filepath.Join(dir, queueDone, last), filepath.Join(dir, queuePending, last)); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = q.Flush(context.Background())
+	if err != nil || stats != (FlushStats{}) {
+		t.Errorf("recovered job was uploaded again: %+v, %v", stats, err)
+	}
+
+	want := map[string]int{"a.gpx": 1, "b.fit": 1, "c.tcx": 1}
+	if diff := cmp.Diff(want, uploads); diff != "" {
+		t.Errorf("files weren't uploaded exactly once: -want +got\n%s", diff)
+	}
+	if pending, _ := q.Pending(); len(pending) != 0 {
+		t.Errorf("jobs left pending: %+v", pending)
+	}
+	for _, j := range done {
+		if j.RideID == 0 {
+			t.Errorf("done job without a ride: %+v", j)
+		}
+	}
+}
+
+func TestUploadQueueCancel(t *testing.T) {
+	f := newFakeRWGPS(t)
+	uploads := flakyUploads(t, f)
+	r := testObj(f.URL)
+
+	p := filepath.Join(t.TempDir(), "a.gpx")
+	writeTestFile(t, p, "ride")
+	q, err := r.NewUploadQueue(t.TempDir(), QueueOptions{})
+	if err != nil {
+		t.Fatalf("can't open queue: %v", err)
+	}
+	if _, err := q.Enqueue(p, UploadOptions{}); err != nil {
+		t.Fatalf("can't queue: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Flush(ctx); err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if len(uploads) != 0 {
+		t.Errorf("uploaded after being cancelled: %v", uploads)
+	}
+}
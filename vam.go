@@ -0,0 +1,95 @@
+package goride
+
+// Grades outside this range are treated as this steep by the cost model,
+// which isn't measured beyond it.
+const maxCostGrade = 0.45
+
+// VAM is the average rate of ascent on a climb, in vertical meters per hour.
+// Points without an elevation get one interpolated from their neighbors. It's
+// 0 if the ends of the climb don't have times.
+func VAM(c Climb, points []TrackPoint) float64 {
+	if c.Start < 0 || c.End >= len(points) || c.End <= c.Start {
+		return 0
+	}
+	from, to := points[c.Start].Time, points[c.End].Time
+	if from.IsZero() || to.IsZero() || !to.After(from) {
+		return 0
+	}
+
+	elev := trackElevations(points)
+	return (elev[c.End] - elev[c.Start]) / to.Sub(from).Hours()
+}
+
+// GradeAdjustedSpeed is the average moving speed in km/h, with each stretch
+// weighted by how hard its grade is compared to riding on the flat, using
+// Minetti's cost model. Points without an elevation get one interpolated from
+// their neighbors.
+func GradeAdjustedSpeed(points []TrackPoint) float64 {
+	dist := trackDistances(points)
+	elev := trackElevations(points)
+	flat := gradeCost(0)
+
+	var adjusted, hours float64
+	for i := 1; i < len(points); i++ {
+		d := dist[i] - dist[i-1]
+		dt := points[i].Time.Sub(points[i-1].Time)
+		if d <= 0 || dt <= 0 || dt > splitMaxGap {
+			continue
+		}
+		if speed := d / 1000 / dt.Hours(); speed < splitStopSpeed || speed > splitMaxSpeed {
+			continue
+		}
+		adjusted += d * gradeCost((elev[i]-elev[i-1])/d) / flat
+		hours += dt.Hours()
+	}
+	if hours == 0 {
+		return 0
+	}
+
+	return adjusted / 1000 / hours
+}
+
+// gradeCost is the energy cost of moving a meter at a grade (as a fraction),
+// from Minetti et al. (2002), in J/kg/m.
+func gradeCost(g float64) float64 {
+	g = max(-maxCostGrade, min(g, maxCostGrade))
+	return ((((155.4*g-30.4)*g-43.3)*g+46.3)*g+19.5)*g + 3.6
+}
+
+// trackElevations returns the elevation at each point. Points without one
+// (a zero elevation, when others have one) are interpolated by distance
+// between the nearest points that have one, or take the nearest one's
+// elevation at the ends of the track.
+func trackElevations(points []TrackPoint) []float64 {
+	res := make([]float64, len(points))
+	var known []int
+	for i, p := range points {
+		res[i] = p.Elevation
+		if p.Elevation != 0 {
+			known = append(known, i)
+		}
+	}
+	if len(known) == 0 {
+		return res
+	}
+
+	dist := trackDistances(points)
+	for i := 0; i < known[0]; i++ {
+		res[i] = res[known[0]]
+	}
+	for i := known[len(known)-1] + 1; i < len(res); i++ {
+		res[i] = res[known[len(known)-1]]
+	}
+	for k := 1; k < len(known); k++ {
+		a, b := known[k-1], known[k]
+		for i := a + 1; i < b; i++ {
+			frac := float64(i-a) / float64(b-a)
+			if span := dist[b] - dist[a]; span > 0 {
+				frac = (dist[i] - dist[a]) / span
+			}
+			res[i] = res[a] + frac*(res[b]-res[a])
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,161 @@
+package goride
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// timedRoute is a track starting at 500m elevation, with a point every 100m.
+// Each segment is its length in meters, grade, and seconds per 100m.
+func timedRoute(segments ...[3]float64) []TrackPoint {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	p := TrackPoint{Time: start, Elevation: 500}
+	res := []TrackPoint{p}
+	for _, s := range segments {
+		for d := 0.0; d < s[0]; d += 100 {
+			p.Distance += 100
+			p.Elevation += 100 * s[1]
+			p.Time = p.Time.Add(time.Duration(s[2] * float64(time.Second)))
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// withoutElevation clears the elevation of the points at idx.
+func withoutElevation(points []TrackPoint, idx ...int) []TrackPoint {
+	res := append([]TrackPoint(nil), points...)
+	for _, i := range idx {
+		res[i].Elevation = 0
+	}
+	return res
+}
+
+// withoutTimes clears the time of every point.
+func withoutTimes(points []TrackPoint) []TrackPoint {
+	res := append([]TrackPoint(nil), points...)
+	for i := range res {
+		res[i].Time = time.Time{}
+	}
+	return res
+}
+
+func TestVAM(t *testing.T) {
+	// 2km flat at 12km/h, then 1km at 10% taking 10 minutes: 100m up in 1/6
+	// of an hour.
+	climb := timedRoute([3]float64{2000, 0, 30}, [3]float64{1000, 0.1, 60})
+	tests := []struct {
+		desc   string
+		climb  Climb
+		points []TrackPoint
+		want   float64
+	}{
+		{
+			desc:   "climb",
+			climb:  Climb{Start: 20, End: 30},
+			points: climb,
+			want:   600,
+		},
+		{
+			desc:   "half the climb",
+			climb:  Climb{Start: 25, End: 30},
+			points: climb,
+			want:   600,
+		},
+		{
+			desc:   "from the flat",
+			climb:  Climb{Start: 10, End: 30},
+			points: climb,
+			want:   100 / (15.0 / 60),
+		},
+		{
+			desc:   "missing elevation",
+			climb:  Climb{Start: 20, End: 30},
+			points: withoutElevation(climb, 22, 23, 24, 25),
+			want:   600,
+		},
+		{
+			desc:   "missing end elevation",
+			climb:  Climb{Start: 20, End: 29},
+			points: withoutElevation(climb, 29),
+			want:   90 / (9.0 / 60),
+		},
+		{
+			desc:   "no times",
+			climb:  Climb{Start: 20, End: 30},
+			points: withoutTimes(climb),
+		},
+		{
+			desc:   "bad climb",
+			climb:  Climb{Start: 20, End: 31},
+			points: climb,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := VAM(tc.climb, tc.points); math.Abs(got-tc.want) > 1e-6 {
+				t.Errorf("bad VAM: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGradeAdjustedSpeed(t *testing.T) {
+	// Minetti's cost at 0%, 10% and -10%.
+	flat, up, down := 3.6, 5.968214, 2.151706
+	climb := timedRoute([3]float64{1000, 0, 30}, [3]float64{1000, 0.1, 60})
+	tests := []struct {
+		desc   string
+		points []TrackPoint
+		want   float64
+	}{
+		{
+			desc:   "flat",
+			points: timedRoute([3]float64{1000, 0, 30}),
+			want:   12,
+		},
+		{
+			desc:   "climb",
+			points: timedRoute([3]float64{1000, 0.1, 60}),
+			want:   6 * up / flat,
+		},
+		{
+			desc:   "descent",
+			points: timedRoute([3]float64{1000, -0.1, 20}),
+			want:   18 * down / flat,
+		},
+		{
+			desc:   "flat then climb",
+			points: climb,
+			want:   (1 + up/flat) / 0.25,
+		},
+		{
+			desc:   "missing elevation",
+			points: withoutElevation(climb, 12, 13, 14, 15, 16),
+			want:   (1 + up/flat) / 0.25,
+		},
+		{
+			desc:   "with a stop",
+			points: timedRoute([3]float64{1000, 0, 30}, [3]float64{100, 0, 600}, [3]float64{1000, 0, 30}),
+			want:   12,
+		},
+		{
+			desc:   "no times",
+			points: withoutTimes(climb),
+		},
+		{
+			desc: "empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := GradeAdjustedSpeed(tc.points); math.Abs(got-tc.want) > 1e-6 {
+				t.Errorf("bad grade adjusted speed: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
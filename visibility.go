@@ -0,0 +1,46 @@
+package goride
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Visibility is who can see a ride or route.
+type Visibility int
+
+const (
+	Public      Visibility = 0
+	Private     Visibility = 1
+	FriendsOnly Visibility = 2
+)
+
+func (v Visibility) String() string {
+	switch v {
+	case Public:
+		return "public"
+	case Private:
+		return "private"
+	case FriendsOnly:
+		return "friends only"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(v))
+	}
+}
+
+func (v Visibility) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(v))), nil
+}
+
+// UnmarshalJSON keeps unknown values as is, so they round trip.
+func (v *Visibility) UnmarshalJSON(data []byte) error {
+	var n *int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("bad visibility %s: %w", data, err)
+	}
+	if n != nil {
+		*v = Visibility(*n)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package goride
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVisibility(t *testing.T) {
+	tests := []struct {
+		wire string
+		want Visibility
+		str  string
+	}{
+		{"0", Public, "public"},
+		{"1", Private, "private"},
+		{"2", FriendsOnly, "friends only"},
+		{"7", Visibility(7), "unknown(7)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.str, func(t *testing.T) {
+			var got Visibility
+			if err := json.Unmarshal([]byte(tc.wire), &got); err != nil {
+				t.Fatalf("error decoding %s: %v", tc.wire, err)
+			}
+			if got != tc.want {
+				t.Errorf("bad visibility: want %d, got %d", tc.want, got)
+			}
+			if got.String() != tc.str {
+				t.Errorf("bad string: want %q, got %q", tc.str, got.String())
+			}
+
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("error encoding %v: %v", got, err)
+			}
+			if string(data) != tc.wire {
+				t.Errorf("bad round trip: want %s, got %s", tc.wire, data)
+			}
+		})
+	}
+
+	var v Visibility
+	if err := json.Unmarshal([]byte("null"), &v); err != nil || v != Public {
+		t.Errorf("expected null to decode to public, got %v, %v", v, err)
+	}
+	if err := json.Unmarshal([]byte(`"public"`), &v); err == nil {
+		t.Errorf("expected an error decoding a string")
+	}
+}
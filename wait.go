@@ -0,0 +1,96 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitOptions control how WaitForRide polls.
+type WaitOptions struct {
+	// Context can cancel the wait. Defaults to context.Background().
+	Context context.Context
+	// Interval is the wait before the second poll, doubling after each one up
+	// to MaxInterval. Default to 2 and 30 seconds.
+	Interval    time.Duration
+	MaxInterval time.Duration
+	// MaxWait is how long to wait overall. Defaults to 5 minutes.
+	MaxWait time.Duration
+	// UpdatedAfter, if set, also waits for the ride to be updated after this
+	// time, e.g. when it's being reprocessed.
+	UpdatedAfter time.Time
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+	if o.Interval == 0 {
+		o.Interval = 2 * time.Second
+	}
+	if o.MaxInterval == 0 {
+		o.MaxInterval = 30 * time.Second
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.MaxWait == 0 {
+		o.MaxWait = 5 * time.Minute
+	}
+	return o
+}
+
+// WaitError is returned when a ride isn't processed in time. Ride is the last
+// version fetched, and Err is the context's error.
+type WaitError struct {
+	Ride  *Ride
+	Polls int
+	Err   error
+}
+
+func (e *WaitError) Error() string {
+	if e.Ride == nil {
+		return fmt.Sprintf("ride not processed after %d polls: %v", e.Polls, e.Err)
+	}
+	return fmt.Sprintf("ride %d not processed after %d polls (last updated %s, %d points): %v",
+		e.Ride.ID, e.Polls, e.Ride.UpdatedAt.Format(time.RFC3339), len(e.Ride.TrackPoints), e.Err)
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForRide polls a ride until the server is done processing it, which
+// takes a while after an upload. If it's not done by MaxWait, or the context
+// is done first, it returns a *WaitError. Rate limited polls just slow down;
+// other errors end the wait.
+func (r *RWGPS) WaitForRide(id int, opts WaitOptions) (*Ride, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(opts.Context, opts.MaxWait)
+	defer cancel()
+
+	var last *Ride
+	interval := opts.Interval
+	for polls := 1; ; polls++ {
+		ride, err := r.GetRide(id)
+		switch {
+		case err == nil && ride.Processed && ride.UpdatedAt.After(opts.UpdatedAfter):
+			return ride, nil
+		case err == nil:
+			last = ride
+		case !errors.Is(err, ErrRateLimited):
+			return nil, err
+		}
+		r.log().Debug("ride not ready", "ride", id, "polls", polls, "next", interval, "err", err)
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, &WaitError{Ride: last, Polls: polls, Err: ctx.Err()}
+		case <-t.C:
+		}
+		interval = min(interval*2, opts.MaxInterval)
+	}
+}
@@ -0,0 +1,106 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// processingHandler serves ride 5, which is processed after ready requests.
+// Requests listed in limited are rate limited instead.
+func processingHandler(ready int, limited ...int) (http.HandlerFunc, *int) {
+	n := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		n++
+		for _, l := range limited {
+			if n == l {
+				http.Error(w, "slow down", http.StatusTooManyRequests)
+				return
+			}
+		}
+		fmt.Fprintf(w, `{"type":"trip","trip":{"id":5,"processed":%v,"updated_at":"2021-08-01T09:00:00Z"}}`, n >= ready)
+	}, &n
+}
+
+func TestWaitForRide(t *testing.T) {
+	tests := []struct {
+		desc      string
+		ready     int
+		limited   []int
+		opts      WaitOptions
+		wantPolls int
+		wantErr   error
+	}{
+		{
+			desc:      "processed",
+			ready:     3,
+			wantPolls: 3,
+		},
+		{
+			desc:      "rate limited",
+			ready:     3,
+			limited:   []int{2},
+			wantPolls: 3,
+		},
+		{
+			desc:      "timeout",
+			ready:     1000,
+			opts:      WaitOptions{MaxWait: 20 * time.Millisecond},
+			wantPolls: -1,
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := newFakeRWGPS(t)
+			h, n := processingHandler(tc.ready, tc.limited...)
+			f.handle("/trips/5.json", h)
+			if tc.opts.Interval == 0 {
+				tc.opts.Interval = time.Millisecond
+			}
+
+			ride, err := testObj(f.URL).WaitForRide(5, tc.opts)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("bad error: want %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantPolls >= 0 && *n != tc.wantPolls {
+				t.Errorf("expected %d polls, got %d", tc.wantPolls, *n)
+			}
+			if err != nil {
+				var we *WaitError
+				if !errors.As(err, &we) || we.Ride == nil || we.Ride.ID != 5 || we.Polls < 2 {
+					t.Errorf("bad wait error: %#v", err)
+				}
+				return
+			}
+			if !ride.Processed {
+				t.Errorf("ride isn't processed: %+v", ride)
+			}
+		})
+	}
+}
+
+func TestWaitForRideErrors(t *testing.T) {
+	f := newFakeRWGPS(t)
+	h, _ := processingHandler(1000)
+	f.handle("/trips/5.json", h)
+	obj := testObj(f.URL)
+
+	if _, err := obj.WaitForRide(6, WaitOptions{Interval: time.Millisecond}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := obj.WaitForRide(5, WaitOptions{Context: ctx, Interval: time.Millisecond})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package goride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zigdon/goride/internal/atomicfile"
+)
+
+const (
+	watchPageSize = 50
+	// watchMaxBackoff is the longest a Watcher waits between polls that fail,
+	// unless its interval is longer.
+	watchMaxBackoff = 30 * time.Minute
+)
+
+// WatchMark is how far a Watcher has got: the creation time of the newest ride
+// it delivered, and the IDs of the rides created at that time, which it
+// delivered too.
+type WatchMark struct {
+	CreatedAt time.Time `json:"created_at"`
+	IDs       []int     `json:"ids"`
+}
+
+// delivered is true if the ride is at or before the mark, so it was either
+// delivered or there before the Watcher started.
+func (m WatchMark) delivered(ride *RideSlim) bool {
+	if !ride.CreatedAt.Equal(m.CreatedAt) {
+		return ride.CreatedAt.Before(m.CreatedAt)
+	}
+	for _, id := range m.IDs {
+		if id == ride.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// add moves the mark past the ride.
+func (m WatchMark) add(ride *RideSlim) WatchMark {
+	if ride.CreatedAt.After(m.CreatedAt) {
+		return WatchMark{CreatedAt: ride.CreatedAt, IDs: []int{ride.ID}}
+	}
+	m.IDs = append(append([]int(nil), m.IDs...), ride.ID)
+	return m
+}
+
+// WatchStore keeps a Watcher's mark for each user, so a restarted Watcher picks
+// up where the last one stopped.
+type WatchStore interface {
+	// LoadMark returns false if there's no mark for the user yet.
+	LoadMark(userID int) (WatchMark, bool, error)
+	SaveMark(userID int, m WatchMark) error
+}
+
+// MemoryWatchStore is a WatchStore that doesn't outlive the process. It's the
+// default.
+type MemoryWatchStore struct {
+	mu    sync.Mutex
+	marks map[int]WatchMark
+}
+
+func NewMemoryWatchStore() *MemoryWatchStore {
+	return &MemoryWatchStore{marks: make(map[int]WatchMark)}
+}
+
+func (s *MemoryWatchStore) LoadMark(userID int) (WatchMark, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.marks[userID]
+	return m, ok, nil
+}
+
+func (s *MemoryWatchStore) SaveMark(userID int, m WatchMark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marks[userID] = m
+	return nil
+}
+
+// FileWatchStore is a WatchStore keeping the marks of all users in a JSON file.
+type FileWatchStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileWatchStore returns a store using the file at path, which is created
+// when the first mark is saved.
+func NewFileWatchStore(path string) *FileWatchStore {
+	return &FileWatchStore{path: path}
+}
+
+func (s *FileWatchStore) load() (map[int]WatchMark, error) {
+	marks := make(map[int]WatchMark)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return marks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read watch marks: %w", err)
+	}
+	if err := json.Unmarshal(data, &marks); err != nil {
+		return nil, fmt.Errorf("bad watch marks in %s: %w", s.path, err)
+	}
+	return marks, nil
+}
+
+func (s *FileWatchStore) LoadMark(userID int) (WatchMark, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	marks, err := s.load()
+	if err != nil {
+		return WatchMark{}, false, err
+	}
+	m, ok := marks[userID]
+	return m, ok, nil
+}
+
+func (s *FileWatchStore) SaveMark(userID int, m WatchMark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	marks, err := s.load()
+	if err != nil {
+		return err
+	}
+	marks[userID] = m
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode watch marks: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("can't save watch marks: %w", err)
+	}
+	return nil
+}
+
+// Watcher polls a user's rides, and calls its subscribers with each new one.
+// Rides that were already there when it first ran aren't new; after that, its
+// mark is saved in its store after each ride, so a restarted Watcher with the
+// same store only delivers the rides it hadn't yet. A ride is never delivered
+// twice by the same Watcher.
+type Watcher struct {
+	r        *RWGPS
+	user     int
+	interval time.Duration
+
+	mu          sync.Mutex
+	store       WatchStore
+	subscribers []func(*RideSlim)
+	mark        WatchMark
+	started     bool
+}
+
+// NewWatcher returns a Watcher for the user's rides, polling every interval
+// once it's Run. The interval has to be positive.
+func NewWatcher(r *RWGPS, userID int, interval time.Duration) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("bad watch interval %v", interval)
+	}
+	return &Watcher{r: r, user: userID, interval: interval, store: NewMemoryWatchStore()}, nil
+}
+
+// SetStore sets where the Watcher keeps its mark. It must be set before the
+// first poll.
+func (w *Watcher) SetStore(s WatchStore) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.store = s
+}
+
+// Subscribe adds a function to call with each new ride. Rides are delivered
+// oldest first, one at a time, from the goroutine polling. The functions can't
+// call the Watcher's methods.
+func (w *Watcher) Subscribe(f func(*RideSlim)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, f)
+}
+
+// Poll checks for new rides once, delivers them, and returns how many there
+// were. The first poll only sets the mark, unless the store already has one.
+func (w *Watcher) Poll() (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		mark, ok, err := w.store.LoadMark(w.user)
+		if err != nil {
+			return 0, fmt.Errorf("can't load the watch mark for user %d: %w", w.user, err)
+		}
+		if ok {
+			w.mark, w.started = mark, true
+		}
+	}
+
+	rides, err := w.newRides()
+	if err != nil {
+		return 0, err
+	}
+	if !w.started {
+		for _, ride := range rides {
+			w.mark = w.mark.add(ride)
+		}
+		w.started = true
+		w.r.log().Debug("watching for new rides", "user", w.user, "since", w.mark.CreatedAt)
+		if err := w.store.SaveMark(w.user, w.mark); err != nil {
+			return 0, fmt.Errorf("can't save the watch mark for user %d: %w", w.user, err)
+		}
+		return 0, nil
+	}
+
+	var saveErr error
+	for _, ride := range rides {
+		for _, f := range w.subscribers {
+			f(ride)
+		}
+		w.mark = w.mark.add(ride)
+		if err := w.store.SaveMark(w.user, w.mark); err != nil && saveErr == nil {
+			saveErr = fmt.Errorf("can't save the watch mark for user %d: %w", w.user, err)
+		}
+	}
+
+	return len(rides), saveErr
+}
+
+// newRides returns the rides past the mark, oldest first. Must be called with
+// the lock held.
+func (w *Watcher) newRides() ([]*RideSlim, error) {
+	opts := GetRidesOpts{SortBy: "created_at", Order: OrderDesc}
+	var res []*RideSlim
+	seen := make(map[int]bool)
+	offset := 0
+pages:
+	for {
+		rides, count, err := w.r.GetRidesWithOpts(w.user, offset, watchPageSize, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error polling rides for user %d: %w", w.user, err)
+		}
+		for _, ride := range rides {
+			if w.started && ride.CreatedAt.Before(w.mark.CreatedAt) {
+				break pages
+			}
+			// New rides can shift a ride onto the next page too.
+			if seen[ride.ID] || ride.IsDeleted() || (w.started && w.mark.delivered(ride)) {
+				continue
+			}
+			seen[ride.ID] = true
+			res = append(res, ride)
+		}
+		// Before the first delivery, only the newest rides are needed for the
+		// mark.
+		offset += len(rides)
+		if !w.started || len(rides) == 0 || offset >= count {
+			break
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].CreatedAt.Before(res[j].CreatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+
+	return res, nil
+}
+
+// Run polls for new rides every interval until the context is done, and then
+// returns its error. Polls that fail are logged, and back off by doubling the
+// wait, up to 30 minutes.
+func (w *Watcher) Run(ctx context.Context) error {
+	wait := w.interval
+	for {
+		n, err := w.Poll()
+		switch {
+		case err != nil:
+			wait = min(wait*2, max(watchMaxBackoff, w.interval))
+			w.r.log().Warn("watching rides failed", "user", w.user, "next", wait, "err", err)
+		default:
+			wait = w.interval
+			if n > 0 {
+				w.r.log().Debug("new rides", "user", w.user, "count", n)
+			}
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package goride
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// watchedRides serves a user's rides, newest first, like the server sorting
+// by created_at.
+type watchedRides struct {
+	mu    sync.Mutex
+	rides []*RideSlim
+	fail  int
+	polls int
+}
+
+// add adds rides, each created its ID in minutes after 9am.
+func (s *watchedRides) add(ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		// Rides 10 and 11 are created at the same time.
+		minute := id
+		if id == 11 {
+			minute = 10
+		}
+		at := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC).Add(time.Duration(minute) * time.Minute)
+		s.rides = append(s.rides, &RideSlim{ID: id, Name: "Ride " + strconv.Itoa(id), CreatedAt: at})
+	}
+	sort.SliceStable(s.rides, func(i, j int) bool { return s.rides[i].CreatedAt.After(s.rides[j].CreatedAt) })
+}
+
+func (s *watchedRides) serve(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls++
+	if s.fail > 0 {
+		s.fail--
+		http.Error(w, "boom", http.StatusInternalServerError)
+		return
+	}
+	if req.URL.Query().Get("sort_by") != "created_at" || req.URL.Query().Get("order") != "desc" {
+		http.Error(w, "bad sort", http.StatusBadRequest)
+		return
+	}
+	offset, _ := strconv.Atoi(req.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+	page := s.rides[min(offset, len(s.rides)):min(offset+limit, len(s.rides))]
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": page, "results_count": len(s.rides)})
+}
+
+func newWatchTest(t *testing.T) (*RWGPS, *watchedRides) {
+	f := newFakeRWGPS(t)
+	s := &watchedRides{}
+	f.handle("/users/7/trips.json", s.serve)
+	return testObj(f.URL), s
+}
+
+// delivered records the IDs of the rides a Watcher delivers.
+func delivered(w *Watcher) func() []int {
+	var mu sync.Mutex
+	var ids []int
+	w.Subscribe(func(r *RideSlim) {
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, r.ID)
+	})
+	return func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), ids...)
+	}
+}
+
+func newWatcher(t *testing.T, r *RWGPS, interval time.Duration) *Watcher {
+	t.Helper()
+	w, err := NewWatcher(r, 7, interval)
+	if err != nil {
+		t.Fatalf("NewWatcher(): %v", err)
+	}
+	return w
+}
+
+func TestNewWatcher(t *testing.T) {
+	r, _ := newWatchTest(t)
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		if _, err := NewWatcher(r, 7, interval); err == nil {
+			t.Errorf("NewWatcher(%v): want an error", interval)
+		}
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	r, rides := newWatchTest(t)
+	rides.add(1, 2, 3)
+	store := NewFileWatchStore(filepath.Join(t.TempDir(), "marks.json"))
+	w := newWatcher(t, r, time.Minute)
+	w.SetStore(store)
+	got := delivered(w)
+
+	poll := func(want int) {
+		t.Helper()
+		n, err := w.Poll()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != want {
+			t.Errorf("want %d new rides, got %d", want, n)
+		}
+	}
+
+	// The rides that were already there aren't new.
+	poll(0)
+	rides.add(5, 4)
+	poll(2)
+	poll(0)
+	// Rides created at the same time as the last one are still new.
+	rides.add(10)
+	poll(1)
+	rides.add(11, 12)
+	poll(2)
+	if diff := cmp.Diff([]int{4, 5, 10, 11, 12}, got()); diff != "" {
+		t.Errorf("bad rides: -want +got\n%s", diff)
+	}
+
+	// A new Watcher with the same store picks up where this one stopped.
+	rides.add(13)
+	w = newWatcher(t, r, time.Minute)
+	w.SetStore(store)
+	got = delivered(w)
+	poll(1)
+	if diff := cmp.Diff([]int{13}, got()); diff != "" {
+		t.Errorf("bad rides after a restart: -want +got\n%s", diff)
+	}
+
+	mark, ok, err := store.LoadMark(7)
+	if err != nil || !ok {
+		t.Fatalf("want a saved mark, got %v, %v", ok, err)
+	}
+	if want := (WatchMark{CreatedAt: time.Date(2021, 8, 1, 9, 13, 0, 0, time.UTC), IDs: []int{13}}); !cmp.Equal(want, mark) {
+		t.Errorf("bad mark: -want +got\n%s", cmp.Diff(want, mark))
+	}
+}
+
+func TestWatcherNoRides(t *testing.T) {
+	r, rides := newWatchTest(t)
+	w := newWatcher(t, r, time.Minute)
+	got := delivered(w)
+
+	if _, err := w.Poll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The user's first ride is new.
+	rides.add(1)
+	if _, err := w.Poll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int{1}, got()); diff != "" {
+		t.Errorf("bad rides: -want +got\n%s", diff)
+	}
+}
+
+func TestWatcherPaging(t *testing.T) {
+	r, rides := newWatchTest(t)
+	rides.add(1)
+	w := newWatcher(t, r, time.Minute)
+	got := delivered(w)
+	if _, err := w.Poll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var want []int
+	for id := 100; id < 100+2*watchPageSize+3; id++ {
+		rides.add(id)
+		want = append(want, id)
+	}
+	if _, err := w.Poll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got()); diff != "" {
+		t.Errorf("bad rides: -want +got\n%s", diff)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	r, rides := newWatchTest(t)
+	rides.add(1)
+	rides.fail = 2
+	w := newWatcher(t, r, time.Millisecond)
+	newRides := make(chan int, 10)
+	w.Subscribe(func(r *RideSlim) { newRides <- r.ID })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	// Wait for the first poll to get through the failures.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		rides.mu.Lock()
+		polls := rides.polls
+		rides.mu.Unlock()
+		if polls > 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("watcher didn't retry, %d polls", polls)
+		}
+	}
+	rides.add(2, 3)
+	for _, want := range []int{2, 3} {
+		select {
+		case got := <-newRides:
+			if got != want {
+				t.Errorf("want ride %d, got %d", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ride %d wasn't delivered", want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watcher didn't stop")
+	}
+	select {
+	case id := <-newRides:
+		t.Errorf("ride %d was delivered again", id)
+	default:
+	}
+}
@@ -0,0 +1,99 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoWeather is returned by providers that have no weather for a place and
+// time.
+var ErrNoWeather = errors.New("no weather")
+
+// Weather is the conditions at a place and time. Temperature is in Celsius,
+// Humidity in percent, WindSpeed in km/h and WindDirection in degrees the
+// wind comes from.
+type Weather struct {
+	Time          time.Time `json:"time"`
+	Location      LatLng    `json:"location"`
+	Temperature   float64   `json:"temperature"`
+	Humidity      float64   `json:"humidity,omitempty"`
+	WindSpeed     float64   `json:"wind_speed,omitempty"`
+	WindDirection float64   `json:"wind_direction,omitempty"`
+	Conditions    string    `json:"conditions,omitempty"`
+}
+
+// RideWeather is the weather at the start, middle and end of a ride. Each is
+// nil if it isn't known.
+type RideWeather struct {
+	Start *Weather `json:"start,omitempty"`
+	Mid   *Weather `json:"mid,omitempty"`
+	End   *Weather `json:"end,omitempty"`
+}
+
+// WeatherProvider looks up the weather, e.g. from a weather service.
+type WeatherProvider interface {
+	Weather(ctx context.Context, at LatLng, t time.Time) (Weather, error)
+}
+
+// WeatherFunc is a function used as a WeatherProvider.
+type WeatherFunc func(ctx context.Context, at LatLng, t time.Time) (Weather, error)
+
+func (f WeatherFunc) Weather(ctx context.Context, at LatLng, t time.Time) (Weather, error) {
+	return f(ctx, at, t)
+}
+
+// NoWeather is a WeatherProvider that never has any weather.
+var NoWeather WeatherProvider = WeatherFunc(func(context.Context, LatLng, time.Time) (Weather, error) {
+	return Weather{}, ErrNoWeather
+})
+
+// EnrichRide looks up the weather at the start, middle and end of the ride's
+// track, and sets ride.Weather. It's best effort: points the provider fails
+// for are skipped, and their errors returned once the rest are done. Rides
+// without timed positions are left alone.
+func EnrichRide(ctx context.Context, ride *Ride, p WeatherProvider) error {
+	var timed []TrackPoint
+	for _, tp := range ride.TrackPoints {
+		if (tp.Lat != 0 || tp.Lng != 0) && !tp.Time.IsZero() {
+			timed = append(timed, tp)
+		}
+	}
+	if len(timed) == 0 {
+		return nil
+	}
+
+	first, last := timed[0], timed[len(timed)-1]
+	midTime := first.Time.Add(last.Time.Sub(first.Time) / 2)
+	mid := first
+	for _, tp := range timed {
+		if tp.Time.After(midTime) {
+			break
+		}
+		mid = tp
+	}
+
+	var res RideWeather
+	var errs []error
+	for _, s := range []struct {
+		name string
+		pt   TrackPoint
+		dst  **Weather
+	}{{"start", first, &res.Start}, {"mid", mid, &res.Mid}, {"end", last, &res.End}} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w, err := p.Weather(ctx, LatLng{Lat: float32(s.pt.Lat), Lng: float32(s.pt.Lng)}, s.pt.Time)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("can't get weather at the %s of ride %d: %w", s.name, ride.ID, err))
+			continue
+		}
+		*s.dst = &w
+	}
+	if res.Start != nil || res.Mid != nil || res.End != nil {
+		ride.Weather = &res
+	}
+
+	return errors.Join(errs...)
+}
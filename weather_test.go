@@ -0,0 +1,98 @@
+package goride
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnrichRide(t *testing.T) {
+	start := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	track := []TrackPoint{
+		{Time: start, HeartRate: 90},
+		{Time: start.Add(time.Minute), Lat: 37.5, Lng: -122.25},
+		{Time: start.Add(20 * time.Minute), Lat: 37.5, Lng: -122.5},
+		{Time: start.Add(40 * time.Minute), Lat: 37.75, Lng: -122.5},
+		{Time: start.Add(61 * time.Minute), Lat: 37.75, Lng: -122.25},
+	}
+	// Temperature goes up by a degree a minute.
+	temps := WeatherFunc(func(_ context.Context, at LatLng, t time.Time) (Weather, error) {
+		return Weather{Time: t, Location: at, Temperature: t.Sub(start).Minutes()}, nil
+	})
+	noMid := WeatherFunc(func(ctx context.Context, at LatLng, t time.Time) (Weather, error) {
+		if t.Equal(start.Add(20 * time.Minute)) {
+			return Weather{}, errors.New("service down")
+		}
+		return temps(ctx, at, t)
+	})
+	at := func(min int, lat, lng float32) *Weather {
+		return &Weather{Time: start.Add(time.Duration(min) * time.Minute), Location: LatLng{Lat: lat, Lng: lng}, Temperature: float64(min)}
+	}
+
+	tests := []struct {
+		desc     string
+		track    []TrackPoint
+		provider WeatherProvider
+		want     *RideWeather
+		wantErr  error
+	}{
+		{
+			desc:     "start, mid and end",
+			track:    track,
+			provider: temps,
+			want:     &RideWeather{Start: at(1, 37.5, -122.25), Mid: at(20, 37.5, -122.5), End: at(61, 37.75, -122.25)},
+		},
+		{
+			desc:     "mid fails",
+			track:    track,
+			provider: noMid,
+			want:     &RideWeather{Start: at(1, 37.5, -122.25), End: at(61, 37.75, -122.25)},
+			wantErr:  errors.New("service down"),
+		},
+		{
+			desc:     "no weather",
+			track:    track,
+			provider: NoWeather,
+			wantErr:  ErrNoWeather,
+		},
+		{
+			desc:     "no track",
+			provider: temps,
+		},
+		{
+			desc:     "no positions",
+			track:    track[:1],
+			provider: temps,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			ride := &Ride{ID: 1, Name: "Loop", TrackPoints: tc.track}
+			err := EnrichRide(context.Background(), ride, tc.provider)
+			switch {
+			case tc.wantErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != nil && err == nil:
+				t.Errorf("expected an error")
+			case errors.Is(tc.wantErr, ErrNoWeather) && !errors.Is(err, ErrNoWeather):
+				t.Errorf("want ErrNoWeather, got %v", err)
+			}
+			if diff := cmp.Diff(tc.want, ride.Weather); diff != "" {
+				t.Errorf("bad weather: -want +got\n%s", diff)
+			}
+			if ride.Name != "Loop" || len(ride.TrackPoints) != len(tc.track) {
+				t.Errorf("ride was changed: %+v", ride)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := EnrichRide(ctx, &Ride{TrackPoints: track}, temps); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
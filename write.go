@@ -0,0 +1,183 @@
+package goride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RideUpdate holds the fields to change on a ride. Nil fields are left as is.
+type RideUpdate struct {
+	Name        *string     `json:"name,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	GearID      *int        `json:"gear_id,omitempty"`
+	Visibility  *Visibility `json:"visibility,omitempty"`
+	Tags        *[]string   `json:"tag_names,omitempty"`
+}
+
+// UpdateRide changes a ride's details. Rides that don't exist return
+// ErrNotFound.
+func (r *RWGPS) UpdateRide(id int, u RideUpdate) error {
+	body, err := json.Marshal(struct {
+		Trip RideUpdate `json:"trip"`
+	}{u})
+	if err != nil {
+		return fmt.Errorf("can't encode update for ride %d: %w", id, err)
+	}
+
+	if err := r.send(http.MethodPut, fmt.Sprintf("/trips/%d.json", id), nil, body); err != nil {
+		return fmt.Errorf("error updating ride %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// DeleteRide deletes a ride. Rides that don't exist return ErrNotFound.
+func (r *RWGPS) DeleteRide(id int) error {
+	if err := r.send(http.MethodDelete, fmt.Sprintf("/trips/%d.json", id), nil, nil); err != nil {
+		return fmt.Errorf("error deleting ride %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// MaxRoutePoints is the most track points CreateRoute sends. Longer routes are
+// simplified to fit.
+const MaxRoutePoints = 10000
+
+// ErrInvalidRoute is returned when a route is rejected, either before it's
+// sent or by the server.
+var ErrInvalidRoute = errors.New("invalid route")
+
+// RouteCreateOptions are the optional details of a new route.
+type RouteCreateOptions struct {
+	Description string
+	Visibility  Visibility
+	// AutoCues asks the server to generate turn by turn cues.
+	AutoCues bool
+}
+
+type routeCreate struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Visibility  Visibility   `json:"visibility"`
+	AutoCues    bool         `json:"auto_cues,omitempty"`
+	TrackPoints []TrackPoint `json:"track_points"`
+}
+
+// CreateRoute uploads a new route through points. Routes need at least two
+// points, and ones with more than MaxRoutePoints are simplified before
+// they're sent. The returned route has its new ID, see RouteURL.
+func (r *RWGPS) CreateRoute(name string, points []LatLng, opts RouteCreateOptions) (*Route, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("can't create route %q with %d points: %w", name, len(points), ErrInvalidRoute)
+	}
+
+	track := make([]TrackPoint, len(points))
+	for i, p := range points {
+		track[i] = TrackPoint{Lat: float64(p.Lat), Lng: float64(p.Lng)}
+	}
+	for tolerance := 1.0; len(track) > MaxRoutePoints; tolerance *= 2 {
+		track = Simplify(track, tolerance)
+	}
+	if len(track) < 2 {
+		return nil, fmt.Errorf("can't create route %q, it has no length: %w", name, ErrInvalidRoute)
+	}
+
+	body, err := json.Marshal(struct {
+		Route routeCreate `json:"route"`
+	}{routeCreate{
+		Name:        name,
+		Description: opts.Description,
+		Visibility:  opts.Visibility,
+		AutoCues:    opts.AutoCues,
+		TrackPoints: track,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode route %q: %w", name, err)
+	}
+
+	res, err := r.do(http.MethodPost, "/routes.json", nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating route %q: %w", name, routeError(err))
+	}
+
+	var resStruct struct {
+		Route Route
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return &resStruct.Route, nil
+}
+
+// RouteURL is the link to a route on the website.
+func (r *RWGPS) RouteURL(id int) string {
+	return joinURL(r.client.server, fmt.Sprintf("/routes/%d", id))
+}
+
+// routeError turns the server's validation errors into ErrInvalidRoute.
+func routeError(err error) error {
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusUnprocessableEntity {
+		return err
+	}
+
+	msg := se.body
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal([]byte(se.body), &body) == nil && len(body.Errors) > 0 {
+		msg = strings.Join(body.Errors, "; ")
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidRoute, msg)
+}
+
+// PinRoute pins a route to the user's profile.
+func (r *RWGPS) PinRoute(id int) error {
+	if err := r.send(http.MethodPost, fmt.Sprintf("/routes/%d/pin.json", id), nil, nil); err != nil {
+		return fmt.Errorf("error pinning route %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// FavoriteRoute adds a route to the user's favorites.
+func (r *RWGPS) FavoriteRoute(id int) error {
+	if err := r.send(http.MethodPost, fmt.Sprintf("/routes/%d/favorite.json", id), nil, nil); err != nil {
+		return fmt.Errorf("error favoriting route %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// UnfavoriteRoute removes a route from the user's favorites.
+func (r *RWGPS) UnfavoriteRoute(id int) error {
+	if err := r.send(http.MethodDelete, fmt.Sprintf("/routes/%d/favorite.json", id), nil, nil); err != nil {
+		return fmt.Errorf("error unfavoriting route %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// CopyRoute copies a route into the user's account, and returns the copy.
+// Routes the user can't see return ErrPrivate.
+func (r *RWGPS) CopyRoute(id int) (*Route, error) {
+	res, err := r.do(http.MethodPost, fmt.Sprintf("/routes/%d/copy.json", id), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error copying route %d: %w", id, err)
+	}
+
+	var resStruct struct {
+		Route Route
+	}
+	if err := r.decode(res, &resStruct); err != nil {
+		return nil, err
+	}
+
+	return &resStruct.Route, nil
+}
@@ -0,0 +1,176 @@
+package goride
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testRides() []*Ride {
+	updated := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	return []*Ride{
+		{ID: 1, Name: "Morning Ride", Gear: &Gear{ID: 17}, UpdatedAt: updated},
+		{ID: 2, Name: "Lunch Ride", Gear: &Gear{ID: 17}, Visibility: Private, UpdatedAt: updated},
+		{ID: 3, Name: "Evening Ride", UpdatedAt: updated},
+	}
+}
+
+func TestUpdateRide(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+
+	name := "Commute"
+	vis := FriendsOnly
+	if err := r.UpdateRide(1, RideUpdate{Name: &name, Visibility: &vis}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := f.ride(1)
+	if got.Name != "Commute" || got.Visibility != FriendsOnly || got.Gear.ID != 17 {
+		t.Errorf("bad updated ride: %+v", got)
+	}
+
+	if err := r.UpdateRide(42, RideUpdate{Name: &name}); err == nil {
+		t.Errorf("expected an error updating a missing ride")
+	}
+}
+
+func TestDeleteRide(t *testing.T) {
+	f := newFakeRWGPS(t, testRides()...)
+	r := testObj(f.URL)
+
+	if err := r.DeleteRide(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.ride(2) != nil {
+		t.Errorf("ride wasn't deleted")
+	}
+	if err := r.DeleteRide(2); err == nil {
+		t.Errorf("expected an error deleting a missing ride")
+	}
+
+	want := []string{"DELETE /trips/2.json", "DELETE /trips/2.json"}
+	if diff := cmp.Diff(want, f.writes()); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+}
+
+func TestCreateRoute(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	var bodies []string
+	f.handle("/routes.json", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) > 2 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"errors":["Track points is too long"]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"type":"route","route":{"id":%d,"name":"Loop"}}`, 100+len(bodies))
+	})
+
+	points := []LatLng{{Lat: 37.5, Lng: -122.25}, {Lat: 37.75, Lng: -122.5}}
+	got, err := r.CreateRoute("Loop", points, RouteCreateOptions{Description: "Around", Visibility: Private, AutoCues: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 101 || got.Name != "Loop" {
+		t.Errorf("bad route: %+v", got)
+	}
+	if want := f.URL + "/routes/101"; r.RouteURL(got.ID) != want {
+		t.Errorf("bad url: want %q, got %q", want, r.RouteURL(got.ID))
+	}
+
+	// A straight line is simplified down to its ends.
+	var long []LatLng
+	for i := 0; i <= MaxRoutePoints; i++ {
+		long = append(long, LatLng{Lat: 37.5, Lng: -122.5 + float32(i)/(4*MaxRoutePoints)})
+	}
+	if _, err := r.CreateRoute("Long", long, RouteCreateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		`{"route":{"name":"Loop","description":"Around","visibility":1,"auto_cues":true,"track_points":[{"y":37.5,"x":-122.25},{"y":37.75,"x":-122.5}]}}`,
+		`{"route":{"name":"Long","visibility":0,"track_points":[{"y":37.5,"x":-122.5},{"y":37.5,"x":-122.25}]}}`,
+	}
+	if diff := cmp.Diff(want, bodies); diff != "" {
+		t.Errorf("bad request bodies: -want +got\n%s", diff)
+	}
+
+	_, err = r.CreateRoute("Loop", points, RouteCreateOptions{})
+	if !errors.Is(err, ErrInvalidRoute) {
+		t.Errorf("want ErrInvalidRoute from a 422, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "Track points is too long") {
+		t.Errorf("error doesn't include the server's reason: %v", err)
+	}
+
+	if _, err := r.CreateRoute("Dot", points[:1], RouteCreateOptions{}); !errors.Is(err, ErrInvalidRoute) {
+		t.Errorf("want ErrInvalidRoute for a single point, got %v", err)
+	}
+	if len(bodies) != 3 {
+		t.Errorf("invalid route was sent to the server")
+	}
+}
+
+func TestRouteActions(t *testing.T) {
+	f := newFakeRWGPS(t)
+	r := testObj(f.URL)
+
+	f.handle("/routes/10/pin.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"success":1}`)
+	})
+	f.handle("/routes/10/favorite.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"success":1}`)
+	})
+	f.handle("/routes/10/copy.json", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"type":"route","route":{"id":11,"name":"Copy of Loop","user_id":1268590}}`)
+	})
+	f.handle("/routes/20/copy.json", func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "not allowed", http.StatusForbidden)
+	})
+
+	if err := r.PinRoute(10); err != nil {
+		t.Errorf("can't pin: %v", err)
+	}
+	if err := r.FavoriteRoute(10); err != nil {
+		t.Errorf("can't favorite: %v", err)
+	}
+	if err := r.UnfavoriteRoute(10); err != nil {
+		t.Errorf("can't unfavorite: %v", err)
+	}
+	got, err := r.CopyRoute(10)
+	if err != nil {
+		t.Fatalf("can't copy: %v", err)
+	}
+	if got.ID != 11 {
+		t.Errorf("want the copy's ID 11, got %d", got.ID)
+	}
+	if _, err := r.CopyRoute(20); !errors.Is(err, ErrPrivate) {
+		t.Errorf("want ErrPrivate copying a private route, got %v", err)
+	}
+	if err := r.PinRoute(30); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound pinning a missing route, got %v", err)
+	}
+
+	want := []string{
+		"POST /routes/10/pin.json",
+		"POST /routes/10/favorite.json",
+		"DELETE /routes/10/favorite.json",
+		"POST /routes/10/copy.json",
+		"POST /routes/20/copy.json",
+		"POST /routes/30/pin.json",
+	}
+	if diff := cmp.Diff(want, f.writes()); diff != "" {
+		t.Errorf("bad requests: -want +got\n%s", diff)
+	}
+}